@@ -0,0 +1,136 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"MinIO-Learn/internal/storage"
+)
+
+// urlExpiry returns the X-Amz-Expires parameter of a presigned URL.
+func urlExpiry(t *testing.T, rawURL string) string {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatalf("parsing URL %q: %v", rawURL, err)
+	}
+	return u.Query().Get("X-Amz-Expires")
+}
+
+func TestPresignExpiry(t *testing.T) {
+	tests := []struct {
+		name       string
+		token      string
+		path       string
+		wantStatus int
+		wantExpiry string
+	}{
+		{"anonymous", "", "/files/uploads/report.pdf", http.StatusFound, "600"},
+		{"authenticated", "secret", "/files/uploads/report.pdf", http.StatusFound, "7200"},
+		{"invalid token is anonymous", "wrong", "/files/uploads/report.pdf", http.StatusFound, "600"},
+		{"anonymous asks for longer", "", "/files/uploads/report.pdf?expiry=48h", http.StatusFound, "600"},
+		{"anonymous asks for shorter", "", "/files/uploads/report.pdf?expiry=1m", http.StatusFound, "60"},
+		{"authenticated asks for longer", "secret", "/files/uploads/report.pdf?expiry=48h", http.StatusFound, "172800"},
+		{"clamped to seven days", "secret", "/files/uploads/report.pdf?expiry=1000h", http.StatusFound, "604800"},
+		{"invalid expiry", "secret", "/files/uploads/report.pdf?expiry=soon", http.StatusBadRequest, ""},
+		{"anonymous listing", "", "/files?urls=true", http.StatusOK, "600"},
+		{"authenticated listing", "secret", "/files?urls=true", http.StatusOK, "7200"},
+	}
+
+	store := storage.NewMemoryStorage("test-bucket")
+	putObject(t, store, "uploads/report.pdf", "application/pdf", []byte("%PDF-1.4"))
+	h := newTestServer(t, store, map[string]string{
+		"MINIO_API_TOKEN":           "secret",
+		"MINIO_AUTH_REQUIRED":       "false",
+		"MINIO_PRESIGN_EXPIRY_ANON": "10m",
+		"MINIO_PRESIGN_EXPIRY_AUTH": "2h",
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newRequest(http.MethodGet, tt.path, "")
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := serve(h, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+
+			var got string
+			switch rec.Code {
+			case http.StatusFound:
+				got = urlExpiry(t, rec.Header().Get("Location"))
+			case http.StatusOK:
+				var page fileListPage
+				decodeData(t, rec, &page)
+				if len(page.Files) != 1 {
+					t.Fatalf("listed %d files, want 1", len(page.Files))
+				}
+				got = urlExpiry(t, page.Files[0].URL)
+			default:
+				return
+			}
+			if got != tt.wantExpiry {
+				t.Errorf("URL expiry = %ss, want %ss", got, tt.wantExpiry)
+			}
+		})
+	}
+}
+
+func TestAuthTokens(t *testing.T) {
+	tests := []struct {
+		name          string
+		env           map[string]string
+		path          string
+		authorization string
+		wantStatus    int
+	}{
+		{"first listed token", nil, "/files?prefix=uploads/", "Bearer alpha", http.StatusOK},
+		{"second listed token", nil, "/files?prefix=uploads/", "Bearer beta", http.StatusOK},
+		{"single token", nil, "/files?prefix=uploads/", "Bearer gamma", http.StatusOK},
+		{"invalid token", nil, "/files?prefix=uploads/", "Bearer delta", http.StatusUnauthorized},
+		{"token prefix", nil, "/files?prefix=uploads/", "Bearer alph", http.StatusUnauthorized},
+		{"empty token", nil, "/files?prefix=uploads/", "Bearer ", http.StatusUnauthorized},
+		{"not a bearer token", nil, "/files?prefix=uploads/", "Basic YWxwaGE6", http.StatusUnauthorized},
+		{"missing token", nil, "/files?prefix=uploads/", "", http.StatusUnauthorized},
+		{"missing token on health", nil, "/livez", "", http.StatusOK},
+		{"missing token on unexempt health", map[string]string{"MINIO_AUTH_EXEMPT_HEALTH": "false"}, "/livez", "", http.StatusUnauthorized},
+		{"missing token when optional", map[string]string{"MINIO_AUTH_REQUIRED": "false"}, "/files?prefix=uploads/", "", http.StatusOK},
+		{"missing token on admin when optional", map[string]string{"MINIO_AUTH_REQUIRED": "false"}, "/admin/buckets", "", http.StatusUnauthorized},
+		{"invalid token on admin when optional", map[string]string{"MINIO_AUTH_REQUIRED": "false"}, "/admin/buckets", "Bearer delta", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := map[string]string{
+				"MINIO_API_TOKENS": "alpha,beta",
+				"MINIO_API_TOKEN":  "gamma",
+			}
+			for key, value := range tt.env {
+				env[key] = value
+			}
+			store := storage.NewMemoryStorage("test-bucket")
+			putObject(t, store, "uploads/report.pdf", "application/pdf", []byte("%PDF-1.4"))
+			h := newTestServer(t, store, env)
+
+			req := newRequest(http.MethodGet, tt.path, "")
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := serve(h, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			challenge := rec.Header().Get("WWW-Authenticate")
+			if tt.wantStatus == http.StatusUnauthorized && challenge == "" {
+				t.Error("401 without a WWW-Authenticate challenge")
+			}
+			if tt.wantStatus != http.StatusUnauthorized && challenge != "" {
+				t.Errorf("WWW-Authenticate = %q on a %d response", challenge, rec.Code)
+			}
+		})
+	}
+}
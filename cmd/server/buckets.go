@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"MinIO-Learn/internal/storage"
+)
+
+type bucketInfo struct {
+	Name         string    `json:"name"`
+	CreationDate time.Time `json:"creationDate,omitzero"`
+}
+
+type createBucketRequest struct {
+	Name   string `json:"name"`
+	Region string `json:"region,omitempty"`
+}
+
+// bucketsHandler lists buckets on GET /admin/buckets and creates one on POST
+// /admin/buckets with a {"name", "region"} body.
+func (s *Server) bucketsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		buckets, err := s.storage.ListBuckets(r.Context())
+		if err != nil {
+			sendResponse(w, false, "Error listing buckets: "+err.Error(), nil, storageErrorStatus(err))
+			return
+		}
+
+		result := make([]bucketInfo, 0, len(buckets))
+		for _, b := range buckets {
+			result = append(result, bucketInfo{Name: b.Name, CreationDate: b.CreationDate})
+		}
+		sendResponse(w, true, fmt.Sprintf("Found %d buckets", len(result)), result, http.StatusOK)
+	case http.MethodPost:
+		var req createBucketRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendResponse(w, false, "Invalid request body: "+err.Error(), nil, http.StatusBadRequest)
+			return
+		}
+
+		err := s.storage.CreateBucket(r.Context(), req.Name, req.Region)
+		if errors.Is(err, storage.ErrInvalidBucketName) {
+			sendResponse(w, false, err.Error(), nil, http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, storage.ErrBucketExists) {
+			sendResponse(w, false, err.Error(), nil, http.StatusConflict)
+			return
+		}
+		if err != nil {
+			sendResponse(w, false, "Error creating bucket: "+err.Error(), nil, storageErrorStatus(err))
+			return
+		}
+		sendResponse(w, true, fmt.Sprintf("Bucket '%s' created", req.Name), req, http.StatusCreated)
+	default:
+		sendResponse(w, false, "Method not allowed", nil, http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"MinIO-Learn/internal/config"
+	"MinIO-Learn/internal/storage"
+	"github.com/minio/minio-go/v7"
+)
+
+// cliCommand is a one-off operation run from the command line instead of the
+// HTTP server.
+type cliCommand struct {
+	usage   string
+	minArgs int
+	maxArgs int // -1 for no limit
+	run     func(ctx context.Context, store storage.Storage, args []string, stdout io.Writer) error
+}
+
+var cliCommands = map[string]cliCommand{
+	"upload":     {"upload <file> [key]", 1, 2, cliUpload},
+	"upload-dir": {"upload-dir <dir> [prefix]", 1, 2, cliUploadDir},
+	"download":   {"download <key> [file|-]", 1, 2, cliDownload},
+	"copy-to":    {"copy-to <bucket> <key> [dst-key]", 2, 3, cliCopyTo},
+	"list":       {"list [prefix]", 0, 1, cliList},
+	"delete":     {"delete <key>...", 1, -1, cliDelete},
+}
+
+var errUsage = errors.New("invalid usage")
+
+// parseCLIArgs picks the command named by args[0] and checks its argument
+// count, so mistakes are reported before connecting to MinIO.
+func parseCLIArgs(args []string) (cliCommand, []string, error) {
+	if len(args) == 0 {
+		return cliCommand{}, nil, errUsage
+	}
+	cmd, ok := cliCommands[args[0]]
+	if !ok {
+		return cliCommand{}, nil, fmt.Errorf("%w: unknown command '%s'", errUsage, args[0])
+	}
+	rest := args[1:]
+	if len(rest) < cmd.minArgs || (cmd.maxArgs >= 0 && len(rest) > cmd.maxArgs) {
+		return cmd, nil, fmt.Errorf("%w: %s", errUsage, cmd.usage)
+	}
+	return cmd, rest, nil
+}
+
+func printCLIUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: server [command]")
+	fmt.Fprintln(w, "\nWithout a command the HTTP server is started. Commands:")
+	for _, name := range []string{"upload", "upload-dir", "download", "copy-to", "list", "delete"} {
+		fmt.Fprintf(w, "  %s\n", cliCommands[name].usage)
+	}
+	fmt.Fprintln(w, "  selftest")
+}
+
+// runCLI runs the command in args against the configured bucket and returns
+// the process exit code: 0 on success, 1 on failure and 2 on bad usage.
+func runCLI(cfg config.MinIOConfig, args []string) int {
+	cmd, rest, err := parseCLIArgs(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		printCLIUsage(os.Stderr)
+		return 2
+	}
+
+	storageConfig, err := newStorageConfig(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to configure MinIO service:", err)
+		return 1
+	}
+	service, err := storage.NewMinIOService(storageConfig)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to initialize MinIO service:", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := cmd.run(ctx, service, rest, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// cliUpload uploads a local file, by default under its base name.
+func cliUpload(ctx context.Context, store storage.Storage, args []string, stdout io.Writer) error {
+	filePath := args[0]
+	objectName := filepath.Base(filePath)
+	if len(args) > 1 {
+		objectName = args[1]
+	}
+	if err := storage.ValidateObjectName(objectName); err != nil {
+		return err
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(filePath))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	info, err := store.UploadLargeFile(ctx, objectName, filePath, contentType, nil)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "uploaded %s (%d bytes, etag %s)\n", info.Key, info.Size, info.ETag)
+	return nil
+}
+
+// cliUploadDir uploads a directory tree under the prefix, by default the
+// directory's base name, and lists the files that were skipped or failed.
+func cliUploadDir(ctx context.Context, store storage.Storage, args []string, stdout io.Writer) error {
+	dir := args[0]
+	prefix := filepath.Base(filepath.Clean(dir)) + "/"
+	if len(args) > 1 {
+		prefix = args[1]
+	}
+
+	result, err := store.UploadDirectory(ctx, dir, prefix)
+	for _, path := range result.Skipped {
+		fmt.Fprintf(stdout, "skipped %s: not a regular file\n", path)
+	}
+	for _, e := range result.Failed {
+		fmt.Fprintf(stdout, "failed to upload %s: %v\n", e.ObjectName, e.Err)
+	}
+	fmt.Fprintf(stdout, "uploaded %d files under %s\n", len(result.Uploaded), prefix)
+	if err != nil {
+		return err
+	}
+	if len(result.Failed) > 0 {
+		return fmt.Errorf("%d files could not be uploaded", len(result.Failed))
+	}
+	return nil
+}
+
+// cliCopyTo copies an object server-side into another bucket, by default
+// under the same key.
+func cliCopyTo(ctx context.Context, store storage.Storage, args []string, stdout io.Writer) error {
+	bucket, objectName := args[0], args[1]
+	dstObject := objectName
+	if len(args) > 2 {
+		dstObject = args[2]
+	}
+	if err := storage.ValidateObjectName(dstObject); err != nil {
+		return err
+	}
+
+	info, err := store.CopyObjectToBucket(ctx, objectName, bucket, dstObject)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "copied %s to %s/%s (%d bytes)\n", objectName, info.Bucket, info.Key, info.Size)
+	return nil
+}
+
+// cliDownload saves an object to a local file, by default named after the
+// key's base name, or writes it to stdout when the file is "-".
+func cliDownload(ctx context.Context, store storage.Storage, args []string, stdout io.Writer) error {
+	objectName := args[0]
+	filePath := filepath.Base(objectName)
+	if len(args) > 1 {
+		filePath = args[1]
+	}
+
+	if filePath == "-" {
+		_, err := store.DownloadToWriter(ctx, objectName, stdout)
+		return err
+	}
+	return store.DownloadFile(ctx, objectName, filePath)
+}
+
+// cliList prints every object under the prefix with its size and
+// modification time.
+func cliList(ctx context.Context, store storage.Storage, args []string, stdout io.Writer) error {
+	var prefix string
+	if len(args) > 0 {
+		prefix = args[0]
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	err := store.ForEachObject(ctx, prefix, func(object minio.ObjectInfo) error {
+		_, err := fmt.Fprintf(tw, "%s\t%d\t%s\n", object.Key, object.Size, object.LastModified.UTC().Format(time.RFC3339))
+		return err
+	})
+	if flushErr := tw.Flush(); err == nil {
+		err = flushErr
+	}
+	return err
+}
+
+// cliDelete removes the given objects, reporting each one that failed.
+func cliDelete(ctx context.Context, store storage.Storage, args []string, stdout io.Writer) error {
+	errs := store.DeleteObjects(ctx, args)
+	for _, e := range errs {
+		fmt.Fprintf(stdout, "failed to delete %s: %v\n", e.ObjectName, e.Err)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%d of %d objects could not be deleted", len(errs), len(args))
+	}
+	fmt.Fprintf(stdout, "deleted %d objects\n", len(args))
+	return nil
+}
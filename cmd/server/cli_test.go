@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"MinIO-Learn/internal/storage"
+)
+
+func TestParseCLIArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantCmd  string
+		wantRest []string
+		wantErr  bool
+	}{
+		{name: "no command", args: nil, wantErr: true},
+		{name: "unknown command", args: []string{"frobnicate"}, wantErr: true},
+		{name: "upload", args: []string{"upload", "a.txt"}, wantCmd: "upload <file> [key]", wantRest: []string{"a.txt"}},
+		{name: "upload with key", args: []string{"upload", "a.txt", "docs/a.txt"}, wantCmd: "upload <file> [key]", wantRest: []string{"a.txt", "docs/a.txt"}},
+		{name: "upload without file", args: []string{"upload"}, wantErr: true},
+		{name: "upload with extra argument", args: []string{"upload", "a", "b", "c"}, wantErr: true},
+		{name: "list without prefix", args: []string{"list"}, wantCmd: "list [prefix]", wantRest: []string{}},
+		{name: "list with two prefixes", args: []string{"list", "a/", "b/"}, wantErr: true},
+		{name: "delete many", args: []string{"delete", "a", "b", "c", "d"}, wantCmd: "delete <key>...", wantRest: []string{"a", "b", "c", "d"}},
+		{name: "delete nothing", args: []string{"delete"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, rest, err := parseCLIArgs(tt.args)
+			if tt.wantErr {
+				if !errors.Is(err, errUsage) {
+					t.Errorf("parseCLIArgs() error = %v, want errUsage", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseCLIArgs() error = %v", err)
+			}
+			if cmd.usage != tt.wantCmd {
+				t.Errorf("command = %q, want %q", cmd.usage, tt.wantCmd)
+			}
+			if !slices.Equal(rest, tt.wantRest) {
+				t.Errorf("args = %q, want %q", rest, tt.wantRest)
+			}
+		})
+	}
+}
+
+// runCLICommand parses args and runs the command against store, returning
+// what it printed.
+func runCLICommand(t *testing.T, store storage.Storage, args ...string) (string, error) {
+	t.Helper()
+	cmd, rest, err := parseCLIArgs(args)
+	if err != nil {
+		t.Fatalf("parseCLIArgs(%q) error = %v", args, err)
+	}
+	var stdout bytes.Buffer
+	err = cmd.run(context.Background(), store, rest, &stdout)
+	return stdout.String(), err
+}
+
+func TestCLICommands(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(source, []byte("meeting notes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store := storage.NewMemoryStorage("test-bucket")
+
+	if _, err := runCLICommand(t, store, "upload", source); err != nil {
+		t.Fatalf("upload error = %v", err)
+	}
+	if _, err := runCLICommand(t, store, "upload", source, "docs/notes.txt"); err != nil {
+		t.Fatalf("upload with key error = %v", err)
+	}
+	if _, err := runCLICommand(t, store, "upload", source, "../escape.txt"); err == nil {
+		t.Error("upload to an invalid key succeeded")
+	}
+
+	out, err := runCLICommand(t, store, "list", "docs/")
+	if err != nil {
+		t.Fatalf("list error = %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 1 || !strings.HasPrefix(lines[0], "docs/notes.txt ") {
+		t.Errorf("list docs/ printed %q, want only docs/notes.txt", out)
+	}
+
+	out, err = runCLICommand(t, store, "download", "docs/notes.txt", "-")
+	if err != nil || out != "meeting notes" {
+		t.Errorf("download to stdout = %q, %v, want the uploaded content", out, err)
+	}
+	target := filepath.Join(dir, "copy.txt")
+	if _, err := runCLICommand(t, store, "download", "notes.txt", target); err != nil {
+		t.Fatalf("download to file error = %v", err)
+	}
+	if got, err := os.ReadFile(target); err != nil || string(got) != "meeting notes" {
+		t.Errorf("downloaded file = %q, %v, want the uploaded content", got, err)
+	}
+
+	if _, err := runCLICommand(t, store, "delete", "notes.txt", "docs/notes.txt"); err != nil {
+		t.Fatalf("delete error = %v", err)
+	}
+	if out, err := runCLICommand(t, store, "list"); err != nil || out != "" {
+		t.Errorf("list after delete = %q, %v, want nothing", out, err)
+	}
+	if _, err := runCLICommand(t, store, "download", "notes.txt", "-"); !errors.Is(err, storage.ErrObjectNotFound) {
+		t.Errorf("download of a deleted object error = %v, want ErrObjectNotFound", err)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// quoteETag returns etag as an HTTP entity tag. MinIO reports ETags without
+// the surrounding quotes.
+func quoteETag(etag string) string {
+	return `"` + strings.Trim(etag, `"`) + `"`
+}
+
+// etagMatches reports whether an If-Match or If-None-Match header value, a
+// comma-separated list of entity tags or "*", includes etag. With weak set,
+// as for If-None-Match, weak tags are compared by their opaque value;
+// otherwise, as for If-Match, they never match (RFC 9110 strong comparison).
+func etagMatches(header, etag string, weak bool) bool {
+	etag = strings.Trim(etag, `"`)
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = candidate[len("W/"):]
+		}
+		if strings.Trim(candidate, `"`) == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPreconditions evaluates If-Match and If-None-Match against etag. It
+// sends 412 Precondition Failed or 304 Not Modified and returns false when
+// the request should not proceed. If-None-Match is only evaluated with
+// servesBody set: a 304 tells the client to reuse its cached copy of the
+// object, which is wrong for a redirect or a metadata response.
+func checkPreconditions(w http.ResponseWriter, r *http.Request, etag string, servesBody bool) bool {
+	if header := r.Header.Get("If-Match"); header != "" && !etagMatches(header, etag, false) {
+		sendResponse(w, false, "ETag does not match If-Match", nil, http.StatusPreconditionFailed)
+		return false
+	}
+
+	if header := r.Header.Get("If-None-Match"); servesBody && header != "" && etagMatches(header, etag, true) {
+		w.Header().Set("ETag", quoteETag(etag))
+		w.WriteHeader(http.StatusNotModified)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"MinIO-Learn/internal/storage"
+)
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		header string
+		weak   bool
+		want   bool
+	}{
+		{`"abc"`, false, true},
+		{`"abc"`, true, true},
+		{`"other", "abc"`, false, true},
+		{`"other"`, true, false},
+		{`*`, false, true},
+		{`W/"abc"`, true, true},
+		{`W/"abc"`, false, false},
+		{`W/"abc", "abc"`, false, true},
+		{`abc`, false, true},
+	}
+
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, "abc", tt.weak); got != tt.want {
+			t.Errorf("etagMatches(%q, weak %v) = %v, want %v", tt.header, tt.weak, got, tt.want)
+		}
+	}
+}
+
+func TestConditionalDownload(t *testing.T) {
+	store := storage.NewMemoryStorage("test-bucket")
+	putObject(t, store, "notes.txt", "text/plain", []byte("hello"))
+	h := newTestServer(t, store, nil)
+
+	rec := serve(h, newRequest(http.MethodGet, "/files/notes.txt?download=true", ""))
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || etag == "" {
+		t.Fatalf("status = %d with ETag %q, want 200 with an ETag", rec.Code, etag)
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		value  string
+		want   int
+	}{
+		{"If-Match strong", "/files/notes.txt?download=true", "If-Match", etag, http.StatusOK},
+		{"If-Match weak", "/files/notes.txt?download=true", "If-Match", "W/" + etag, http.StatusPreconditionFailed},
+		{"If-Match other", "/files/notes.txt?download=true", "If-Match", `"other"`, http.StatusPreconditionFailed},
+		{"If-None-Match strong", "/files/notes.txt?download=true", "If-None-Match", etag, http.StatusNotModified},
+		{"If-None-Match weak", "/files/notes.txt?download=true", "If-None-Match", "W/" + etag, http.StatusNotModified},
+		{"If-None-Match other", "/files/notes.txt?download=true", "If-None-Match", `"other"`, http.StatusOK},
+		{"If-None-Match raw", "/files/notes.txt/raw", "If-None-Match", etag, http.StatusNotModified},
+		{"If-Match raw other", "/files/notes.txt/raw", "If-Match", `"other"`, http.StatusPreconditionFailed},
+		// Only responses carrying the object can be answered from a cache.
+		{"If-None-Match redirect", "/files/notes.txt", "If-None-Match", etag, http.StatusFound},
+		{"If-None-Match metadata", "/files/notes.txt?download=true&metadata=true", "If-None-Match", etag, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newRequest(http.MethodGet, tt.path, "")
+			req.Header.Set(tt.header, tt.value)
+			if rec := serve(h, req); rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// corsMaxAge is how long, in seconds, browsers may cache a preflight result.
+const corsMaxAge = "600"
+
+// corsExposedHeaders are the response headers browser scripts may read
+// besides the CORS-safelisted ones.
+var corsExposedHeaders = strings.Join([]string{
+	"Content-Disposition",
+	"Content-Range",
+	"ETag",
+	"Retry-After",
+	requestIDHeader,
+}, ", ")
+
+// corsMiddleware lets browser pages served from origins call the API. An
+// origin of "*" allows any. Preflight requests are answered here, before
+// authentication, since browsers never send credentials with them; a
+// preflight from an origin that isn't allowed, or asking for a method that
+// isn't, gets 403. Other requests from disallowed origins are served without
+// CORS headers, so the browser withholds the response from the page.
+func corsMiddleware(origins, methods, headers []string, next http.Handler) http.Handler {
+	allowMethods := strings.Join(methods, ", ")
+	allowHeaders := strings.Join(headers, ", ")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Origin")
+
+		allowed := slices.Contains(origins, "*") || slices.Contains(origins, origin)
+		requestMethod := r.Header.Get("Access-Control-Request-Method")
+		if r.Method == http.MethodOptions && requestMethod != "" {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			if !allowed || !slices.Contains(methods, requestMethod) {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Methods", allowMethods)
+			w.Header().Set("Access-Control-Allow-Headers", allowHeaders)
+			w.Header().Set("Access-Control-Max-Age", corsMaxAge)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if allowed {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Expose-Headers", corsExposedHeaders)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"MinIO-Learn/internal/storage"
+)
+
+func TestCORS(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		origin     string
+		preflight  string
+		wantStatus int
+		wantOrigin string
+	}{
+		{"listed origin", http.MethodGet, "https://app.example.com", "", http.StatusOK, "https://app.example.com"},
+		{"unlisted origin", http.MethodGet, "https://evil.example.com", "", http.StatusOK, ""},
+		{"no origin", http.MethodGet, "", "", http.StatusOK, ""},
+		{"preflight from listed origin", http.MethodOptions, "https://app.example.com", "PUT", http.StatusNoContent, "https://app.example.com"},
+		{"preflight from unlisted origin", http.MethodOptions, "https://evil.example.com", "PUT", http.StatusForbidden, ""},
+		{"preflight for unlisted method", http.MethodOptions, "https://app.example.com", "PATCH", http.StatusForbidden, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStorage("test-bucket")
+			putObject(t, store, "notes.txt", "text/plain", []byte("hello"))
+			h := newTestServer(t, store, map[string]string{
+				"MINIO_CORS_ORIGINS": "https://app.example.com,https://admin.example.com",
+			})
+
+			req := newRequest(tt.method, "/files/notes.txt?download=true", "")
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.preflight != "" {
+				req.Header.Set("Access-Control-Request-Method", tt.preflight)
+			}
+			rec := serve(h, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+		})
+	}
+}
+
+func TestCORSDisabled(t *testing.T) {
+	store := storage.NewMemoryStorage("test-bucket")
+	putObject(t, store, "notes.txt", "text/plain", []byte("hello"))
+	h := newTestServer(t, store, map[string]string{"MINIO_CORS_ORIGINS": ""})
+
+	req := newRequest(http.MethodGet, "/files/notes.txt?download=true", "")
+	req.Header.Set("Origin", "https://app.example.com")
+	rec := serve(h, req)
+
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want none", got)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"MinIO-Learn/internal/storage"
+)
+
+// bucketEvent is the JSON body POSTed to the event webhook.
+type bucketEvent struct {
+	Event     string    `json:"event"`
+	Bucket    string    `json:"bucket"`
+	Key       string    `json:"key"`
+	Size      int64     `json:"size,omitempty"`
+	ETag      string    `json:"etag,omitempty"`
+	VersionID string    `json:"versionId,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// forwardBucketEvents relays object created and removed notifications for
+// keys matching prefix and suffix to notifier until ctx is cancelled. Each
+// event is delivered in the background with the notifier's signing and
+// retries, so a slow receiver doesn't hold up the subscription.
+func forwardBucketEvents(ctx context.Context, service *storage.MinIOService, notifier *webhookNotifier, prefix, suffix string) {
+	slog.Info("Forwarding bucket events", "url", notifier.url, "prefix", prefix, "suffix", suffix)
+
+	service.ListenObjectEvents(ctx, prefix, suffix, func(event storage.ObjectEvent) {
+		body, err := json.Marshal(bucketEvent{
+			Event:     event.Type,
+			Bucket:    event.Bucket,
+			Key:       event.Key,
+			Size:      event.Size,
+			ETag:      event.ETag,
+			VersionID: event.VersionID,
+			Timestamp: event.Time,
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to encode bucket event", "object", event.Key, "error", err)
+			return
+		}
+		notifier.background(func() { notifier.deliver(body, event.Key) })
+	})
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"MinIO-Learn/internal/storage"
+	"MinIO-Learn/internal/storage/storagetest"
+)
+
+// bucketNotification is one line of a MinIO ListenBucketNotification stream
+// reporting that key was created.
+const bucketNotification = `{"Records":[{"eventName":"s3:ObjectCreated:Put","eventTime":"2026-01-02T03:04:05Z",` +
+	`"s3":{"bucket":{"name":"test-bucket"},"object":{"key":"uploads%2Freport.pdf","size":8,"eTag":"abc123"}}}]}` + "\n"
+
+func TestForwardBucketEventsRetries(t *testing.T) {
+	// The receiver fails the first delivery and accepts the next.
+	var attempts atomic.Int32
+	deliveries := make(chan []byte, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		deliveries <- body
+	}))
+	t.Cleanup(receiver.Close)
+
+	// The fake sends one notification on the listen stream, then holds it
+	// open until the subscription is cancelled.
+	fake := storagetest.NewFakeS3()
+	fake.Before = func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method != http.MethodGet || !r.URL.Query().Has("events") {
+			return false
+		}
+		io.WriteString(w, bucketNotification)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+		return true
+	}
+	storageConfig, err := newStorageConfig(testConfig(t, fakeBackendEnv(t, fake)))
+	if err != nil {
+		t.Fatalf("newStorageConfig() error = %v", err)
+	}
+	service, err := storage.NewMinIOService(storageConfig)
+	if err != nil {
+		t.Fatalf("NewMinIOService() error = %v", err)
+	}
+
+	notifier := newWebhookNotifier(service, receiver.URL, "secret", func(fn func()) { go fn() })
+	notifier.retryDelay = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go forwardBucketEvents(ctx, service, notifier, "uploads/", "")
+
+	var body []byte
+	select {
+	case body = <-deliveries:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no event delivered after %d attempts", attempts.Load())
+	}
+	if got := attempts.Load(); got != 2 {
+		t.Errorf("delivery attempts = %d, want 2", got)
+	}
+
+	var event bucketEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		t.Fatalf("decoding event %q: %v", body, err)
+	}
+	want := bucketEvent{
+		Event:     "s3:ObjectCreated:Put",
+		Bucket:    "test-bucket",
+		Key:       "uploads/report.pdf",
+		Size:      8,
+		ETag:      "abc123",
+		Timestamp: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	if !event.Timestamp.Equal(want.Timestamp) {
+		t.Errorf("event timestamp = %v, want %v", event.Timestamp, want.Timestamp)
+	}
+	event.Timestamp = want.Timestamp
+	if event != want {
+		t.Errorf("event = %+v, want %+v", event, want)
+	}
+}
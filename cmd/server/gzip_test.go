@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"MinIO-Learn/internal/storage"
+)
+
+func TestGzipResponses(t *testing.T) {
+	text := bytes.Repeat([]byte("compress me please "), 100)
+	pngHeader := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+	tests := []struct {
+		name           string
+		key            string
+		contentType    string
+		data           []byte
+		acceptEncoding string
+		wantEncoding   string
+	}{
+		{"text with gzip", "notes.txt", "text/plain", text, "gzip, deflate", "gzip"},
+		{"json with gzip", "data.json", "application/json", []byte(`{"a":"` + string(text) + `"}`), "gzip", "gzip"},
+		{"image with gzip", "photo.png", "image/png", pngHeader, "gzip", ""},
+		{"text without gzip", "notes.txt", "text/plain", text, "", ""},
+		{"text with gzip refused", "notes.txt", "text/plain", text, "gzip;q=0", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStorage("test-bucket")
+			putObject(t, store, tt.key, tt.contentType, tt.data)
+			h := newTestServer(t, store, map[string]string{"MINIO_GZIP_RESPONSES": "true"})
+
+			req := newRequest(http.MethodGet, "/files/"+tt.key+"?download=true", "")
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rec := serve(h, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+			}
+			if got := rec.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			etag := rec.Header().Get("ETag")
+			if weak := strings.HasPrefix(etag, "W/"); etag == "" || weak != (tt.wantEncoding == "gzip") {
+				t.Errorf("ETag = %q, want it weak only when compressed", etag)
+			}
+
+			body := io.Reader(rec.Body)
+			if tt.wantEncoding == "gzip" {
+				gz, err := gzip.NewReader(rec.Body)
+				if err != nil {
+					t.Fatalf("gzip.NewReader() error = %v", err)
+				}
+				body = gz
+			}
+			got, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatalf("reading body: %v", err)
+			}
+			if !bytes.Equal(got, tt.data) {
+				t.Errorf("body = %q, want %q", got, tt.data)
+			}
+		})
+	}
+}
+
+func TestGzipResponsesDisabled(t *testing.T) {
+	store := storage.NewMemoryStorage("test-bucket")
+	putObject(t, store, "notes.txt", "text/plain", bytes.Repeat([]byte("a"), 1000))
+	h := newTestServer(t, store, map[string]string{"MINIO_GZIP_RESPONSES": "false"})
+
+	req := newRequest(http.MethodGet, "/files/notes.txt?download=true", "")
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := serve(h, req)
+
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("Content-Encoding = %q, want none", got)
+	}
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"deflate, gzip;q=0.5", true},
+		{"GZIP", true},
+		{"gzip;q=0", false},
+		{"br", false},
+	}
+
+	for _, tt := range tests {
+		req := newRequest(http.MethodGet, "/", "")
+		req.Header.Set("Accept-Encoding", tt.header)
+		if got := acceptsGzip(req); got != tt.want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestGzipResponsesSkipRanges(t *testing.T) {
+	data := bytes.Repeat([]byte("compress me please "), 100)
+	store := storage.NewMemoryStorage("test-bucket")
+	putObject(t, store, "notes.txt", "text/plain", data)
+	h := newTestServer(t, store, map[string]string{"MINIO_GZIP_RESPONSES": "true"})
+
+	tests := []struct {
+		name  string
+		rng   string
+		check func(t *testing.T, body []byte)
+	}{
+		{"single range", "bytes=100-199", func(t *testing.T, body []byte) {
+			if !bytes.Equal(body, data[100:200]) {
+				t.Errorf("body = %q, want bytes 100-199", body)
+			}
+		}},
+		{"several ranges", "bytes=0-9,500-509", func(t *testing.T, body []byte) {
+			if !bytes.Contains(body, data[500:510]) {
+				t.Errorf("body = %q, want it to contain bytes 500-509 uncompressed", body)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newRequest(http.MethodGet, "/files/notes.txt?download=true", "")
+			req.Header.Set("Accept-Encoding", "gzip")
+			req.Header.Set("Range", tt.rng)
+			rec := serve(h, req)
+
+			if rec.Code != http.StatusPartialContent {
+				t.Fatalf("status = %d, want 206: %s", rec.Code, rec.Body)
+			}
+			if got := rec.Header().Get("Content-Encoding"); got != "" {
+				t.Errorf("Content-Encoding = %q, want none", got)
+			}
+			tt.check(t, rec.Body.Bytes())
+		})
+	}
+}
+
+func TestGzipResponsesRevalidate(t *testing.T) {
+	store := storage.NewMemoryStorage("test-bucket")
+	putObject(t, store, "notes.txt", "text/plain", bytes.Repeat([]byte("compress me please "), 100))
+	h := newTestServer(t, store, map[string]string{"MINIO_GZIP_RESPONSES": "true"})
+
+	req := newRequest(http.MethodGet, "/files/notes.txt?download=true", "")
+	req.Header.Set("Accept-Encoding", "gzip")
+	etag := serve(h, req).Header().Get("ETag")
+
+	// A cache revalidates with the weak ETag it was given.
+	req = newRequest(http.MethodGet, "/files/notes.txt?download=true", "")
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("If-None-Match", etag)
+	if rec := serve(h, req); rec.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match %s: status = %d, want 304", etag, rec.Code)
+	}
+
+	// A weak ETag never passes If-Match.
+	req = newRequest(http.MethodGet, "/files/notes.txt?download=true", "")
+	req.Header.Set("If-Match", etag)
+	if rec := serve(h, req); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("If-Match %s: status = %d, want 412", etag, rec.Code)
+	}
+}
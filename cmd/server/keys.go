@@ -0,0 +1,57 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// keyStrategy builds the object key for an upload of fileName at now. Every
+// strategy mixes in randomness, so concurrent uploads of the same file never
+// share a key.
+type keyStrategy func(fileName string, now time.Time) string
+
+// keyStrategies are the values of MINIO_KEY_STRATEGY.
+var keyStrategies = map[string]keyStrategy{
+	// uploads/1704153600-1a2b3c4d-report.pdf
+	"timestamp": func(fileName string, now time.Time) string {
+		return fmt.Sprintf("uploads/%d-%s-%s", now.Unix(), uuid.NewString()[:8], fileName)
+	},
+	// uploads/0b7e9f4c-3d2a-4c1e-9f6b-8a5d2e7c1b3f.pdf
+	"uuid": func(fileName string, now time.Time) string {
+		return "uploads/" + uuid.NewString() + keyExt(fileName)
+	},
+	// uploads/2024/01/02/0b7e9f4c-3d2a-4c1e-9f6b-8a5d2e7c1b3f.pdf
+	"date": func(fileName string, now time.Time) string {
+		return now.UTC().Format("uploads/2006/01/02/") + uuid.NewString() + keyExt(fileName)
+	},
+	// uploads/5f/5f3a...e1.pdf, spreading keys evenly over 256 prefixes.
+	"hash": func(fileName string, now time.Time) string {
+		sum := sha256.Sum256([]byte(uuid.NewString() + fileName))
+		hash := hex.EncodeToString(sum[:16])
+		return "uploads/" + hash[:2] + "/" + hash + keyExt(fileName)
+	},
+}
+
+// keyExt returns the lowercased extension of fileName, which strategies that
+// hide the filename keep so the key still hints at the content.
+func keyExt(fileName string) string {
+	return strings.ToLower(filepath.Ext(fileName))
+}
+
+// objectKey returns the key to store an upload of fileName from r under,
+// using the configured strategy within r's tenant. The original filename is
+// kept in the object's metadata regardless.
+func (s *Server) objectKey(r *http.Request, fileName string) string {
+	strategy, ok := keyStrategies[s.config.KeyStrategy]
+	if !ok {
+		strategy = keyStrategies["timestamp"]
+	}
+	return tenantPrefix(r) + strategy(fileName, time.Now())
+}
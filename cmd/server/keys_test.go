@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"MinIO-Learn/internal/storage"
+)
+
+func TestKeyStrategies(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	const uuidPattern = `[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`
+
+	tests := []struct {
+		strategy string
+		want     *regexp.Regexp
+	}{
+		{"timestamp", regexp.MustCompile(`^uploads/1704164645-[0-9a-f]{8}-Q3 Report\.PDF$`)},
+		{"uuid", regexp.MustCompile(`^uploads/` + uuidPattern + `\.pdf$`)},
+		{"date", regexp.MustCompile(`^uploads/2024/01/02/` + uuidPattern + `\.pdf$`)},
+		{"hash", regexp.MustCompile(`^uploads/([0-9a-f]{2})/([0-9a-f]{32})\.pdf$`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			strategy, ok := keyStrategies[tt.strategy]
+			if !ok {
+				t.Fatalf("no %q key strategy", tt.strategy)
+			}
+
+			first := strategy("Q3 Report.PDF", now)
+			if !tt.want.MatchString(first) {
+				t.Errorf("key = %q, want it to match %s", first, tt.want)
+			}
+			if err := storage.ValidateObjectName(first); err != nil {
+				t.Errorf("key %q is not a valid object name: %v", first, err)
+			}
+			if match := tt.want.FindStringSubmatch(first); len(match) == 3 && !strings.HasPrefix(match[2], match[1]) {
+				t.Errorf("key %q isn't filed under its hash prefix", first)
+			}
+
+			if second := strategy("Q3 Report.PDF", now); second == first {
+				t.Errorf("two uploads of the same file at the same time both got %q", first)
+			}
+		})
+	}
+}
+
+func TestUploadKeyStrategy(t *testing.T) {
+	for strategy := range keyStrategies {
+		t.Run(strategy, func(t *testing.T) {
+			store := storage.NewMemoryStorage("test-bucket")
+			h := newTestServer(t, store, map[string]string{"MINIO_KEY_STRATEGY": strategy})
+
+			rec := serve(h, newUploadRequest(t, "report.pdf", []byte("%PDF-1.4")))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("upload status = %d: %s", rec.Code, rec.Body)
+			}
+			key := onlyObject(t, store)
+			if !strings.HasPrefix(key, "uploads/") || !strings.HasSuffix(key, ".pdf") {
+				t.Errorf("key = %q, want an uploads/ key ending in .pdf", key)
+			}
+
+			// The key may hide the filename, but downloads still use it.
+			rec = serve(h, newRequest(http.MethodGet, "/files/"+key+"?download=true", ""))
+			if got := rec.Header().Get("Content-Disposition"); got != "attachment; filename=report.pdf" {
+				t.Errorf("Content-Disposition = %q, want the original filename", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// semaphore caps concurrent holders. A nil *semaphore never blocks.
+type semaphore struct {
+	slots chan struct{}
+}
+
+func newSemaphore(n int) *semaphore {
+	if n <= 0 {
+		return nil
+	}
+	return &semaphore{slots: make(chan struct{}, n)}
+}
+
+// acquire waits up to timeout for a free slot and reports whether one was
+// obtained.
+func (s *semaphore) acquire(ctx context.Context, timeout time.Duration) bool {
+	if s == nil {
+		return true
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case s.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (s *semaphore) release() {
+	if s == nil {
+		return
+	}
+	<-s.slots
+}
+
+// acquireDownload queues for a slot in sem. If none frees up within the
+// configured queue timeout it answers 503 with Retry-After and returns false.
+// Callers that get true must release the slot.
+func (s *Server) acquireDownload(w http.ResponseWriter, r *http.Request, sem *semaphore) bool {
+	if sem.acquire(r.Context(), s.config.DownloadQueueTimeout) {
+		return true
+	}
+
+	w.Header().Set("Retry-After", fmt.Sprintf("%d", retryAfterSeconds(s.config.DownloadQueueTimeout)))
+	sendResponse(w, false, "Too many concurrent downloads, try again later", nil, http.StatusServiceUnavailable)
+	return false
+}
+
+func retryAfterSeconds(d time.Duration) int {
+	seconds := int(d.Round(time.Second) / time.Second)
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
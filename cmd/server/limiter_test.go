@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"MinIO-Learn/internal/storage"
+)
+
+// blockingStorage holds every download until release is closed, announcing
+// each one on started.
+type blockingStorage struct {
+	storage.Storage
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingStorage) DownloadToWriterWithOptions(ctx context.Context, objectName string, opts storage.ReadOptions, w io.Writer) (int64, error) {
+	b.started <- struct{}{}
+	select {
+	case <-b.release:
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+	return b.Storage.DownloadToWriterWithOptions(ctx, objectName, opts, w)
+}
+
+func TestBufferedDownloadLimit(t *testing.T) {
+	tests := []struct {
+		name           string
+		limit          int
+		queueTimeout   string
+		wantStatus     int
+		wantRetryAfter string
+	}{
+		{"rejected after queueing", 2, "10ms", http.StatusServiceUnavailable, "1"},
+		{"retry after the queue timeout", 1, "1500ms", http.StatusServiceUnavailable, "2"},
+		{"queued until a slot frees", 2, "5s", http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memory := storage.NewMemoryStorage("test-bucket")
+			putObject(t, memory, "report.txt", "text/plain", []byte("report"))
+			store := &blockingStorage{Storage: memory, started: make(chan struct{}, tt.limit+1), release: make(chan struct{})}
+			h := newTestServer(t, store, map[string]string{
+				"MINIO_MAX_BUFFERED_DOWNLOADS": strconv.Itoa(tt.limit),
+				"MINIO_DOWNLOAD_QUEUE_TIMEOUT": tt.queueTimeout,
+			})
+
+			done := make(chan int, tt.limit)
+			for range tt.limit {
+				go func() {
+					done <- serve(h, newRequest(http.MethodGet, "/files/report.txt/raw", "")).Code
+				}()
+			}
+			for range tt.limit {
+				select {
+				case <-store.started:
+				case <-time.After(5 * time.Second):
+					t.Fatal("downloads within the limit did not start")
+				}
+			}
+
+			extra := make(chan int, 1)
+			var resp *http.Response
+			go func() {
+				rec := serve(h, newRequest(http.MethodGet, "/files/report.txt/raw", ""))
+				resp = rec.Result()
+				extra <- rec.Code
+			}()
+
+			if tt.wantStatus == http.StatusOK {
+				select {
+				case <-store.started:
+					t.Fatal("download over the limit started while all slots were held")
+				case <-time.After(50 * time.Millisecond):
+				}
+				close(store.release)
+			}
+
+			var code int
+			select {
+			case code = <-extra:
+			case <-time.After(10 * time.Second):
+				t.Fatal("download over the limit never finished")
+			}
+			if code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", code, tt.wantStatus)
+			}
+			if got := resp.Header.Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.wantRetryAfter)
+			}
+
+			if tt.wantStatus != http.StatusOK {
+				close(store.release)
+			}
+			for range tt.limit {
+				if code := <-done; code != http.StatusOK {
+					t.Errorf("download within the limit status = %d, want 200", code)
+				}
+			}
+		})
+	}
+}
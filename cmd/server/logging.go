@@ -0,0 +1,25 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+
+	"MinIO-Learn/internal/config"
+)
+
+// newLogger builds the process logger from MINIO_LOG_LEVEL and
+// MINIO_LOG_FORMAT. The default text format stays readable for local
+// development; use json for log aggregation.
+func newLogger(cfg config.MinIOConfig) *slog.Logger {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+		level = slog.LevelInfo
+	}
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler = slog.NewTextHandler(os.Stderr, opts)
+	if cfg.LogFormat == "json" {
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	}
+	return slog.New(requestIDHandler{handler})
+}
@@ -2,39 +2,35 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"mime"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
+	"MinIO-Learn/internal/config"
+	"MinIO-Learn/internal/storage"
 	"github.com/minio/minio-go/v7"
-	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
 )
 
-type MinIOConfig struct {
-	Endpoint        string
-	AccessKeyID     string
-	SecretAccessKey string
-	UseSSL          bool
-	BucketName      string
-	Location        string
-}
-
-type MinIOService struct {
-	Client     *minio.Client
-	BucketName string
-	Location   string
-}
-
 type Response struct {
-	Success bool        `json:"success"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
+	Success   bool        `json:"success"`
+	Message   string      `json:"message"`
+	Data      interface{} `json:"data,omitempty"`
+	RequestID string      `json:"requestId,omitempty"`
 }
 
 type FileInfo struct {
@@ -42,267 +38,615 @@ type FileInfo struct {
 	Size        int64     `json:"size"`
 	ContentType string    `json:"contentType"`
 	URL         string    `json:"url,omitempty"`
+	SHA256      string    `json:"sha256,omitempty"`
 	UploadedAt  time.Time `json:"uploadedAt"`
 }
 
-var minioService *MinIOService
+// loadConfig reads the configuration from MINIO_CONFIG_FILE when it is set,
+// and from the environment alone otherwise.
+func loadConfig() (config.MinIOConfig, error) {
+	if path := os.Getenv("MINIO_CONFIG_FILE"); path != "" {
+		return config.LoadMinIOConfigFromFile(path)
+	}
+	return config.LoadMinIOConfig()
+}
+
+// useTempDir makes dir, if set, the directory temporary files are created
+// in, creating it if needed. ParseMultipartForm spools large uploads to
+// os.TempDir and offers no other way to move them, so this sets TMPDIR for
+// the whole process.
+func useTempDir(dir string) error {
+	if dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	return os.Setenv("TMPDIR", dir)
+}
 
 func main() {
-	config, err := loadMinIOConfig()
+	cfg, err := loadConfig()
 	if err != nil {
-		log.Fatalf("Failed to load MinIO configuration: %v", err)
+		slog.Error("Failed to load MinIO configuration", "error", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(newLogger(cfg))
+
+	if err := useTempDir(cfg.TempDir); err != nil {
+		slog.Error("Failed to set up MINIO_TEMP_DIR", "dir", cfg.TempDir, "error", err)
+		os.Exit(1)
 	}
 
-	minioService, err = newMinIOService(config)
+	if (len(os.Args) > 1 && os.Args[1] == "selftest") || cfg.SelfTest {
+		os.Exit(runSelfTest(cfg, os.Stdout))
+	}
+	if len(os.Args) > 1 {
+		os.Exit(runCLI(cfg, os.Args[1:]))
+	}
+
+	storageConfig, err := newStorageConfig(cfg)
 	if err != nil {
-		log.Fatalf("Failed to initialize MinIO service: %v", err)
+		slog.Error("Failed to configure MinIO service", "error", err)
+		os.Exit(1)
 	}
 
-	http.HandleFunc("/upload", uploadHandler)
-	http.HandleFunc("/files", listFilesHandler)
-	http.HandleFunc("/files/", getFileHandler)
-	http.HandleFunc("/health", healthCheckHandler)
+	service, err := storage.NewMinIOService(storageConfig)
+	if err != nil {
+		slog.Error("Failed to initialize MinIO service", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("MinIO service initialized successfully", "endpoint", cfg.Endpoint, "bucket", cfg.BucketName)
 
-	port := getEnv("PORT", "8080")
-	log.Printf("Server starting on port %s...", port)
-	log.Fatal(http.ListenAndServe(":"+port, nil))
-}
+	srv := NewServer(service, cfg)
 
-func loadMinIOConfig() (MinIOConfig, error) {
-	config := MinIOConfig{
-		Endpoint:        getEnv("MINIO_ENDPOINT", "localhost:9000"),
-		AccessKeyID:     getEnv("MINIO_ACCESS_KEY", "minio_admin"),
-		SecretAccessKey: getEnv("MINIO_SECRET_KEY", "minio_password"),
-		UseSSL:          getEnvBool("MINIO_USE_SSL", false),
-		BucketName:      getEnv("MINIO_BUCKET", "mybucket"),
-		Location:        getEnv("MINIO_LOCATION", "us-east-1"),
+	if len(cfg.Buckets) > 0 {
+		if err := srv.provisionBuckets(cfg.Buckets); err != nil {
+			slog.Error("Failed to provision buckets", "error", err)
+			os.Exit(1)
+		}
 	}
 
-	if config.Endpoint == "" {
-		return config, fmt.Errorf("MINIO_ENDPOINT is required")
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if cfg.SweepInterval > 0 {
+		go srv.runRetentionSweeper(ctx, cfg.SweepInterval, cfg.SweepPrefix, cfg.SweepDryRun)
 	}
-	if config.AccessKeyID == "" {
-		return config, fmt.Errorf("MINIO_ACCESS_KEY is required")
+	if cfg.UploadSessionTTL > 0 {
+		go srv.runSessionSweeper(ctx, min(cfg.UploadSessionTTL, time.Hour), cfg.UploadSessionTTL)
 	}
-	if config.SecretAccessKey == "" {
-		return config, fmt.Errorf("MINIO_SECRET_KEY is required")
+
+	if cfg.EventWebhook != "" {
+		notifier := newWebhookNotifier(srv.storage, cfg.EventWebhook, cfg.WebhookSecret, srv.postUpload.Go)
+		go forwardBucketEvents(ctx, service, notifier, cfg.EventPrefix, cfg.EventSuffix)
 	}
-	if config.BucketName == "" {
-		return config, fmt.Errorf("MINIO_BUCKET is required")
+
+	server := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           srv.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
 	}
 
-	return config, nil
+	slog.Info("Server starting", "port", cfg.Port)
+	if err := runServer(ctx, server, cfg.ShutdownTimeout, srv.postUpload.Wait); err != nil {
+		slog.Error("Server failed", "error", err)
+		os.Exit(1)
+	}
 }
 
-func newMinIOService(config MinIOConfig) (*MinIOService, error) {
-	client, err := minio.New(config.Endpoint, &minio.Options{
-		Creds:  credentials.NewStaticV4(config.AccessKeyID, config.SecretAccessKey, ""),
-		Secure: config.UseSSL,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialize MinIO client: %w", err)
+func newStorageConfig(cfg config.MinIOConfig) (storage.Config, error) {
+	storageConfig := storage.Config{
+		Endpoint:              cfg.Endpoint,
+		AccessKeyID:           cfg.AccessKeyID,
+		SecretAccessKey:       cfg.SecretAccessKey,
+		UseSSL:                cfg.UseSSL,
+		BucketName:            cfg.BucketName,
+		Location:              cfg.Location,
+		CredsMode:             cfg.CredsMode,
+		IAMEndpoint:           cfg.IAMEndpoint,
+		STSEndpoint:           cfg.STSEndpoint,
+		RoleARN:               cfg.RoleARN,
+		SignatureVersion:      cfg.SignatureVersion,
+		ConnectTimeout:        cfg.ConnectTimeout,
+		DialTimeout:           cfg.DialTimeout,
+		ResponseHeaderTimeout: cfg.ResponseHeaderTimeout,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		CACertFile:            cfg.CACertFile,
+		RegionEndpoints:       cfg.RegionEndpoints,
+		ObjectLock:            cfg.ObjectLock,
+		AllowPublic:           cfg.AllowPublic,
+		SoftDelete:            cfg.SoftDelete,
+		MultiTenant:           cfg.MultiTenant,
+		StatCacheTTL:          cfg.StatCacheTTL,
+		PartSize:              uint64(cfg.PartSize),
+		UploadThreads:         uint(cfg.UploadThreads),
+		UploadConcurrency:     cfg.UploadConcurrency,
+		MaxDownloadBuffer:     cfg.MaxDownloadBuffer,
+		Compress:              cfg.CompressUploads,
+		StorageClass:          cfg.StorageClass,
+		Logger:                slog.Default(),
 	}
 
-	service := &MinIOService{
-		Client:     client,
-		BucketName: config.BucketName,
-		Location:   config.Location,
+	if cfg.StorageClass != "" {
+		if err := storage.ValidateStorageClass(cfg.StorageClass); err != nil {
+			return storageConfig, fmt.Errorf("MINIO_STORAGE_CLASS: %w", err)
+		}
 	}
 
-	err = service.ensureBucket()
-	if err != nil {
-		return nil, fmt.Errorf("failed to ensure bucket exists: %w", err)
+	switch cfg.SSE {
+	case "s3":
+		storageConfig.Encryption = encrypt.NewSSE()
+	case "c":
+		sse, err := encrypt.NewSSEC(cfg.SSECKey)
+		if err != nil {
+			return storageConfig, fmt.Errorf("invalid SSE-C key: %w", err)
+		}
+		storageConfig.Encryption = sse
 	}
 
-	log.Printf("MinIO service initialized successfully (endpoint: %s, bucket: %s)", config.Endpoint, config.BucketName)
-	return service, nil
+	return storageConfig, nil
 }
 
-func (s *MinIOService) ensureBucket() error {
-	ctx := context.Background()
-	exists, err := s.Client.BucketExists(ctx, s.BucketName)
-	if err != nil {
-		return fmt.Errorf("failed to check if bucket exists: %w", err)
+func (s *Server) provisionBuckets(buckets []config.BucketConfig) error {
+	specs := make([]storage.BucketSpec, 0, len(buckets))
+	for _, b := range buckets {
+		specs = append(specs, storage.BucketSpec{
+			Name:             b.Name,
+			Region:           b.Region,
+			Versioning:       b.Versioning,
+			ExpireDays:       b.ExpireDays,
+			ExpirePrefix:     b.ExpirePrefix,
+			PublicReadPrefix: b.PublicReadPrefix,
+		})
 	}
 
-	if !exists {
-		err = s.Client.MakeBucket(ctx, s.BucketName, minio.MakeBucketOptions{Region: s.Location})
-		if err != nil {
-			return fmt.Errorf("failed to create bucket: %w", err)
+	results, err := s.storage.EnsureBuckets(context.Background(), specs)
+	for _, result := range results {
+		if result.Created {
+			slog.Info("Bucket created", "bucket", result.Name)
+		} else {
+			slog.Info("Bucket already exists", "bucket", result.Name)
 		}
-		log.Printf("Bucket '%s' created successfully", s.BucketName)
-	} else {
-		log.Printf("Bucket '%s' already exists", s.BucketName)
 	}
-
-	return nil
+	return err
 }
 
-func (s *MinIOService) uploadFile(objectName, filePath, contentType string) (minio.UploadInfo, error) {
-	ctx := context.Background()
-	file, err := os.Open(filePath)
-	if err != nil {
-		return minio.UploadInfo{}, fmt.Errorf("failed to open file: %w", err)
+func (s *Server) uploadHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPut {
+		s.rawUploadHandler(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		sendResponse(w, false, "Method not allowed", nil, http.StatusMethodNotAllowed)
+		return
 	}
-	defer file.Close()
 
-	fileInfo, err := file.Stat()
-	if err != nil {
-		return minio.UploadInfo{}, fmt.Errorf("failed to get file stats: %w", err)
+	if !s.limitUploadBody(w, r) {
+		return
+	}
+	s.guardUploadBody(w, r)
+	if err := r.ParseMultipartForm(10 << 20); errors.Is(err, errSlowUpload) {
+		sendResponse(w, false, "Upload aborted: "+err.Error(), nil, http.StatusRequestTimeout)
+		return
+	} else if bodyTooLarge(err) {
+		s.sendUploadTooLarge(w)
+		return
+	} else if err != nil {
+		sendResponse(w, false, "Error parsing upload: "+err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	// ReadForm already removed the spooled files if parsing failed; remove
+	// them as soon as we are done rather than when the server finishes the
+	// request.
+	defer r.MultipartForm.RemoveAll()
+
+	if _, err := requestStorageClass(r); err != nil {
+		sendResponse(w, false, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+
+	fileHeaders := r.MultipartForm.File["file"]
+	if len(fileHeaders) == 0 {
+		sendResponse(w, false, "Error retrieving file: "+http.ErrMissingFile.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	if len(fileHeaders) > 1 {
+		s.uploadMultipleFiles(w, r, fileHeaders)
+		return
 	}
 
-	uploadInfo, err := s.Client.PutObject(ctx, s.BucketName, objectName, file, fileInfo.Size(),
-		minio.PutObjectOptions{ContentType: contentType})
+	fileInfo, err := s.storeFormFile(r, fileHeaders[0], true)
 	if err != nil {
-		return minio.UploadInfo{}, fmt.Errorf("failed to upload file: %w", err)
+		sendUploadError(w, err)
+		return
 	}
 
-	log.Printf("File '%s' uploaded successfully as '%s' (size: %d bytes)", filePath, objectName, uploadInfo.Size)
-	return uploadInfo, nil
+	sendResponse(w, true, "File uploaded successfully", fileInfo, http.StatusOK)
 }
 
-func (s *MinIOService) downloadFile(objectName, filePath string) error {
-	ctx := context.Background()
-	err := s.Client.FGetObject(ctx, s.BucketName, objectName, filePath, minio.GetObjectOptions{})
-	if err != nil {
-		return fmt.Errorf("failed to download file: %w", err)
+// requestStorageClass returns the storage class requested with a
+// "storageClass" form field or query parameter, or "" for the default.
+func requestStorageClass(r *http.Request) (string, error) {
+	class := r.URL.Query().Get("storageClass")
+	if r.MultipartForm != nil {
+		if values := r.MultipartForm.Value["storageClass"]; len(values) > 0 {
+			class = values[0]
+		}
+	}
+	if class == "" {
+		return "", nil
 	}
+	return class, storage.ValidateStorageClass(class)
+}
 
-	log.Printf("File '%s' downloaded successfully to '%s'", objectName, filePath)
-	return nil
+// formMetadata collects user metadata from multipart form fields named
+// "meta-<key>", e.g. a "meta-owner" field is stored as x-amz-meta-owner.
+func formMetadata(r *http.Request) map[string]string {
+	metadata := make(map[string]string)
+	if r.MultipartForm == nil {
+		return metadata
+	}
+
+	for field, values := range r.MultipartForm.Value {
+		key, ok := strings.CutPrefix(strings.ToLower(field), "meta-")
+		if !ok || key == "" || len(values) == 0 {
+			continue
+		}
+		metadata[key] = values[0]
+	}
+	return metadata
 }
 
-func (s *MinIOService) downloadBuffer(objectName string) ([]byte, error) {
-	ctx := context.Background()
-	obj, err := s.Client.GetObject(ctx, s.BucketName, objectName, minio.GetObjectOptions{})
+// rawUploadHandler handles PUT /upload?filename=name, where the request body
+// is the file itself. The body may be sent with chunked transfer encoding, in
+// which case r.ContentLength is -1 and the object is streamed in parts. With
+// &uploadId=id its progress can be followed on /upload/progress?uploadId=id.
+func (s *Server) rawUploadHandler(w http.ResponseWriter, r *http.Request) {
+	fileName := filepath.Base(r.URL.Query().Get("filename"))
+	if fileName == "" || fileName == "." || fileName == "/" {
+		sendResponse(w, false, "filename query parameter is required", nil, http.StatusBadRequest)
+		return
+	}
+
+	objectName := s.objectKey(r, fileName)
+
+	storageClass, err := requestStorageClass(r)
+	if err != nil {
+		sendResponse(w, false, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	metadata := storage.OriginalFilenameMetadata(fileName)
+	if storageClass != "" {
+		for k, v := range storage.StorageClassMetadata(storageClass) {
+			metadata[k] = v
+		}
+	}
+
+	if !s.limitUploadBody(w, r) {
+		return
+	}
+	s.guardUploadBody(w, r)
+	contentType, body, err := sniffStream(r.Header.Get("Content-Type"), r.Body)
+	if errors.Is(err, errSlowUpload) {
+		sendResponse(w, false, "Upload aborted: "+err.Error(), nil, http.StatusRequestTimeout)
+		return
+	}
+	if bodyTooLarge(err) {
+		s.sendUploadTooLarge(w)
+		return
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get object: %w", err)
+		sendResponse(w, false, "Error reading upload: "+err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+
+	if s.config.ValidateContent {
+		if validator := contentValidatorFor(contentType); validator != nil {
+			// Validators read the whole body, which must then be read
+			// again to store it.
+			spooled, cleanup, err := spoolUpload(body)
+			if errors.Is(err, errSlowUpload) {
+				sendResponse(w, false, "Upload aborted: "+err.Error(), nil, http.StatusRequestTimeout)
+				return
+			}
+			if bodyTooLarge(err) {
+				s.sendUploadTooLarge(w)
+				return
+			}
+			if err != nil {
+				sendResponse(w, false, "Error reading upload: "+err.Error(), nil, http.StatusBadRequest)
+				return
+			}
+			defer cleanup()
+
+			if err := validator(spooled); err != nil {
+				sendResponse(w, false, "Invalid file content: "+err.Error(), nil, http.StatusUnprocessableEntity)
+				return
+			}
+			if _, err := spooled.Seek(0, io.SeekStart); err != nil {
+				sendResponse(w, false, "Error rewinding file: "+err.Error(), nil, http.StatusInternalServerError)
+				return
+			}
+			body = spooled
+		}
 	}
-	defer obj.Close()
 
-	data, err := io.ReadAll(obj)
+	hasher := sha256.New()
+	body = io.TeeReader(body, hasher)
+
+	ctx, endProgress := s.trackUpload(r, r.ContentLength)
+	uploadInfo, err := s.storage.UploadStream(ctx, objectName, body, r.ContentLength, contentType, metadata)
+	endProgress(err)
+	if errors.Is(err, errSlowUpload) {
+		sendResponse(w, false, "Upload aborted: "+err.Error(), nil, http.StatusRequestTimeout)
+		return
+	}
+	if bodyTooLarge(err) {
+		s.sendUploadTooLarge(w)
+		return
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to read object data: %w", err)
+		sendResponse(w, false, "Error uploading to MinIO: "+err.Error(), nil, storageErrorStatus(err))
+		return
+	}
+
+	checksum := hex.EncodeToString(hasher.Sum(nil))
+	s.indexContentHash(r, checksum, objectName)
+
+	url := s.objectURL(r, objectName, s.config.PresignExpiry)
+
+	fileInfo := FileInfo{
+		FileName:    fileName,
+		Size:        uploadInfo.Size,
+		ContentType: contentType,
+		URL:         url,
+		SHA256:      checksum,
+		UploadedAt:  time.Now(),
+	}
+
+	if !s.runPostUpload(w, r, fileInfo, objectName) {
+		return
 	}
 
-	log.Printf("File '%s' downloaded successfully as buffer (size: %d bytes)", objectName, len(data))
-	return data, nil
+	sendResponse(w, true, "File uploaded successfully", fileInfo, http.StatusOK)
 }
 
-func (s *MinIOService) listObjects(prefix string) ([]minio.ObjectInfo, error) {
-	ctx := context.Background()
-	objectCh := s.Client.ListObjects(ctx, s.BucketName, minio.ListObjectsOptions{
-		Prefix:    prefix,
-		Recursive: true,
-	})
+// runPostUpload runs the post-upload pipeline and, if a synchronous processor
+// fails, removes the object and reports the failure. It returns false when a
+// response has already been sent.
+func (s *Server) runPostUpload(w http.ResponseWriter, r *http.Request, fileInfo FileInfo, objectName string) bool {
+	if err := s.postProcessUpload(r, fileInfo, objectName); err != nil {
+		sendResponse(w, false, err.Error(), nil, http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
 
-	var objects []minio.ObjectInfo
-	for object := range objectCh {
-		if object.Err != nil {
-			return nil, fmt.Errorf("error listing objects: %w", object.Err)
+// postProcessUpload runs the post-upload pipeline, removing the object if a
+// synchronous processor fails.
+func (s *Server) postProcessUpload(r *http.Request, fileInfo FileInfo, objectName string) error {
+	if err := s.postUpload.Run(r.Context(), fileInfo, objectName); err != nil {
+		if delErr := s.storage.DeleteObject(r.Context(), objectName); delErr != nil {
+			slog.WarnContext(r.Context(), "Failed to remove object after post-upload failure", "object", objectName, "error", delErr)
 		}
-		objects = append(objects, object)
+		return fmt.Errorf("Error processing upload: %w", err)
 	}
+	return nil
+}
 
-	log.Printf("Listed %d objects with prefix '%s'", len(objects), prefix)
-	return objects, nil
+type uploadURLInfo struct {
+	Key       string    `json:"key"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
 }
 
-func (s *MinIOService) deleteObject(objectName string) error {
-	ctx := context.Background()
-	err := s.Client.RemoveObject(ctx, s.BucketName, objectName, minio.RemoveObjectOptions{})
+// uploadURLHandler issues a presigned PUT URL for a key chosen by the server
+// with the configured key strategy. Only the base name of the client's
+// ?filename= is used, so clients cannot place objects at arbitrary paths.
+func (s *Server) uploadURLHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendResponse(w, false, "Method not allowed", nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	fileName := filepath.Base(r.URL.Query().Get("filename"))
+	if fileName == "" || fileName == "." || fileName == "/" {
+		sendResponse(w, false, "filename query parameter is required", nil, http.StatusBadRequest)
+		return
+	}
+	objectName := s.objectKey(r, fileName)
+
+	expiry := s.presignExpiry(r)
+	url, err := s.storage.GetUploadURL(r.Context(), objectName, expiry)
 	if err != nil {
-		return fmt.Errorf("failed to delete object: %w", err)
+		sendResponse(w, false, "Error generating upload URL: "+err.Error(), nil, http.StatusInternalServerError)
+		return
 	}
 
-	log.Printf("Object '%s' deleted successfully", objectName)
-	return nil
+	sendResponse(w, true, "Upload URL generated", uploadURLInfo{
+		Key:       objectName,
+		URL:       url,
+		ExpiresAt: time.Now().Add(expiry),
+	}, http.StatusOK)
+}
+
+type uploadPolicyInfo struct {
+	URL       string            `json:"url"`
+	Fields    map[string]string `json:"fields"`
+	KeyPrefix string            `json:"keyPrefix"`
+	MaxSize   int64             `json:"maxSize"`
+	ExpiresAt time.Time         `json:"expiresAt"`
 }
 
-func (s *MinIOService) getObjectURL(objectName string, expiry time.Duration) (string, error) {
-	ctx := context.Background()
-	presignedURL, err := s.Client.PresignedGetObject(ctx, s.BucketName, objectName, expiry, nil)
+// uploadPolicyHandler serves GET /upload-policy?prefix=uploads/, returning a
+// presigned POST policy for browser form uploads. The browser must submit
+// every returned field plus "key" (starting with the prefix) and "file".
+func (s *Server) uploadPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendResponse(w, false, "Method not allowed", nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	prefix := r.URL.Query().Get("prefix")
+	if prefix == "" {
+		prefix = "uploads/"
+	}
+	// A prefix of SystemPrefix would let the browser pick a key inside it.
+	if strings.HasPrefix(prefix, "/") || strings.Contains(prefix, "..") ||
+		storage.IsSystemKey(prefix) || strings.HasPrefix(storage.SystemPrefix, prefix) {
+		sendResponse(w, false, "Invalid prefix", nil, http.StatusBadRequest)
+		return
+	}
+	prefix = tenantPrefix(r) + prefix
+
+	expiry := s.config.PostPolicyExpiry
+	url, fields, err := s.storage.GetPresignedPostPolicy(r.Context(), prefix, 1, s.config.PostPolicyMaxSize, expiry)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate presigned URL: %w", err)
+		sendResponse(w, false, "Error generating upload policy: "+err.Error(), nil, http.StatusInternalServerError)
+		return
 	}
 
-	log.Printf("Generated presigned URL for '%s' (valid for %v)", objectName, expiry)
-	return presignedURL.String(), nil
+	sendResponse(w, true, "Upload policy generated", uploadPolicyInfo{
+		URL:       url,
+		Fields:    fields,
+		KeyPrefix: prefix,
+		MaxSize:   s.config.PostPolicyMaxSize,
+		ExpiresAt: time.Now().Add(expiry),
+	}, http.StatusOK)
 }
 
-func (s *MinIOService) checkObjectExists(objectName string) (bool, error) {
-	ctx := context.Background()
-	_, err := s.Client.StatObject(ctx, s.BucketName, objectName, minio.StatObjectOptions{})
-	if err != nil {
-		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
-			return false, nil
-		}
-		return false, fmt.Errorf("failed to check if object exists: %w", err)
+// indexContentHash records objectName in the content index of r's tenant.
+func (s *Server) indexContentHash(r *http.Request, hash, objectName string) {
+	ctx := r.Context()
+	if err := s.storage.IndexContentHash(ctx, tenantPrefix(r), hash, objectName); err != nil {
+		slog.WarnContext(ctx, "Failed to index content hash", "object", objectName, "error", err)
 	}
+}
 
-	return true, nil
+type uploadCheckResult struct {
+	Exists bool   `json:"exists"`
+	Key    string `json:"key,omitempty"`
+	URL    string `json:"url,omitempty"`
 }
 
-func uploadHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
+// uploadCheckHandler lets a client ask whether content with a given SHA-256
+// (and optionally size) was already uploaded, so retries can skip the
+// transfer. GET answers with JSON; HEAD answers with 200 or 404 and the
+// existing key in X-Object-Key.
+func (s *Server) uploadCheckHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
 		sendResponse(w, false, "Method not allowed", nil, http.StatusMethodNotAllowed)
 		return
 	}
 
-	r.ParseMultipartForm(10 << 20)
+	hash := strings.ToLower(r.URL.Query().Get("hash"))
+	if hash == "" {
+		sendResponse(w, false, "hash query parameter is required", nil, http.StatusBadRequest)
+		return
+	}
+
+	size := int64(-1)
+	if value := r.URL.Query().Get("size"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed < 0 {
+			sendResponse(w, false, "size must be a non-negative integer", nil, http.StatusBadRequest)
+			return
+		}
+		size = parsed
+	}
 
-	file, handler, err := r.FormFile("file")
+	info, found, err := s.storage.LookupContentHash(r.Context(), tenantPrefix(r), hash, size)
 	if err != nil {
-		sendResponse(w, false, "Error retrieving file: "+err.Error(), nil, http.StatusBadRequest)
+		sendResponse(w, false, "Error checking content: "+err.Error(), nil, http.StatusBadRequest)
 		return
 	}
-	defer file.Close()
 
-	tempFile, err := os.CreateTemp("", "upload-*"+filepath.Ext(handler.Filename))
-	if err != nil {
-		sendResponse(w, false, "Error creating temporary file: "+err.Error(), nil, http.StatusInternalServerError)
+	if !found || !ownsObject(r, info.Key) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		sendResponse(w, true, "Content not uploaded yet", uploadCheckResult{Exists: false}, http.StatusNotFound)
 		return
 	}
-	defer os.Remove(tempFile.Name())
-	defer tempFile.Close()
 
-	_, err = io.Copy(tempFile, file)
-	if err != nil {
-		sendResponse(w, false, "Error saving temporary file: "+err.Error(), nil, http.StatusInternalServerError)
+	url := s.objectURL(r, info.Key, s.config.PresignExpiry)
+
+	if r.Method == http.MethodHead {
+		w.Header().Set("X-Object-Key", info.Key)
+		if url != "" {
+			w.Header().Set("Location", url)
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	sendResponse(w, true, "Content already uploaded", uploadCheckResult{Exists: true, Key: info.Key, URL: url}, http.StatusOK)
+}
+
+// filesRootHandler routes /files: GET lists, DELETE removes in bulk.
+func (s *Server) filesRootHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodDelete {
+		s.batchDeleteHandler(w, r)
 		return
 	}
-	tempFile.Close()
+	s.listFilesHandler(w, r)
+}
+
+type batchDeleteRequest struct {
+	Keys []string `json:"keys"`
+}
+
+type batchDeleteFailure struct {
+	Key   string `json:"key"`
+	Error string `json:"error"`
+}
 
-	objectName := fmt.Sprintf("uploads/%d-%s", time.Now().Unix(), handler.Filename)
+type batchDeleteResult struct {
+	Deleted int                  `json:"deleted"`
+	Failed  []batchDeleteFailure `json:"failed,omitempty"`
+}
 
-	contentType := handler.Header.Get("Content-Type")
-	if contentType == "" {
-		contentType = "application/octet-stream"
+func (s *Server) batchDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		sendResponse(w, false, "Method not allowed", nil, http.StatusMethodNotAllowed)
+		return
 	}
 
-	uploadInfo, err := minioService.uploadFile(objectName, tempFile.Name(), contentType)
-	if err != nil {
-		sendResponse(w, false, "Error uploading to MinIO: "+err.Error(), nil, http.StatusInternalServerError)
+	var req batchDeleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendResponse(w, false, "Invalid request body: "+err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	if len(req.Keys) == 0 {
+		sendResponse(w, false, "keys must not be empty", nil, http.StatusBadRequest)
 		return
 	}
 
-	url, err := minioService.getObjectURL(objectName, time.Hour*24)
-	if err != nil {
-		log.Printf("Warning: Failed to generate presigned URL: %v", err)
+	if !checkTenant(w, r, req.Keys...) {
+		return
 	}
 
-	fileInfo := FileInfo{
-		FileName:    handler.Filename,
-		Size:        uploadInfo.Size,
-		ContentType: contentType,
-		URL:         url,
-		UploadedAt:  time.Now(),
+	errs := s.storage.DeleteObjects(r.Context(), req.Keys)
+
+	result := batchDeleteResult{Deleted: len(req.Keys) - len(errs)}
+	for _, e := range errs {
+		result.Failed = append(result.Failed, batchDeleteFailure{Key: e.ObjectName, Error: e.Err.Error()})
 	}
 
-	sendResponse(w, true, "File uploaded successfully", fileInfo, http.StatusOK)
+	if len(result.Failed) > 0 {
+		sendResponse(w, false, fmt.Sprintf("Deleted %d files, %d failed", result.Deleted, len(result.Failed)), result, http.StatusMultiStatus)
+		return
+	}
+	sendResponse(w, true, fmt.Sprintf("Deleted %d files", result.Deleted), result, http.StatusOK)
+}
+
+type fileListPage struct {
+	Files     []FileInfo `json:"files"`
+	NextToken string     `json:"nextToken,omitempty"`
 }
 
-func listFilesHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) listFilesHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		sendResponse(w, false, "Method not allowed", nil, http.StatusMethodNotAllowed)
 		return
@@ -312,122 +656,1108 @@ func listFilesHandler(w http.ResponseWriter, r *http.Request) {
 	if prefix == "" {
 		prefix = "uploads/"
 	}
+	prefix = tenantPrefix(r) + prefix
+
+	if delimiter := r.URL.Query().Get("delimiter"); delimiter != "" {
+		if delimiter != "/" {
+			sendResponse(w, false, "delimiter must be /", nil, http.StatusBadRequest)
+			return
+		}
+		s.listFolderHandler(w, r, prefix)
+		return
+	}
 
-	objects, err := minioService.listObjects(prefix)
+	limit := 1000
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > 1000 {
+			sendResponse(w, false, "limit must be an integer between 1 and 1000", nil, http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	filter, err := parseObjectFilter(r)
 	if err != nil {
-		sendResponse(w, false, "Error listing files: "+err.Error(), nil, http.StatusInternalServerError)
+		sendResponse(w, false, err.Error(), nil, http.StatusBadRequest)
 		return
 	}
 
-	var fileList []FileInfo
-	for _, obj := range objects {
-		url, _ := minioService.getObjectURL(obj.Key, time.Hour*24)
+	var page storage.ObjectPage
+	if filter.IsZero() {
+		page, err = s.storage.ListObjectsPaginated(r.Context(), prefix, r.URL.Query().Get("after"), limit)
+	} else {
+		page, err = s.storage.SearchObjectsPaginated(r.Context(), prefix, r.URL.Query().Get("after"), limit, filter)
+	}
+	if err != nil {
+		sendResponse(w, false, "Error listing files: "+err.Error(), nil, storageErrorStatus(err))
+		return
+	}
 
+	fileList := make([]FileInfo, 0, len(page.Objects))
+	keys := make([]string, 0, len(page.Objects))
+	for _, obj := range page.Objects {
 		fileList = append(fileList, FileInfo{
 			FileName:    filepath.Base(obj.Key),
 			Size:        obj.Size,
 			ContentType: obj.ContentType,
-			URL:         url,
 			UploadedAt:  obj.LastModified,
 		})
+		keys = append(keys, obj.Key)
+	}
+
+	if s.config.FillContentTypes {
+		s.fillContentTypes(r, fileList, keys)
+	}
+	if r.URL.Query().Get("urls") == "true" {
+		s.fillObjectURLs(r, fileList, keys)
 	}
 
-	sendResponse(w, true, fmt.Sprintf("Found %d files", len(fileList)), fileList, http.StatusOK)
+	sendResponse(w, true, fmt.Sprintf("Found %d files", len(fileList)), fileListPage{
+		Files:     fileList,
+		NextToken: page.NextToken,
+	}, http.StatusOK)
 }
 
-func getFileHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		sendResponse(w, false, "Method not allowed", nil, http.StatusMethodNotAllowed)
-		return
-	}
+type folderListing struct {
+	Files   []FileInfo `json:"files"`
+	Folders []string   `json:"folders"`
+}
 
-	objectName := r.URL.Path[len("/files/"):]
-	if objectName == "" {
-		sendResponse(w, false, "Object name is required", nil, http.StatusBadRequest)
+// listFolderHandler serves /files?delimiter=/, listing only the files
+// directly under prefix and the prefixes of its subfolders, which can be
+// passed back as ?prefix= to descend into them.
+func (s *Server) listFolderHandler(w http.ResponseWriter, r *http.Request, prefix string) {
+	listing, err := s.storage.ListFolder(r.Context(), prefix)
+	if err != nil {
+		sendResponse(w, false, "Error listing files: "+err.Error(), nil, storageErrorStatus(err))
 		return
 	}
 
-	exists, err := minioService.checkObjectExists(objectName)
-	if err != nil {
-		sendResponse(w, false, "Error checking object: "+err.Error(), nil, http.StatusInternalServerError)
-		return
+	result := folderListing{
+		Files:   make([]FileInfo, 0, len(listing.Objects)),
+		Folders: make([]string, 0, len(listing.Prefixes)),
+	}
+	keys := make([]string, 0, len(listing.Objects))
+	for _, obj := range listing.Objects {
+		result.Files = append(result.Files, FileInfo{
+			FileName:    filepath.Base(obj.Key),
+			Size:        obj.Size,
+			ContentType: obj.ContentType,
+			UploadedAt:  obj.LastModified,
+		})
+		keys = append(keys, obj.Key)
+	}
+	for _, folder := range listing.Prefixes {
+		result.Folders = append(result.Folders, strings.TrimPrefix(folder, tenantPrefix(r)))
 	}
 
-	if !exists {
-		sendResponse(w, false, "File not found", nil, http.StatusNotFound)
-		return
+	if s.config.FillContentTypes {
+		s.fillContentTypes(r, result.Files, keys)
+	}
+	if r.URL.Query().Get("urls") == "true" {
+		s.fillObjectURLs(r, result.Files, keys)
 	}
 
-	download := r.URL.Query().Get("download") == "true"
+	sendResponse(w, true, fmt.Sprintf("Found %d files and %d folders", len(result.Files), len(result.Folders)), result, http.StatusOK)
+}
 
-	if download {
-		data, err := minioService.downloadBuffer(objectName)
-		if err != nil {
-			sendResponse(w, false, "Error downloading file: "+err.Error(), nil, http.StatusInternalServerError)
-			return
+// parseObjectFilter reads the /files search parameters minSize, maxSize,
+// modifiedAfter, modifiedBefore and contentType. Times are RFC 3339
+// timestamps or plain dates such as 2024-01-01.
+func parseObjectFilter(r *http.Request) (storage.ObjectFilter, error) {
+	query := r.URL.Query()
+	filter := storage.ObjectFilter{ContentType: query.Get("contentType")}
+
+	for name, dst := range map[string]*int64{"minSize": &filter.MinSize, "maxSize": &filter.MaxSize} {
+		if value := query.Get(name); value != "" {
+			size, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || size < 0 {
+				return filter, fmt.Errorf("%s must be a non-negative integer", name)
+			}
+			*dst = size
 		}
+	}
 
-		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filepath.Base(objectName)))
-		w.Header().Set("Content-Type", "application/octet-stream")
-		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(data)))
-
-		w.WriteHeader(http.StatusOK)
-		w.Write(data)
-	} else {
-		url, err := minioService.getObjectURL(objectName, time.Hour)
-		if err != nil {
-			sendResponse(w, false, "Error generating URL: "+err.Error(), nil, http.StatusInternalServerError)
-			return
+	for name, dst := range map[string]*time.Time{"modifiedAfter": &filter.ModifiedAfter, "modifiedBefore": &filter.ModifiedBefore} {
+		if value := query.Get(name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				t, err = time.Parse(time.DateOnly, value)
+			}
+			if err != nil {
+				return filter, fmt.Errorf("%s must be an RFC 3339 timestamp or a YYYY-MM-DD date", name)
+			}
+			*dst = t
 		}
-
-		http.Redirect(w, r, url, http.StatusFound)
 	}
+
+	return filter, nil
 }
 
-func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
-	_, err := minioService.listObjects("")
-	if err != nil {
-		sendResponse(w, false, "MinIO service is not healthy: "+err.Error(), nil, http.StatusServiceUnavailable)
-		return
+// urlConcurrency bounds the presigned URLs fillObjectURLs generates at once.
+const urlConcurrency = 8
+
+// fillObjectURLs sets files[i].URL to the object URL of keys[i], generating
+// them concurrently.
+func (s *Server) fillObjectURLs(r *http.Request, files []FileInfo, keys []string) {
+	expiry := s.presignExpiry(r)
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < min(urlConcurrency, len(keys)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				files[j].URL = s.objectURL(r, keys[j], expiry)
+			}
+		}()
 	}
 
-	sendResponse(w, true, "Service is healthy", nil, http.StatusOK)
+	for i := range keys {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
 }
 
-func sendResponse(w http.ResponseWriter, success bool, message string, data interface{}, statusCode int) {
-	response := Response{
-		Success: success,
-		Message: message,
-		Data:    data,
+// contentTypeConcurrency bounds the objects fillContentTypes stats at once.
+const contentTypeConcurrency = 8
+
+// fillContentTypes sets the content type of files listed without one from
+// their object's metadata, statting them concurrently, or failing that from
+// the extension of keys[i].
+func (s *Server) fillContentTypes(r *http.Request, files []FileInfo, keys []string) {
+	var missing []int
+	for i := range files {
+		if files[i].ContentType == "" {
+			missing = append(missing, i)
+		}
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("Error encoding response: %v", err)
-		http.Error(w, "Error encoding response", http.StatusInternalServerError)
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < min(contentTypeConcurrency, len(missing)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				if info, err := s.storage.GetObjectInfo(r.Context(), keys[j]); err == nil {
+					files[j].ContentType = info.ContentType
+				}
+				if files[j].ContentType == "" {
+					files[j].ContentType = mime.TypeByExtension(filepath.Ext(keys[j]))
+				}
+			}
+		}()
 	}
-}
 
-func getEnv(key, defaultValue string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
+	for _, i := range missing {
+		jobs <- i
 	}
-	return value
+	close(jobs)
+	wg.Wait()
 }
 
-func getEnvBool(key string, defaultValue bool) bool {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
+func (s *Server) recentUploadsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendResponse(w, false, "Method not allowed", nil, http.StatusMethodNotAllowed)
+		return
 	}
 
-	boolValue, err := strconv.ParseBool(value)
-	if err != nil {
-		return defaultValue
+	n := 20
+	if value := r.URL.Query().Get("n"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > 1000 {
+			sendResponse(w, false, "n must be an integer between 1 and 1000", nil, http.StatusBadRequest)
+			return
+		}
+		n = parsed
 	}
 
-	return boolValue
+	prefix := r.URL.Query().Get("prefix")
+	if prefix == "" {
+		prefix = "uploads/"
+	}
+	prefix = tenantPrefix(r) + prefix
+
+	objects, err := s.storage.RecentUploads(r.Context(), prefix, n)
+	if err != nil {
+		sendResponse(w, false, "Error listing recent files: "+err.Error(), nil, storageErrorStatus(err))
+		return
+	}
+
+	fileList := make([]FileInfo, 0, len(objects))
+	keys := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		url := s.objectURL(r, obj.Key, s.presignExpiry(r))
+
+		fileList = append(fileList, FileInfo{
+			FileName:    filepath.Base(obj.Key),
+			Size:        obj.Size,
+			ContentType: obj.ContentType,
+			URL:         url,
+			UploadedAt:  obj.LastModified,
+		})
+		keys = append(keys, obj.Key)
+	}
+
+	if s.config.FillContentTypes {
+		s.fillContentTypes(r, fileList, keys)
+	}
+
+	sendResponse(w, true, fmt.Sprintf("Found %d recent files", len(fileList)), fileList, http.StatusOK)
+}
+
+// filesHandler routes /files/{objectName} and its sub-resources.
+func (s *Server) filesHandler(w http.ResponseWriter, r *http.Request) {
+	// Sub-resources are suffixes, so checking the whole path covers them.
+	if !checkTenant(w, r, strings.TrimPrefix(r.URL.Path, "/files/")) {
+		return
+	}
+
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/transition"):
+		s.transitionHandler(w, r)
+	case strings.HasSuffix(r.URL.Path, "/presign-ip"):
+		s.presignForIPHandler(w, r)
+	case strings.HasSuffix(r.URL.Path, "/raw"):
+		s.rawFileHandler(w, r)
+	case strings.HasSuffix(r.URL.Path, "/versions"):
+		s.versionsHandler(w, r)
+	case strings.HasSuffix(r.URL.Path, "/tags"):
+		s.tagsHandler(w, r)
+	case r.Method == http.MethodDelete:
+		s.deleteFileHandler(w, r)
+	default:
+		s.getFileHandler(w, r)
+	}
+}
+
+func (s *Server) deleteFileHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		sendResponse(w, false, "Method not allowed", nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	objectName := r.URL.Path[len("/files/"):]
+	if objectName == "" {
+		sendResponse(w, false, "Object name is required", nil, http.StatusBadRequest)
+		return
+	}
+	if err := storage.ValidateObjectName(objectName); err != nil {
+		sendResponse(w, false, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+
+	// A specific version is removed outright, even if the object's latest
+	// version is a delete marker, so skip the existence check.
+	if versionID := r.URL.Query().Get("versionId"); versionID != "" {
+		if err := s.storage.DeleteObjectVersion(r.Context(), objectName, versionID); err != nil {
+			sendResponse(w, false, "Error deleting file version: "+err.Error(), nil, storageErrorStatus(err))
+			return
+		}
+		sendResponse(w, true, "File version deleted successfully", nil, http.StatusOK)
+		return
+	}
+
+	exists, err := s.storage.CheckObjectExists(r.Context(), objectName)
+	if err != nil {
+		sendResponse(w, false, "Error checking object: "+err.Error(), nil, storageErrorStatus(err))
+		return
+	}
+
+	if !exists {
+		sendResponse(w, false, "File not found", nil, http.StatusNotFound)
+		return
+	}
+
+	if err := s.storage.DeleteObject(r.Context(), objectName); err != nil {
+		sendResponse(w, false, "Error deleting file: "+err.Error(), nil, storageErrorStatus(err))
+		return
+	}
+
+	sendResponse(w, true, "File deleted successfully", nil, http.StatusOK)
+}
+
+type copyRequest struct {
+	Src string `json:"src"`
+	Dst string `json:"dst"`
+}
+
+func (s *Server) copyFileHandler(w http.ResponseWriter, r *http.Request) {
+	s.relocateObject(w, r, "copied", s.storage.CopyObject)
+}
+
+func (s *Server) moveFileHandler(w http.ResponseWriter, r *http.Request) {
+	s.relocateObject(w, r, "moved", s.storage.MoveObject)
+}
+
+// relocateObject implements the copy and move endpoints, which differ only in
+// the storage operation they call.
+func (s *Server) relocateObject(w http.ResponseWriter, r *http.Request, verb string,
+	op func(ctx context.Context, src, dst string) (minio.UploadInfo, error)) {
+	if r.Method != http.MethodPost {
+		sendResponse(w, false, "Method not allowed", nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req copyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendResponse(w, false, "Invalid request body: "+err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	if req.Src == "" || req.Dst == "" {
+		sendResponse(w, false, "src and dst are required", nil, http.StatusBadRequest)
+		return
+	}
+	if !checkTenant(w, r, req.Src, req.Dst) {
+		return
+	}
+
+	exists, err := s.storage.CheckObjectExists(r.Context(), req.Src)
+	if err != nil {
+		sendResponse(w, false, "Error checking object: "+err.Error(), nil, storageErrorStatus(err))
+		return
+	}
+	if !exists {
+		sendResponse(w, false, "File not found", nil, http.StatusNotFound)
+		return
+	}
+
+	if _, err := op(r.Context(), req.Src, req.Dst); err != nil {
+		sendResponse(w, false, "Error relocating file: "+err.Error(), nil, storageErrorStatus(err))
+		return
+	}
+
+	sendResponse(w, true, fmt.Sprintf("File %s successfully", verb), req, http.StatusOK)
+}
+
+type restoreRequest struct {
+	Key string `json:"key"`
+}
+
+// restoreFileHandler serves POST /files/restore with {"key"}, moving a
+// soft-deleted object back from the trash.
+func (s *Server) restoreFileHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendResponse(w, false, "Method not allowed", nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req restoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendResponse(w, false, "Invalid request body: "+err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	if req.Key == "" {
+		sendResponse(w, false, "key is required", nil, http.StatusBadRequest)
+		return
+	}
+	if !checkTenant(w, r, req.Key) {
+		return
+	}
+
+	_, err := s.storage.RestoreObject(r.Context(), req.Key)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		sendResponse(w, false, "File not found in trash", nil, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		status := storageErrorStatus(err)
+		if errors.Is(err, storage.ErrRestoreConflict) {
+			status = http.StatusConflict
+		}
+		sendResponse(w, false, "Error restoring file: "+err.Error(), nil, status)
+		return
+	}
+
+	sendResponse(w, true, "File restored successfully", req, http.StatusOK)
+}
+
+type ObjectMetadata struct {
+	Key          string            `json:"key"`
+	Size         int64             `json:"size"`
+	ContentType  string            `json:"contentType"`
+	ETag         string            `json:"etag"`
+	LastModified time.Time         `json:"lastModified"`
+	StorageClass string            `json:"storageClass,omitempty"`
+	UserMetadata map[string]string `json:"userMetadata,omitempty"`
+	UserTags     map[string]string `json:"userTags,omitempty"`
+}
+
+func newObjectMetadata(info minio.ObjectInfo) ObjectMetadata {
+	return ObjectMetadata{
+		Key:          info.Key,
+		Size:         info.Size,
+		ContentType:  info.ContentType,
+		ETag:         info.ETag,
+		LastModified: info.LastModified,
+		StorageClass: info.StorageClass,
+		UserMetadata: info.UserMetadata,
+		UserTags:     info.UserTags,
+	}
+}
+
+// tagsHandler serves /files/{objectName}/tags: GET returns the object's
+// tags, PUT replaces them with a JSON object of key/value pairs and DELETE
+// removes them all.
+func (s *Server) tagsHandler(w http.ResponseWriter, r *http.Request) {
+	objectName := strings.TrimSuffix(r.URL.Path[len("/files/"):], "/tags")
+	if err := storage.ValidateObjectName(objectName); err != nil {
+		sendResponse(w, false, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodPut && r.Method != http.MethodDelete {
+		sendResponse(w, false, "Method not allowed", nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	exists, err := s.storage.CheckObjectExists(r.Context(), objectName)
+	if err != nil {
+		sendResponse(w, false, "Error checking object: "+err.Error(), nil, storageErrorStatus(err))
+		return
+	}
+	if !exists {
+		sendResponse(w, false, "File not found", nil, http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		tags, err := s.storage.GetObjectTags(r.Context(), objectName)
+		if err != nil {
+			sendResponse(w, false, "Error getting tags: "+err.Error(), nil, storageErrorStatus(err))
+			return
+		}
+		sendResponse(w, true, fmt.Sprintf("Found %d tags", len(tags)), tags, http.StatusOK)
+	case http.MethodPut:
+		var tags map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&tags); err != nil {
+			sendResponse(w, false, "Invalid request body: "+err.Error(), nil, http.StatusBadRequest)
+			return
+		}
+		if err := s.storage.SetObjectTags(r.Context(), objectName, tags); err != nil {
+			if errors.Is(err, storage.ErrInvalidTags) {
+				sendResponse(w, false, err.Error(), nil, http.StatusBadRequest)
+				return
+			}
+			sendResponse(w, false, "Error setting tags: "+err.Error(), nil, storageErrorStatus(err))
+			return
+		}
+		sendResponse(w, true, "Tags updated", tags, http.StatusOK)
+	case http.MethodDelete:
+		if err := s.storage.RemoveObjectTags(r.Context(), objectName); err != nil {
+			sendResponse(w, false, "Error removing tags: "+err.Error(), nil, storageErrorStatus(err))
+			return
+		}
+		sendResponse(w, true, "Tags removed", nil, http.StatusOK)
+	}
+}
+
+type objectVersion struct {
+	VersionID      string    `json:"versionId"`
+	Size           int64     `json:"size"`
+	LastModified   time.Time `json:"lastModified"`
+	IsLatest       bool      `json:"isLatest"`
+	IsDeleteMarker bool      `json:"isDeleteMarker"`
+}
+
+// versionsHandler serves GET /files/{objectName}/versions, listing every
+// version of the object newest first.
+func (s *Server) versionsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendResponse(w, false, "Method not allowed", nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	objectName := strings.TrimSuffix(r.URL.Path[len("/files/"):], "/versions")
+	if err := storage.ValidateObjectName(objectName); err != nil {
+		sendResponse(w, false, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+
+	objects, err := s.storage.ListObjectVersions(r.Context(), objectName)
+	if err != nil {
+		sendResponse(w, false, "Error listing versions: "+err.Error(), nil, storageErrorStatus(err))
+		return
+	}
+
+	versions := []objectVersion{}
+	for _, obj := range objects {
+		// The listing is by prefix; keep only exact matches.
+		if obj.Key != objectName {
+			continue
+		}
+		versions = append(versions, objectVersion{
+			VersionID:      obj.VersionID,
+			Size:           obj.Size,
+			LastModified:   obj.LastModified,
+			IsLatest:       obj.IsLatest,
+			IsDeleteMarker: obj.IsDeleteMarker,
+		})
+	}
+
+	if len(versions) == 0 {
+		sendResponse(w, false, "File not found", nil, http.StatusNotFound)
+		return
+	}
+
+	sendResponse(w, true, fmt.Sprintf("Found %d versions", len(versions)), versions, http.StatusOK)
+}
+
+type transitionRequest struct {
+	StorageClass string `json:"storageClass"`
+}
+
+func (s *Server) transitionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendResponse(w, false, "Method not allowed", nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	objectName := strings.TrimSuffix(r.URL.Path[len("/files/"):], "/transition")
+	if objectName == "" {
+		sendResponse(w, false, "Object name is required", nil, http.StatusBadRequest)
+		return
+	}
+	if err := storage.ValidateObjectName(objectName); err != nil {
+		sendResponse(w, false, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+
+	var req transitionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendResponse(w, false, "Invalid request body: "+err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	if req.StorageClass == "" {
+		sendResponse(w, false, "storageClass is required", nil, http.StatusBadRequest)
+		return
+	}
+
+	exists, err := s.storage.CheckObjectExists(r.Context(), objectName)
+	if err != nil {
+		sendResponse(w, false, "Error checking object: "+err.Error(), nil, storageErrorStatus(err))
+		return
+	}
+	if !exists {
+		sendResponse(w, false, "File not found", nil, http.StatusNotFound)
+		return
+	}
+
+	storageClass, err := s.storage.TransitionObject(r.Context(), objectName, req.StorageClass)
+	if err != nil {
+		sendResponse(w, false, "Error transitioning object: "+err.Error(), nil, storageErrorStatus(err))
+		return
+	}
+
+	sendResponse(w, true, "Object transitioned successfully", transitionRequest{StorageClass: storageClass}, http.StatusOK)
+}
+
+type ipPresignInfo struct {
+	URL          string `json:"url"`
+	ClientIP     string `json:"clientIp"`
+	IPRestricted bool   `json:"ipRestricted"`
+	Warning      string `json:"warning,omitempty"`
+}
+
+// presignForIPHandler issues a presigned URL meant for the requesting client's
+// IP only, reporting whether the backend could actually enforce that.
+func (s *Server) presignForIPHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendResponse(w, false, "Method not allowed", nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	objectName := strings.TrimSuffix(r.URL.Path[len("/files/"):], "/presign-ip")
+	if objectName == "" {
+		sendResponse(w, false, "Object name is required", nil, http.StatusBadRequest)
+		return
+	}
+	if err := storage.ValidateObjectName(objectName); err != nil {
+		sendResponse(w, false, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+
+	clientIP := remoteIP(r, s.config.TrustProxy)
+
+	exists, err := s.storage.CheckObjectExists(r.Context(), objectName)
+	if err != nil {
+		sendResponse(w, false, "Error checking object: "+err.Error(), nil, storageErrorStatus(err))
+		return
+	}
+	if !exists {
+		sendResponse(w, false, "File not found", nil, http.StatusNotFound)
+		return
+	}
+
+	url, restricted, err := s.storage.GeneratePresignedGetForIP(r.Context(), objectName, clientIP, time.Hour)
+	if err != nil {
+		sendResponse(w, false, "Error generating URL: "+err.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	info := ipPresignInfo{URL: url, ClientIP: clientIP, IPRestricted: restricted}
+	if !restricted {
+		info.Warning = "backend does not support IP-restricted presigned GET URLs; URL is usable from any address"
+		slog.WarnContext(r.Context(), "Presigned URL could not be restricted to client IP", "object", objectName, "clientIP", clientIP)
+	}
+
+	sendResponse(w, true, "Presigned URL generated", info, http.StatusOK)
+}
+
+// changedFilesHandler serves GET /files/changes?since=<RFC 3339>, listing
+// objects modified at or after that time, oldest first, for incremental sync.
+func (s *Server) changedFilesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendResponse(w, false, "Method not allowed", nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	since, err := time.Parse(time.RFC3339, r.URL.Query().Get("since"))
+	if err != nil {
+		sendResponse(w, false, "since must be an RFC 3339 timestamp", nil, http.StatusBadRequest)
+		return
+	}
+
+	prefix := r.URL.Query().Get("prefix")
+	if prefix == "" {
+		prefix = "uploads/"
+	}
+	prefix = tenantPrefix(r) + prefix
+
+	objects, err := s.storage.ListModifiedSince(r.Context(), prefix, since)
+	if err != nil {
+		sendResponse(w, false, "Error listing changes: "+err.Error(), nil, storageErrorStatus(err))
+		return
+	}
+
+	fileList := make([]FileInfo, 0, len(objects))
+	keys := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		fileList = append(fileList, FileInfo{
+			FileName:    filepath.Base(obj.Key),
+			Size:        obj.Size,
+			ContentType: obj.ContentType,
+			UploadedAt:  obj.LastModified,
+		})
+		keys = append(keys, obj.Key)
+	}
+
+	if s.config.FillContentTypes {
+		s.fillContentTypes(r, fileList, keys)
+	}
+
+	sendResponse(w, true, fmt.Sprintf("Found %d changed files", len(fileList)), fileList, http.StatusOK)
+}
+
+func (s *Server) getFileHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendResponse(w, false, "Method not allowed", nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	objectName := r.URL.Path[len("/files/"):]
+	if objectName == "" {
+		sendResponse(w, false, "Object name is required", nil, http.StatusBadRequest)
+		return
+	}
+	if err := storage.ValidateObjectName(objectName); err != nil {
+		sendResponse(w, false, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+
+	if r.URL.Query().Get("versionId") != "" && r.URL.Query().Get("download") != "true" {
+		sendResponse(w, false, "versionId requires download=true", nil, http.StatusBadRequest)
+		return
+	}
+
+	expiry, err := s.requestedExpiry(r)
+	if err != nil {
+		sendResponse(w, false, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+
+	exists, err := s.storage.CheckObjectExists(r.Context(), objectName)
+	if err != nil {
+		sendResponse(w, false, "Error checking object: "+err.Error(), nil, storageErrorStatus(err))
+		return
+	}
+
+	if !exists && s.config.CaseInsensitiveKeys {
+		match, err := s.storage.FindObjectCaseInsensitive(r.Context(), objectName)
+		if err != nil {
+			sendResponse(w, false, "Error checking object: "+err.Error(), nil, storageErrorStatus(err))
+			return
+		}
+		// Tenant IDs may differ only in case.
+		if match != "" && ownsObject(r, match) {
+			slog.InfoContext(r.Context(), "Resolved object using case-insensitive lookup", "object", objectName, "match", match)
+			objectName = match
+			exists = true
+		}
+	}
+
+	// Only downloads read through; metadata requests and presigned URLs
+	// would have nothing to describe or point at until the object is stored.
+	// There is nothing to stat for checkExpectation yet, so serveFromOrigin
+	// checks the origin's response against the expectation instead.
+	download := r.URL.Query().Get("download") == "true"
+	if !exists && s.config.OriginURL != "" && download && r.URL.Query().Get("metadata") != "true" {
+		s.serveFromOrigin(w, r, objectName)
+		return
+	}
+
+	if !exists {
+		sendResponse(w, false, "File not found", nil, http.StatusNotFound)
+		return
+	}
+
+	info, err := s.storage.GetObjectInfo(r.Context(), objectName)
+	if err != nil {
+		sendResponse(w, false, "Error checking object: "+err.Error(), nil, storageErrorStatus(err))
+		return
+	}
+	if !checkExpectation(w, r, info) {
+		return
+	}
+	fileName := storage.OriginalFilename(info)
+	// ?filename= renames the download; only its base name is used.
+	if override := filepath.Base(r.URL.Query().Get("filename")); override != "." && override != "/" {
+		fileName = override
+	}
+
+	// Preconditions only apply to the latest version, and If-None-Match only
+	// to responses carrying the object itself.
+	if r.URL.Query().Get("versionId") == "" {
+		servesBody := download && r.URL.Query().Get("metadata") != "true"
+		if !checkPreconditions(w, r, info.ETag, servesBody) {
+			return
+		}
+		w.Header().Set("ETag", quoteETag(info.ETag))
+	}
+
+	if r.URL.Query().Get("metadata") == "true" {
+		sendResponse(w, true, "Object metadata retrieved", newObjectMetadata(info), http.StatusOK)
+		return
+	}
+
+	if download {
+		// ?inline=true lets browsers render the file, e.g. show an image,
+		// instead of saving it.
+		disposition := "attachment"
+		if r.URL.Query().Get("inline") == "true" {
+			disposition = "inline"
+			// Whoever uploaded the object chose its type; keep an HTML
+			// upload from running scripts on this origin.
+			w.Header().Set("Content-Security-Policy", "sandbox")
+		}
+		w.Header().Set("Accept-Ranges", "bytes")
+		w.Header().Set("Content-Disposition", contentDisposition(disposition, fileName))
+
+		// Ranges are always read from the latest version.
+		if r.Header.Get("Range") != "" && r.URL.Query().Get("versionId") == "" {
+			if !s.acquireDownload(w, r, s.rangedDownloads) {
+				return
+			}
+			served := s.serveRanges(w, r, objectName, info)
+			s.rangedDownloads.release()
+			if served {
+				return
+			}
+		}
+
+		// Pin the read to the ETag the preconditions were checked against,
+		// in case the object is replaced in between. That also makes info's
+		// size the size of what is streamed, unless it is stored compressed.
+		var opts storage.ReadOptions
+		if versionID := r.URL.Query().Get("versionId"); versionID != "" {
+			opts.SetVersionID(versionID)
+		} else if err := opts.SetMatchETag(info.ETag); err != nil {
+			sendResponse(w, false, "Error downloading file: "+err.Error(), nil, storageErrorStatus(err))
+			return
+		} else if !storage.IsCompressed(info) {
+			w.Header().Set("Content-Length", fmt.Sprintf("%d", info.Size))
+		}
+		contentType := info.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		w.Header().Set("Content-Type", contentType)
+		// The type was supplied by whoever uploaded the object.
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+
+		written, err := s.storage.DownloadToWriterWithOptions(r.Context(), objectName, opts, expectedSizeWriter(r, w))
+		if err != nil && written == 0 {
+			// Nothing has been sent yet, so the error can still be reported.
+			w.Header().Del("Content-Length")
+			if errors.Is(err, storage.ErrPreconditionFailed) {
+				sendResponse(w, false, "Object changed during download, please retry", nil, http.StatusPreconditionFailed)
+				return
+			}
+			if errors.Is(err, storage.ErrUnexpectedObject) {
+				sendResponse(w, false, "Refusing to serve object: "+err.Error(), nil, http.StatusConflict)
+				return
+			}
+			sendResponse(w, false, "Error downloading file: "+err.Error(), nil, storageErrorStatus(err))
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Download interrupted", "object", objectName, "written", written, "error", err)
+			if errors.Is(err, storage.ErrUnexpectedObject) {
+				// Don't let the client take the truncated body for the
+				// whole object.
+				panic(http.ErrAbortHandler)
+			}
+		}
+	} else {
+		url, err := s.downloadURL(r, objectName, fileName, expiry)
+		if err != nil {
+			sendResponse(w, false, "Error generating URL: "+err.Error(), nil, http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, url, http.StatusFound)
+	}
+}
+
+// contentDisposition formats a Content-Disposition header, quoting fileName
+// and falling back to RFC 2231 encoding for non-ASCII names.
+func contentDisposition(dispositionType, fileName string) string {
+	return mime.FormatMediaType(dispositionType, map[string]string{"filename": fileName})
+}
+
+type expectationKey struct{}
+
+// expectObject makes getFileHandler and rawFileHandler refuse (409) objects whose size or
+// content type don't match expect. It applies only to the route it wraps.
+func expectObject(expect storage.ObjectExpectation, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), expectationKey{}, expect)
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// checkExpectation answers 409 and returns false if info doesn't match the
+// expectation expectObject attached to r, if any. info must describe the
+// version that is served. The size of an object stored compressed isn't known
+// until it is decoded, so expectedSizeWriter enforces that limit instead.
+func checkExpectation(w http.ResponseWriter, r *http.Request, info minio.ObjectInfo) bool {
+	expect, ok := r.Context().Value(expectationKey{}).(storage.ObjectExpectation)
+	if !ok {
+		return true
+	}
+
+	if storage.IsCompressed(info) {
+		expect.MaxSize = 0
+	}
+	if err := expect.Check(info); err != nil {
+		sendResponse(w, false, "Refusing to serve object: "+err.Error(), nil, http.StatusConflict)
+		return false
+	}
+	return true
+}
+
+// expectedSizeWriter returns w limited to the size the expectation attached
+// to r allows, if any.
+func expectedSizeWriter(r *http.Request, w io.Writer) io.Writer {
+	expect, ok := r.Context().Value(expectationKey{}).(storage.ObjectExpectation)
+	if !ok || expect.MaxSize <= 0 {
+		return w
+	}
+	return &sizeLimitWriter{w: w, limit: expect.MaxSize}
+}
+
+// sizeLimitWriter fails a write that would take the total written past limit
+// with an error wrapping storage.ErrUnexpectedObject, writing nothing of it.
+type sizeLimitWriter struct {
+	w       io.Writer
+	limit   int64
+	written int64
+}
+
+func (l *sizeLimitWriter) Write(p []byte) (int, error) {
+	if l.written+int64(len(p)) > l.limit {
+		return 0, fmt.Errorf("%w: size exceeds limit of %d bytes", storage.ErrUnexpectedObject, l.limit)
+	}
+	n, err := l.w.Write(p)
+	l.written += int64(n)
+	return n, err
+}
+
+type archiveRequest struct {
+	SourcePrefix  string `json:"sourcePrefix"`
+	ArchivePrefix string `json:"archivePrefix"`
+	OlderThan     string `json:"olderThan"`
+	DeleteSource  bool   `json:"deleteSource"`
+}
+
+type archiveResult struct {
+	Archived int `json:"archived"`
+}
+
+func (s *Server) archiveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendResponse(w, false, "Method not allowed", nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	req := archiveRequest{SourcePrefix: "uploads/", ArchivePrefix: "archive/"}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendResponse(w, false, "Invalid request body: "+err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+
+	olderThan, err := time.ParseDuration(req.OlderThan)
+	if err != nil || olderThan < 0 {
+		sendResponse(w, false, "olderThan must be a non-negative duration such as 720h", nil, http.StatusBadRequest)
+		return
+	}
+
+	archived, err := s.storage.ArchiveByDate(r.Context(), req.SourcePrefix, req.ArchivePrefix, olderThan, req.DeleteSource)
+	if err != nil {
+		sendResponse(w, false, "Error archiving objects: "+err.Error(), archiveResult{Archived: archived}, storageErrorStatus(err))
+		return
+	}
+
+	sendResponse(w, true, fmt.Sprintf("Archived %d objects", archived), archiveResult{Archived: archived}, http.StatusOK)
+}
+
+type purgeTrashRequest struct {
+	OlderThan string `json:"olderThan"`
+}
+
+type purgeTrashResult struct {
+	Purged int `json:"purged"`
+}
+
+// purgeTrashHandler serves POST /admin/trash/purge with {"olderThan"},
+// permanently removing objects that have been in the trash that long.
+func (s *Server) purgeTrashHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendResponse(w, false, "Method not allowed", nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req purgeTrashRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendResponse(w, false, "Invalid request body: "+err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+
+	olderThan, err := time.ParseDuration(req.OlderThan)
+	if err != nil || olderThan < 0 {
+		sendResponse(w, false, "olderThan must be a non-negative duration such as 720h", nil, http.StatusBadRequest)
+		return
+	}
+
+	purged, err := s.storage.PurgeTrash(r.Context(), olderThan)
+	if err != nil {
+		sendResponse(w, false, "Error purging trash: "+err.Error(), purgeTrashResult{Purged: purged}, storageErrorStatus(err))
+		return
+	}
+
+	sendResponse(w, true, fmt.Sprintf("Purged %d objects", purged), purgeTrashResult{Purged: purged}, http.StatusOK)
+}
+
+type lifecycleRequest struct {
+	Prefix     string `json:"prefix"`
+	ExpireDays int    `json:"expireDays"`
+}
+
+// lifecycleHandler serves GET /admin/lifecycle, listing the bucket's
+// expiration rules, and POST /admin/lifecycle with {"prefix", "expireDays"},
+// which adds or replaces the rule for that prefix.
+func (s *Server) lifecycleHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		rules, err := s.storage.GetLifecycle(r.Context())
+		if err != nil {
+			sendResponse(w, false, "Error getting lifecycle: "+err.Error(), nil, storageErrorStatus(err))
+			return
+		}
+		sendResponse(w, true, fmt.Sprintf("Found %d lifecycle rules", len(rules)), rules, http.StatusOK)
+	case http.MethodPost:
+		var req lifecycleRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendResponse(w, false, "Invalid request body: "+err.Error(), nil, http.StatusBadRequest)
+			return
+		}
+		if req.Prefix == "" {
+			sendResponse(w, false, "prefix is required", nil, http.StatusBadRequest)
+			return
+		}
+		if req.ExpireDays <= 0 {
+			sendResponse(w, false, "expireDays must be a positive number of days", nil, http.StatusBadRequest)
+			return
+		}
+
+		if err := s.storage.SetLifecycleRule(r.Context(), req.Prefix, req.ExpireDays); err != nil {
+			sendResponse(w, false, "Error setting lifecycle: "+err.Error(), nil, storageErrorStatus(err))
+			return
+		}
+		sendResponse(w, true, fmt.Sprintf("Objects under '%s' now expire after %d days", req.Prefix, req.ExpireDays), req, http.StatusOK)
+	default:
+		sendResponse(w, false, "Method not allowed", nil, http.StatusMethodNotAllowed)
+	}
+}
+
+type healthStatus struct {
+	LatencyMs float64 `json:"latencyMs"`
+}
+
+// livezHandler reports that the process is up. It doesn't touch MinIO, so an
+// outage there doesn't get the server restarted.
+func (s *Server) livezHandler(w http.ResponseWriter, r *http.Request) {
+	sendResponse(w, true, "Service is alive", nil, http.StatusOK)
+}
+
+// readyzHandler reports whether MinIO is reachable, so traffic is only
+// routed here while requests can be served. /health is an alias.
+func (s *Server) readyzHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	err := s.storage.HealthCheck(ctx)
+	status := healthStatus{LatencyMs: float64(time.Since(start).Microseconds()) / 1000}
+	if err != nil {
+		sendResponse(w, false, "MinIO service is not healthy: "+err.Error(), status, http.StatusServiceUnavailable)
+		return
+	}
+
+	sendResponse(w, true, "Service is healthy", status, http.StatusOK)
+}
+
+func sendResponse(w http.ResponseWriter, success bool, message string, data interface{}, statusCode int) {
+	response := Response{
+		Success:   success,
+		Message:   message,
+		Data:      data,
+		RequestID: w.Header().Get(requestIDHeader),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.Error("Error encoding response", "error", err)
+		http.Error(w, "Error encoding response", http.StatusInternalServerError)
+	}
+}
+
+// storageErrorStatus is the HTTP status for an error from storage: 404 for a
+// missing object or bucket, 403 when the backend denies access and 500
+// otherwise.
+func storageErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, storage.ErrObjectNotFound), errors.Is(err, storage.ErrBucketNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, storage.ErrAccessDenied):
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
 }
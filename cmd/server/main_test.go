@@ -0,0 +1,1007 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"maps"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"MinIO-Learn/internal/storage"
+	"MinIO-Learn/internal/storage/storagetest"
+	"github.com/minio/minio-go/v7"
+)
+
+func TestExpectObject(t *testing.T) {
+	store := storage.NewMemoryStorage("test-bucket")
+	putObject(t, store, "configs/small.json", "application/json", []byte(`{"ok":true}`))
+	putObject(t, store, "configs/large.json", "application/json", []byte(`{"data":"`+strings.Repeat("x", 200)+`"}`))
+	putObject(t, store, "configs/page.html", "text/html", []byte("<p>hi</p>"))
+	putObject(t, store, "pages/page.html", "text/html", []byte("<p>hi</p>"))
+	h := newTestServer(t, store, map[string]string{
+		"MINIO_EXPECT_CONTENT_TYPE": "application/json",
+		"MINIO_EXPECT_MAX_SIZE":     "100",
+		"MINIO_EXPECT_PREFIXES":     "configs/",
+	})
+
+	tests := []struct {
+		name     string
+		path     string
+		rangeHdr string
+		want     int
+		wantBody string
+	}{
+		{"conforming", "/files/configs/small.json?download=true", "", http.StatusOK, `{"ok":true}`},
+		{"oversized", "/files/configs/large.json?download=true", "", http.StatusConflict, ""},
+		{"wrong type", "/files/configs/page.html?download=true", "", http.StatusConflict, ""},
+		{"oversized range", "/files/configs/large.json?download=true", "bytes=0-9", http.StatusConflict, ""},
+		{"conforming range", "/files/configs/small.json?download=true", "bytes=1-4", http.StatusPartialContent, `"ok"`},
+		{"raw conforming", "/files/configs/small.json/raw", "", http.StatusOK, `{"ok":true}`},
+		{"raw oversized", "/files/configs/large.json/raw", "", http.StatusConflict, ""},
+		{"raw wrong type", "/files/configs/page.html/raw", "", http.StatusConflict, ""},
+		{"missing", "/files/configs/missing.json?download=true", "", http.StatusNotFound, ""},
+		{"other prefix", "/files/pages/page.html?download=true", "", http.StatusOK, "<p>hi</p>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newRequest(http.MethodGet, tt.path, "")
+			if tt.rangeHdr != "" {
+				req.Header.Set("Range", tt.rangeHdr)
+			}
+			rec := serve(h, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", rec.Body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestExpectObjectCompressed(t *testing.T) {
+	fake := storagetest.NewFakeS3()
+	// Each object compresses to far less than either limit below.
+	putCompressed := func(key string, data []byte) {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		gz.Write(data)
+		gz.Close()
+		fake.PutObject("test-bucket", key, buf.Bytes(), http.Header{
+			"Content-Type":     {"application/json"},
+			"Content-Encoding": {"gzip"},
+		})
+	}
+	small := []byte(`{"ok":true}`)
+	putCompressed("configs/small.json", small)
+	putCompressed("configs/large.json", bytes.Repeat([]byte("x"), 100000))
+
+	h := newFakeBackendServer(t, fake, map[string]string{
+		"MINIO_EXPECT_MAX_SIZE": "1000",
+		"MINIO_EXPECT_PREFIXES": "configs/",
+	})
+	for _, path := range []string{"/files/configs/small.json?download=true", "/files/configs/small.json/raw"} {
+		rec := serve(h, newRequest(http.MethodGet, path, ""))
+		if rec.Code != http.StatusOK || !bytes.Equal(rec.Body.Bytes(), small) {
+			t.Errorf("GET %s = %d %q, want 200 with the object", path, rec.Code, rec.Body)
+		}
+	}
+	// The first chunk is already over the limit, so nothing is sent.
+	for _, path := range []string{"/files/configs/large.json?download=true", "/files/configs/large.json/raw"} {
+		if rec := serve(h, newRequest(http.MethodGet, path, "")); rec.Code != http.StatusConflict {
+			t.Errorf("GET %s = %d, want 409: %s", path, rec.Code, rec.Body)
+		}
+	}
+
+	// With a limit past the first chunk, the connection is cut once the
+	// limit is reached instead.
+	server := httptest.NewServer(newFakeBackendServer(t, fake, map[string]string{
+		"MINIO_EXPECT_MAX_SIZE": "40000",
+		"MINIO_EXPECT_PREFIXES": "configs/",
+	}))
+	t.Cleanup(server.Close)
+	for _, path := range []string{"/files/configs/large.json?download=true", "/files/configs/large.json/raw"} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s error = %v", path, err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err == nil {
+			t.Errorf("GET %s read %d bytes without error, want the download aborted", path, len(body))
+		}
+		if len(body) > 40000 {
+			t.Errorf("GET %s read %d bytes, want at most the 40000 byte limit", path, len(body))
+		}
+	}
+}
+
+func TestRawUploadChunked(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"small", []byte("hello, chunked world")},
+		{"several parts", bytes.Repeat([]byte("0123456789abcdef"), 1<<19)},
+		{"empty", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStorage("test-bucket")
+			h := newTestServer(t, store, nil)
+
+			// Hide the length, as a chunked request body would.
+			req := httptest.NewRequest(http.MethodPut, "/upload?filename=data.bin", io.MultiReader(bytes.NewReader(tt.data)))
+			req.ContentLength = -1
+			req.TransferEncoding = []string{"chunked"}
+			rec := serve(h, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+			}
+
+			var info FileInfo
+			decodeData(t, rec, &info)
+			if info.Size != int64(len(tt.data)) {
+				t.Errorf("reported size = %d, want %d", info.Size, len(tt.data))
+			}
+
+			got, err := store.DownloadBuffer(context.Background(), onlyObject(t, store))
+			if err != nil {
+				t.Fatalf("DownloadBuffer() error = %v", err)
+			}
+			if !bytes.Equal(got, tt.data) {
+				t.Errorf("stored %d bytes, want the %d uploaded", len(got), len(tt.data))
+			}
+		})
+	}
+}
+
+func TestCaseInsensitiveKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled string
+		path    string
+		want    int
+	}{
+		{"disabled exact case", "false", "/files/docs/Report.pdf?download=true", http.StatusOK},
+		{"disabled wrong case", "false", "/files/docs/report.PDF?download=true", http.StatusNotFound},
+		{"enabled wrong case", "true", "/files/docs/report.PDF?download=true", http.StatusOK},
+		{"enabled no match", "true", "/files/docs/summary.pdf?download=true", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStorage("test-bucket")
+			putObject(t, store, "docs/Report.pdf", "application/pdf", []byte("%PDF-1.4"))
+			h := newTestServer(t, store, map[string]string{"MINIO_CASE_INSENSITIVE_KEYS": tt.enabled})
+
+			rec := serve(h, newRequest(http.MethodGet, tt.path, ""))
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want == http.StatusOK && rec.Body.String() != "%PDF-1.4" {
+				t.Errorf("body = %q, want the object", rec.Body)
+			}
+		})
+	}
+}
+
+func TestPresignForIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		trustProxy string
+		forwarded  string
+		path       string
+		want       int
+		wantIP     string
+	}{
+		{"remote address", "false", "", "/files/report.pdf/presign-ip", http.StatusOK, "203.0.113.7"},
+		{"untrusted proxy header", "false", "198.51.100.9", "/files/report.pdf/presign-ip", http.StatusOK, "203.0.113.7"},
+		{"trusted proxy header", "true", "198.51.100.9", "/files/report.pdf/presign-ip", http.StatusOK, "198.51.100.9"},
+		{"missing object", "false", "", "/files/missing.pdf/presign-ip", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStorage("test-bucket")
+			putObject(t, store, "report.pdf", "application/pdf", []byte("%PDF-1.4"))
+			h := newTestServer(t, store, map[string]string{"MINIO_TRUST_PROXY": tt.trustProxy})
+
+			req := newRequest(http.MethodGet, tt.path, "")
+			req.RemoteAddr = "203.0.113.7:52000"
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			rec := serve(h, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want != http.StatusOK {
+				return
+			}
+
+			var info ipPresignInfo
+			decodeData(t, rec, &info)
+			if info.ClientIP != tt.wantIP {
+				t.Errorf("clientIp = %q, want %q", info.ClientIP, tt.wantIP)
+			}
+			if info.URL == "" {
+				t.Error("url is empty")
+			}
+			if !info.IPRestricted && info.Warning == "" {
+				t.Error("unrestricted URL carries no warning")
+			}
+		})
+	}
+}
+
+func TestUploadCheck(t *testing.T) {
+	content := []byte("quarterly report")
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+	otherSum := sha256.Sum256([]byte("something else"))
+	otherHash := hex.EncodeToString(otherSum[:])
+
+	store := storage.NewMemoryStorage("test-bucket")
+	h := newTestServer(t, store, map[string]string{
+		"MINIO_TENANT_TOKENS": `{"token-a":"alpha","token-b":"beta"}`,
+	})
+
+	upload := newUploadRequest(t, "report.txt", content)
+	upload.Header.Set("Authorization", "Bearer token-a")
+	if rec := serve(h, upload); rec.Code != http.StatusOK {
+		t.Fatalf("upload status = %d: %s", rec.Code, rec.Body)
+	}
+	key := onlyObject(t, store)
+
+	tests := []struct {
+		name    string
+		method  string
+		token   string
+		query   string
+		want    int
+		wantKey string
+	}{
+		{"existing content", http.MethodGet, "token-a", "hash=" + hash + "&size=16", http.StatusOK, key},
+		{"existing content without size", http.MethodGet, "token-a", "hash=" + hash, http.StatusOK, key},
+		{"uppercase hash", http.MethodGet, "token-a", "hash=" + strings.ToUpper(hash), http.StatusOK, key},
+		{"existing content HEAD", http.MethodHead, "token-a", "hash=" + hash + "&size=16", http.StatusOK, key},
+		{"new content", http.MethodGet, "token-a", "hash=" + otherHash, http.StatusNotFound, ""},
+		{"new content HEAD", http.MethodHead, "token-a", "hash=" + otherHash, http.StatusNotFound, ""},
+		{"size mismatch", http.MethodGet, "token-a", "hash=" + hash + "&size=17", http.StatusNotFound, ""},
+		{"other tenant", http.MethodGet, "token-b", "hash=" + hash, http.StatusNotFound, ""},
+		{"missing hash", http.MethodGet, "token-a", "", http.StatusBadRequest, ""},
+		{"malformed hash", http.MethodGet, "token-a", "hash=abc", http.StatusBadRequest, ""},
+		{"negative size", http.MethodGet, "token-a", "hash=" + hash + "&size=-1", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newRequest(tt.method, "/upload/check?"+tt.query, "")
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rec := serve(h, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.wantKey == "" {
+				return
+			}
+
+			if tt.method == http.MethodHead {
+				if got := rec.Header().Get("X-Object-Key"); got != tt.wantKey {
+					t.Errorf("X-Object-Key = %q, want %q", got, tt.wantKey)
+				}
+				return
+			}
+			var result uploadCheckResult
+			decodeData(t, rec, &result)
+			if !result.Exists || result.Key != tt.wantKey {
+				t.Errorf("result = %+v, want existing key %q", result, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestDownloadOriginalFilename(t *testing.T) {
+	tests := []struct {
+		name            string
+		fileName        string
+		query           string
+		wantDisposition string
+	}{
+		{"download", "report.pdf", "download=true", `attachment; filename=report.pdf`},
+		{"inline", "report.pdf", "download=true&inline=true", `inline; filename=report.pdf`},
+		{"quoted", "Q3 report.pdf", "download=true", `attachment; filename="Q3 report.pdf"`},
+		{"non-ASCII", "résumé.pdf", "download=true", `attachment; filename*=utf-8''r%C3%A9sum%C3%A9.pdf`},
+		{"renamed", "report.pdf", "download=true&filename=../../summary.pdf", `attachment; filename=summary.pdf`},
+		{"presigned redirect", "report.pdf", "", `attachment; filename=report.pdf`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStorage("test-bucket")
+			h := newTestServer(t, store, nil)
+			if rec := serve(h, newUploadRequest(t, tt.fileName, []byte("%PDF-1.4"))); rec.Code != http.StatusOK {
+				t.Fatalf("upload status = %d: %s", rec.Code, rec.Body)
+			}
+			key := onlyObject(t, store)
+			if key == tt.fileName {
+				t.Fatalf("key = %q, want it to differ from the filename", key)
+			}
+
+			target := (&url.URL{Path: "/files/" + key, RawQuery: tt.query}).String()
+			rec := serve(h, newRequest(http.MethodGet, target, ""))
+			if tt.query == "" {
+				if rec.Code != http.StatusFound {
+					t.Fatalf("status = %d, want 302: %s", rec.Code, rec.Body)
+				}
+				location, err := url.Parse(rec.Header().Get("Location"))
+				if err != nil {
+					t.Fatalf("Location: %v", err)
+				}
+				if got := location.Query().Get("response-content-disposition"); got != tt.wantDisposition {
+					t.Errorf("response-content-disposition = %q, want %q", got, tt.wantDisposition)
+				}
+				return
+			}
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+			}
+			if got := rec.Header().Get("Content-Disposition"); got != tt.wantDisposition {
+				t.Errorf("Content-Disposition = %q, want %q", got, tt.wantDisposition)
+			}
+		})
+	}
+}
+
+func TestDownloadContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		want        string
+	}{
+		{"text", "text/csv", "text/csv"},
+		{"with parameters", "text/plain; charset=utf-8", "text/plain; charset=utf-8"},
+		{"image", "image/png", "image/png"},
+		{"html", "text/html", "text/html"},
+		{"missing", "", "application/octet-stream"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStorage("test-bucket")
+			putObject(t, store, "uploads/object", tt.contentType, []byte("content"))
+			h := newTestServer(t, store, nil)
+
+			rec := serve(h, newRequest(http.MethodGet, "/files/uploads/object?download=true", ""))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.want {
+				t.Errorf("Content-Type = %q, want %q", got, tt.want)
+			}
+			if got := rec.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+				t.Errorf("X-Content-Type-Options = %q, want nosniff", got)
+			}
+		})
+	}
+}
+
+func TestDownloadDisposition(t *testing.T) {
+	tests := []struct {
+		name            string
+		query           string
+		wantDisposition string
+		wantCSP         string
+	}{
+		{"attachment by default", "download=true", "attachment; filename=photo.png", ""},
+		{"inline", "download=true&inline=true", "inline; filename=photo.png", "sandbox"},
+		{"inline not true", "download=true&inline=1", "attachment; filename=photo.png", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStorage("test-bucket")
+			putObject(t, store, "photo.png", "image/png", []byte("\x89PNG\r\n\x1a\n"))
+			h := newTestServer(t, store, nil)
+
+			rec := serve(h, newRequest(http.MethodGet, "/files/photo.png?"+tt.query, ""))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+			}
+			if got := rec.Header().Get("Content-Disposition"); got != tt.wantDisposition {
+				t.Errorf("Content-Disposition = %q, want %q", got, tt.wantDisposition)
+			}
+			if got := rec.Header().Get("Content-Type"); got != "image/png" {
+				t.Errorf("Content-Type = %q, want image/png", got)
+			}
+			if got := rec.Header().Get("Content-Security-Policy"); got != tt.wantCSP {
+				t.Errorf("Content-Security-Policy = %q, want %q", got, tt.wantCSP)
+			}
+		})
+	}
+}
+
+func TestUploadStorageClass(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		env        map[string]string
+		wantStatus int
+		wantClass  string
+	}{
+		{"default", "", nil, http.StatusOK, ""},
+		{"configured default", "", map[string]string{"MINIO_STORAGE_CLASS": "REDUCED_REDUNDANCY"}, http.StatusOK, "REDUCED_REDUNDANCY"},
+		{"requested", "?storageClass=REDUCED_REDUNDANCY", nil, http.StatusOK, "REDUCED_REDUNDANCY"},
+		{"requested over configured", "?storageClass=STANDARD", map[string]string{"MINIO_STORAGE_CLASS": "REDUCED_REDUNDANCY"}, http.StatusOK, "STANDARD"},
+		{"unknown", "?storageClass=GLACIER", nil, http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := storagetest.NewFakeS3()
+			h := newFakeBackendServer(t, fake, tt.env)
+
+			req := newUploadRequest(t, "archive.tar", []byte("archive"))
+			req.URL.RawQuery = strings.TrimPrefix(tt.query, "?")
+			rec := serve(h, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+
+			var keys []string
+			for _, key := range fake.Keys("test-bucket") {
+				if strings.HasPrefix(key, "uploads/") {
+					keys = append(keys, key)
+				}
+			}
+			if tt.wantStatus != http.StatusOK {
+				if len(keys) != 0 {
+					t.Errorf("stored %v after a rejected upload", keys)
+				}
+				return
+			}
+			if len(keys) != 1 {
+				t.Fatalf("stored %v, want one object", keys)
+			}
+			if got := fake.Object("test-bucket", keys[0]).Header.Get("X-Amz-Storage-Class"); got != tt.wantClass {
+				t.Errorf("stored X-Amz-Storage-Class = %q, want %q", got, tt.wantClass)
+			}
+		})
+	}
+}
+
+func TestUploadFormMetadata(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields map[string]string
+		want   map[string]string
+	}{
+		{"meta fields", map[string]string{"meta-owner": "alice", "meta-project": "x-42"}, map[string]string{"Owner": "alice", "Project": "x-42"}},
+		{"prefix case-insensitive", map[string]string{"META-Owner": "bob"}, map[string]string{"Owner": "bob"}},
+		{"other fields ignored", map[string]string{"owner": "carol", "meta-": "empty"}, map[string]string{"Owner": ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStorage("test-bucket")
+			h := newTestServer(t, store, nil)
+
+			var body bytes.Buffer
+			mw := multipart.NewWriter(&body)
+			for field, value := range tt.fields {
+				mw.WriteField(field, value)
+			}
+			part, _ := mw.CreateFormFile("file", "notes.txt")
+			part.Write([]byte("notes"))
+			mw.Close()
+			req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+			req.Header.Set("Content-Type", mw.FormDataContentType())
+
+			if rec := serve(h, req); rec.Code != http.StatusOK {
+				t.Fatalf("upload status = %d: %s", rec.Code, rec.Body)
+			}
+			info, err := store.GetObjectInfo(context.Background(), onlyObject(t, store))
+			if err != nil {
+				t.Fatalf("GetObjectInfo() error = %v", err)
+			}
+			for key, value := range tt.want {
+				if got := info.UserMetadata[key]; got != value {
+					t.Errorf("UserMetadata[%q] = %q, want %q", key, got, value)
+				}
+			}
+		})
+	}
+}
+
+func TestUploadPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		want       int
+		wantPrefix string
+	}{
+		{"default prefix", "", http.StatusOK, "uploads/"},
+		{"custom prefix", "prefix=images/", http.StatusOK, "images/"},
+		{"absolute prefix", "prefix=/etc/", http.StatusBadRequest, ""},
+		{"traversal", "prefix=uploads/../", http.StatusBadRequest, ""},
+		{"system prefix", "prefix=" + url.QueryEscape(storage.SystemPrefix), http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestServer(t, storage.NewMemoryStorage("test-bucket"), map[string]string{"MINIO_POST_POLICY_MAX_SIZE": "1048576"})
+			rec := serve(h, newRequest(http.MethodGet, "/upload-policy?"+tt.query, ""))
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want != http.StatusOK {
+				return
+			}
+
+			var info uploadPolicyInfo
+			decodeData(t, rec, &info)
+			if info.KeyPrefix != tt.wantPrefix || info.Fields["key"] != tt.wantPrefix {
+				t.Errorf("keyPrefix = %q, key field = %q, want %q", info.KeyPrefix, info.Fields["key"], tt.wantPrefix)
+			}
+			if info.MaxSize != 1<<20 {
+				t.Errorf("maxSize = %d, want %d", info.MaxSize, 1<<20)
+			}
+		})
+	}
+}
+
+func TestObjectNameTraversal(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want int
+	}{
+		{"encoded parent segment", "/files/uploads/%2e%2e/secret.txt", http.StatusBadRequest},
+		{"encoded slashes", "/files/uploads%2F..%2Fsecret.txt", http.StatusBadRequest},
+		{"backslashes", "/files/uploads%5C..%5Csecret.txt", http.StatusBadRequest},
+		{"leading slash", "/files/%2Fsecret.txt", http.StatusBadRequest},
+		{"control character", "/files/uploads/a%01b.txt", http.StatusBadRequest},
+		// Refused by the tenant check before the name is validated.
+		{"system prefix", "/files/" + storage.SystemPrefix + "index", http.StatusNotFound},
+	}
+	methods := []struct {
+		method string
+		suffix string
+		body   string
+	}{
+		{http.MethodGet, "", ""},
+		{http.MethodGet, "?download=true", ""},
+		{http.MethodGet, "/raw", ""},
+		{http.MethodGet, "/presign-ip", ""},
+		{http.MethodPost, "/transition", `{"storageClass":"STANDARD"}`},
+		{http.MethodDelete, "", ""},
+	}
+
+	store := storage.NewMemoryStorage("test-bucket")
+	putObject(t, store, "secret.txt", "text/plain", []byte("secret"))
+	h := newTestServer(t, store, nil)
+
+	for _, tt := range tests {
+		for _, m := range methods {
+			t.Run(tt.name+"/"+m.method+m.suffix, func(t *testing.T) {
+				rec := serve(h, newRequest(m.method, tt.path+m.suffix, m.body))
+				if rec.Code != tt.want {
+					t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+				}
+				if strings.Contains(rec.Body.String(), `"success":true`) || rec.Body.String() == "secret" {
+					t.Errorf("body = %s, want an error", rec.Body)
+				}
+			})
+		}
+	}
+
+	// Unescaped parent segments are cleaned and redirected by the router
+	// before any handler sees them.
+	rec := serve(h, newRequest(http.MethodGet, "/files/uploads/../../secret.txt?download=true", ""))
+	if rec.Code == http.StatusOK {
+		t.Errorf("unescaped traversal status = %d, want a redirect", rec.Code)
+	}
+
+	if exists, err := store.CheckObjectExists(context.Background(), "secret.txt"); err != nil || !exists {
+		t.Errorf("secret.txt exists = %v, error %v after traversal attempts", exists, err)
+	}
+}
+
+func TestFileLifecycle(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		data     []byte
+	}{
+		{"text", "notes.txt", []byte("meeting notes")},
+		{"binary", "blob.bin", bytes.Repeat([]byte{0x00, 0xff, 0x10}, 1000)},
+		{"empty", "empty.txt", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStorage("test-bucket")
+			h := newTestServer(t, store, nil)
+
+			rec := serve(h, newUploadRequest(t, tt.fileName, tt.data))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("upload status = %d: %s", rec.Code, rec.Body)
+			}
+			var uploaded FileInfo
+			decodeData(t, rec, &uploaded)
+			if uploaded.Size != int64(len(tt.data)) {
+				t.Errorf("uploaded size = %d, want %d", uploaded.Size, len(tt.data))
+			}
+			key := onlyObject(t, store)
+
+			rec = serve(h, newRequest(http.MethodGet, "/files", ""))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("list status = %d: %s", rec.Code, rec.Body)
+			}
+			var page fileListPage
+			decodeData(t, rec, &page)
+			if len(page.Files) != 1 || page.Files[0].FileName != path.Base(key) || page.Files[0].Size != int64(len(tt.data)) {
+				t.Errorf("listed %+v, want %s of %d bytes", page.Files, path.Base(key), len(tt.data))
+			}
+
+			rec = serve(h, newRequest(http.MethodGet, "/files/"+key+"?download=true", ""))
+			if rec.Code != http.StatusOK || !bytes.Equal(rec.Body.Bytes(), tt.data) {
+				t.Fatalf("download = %d with %d bytes, want 200 with the %d uploaded", rec.Code, rec.Body.Len(), len(tt.data))
+			}
+
+			if rec = serve(h, newRequest(http.MethodDelete, "/files/"+key, "")); rec.Code != http.StatusOK {
+				t.Fatalf("delete status = %d: %s", rec.Code, rec.Body)
+			}
+			if rec = serve(h, newRequest(http.MethodGet, "/files/"+key+"?download=true", "")); rec.Code != http.StatusNotFound {
+				t.Errorf("download after delete status = %d, want 404", rec.Code)
+			}
+			rec = serve(h, newRequest(http.MethodGet, "/files", ""))
+			page = fileListPage{}
+			decodeData(t, rec, &page)
+			if len(page.Files) != 0 {
+				t.Errorf("listed %+v after delete, want nothing", page.Files)
+			}
+		})
+	}
+}
+
+func TestListFolderHandler(t *testing.T) {
+	store := storage.NewMemoryStorage("test-bucket")
+	for _, key := range []string{
+		"uploads/readme.txt",
+		"uploads/2024/jan/a.txt",
+		"uploads/2024/feb/b.txt",
+		"uploads/photos/cat.jpg",
+	} {
+		putObject(t, store, key, "text/plain", []byte(key))
+	}
+	h := newTestServer(t, store, nil)
+
+	tests := []struct {
+		query       string
+		wantStatus  int
+		wantFiles   []string
+		wantFolders []string
+	}{
+		{"delimiter=/", http.StatusOK, []string{"readme.txt"}, []string{"uploads/2024/", "uploads/photos/"}},
+		{"delimiter=/&prefix=uploads/2024/", http.StatusOK, []string{}, []string{"uploads/2024/feb/", "uploads/2024/jan/"}},
+		{"delimiter=/&prefix=uploads/2024/jan/", http.StatusOK, []string{"a.txt"}, []string{}},
+		{"delimiter=-", http.StatusBadRequest, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rec := serve(h, newRequest(http.MethodGet, "/files?"+tt.query, ""))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var listing folderListing
+			decodeData(t, rec, &listing)
+			files := []string{}
+			for _, file := range listing.Files {
+				files = append(files, file.FileName)
+			}
+			if !slices.Equal(files, tt.wantFiles) {
+				t.Errorf("files = %q, want %q", files, tt.wantFiles)
+			}
+			if !slices.Equal(listing.Folders, tt.wantFolders) {
+				t.Errorf("folders = %q, want %q", listing.Folders, tt.wantFolders)
+			}
+		})
+	}
+}
+
+func TestTenantIsolation(t *testing.T) {
+	store := storage.NewMemoryStorage("test-bucket")
+	h := newTestServer(t, store, map[string]string{
+		"MINIO_TENANT_TOKENS": `{"token-a":"alpha","token-b":"beta"}`,
+	})
+	asTenant := func(req *http.Request, token string) *http.Request {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return req
+	}
+
+	rec := serve(h, asTenant(newUploadRequest(t, "secret.txt", []byte("alpha's secret")), "token-a"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("upload as alpha status = %d: %s", rec.Code, rec.Body)
+	}
+	key := onlyObject(t, store)
+	if !strings.HasPrefix(key, "alpha/") {
+		t.Fatalf("key = %q, want it under alpha/", key)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+	}{
+		{"download", http.MethodGet, "/files/" + key + "?download=true"},
+		{"raw", http.MethodGet, "/files/" + key + "/raw"},
+		{"presigned redirect", http.MethodGet, "/files/" + key},
+		{"metadata", http.MethodGet, "/files/" + key + "?download=true&metadata=true"},
+		{"delete", http.MethodDelete, "/files/" + key},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(h, asTenant(newRequest(tt.method, tt.path, ""), "token-b"))
+			if rec.Code != http.StatusNotFound {
+				t.Errorf("status = %d, want 404: %s", rec.Code, rec.Body)
+			}
+			if strings.Contains(rec.Body.String(), "alpha's secret") {
+				t.Errorf("body = %s, want no content of alpha's object", rec.Body)
+			}
+		})
+	}
+
+	t.Run("listing", func(t *testing.T) {
+		for _, path := range []string{"/files", "/files?prefix=alpha/", "/files?prefix=../alpha/"} {
+			rec := serve(h, asTenant(newRequest(http.MethodGet, path, ""), "token-b"))
+			if rec.Code != http.StatusOK && rec.Code != http.StatusBadRequest {
+				t.Fatalf("list %s status = %d: %s", path, rec.Code, rec.Body)
+			}
+			var page fileListPage
+			if rec.Code == http.StatusOK {
+				decodeData(t, rec, &page)
+			}
+			if len(page.Files) != 0 {
+				t.Errorf("list %s as beta = %+v, want nothing", path, page.Files)
+			}
+		}
+	})
+
+	t.Run("upload", func(t *testing.T) {
+		rec := serve(h, asTenant(newUploadRequest(t, "secret.txt", []byte("beta's file")), "token-b"))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("upload as beta status = %d: %s", rec.Code, rec.Body)
+		}
+		objects, err := store.ListObjects(context.Background(), "beta/")
+		if err != nil || len(objects) != 1 {
+			t.Fatalf("beta's objects = %v, %v, want one", objects, err)
+		}
+
+		rec = serve(h, asTenant(newRequest(http.MethodGet, "/files", ""), "token-a"))
+		var page fileListPage
+		decodeData(t, rec, &page)
+		if len(page.Files) != 1 || page.Files[0].FileName != path.Base(key) {
+			t.Errorf("list as alpha = %+v, want only %s", page.Files, path.Base(key))
+		}
+	})
+
+	rec = serve(h, asTenant(newRequest(http.MethodGet, "/files/"+key+"?download=true", ""), "token-a"))
+	if rec.Code != http.StatusOK || rec.Body.String() != "alpha's secret" {
+		t.Errorf("download as alpha = %d %q, want its object untouched", rec.Code, rec.Body)
+	}
+}
+
+func TestFillContentTypes(t *testing.T) {
+	tests := []struct {
+		name      string
+		fill      string
+		wantTypes map[string]string
+	}{
+		{"enabled", "true", map[string]string{
+			"report.pdf": "application/x-pdf",
+			"data.json":  "application/json",
+			"blob":       "application/octet-stream",
+		}},
+		{"disabled", "false", map[string]string{
+			"report.pdf": "",
+			"data.json":  "",
+			"blob":       "",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := storagetest.NewFakeS3()
+			h := newFakeBackendServer(t, fake, map[string]string{"MINIO_FILL_CONTENT_TYPES": tt.fill})
+			// Listings never carry content types. The PDF's type is only in its
+			// metadata, the JSON file can't be statted, so only its extension
+			// is left, and the blob reads back with the default type.
+			fake.PutObject("test-bucket", "uploads/report.pdf", []byte("%PDF-1.4"), http.Header{"Content-Type": {"application/x-pdf"}})
+			fake.PutObject("test-bucket", "uploads/data.json", []byte(`{}`), nil)
+			fake.PutObject("test-bucket", "uploads/blob", []byte{0x00}, nil)
+			fake.Before = func(w http.ResponseWriter, r *http.Request) bool {
+				if r.Method != http.MethodHead || r.URL.Path != "/test-bucket/uploads/data.json" {
+					return false
+				}
+				w.WriteHeader(http.StatusForbidden)
+				return true
+			}
+
+			for _, query := range []string{"", "?delimiter=/"} {
+				rec := serve(h, newRequest(http.MethodGet, "/files"+query, ""))
+				if rec.Code != http.StatusOK {
+					t.Fatalf("list%s status = %d: %s", query, rec.Code, rec.Body)
+				}
+				var listing struct{ Files []FileInfo }
+				decodeData(t, rec, &listing)
+
+				got := make(map[string]string)
+				for _, file := range listing.Files {
+					got[file.FileName] = file.ContentType
+				}
+				if !maps.Equal(got, tt.wantTypes) {
+					t.Errorf("list%s content types = %v, want %v", query, got, tt.wantTypes)
+				}
+			}
+		})
+	}
+}
+
+func TestStatCacheSetting(t *testing.T) {
+	const key = "docs/report.txt"
+
+	tests := []struct {
+		name   string
+		ttl    string
+		cached bool
+	}{
+		{"enabled", "1m", true},
+		{"disabled", "0s", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := storagetest.NewFakeS3()
+			h := newFakeBackendServer(t, fake, map[string]string{"MINIO_STAT_CACHE_TTL": tt.ttl})
+			fake.PutObject("test-bucket", key, []byte("v1"), http.Header{"Content-Type": {"text/plain"}})
+
+			for range 2 {
+				if rec := serve(h, newRequest(http.MethodGet, "/files/"+key, "")); rec.Code != http.StatusFound {
+					t.Fatalf("get status = %d, want 302: %s", rec.Code, rec.Body)
+				}
+			}
+			// Uncached, every get stats the object at least once.
+			heads := fake.Count(http.MethodHead, "test-bucket", key)
+			if tt.cached && heads != 1 {
+				t.Errorf("backend stats after two gets = %d, want 1", heads)
+			}
+			if !tt.cached && heads < 2 {
+				t.Errorf("backend stats after two gets = %d, want at least 2", heads)
+			}
+
+			// A delete through the server must not leave a cached hit behind.
+			if rec := serve(h, newRequest(http.MethodDelete, "/files/"+key, "")); rec.Code != http.StatusOK {
+				t.Fatalf("delete status = %d: %s", rec.Code, rec.Body)
+			}
+			if rec := serve(h, newRequest(http.MethodGet, "/files/"+key, "")); rec.Code != http.StatusNotFound {
+				t.Errorf("get after delete status = %d, want 404", rec.Code)
+			}
+		})
+	}
+}
+
+// unhealthyStorage fails every health check.
+type unhealthyStorage struct {
+	storage.Storage
+}
+
+func (unhealthyStorage) HealthCheck(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	tests := []struct {
+		name       string
+		store      storage.Storage
+		path       string
+		wantStatus int
+	}{
+		{"livez", storage.NewMemoryStorage("test-bucket"), "/livez", http.StatusOK},
+		{"readyz", storage.NewMemoryStorage("test-bucket"), "/readyz", http.StatusOK},
+		{"health", storage.NewMemoryStorage("test-bucket"), "/health", http.StatusOK},
+		{"livez with MinIO down", unhealthyStorage{storage.NewMemoryStorage("test-bucket")}, "/livez", http.StatusOK},
+		{"readyz with MinIO down", unhealthyStorage{storage.NewMemoryStorage("test-bucket")}, "/readyz", http.StatusServiceUnavailable},
+		{"health with MinIO down", unhealthyStorage{storage.NewMemoryStorage("test-bucket")}, "/health", http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestServer(t, tt.store, nil)
+
+			rec := serve(h, newRequest(http.MethodGet, tt.path, ""))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			resp := Response{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decoding response %q: %v", rec.Body, err)
+			}
+			if resp.Success != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("success = %v with status %d", resp.Success, rec.Code)
+			}
+			if tt.wantStatus == http.StatusServiceUnavailable && !strings.Contains(resp.Message, "connection refused") {
+				t.Errorf("message = %q, want the health check error", resp.Message)
+			}
+		})
+	}
+}
+
+// failingUploadStorage fails every UploadStream, recording which files were
+// in the temp directory while it ran.
+type failingUploadStorage struct {
+	storage.Storage
+	tempDir string
+	spooled []string
+}
+
+func (f *failingUploadStorage) UploadStream(ctx context.Context, objectName string, reader io.Reader, size int64, contentType string, metadata map[string]string) (minio.UploadInfo, error) {
+	entries, _ := os.ReadDir(f.tempDir)
+	for _, entry := range entries {
+		f.spooled = append(f.spooled, entry.Name())
+	}
+	return minio.UploadInfo{}, errors.New("backend unavailable")
+}
+
+func TestUploadTempFileRemoved(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "spool", "uploads")
+	t.Setenv("TMPDIR", "")
+	if err := useTempDir(dir); err != nil {
+		t.Fatalf("useTempDir() error = %v", err)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		t.Fatalf("temp dir %s not created: %v", dir, err)
+	}
+
+	store := &failingUploadStorage{Storage: storage.NewMemoryStorage("test-bucket"), tempDir: dir}
+	h := newTestServer(t, store, nil)
+
+	// Larger than the 10 MiB ParseMultipartForm keeps in memory.
+	rec := serve(h, newUploadRequest(t, "large.bin", bytes.Repeat([]byte("x"), 11<<20)))
+	if rec.Code == http.StatusOK {
+		t.Fatalf("upload status = 200, want a failure")
+	}
+	if len(store.spooled) == 0 {
+		t.Fatal("the upload was not spooled to the temp dir")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("temp dir holds %d files after the failed upload, want none", len(entries))
+	}
+}
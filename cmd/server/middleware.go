@@ -0,0 +1,56 @@
+package main
+
+import (
+	"log/slog"
+	"maps"
+	"net/http"
+	"runtime/debug"
+)
+
+// middleware wraps a handler with behaviour shared by every route.
+type middleware func(http.Handler) http.Handler
+
+// chain wraps h in middlewares, the first outermost: chain(h, a, b) serves
+// requests through a, then b, then h.
+func chain(h http.Handler, middlewares ...middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+// recoverMiddleware turns a panic in next into a logged stack trace and a 500
+// response, instead of the connection being dropped. Headers next set are
+// discarded first. If next had already started the response, the panic is
+// still logged but the connection is aborted, as the client can't be told.
+func recoverMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header().Clone()
+		rec := &statusRecorder{ResponseWriter: w}
+
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+
+			slog.ErrorContext(r.Context(), "Handler panicked",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"panic", v,
+				"stack", string(debug.Stack()))
+
+			if rec.status != 0 {
+				panic(http.ErrAbortHandler)
+			}
+			clear(w.Header())
+			maps.Copy(w.Header(), header)
+			sendResponse(w, false, "Internal server error", nil, http.StatusInternalServerError)
+		}()
+
+		next.ServeHTTP(rec, r)
+	})
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestRecoverMiddleware(t *testing.T) {
+	panicking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Disposition", "attachment; filename=half.bin")
+		panic("boom")
+	})
+	h := chain(panicking, requestIDMiddleware, recoverMiddleware)
+
+	req := newRequest(http.MethodGet, "/files/half.bin", "")
+	req.Header.Set(requestIDHeader, "panic-request")
+	rec := serve(h, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != "" {
+		t.Errorf("Content-Disposition = %q, want the handler's headers discarded", got)
+	}
+	if got := rec.Header().Get(requestIDHeader); got != "panic-request" {
+		t.Errorf("%s = %q, want the outer middleware's header kept", requestIDHeader, got)
+	}
+
+	var resp Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decoding response %q: %v", rec.Body, err)
+	}
+	if resp.Success || resp.Message != "Internal server error" || resp.RequestID != "panic-request" {
+		t.Errorf("response = %+v, want an internal server error for panic-request", resp)
+	}
+}
+
+func TestRecoverMiddlewareAfterWrite(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"panic after writing", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("partial"))
+			panic("boom")
+		}},
+		{"abort", func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if v := recover(); v != http.ErrAbortHandler {
+					t.Errorf("recovered %v, want http.ErrAbortHandler so the connection is aborted", v)
+				}
+			}()
+			serve(recoverMiddleware(tt.handler), newRequest(http.MethodGet, "/files/half.bin", ""))
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"sync"
+	"time"
+
+	"MinIO-Learn/internal/storage"
+)
+
+// uploadError is a failure storing an uploaded file, with the HTTP status it
+// should be reported as.
+type uploadError struct {
+	status  int
+	message string
+}
+
+func (e *uploadError) Error() string { return e.message }
+
+// sendUploadError reports a storeFormFile failure.
+func sendUploadError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var uploadErr *uploadError
+	if errors.As(err, &uploadErr) {
+		status = uploadErr.status
+	}
+	sendResponse(w, false, err.Error(), nil, status)
+}
+
+// storeFormFile uploads one file from a parsed multipart form, including its
+// content hash indexing and post-upload processing. If trackProgress is set,
+// the upload's progress is published under the request's ?uploadId=.
+func (s *Server) storeFormFile(r *http.Request, header *multipart.FileHeader, trackProgress bool) (FileInfo, error) {
+	file, err := header.Open()
+	if err != nil {
+		return FileInfo{}, &uploadError{http.StatusBadRequest, "Error retrieving file: " + err.Error()}
+	}
+	defer file.Close()
+
+	objectName := s.objectKey(r, header.Filename)
+
+	contentType, err := sniffSeeker(header.Header.Get("Content-Type"), file)
+	if err != nil {
+		return FileInfo{}, &uploadError{http.StatusInternalServerError, "Error reading file: " + err.Error()}
+	}
+
+	if s.config.ValidateContent {
+		if validator := contentValidatorFor(contentType); validator != nil {
+			if err := validator(file); err != nil {
+				return FileInfo{}, &uploadError{http.StatusUnprocessableEntity, "Invalid file content: " + err.Error()}
+			}
+			if _, err := file.Seek(0, io.SeekStart); err != nil {
+				return FileInfo{}, &uploadError{http.StatusInternalServerError, "Error rewinding file: " + err.Error()}
+			}
+		}
+	}
+
+	metadata := formMetadata(r)
+	for k, v := range storage.OriginalFilenameMetadata(header.Filename) {
+		metadata[k] = v
+	}
+	// uploadHandler has already validated it.
+	if storageClass, _ := requestStorageClass(r); storageClass != "" {
+		for k, v := range storage.StorageClassMetadata(storageClass) {
+			metadata[k] = v
+		}
+	}
+
+	// The file is on disk or in memory, so hash it up front and store the
+	// checksum with the object.
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return FileInfo{}, &uploadError{http.StatusInternalServerError, "Error reading file: " + err.Error()}
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return FileInfo{}, &uploadError{http.StatusInternalServerError, "Error rewinding file: " + err.Error()}
+	}
+	checksum := hex.EncodeToString(hasher.Sum(nil))
+	for k, v := range storage.ChecksumMetadata(checksum) {
+		metadata[k] = v
+	}
+
+	ctx, endProgress := r.Context(), func(error) {}
+	if trackProgress {
+		ctx, endProgress = s.trackUpload(r, header.Size)
+	}
+	uploadInfo, err := s.storage.UploadStream(ctx, objectName, file, header.Size, contentType, metadata)
+	endProgress(err)
+	if err != nil {
+		return FileInfo{}, &uploadError{storageErrorStatus(err), "Error uploading to MinIO: " + err.Error()}
+	}
+
+	s.indexContentHash(r, checksum, objectName)
+
+	fileInfo := FileInfo{
+		FileName:    header.Filename,
+		Size:        uploadInfo.Size,
+		ContentType: contentType,
+		URL:         s.objectURL(r, objectName, s.config.PresignExpiry),
+		SHA256:      checksum,
+		UploadedAt:  time.Now(),
+	}
+
+	if err := s.postProcessUpload(r, fileInfo, objectName); err != nil {
+		return FileInfo{}, err
+	}
+	return fileInfo, nil
+}
+
+type fileUploadResult struct {
+	FileName string    `json:"fileName"`
+	Success  bool      `json:"success"`
+	File     *FileInfo `json:"file,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// uploadMultipleFiles stores every "file" part of a multipart upload using up
+// to MINIO_UPLOAD_CONCURRENCY uploads at a time. Results are reported per
+// file in request order.
+func (s *Server) uploadMultipleFiles(w http.ResponseWriter, r *http.Request, headers []*multipart.FileHeader) {
+	results := make([]fileUploadResult, len(headers))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < min(s.config.UploadConcurrency, len(headers)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				fileInfo, err := s.storeFormFile(r, headers[j], false)
+				if err != nil {
+					results[j].Error = err.Error()
+					continue
+				}
+				results[j].Success = true
+				results[j].File = &fileInfo
+			}
+		}()
+	}
+
+	for i, header := range headers {
+		results[i].FileName = header.Filename
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	succeeded := 0
+	for _, result := range results {
+		if result.Success {
+			succeeded++
+		}
+	}
+
+	status := http.StatusOK
+	switch {
+	case succeeded == 0:
+		status = http.StatusInternalServerError
+	case succeeded < len(results):
+		status = http.StatusMultiStatus
+	}
+	sendResponse(w, succeeded > 0, fmt.Sprintf("Uploaded %d of %d files", succeeded, len(results)), results, status)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"MinIO-Learn/internal/storage"
+	"github.com/minio/minio-go/v7"
+)
+
+var originClient = &http.Client{Timeout: 5 * time.Minute}
+
+// serveFromOrigin handles a miss in read-through mode: it fetches
+// <MINIO_ORIGIN_URL>/<objectName>, streams the body to the client and, at
+// the same time, uploads it under objectName so later requests are served
+// from storage. An upstream 404 becomes our 404, and a response that doesn't
+// match the route's expectation a 409, with nothing stored. If the upload
+// fails or the body exceeds MINIO_MAX_UPLOAD_SIZE, the client still gets the
+// full body but nothing is stored; if the client goes away the upload is
+// abandoned rather than storing a truncated object. Only downloads read
+// through, as they are the requests that serve the object's bytes.
+func (s *Server) serveFromOrigin(w http.ResponseWriter, r *http.Request, objectName string) {
+	originURL := strings.TrimSuffix(s.config.OriginURL, "/") + (&url.URL{Path: "/" + objectName}).EscapedPath()
+
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, originURL, nil)
+	if err != nil {
+		sendResponse(w, false, "Error building origin request: "+err.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := originClient.Do(req)
+	if err != nil {
+		sendResponse(w, false, "Error fetching from origin: "+err.Error(), nil, http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		sendResponse(w, false, "File not found", nil, http.StatusNotFound)
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		sendResponse(w, false, fmt.Sprintf("Origin responded with %s", resp.Status), nil, http.StatusBadGateway)
+		return
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	// Nothing is stored yet for checkExpectation to stat, so hold what the
+	// origin sends to the route's expectation before serving or storing it.
+	if expect, ok := r.Context().Value(expectationKey{}).(storage.ObjectExpectation); ok {
+		err := expect.Check(minio.ObjectInfo{Size: resp.ContentLength, ContentType: contentType})
+		if err == nil && expect.MaxSize > 0 && resp.ContentLength < 0 {
+			err = fmt.Errorf("%w: origin sent no length to check against the limit of %d bytes", storage.ErrUnexpectedObject, expect.MaxSize)
+		}
+		if err != nil {
+			sendResponse(w, false, "Refusing to serve object: "+err.Error(), nil, http.StatusConflict)
+			return
+		}
+	}
+
+	limit := s.config.MaxUploadSize
+	uploading := limit <= 0 || resp.ContentLength <= limit
+	if !uploading {
+		slog.WarnContext(r.Context(), "Not storing object fetched from origin", "object", objectName,
+			"size", resp.ContentLength, "limit", limit)
+	}
+
+	pr, pw := io.Pipe()
+	uploadDone := make(chan error, 1)
+	if uploading {
+		metadata := storage.OriginalFilenameMetadata(path.Base(objectName))
+		go func() {
+			_, err := s.storage.UploadStream(r.Context(), objectName, pr, resp.ContentLength, contentType, metadata)
+			pr.CloseWithError(err)
+			uploadDone <- err
+		}()
+	} else {
+		uploadDone <- nil
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	if resp.ContentLength >= 0 {
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", resp.ContentLength))
+	}
+	w.WriteHeader(http.StatusOK)
+
+	var stored int64
+	buf := make([]byte, 32<<10)
+	for {
+		n, readErr := resp.Body.Read(buf)
+		if n > 0 {
+			if _, err := w.Write(buf[:n]); err != nil {
+				pw.CloseWithError(fmt.Errorf("client disconnected: %w", err))
+				uploading = false
+				break
+			}
+			if uploading && limit > 0 && stored+int64(n) > limit {
+				// A body without a length can still turn out too large.
+				pw.CloseWithError(&http.MaxBytesError{Limit: limit})
+				uploading = false
+			}
+			if uploading {
+				stored += int64(n)
+				if _, err := pw.Write(buf[:n]); err != nil {
+					// The upload goroutine failed; keep serving the client.
+					uploading = false
+				}
+			}
+		}
+		if readErr == io.EOF {
+			pw.Close()
+			break
+		}
+		if readErr != nil {
+			pw.CloseWithError(readErr)
+			uploading = false
+			break
+		}
+	}
+
+	if err := <-uploadDone; err != nil {
+		slog.WarnContext(r.Context(), "Failed to store object fetched from origin", "object", objectName, "error", err)
+		return
+	}
+	if uploading {
+		slog.InfoContext(r.Context(), "Stored object fetched from origin", "object", objectName)
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"MinIO-Learn/internal/storage"
+)
+
+func TestReadThroughOrigin(t *testing.T) {
+	content := bytes.Repeat([]byte("origin data "), 10000)
+
+	tests := []struct {
+		name           string
+		originStatus   int
+		wantFirst      int
+		wantSecond     int
+		wantStored     bool
+		wantOriginHits int64
+	}{
+		{"fetched then cached", http.StatusOK, http.StatusOK, http.StatusOK, true, 1},
+		{"missing upstream", http.StatusNotFound, http.StatusNotFound, http.StatusNotFound, false, 2},
+		{"failing upstream", http.StatusInternalServerError, http.StatusBadGateway, http.StatusBadGateway, false, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits atomic.Int64
+			origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hits.Add(1)
+				if r.URL.Path != "/docs/report.txt" || tt.originStatus != http.StatusOK {
+					http.Error(w, "unavailable", tt.originStatus)
+					return
+				}
+				w.Header().Set("Content-Type", "text/plain")
+				w.Write(content)
+			}))
+			t.Cleanup(origin.Close)
+
+			store := storage.NewMemoryStorage("test-bucket")
+			h := newTestServer(t, store, map[string]string{"MINIO_ORIGIN_URL": origin.URL + "/"})
+
+			for i, want := range []int{tt.wantFirst, tt.wantSecond} {
+				rec := serve(h, newRequest(http.MethodGet, "/files/docs/report.txt?download=true", ""))
+				if rec.Code != want {
+					t.Fatalf("request %d status = %d, want %d: %.200s", i+1, rec.Code, want, rec.Body)
+				}
+				if want == http.StatusOK && !bytes.Equal(rec.Body.Bytes(), content) {
+					t.Errorf("request %d body is %d bytes, want the %d from origin", i+1, rec.Body.Len(), len(content))
+				}
+			}
+
+			if got := hits.Load(); got != tt.wantOriginHits {
+				t.Errorf("origin requests = %d, want %d", got, tt.wantOriginHits)
+			}
+			stored, err := store.DownloadBuffer(context.Background(), "docs/report.txt")
+			if tt.wantStored {
+				if err != nil || !bytes.Equal(stored, content) {
+					t.Errorf("stored object = %d bytes, error %v, want the origin content", len(stored), err)
+				}
+				info, err := store.GetObjectInfo(context.Background(), "docs/report.txt")
+				if err != nil {
+					t.Fatalf("GetObjectInfo() error = %v", err)
+				}
+				if got := storage.OriginalFilename(info); got != "report.txt" {
+					t.Errorf("OriginalFilename() = %q, want report.txt", got)
+				}
+			} else if err == nil {
+				t.Error("object stored after an origin failure")
+			}
+		})
+	}
+}
+
+func TestReadThroughOriginExpectation(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		chunked     bool
+		want        int
+	}{
+		{"conforming", "application/json", []byte(`{"ok":true}`), false, http.StatusOK},
+		{"wrong type", "text/html", []byte("<p>hi</p>"), false, http.StatusConflict},
+		{"oversized", "application/json", bytes.Repeat([]byte("x"), 200), false, http.StatusConflict},
+		{"unknown length", "application/json", []byte(`{"ok":true}`), true, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				if tt.chunked {
+					// Flushing before the body leaves the length unknown.
+					w.(http.Flusher).Flush()
+				}
+				w.Write(tt.body)
+			}))
+			t.Cleanup(origin.Close)
+
+			store := storage.NewMemoryStorage("test-bucket")
+			h := newTestServer(t, store, map[string]string{
+				"MINIO_ORIGIN_URL":          origin.URL,
+				"MINIO_EXPECT_CONTENT_TYPE": "application/json",
+				"MINIO_EXPECT_MAX_SIZE":     "100",
+				"MINIO_EXPECT_PREFIXES":     "data/",
+			})
+
+			rec := serve(h, newRequest(http.MethodGet, "/files/data/data.json?download=true", ""))
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			stored, err := store.CheckObjectExists(context.Background(), "data/data.json")
+			if err != nil {
+				t.Fatalf("CheckObjectExists() error = %v", err)
+			}
+			if want := tt.want == http.StatusOK; stored != want {
+				t.Errorf("stored = %v, want %v", stored, want)
+			}
+		})
+	}
+}
+
+func TestReadThroughOriginOnlyDownloads(t *testing.T) {
+	var hits atomic.Int64
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("origin data"))
+	}))
+	t.Cleanup(origin.Close)
+
+	store := storage.NewMemoryStorage("test-bucket")
+	h := newTestServer(t, store, map[string]string{"MINIO_ORIGIN_URL": origin.URL})
+
+	for _, path := range []string{"/files/report.txt", "/files/report.txt?metadata=true", "/files/report.txt?download=true&metadata=true"} {
+		if rec := serve(h, newRequest(http.MethodGet, path, "")); rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s status = %d, want 404: %s", path, rec.Code, rec.Body)
+		}
+	}
+	if got := hits.Load(); got != 0 {
+		t.Errorf("origin requests = %d, want none", got)
+	}
+	if exists, _ := store.CheckObjectExists(context.Background(), "report.txt"); exists {
+		t.Error("object stored without a download")
+	}
+}
+
+func TestReadThroughOriginUploadLimit(t *testing.T) {
+	content := bytes.Repeat([]byte("x"), 200<<10)
+
+	for _, chunked := range []bool{false, true} {
+		origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			if chunked {
+				// Flushing before the body leaves the length unknown.
+				w.(http.Flusher).Flush()
+			}
+			w.Write(content)
+		}))
+		t.Cleanup(origin.Close)
+
+		store := storage.NewMemoryStorage("test-bucket")
+		h := newTestServer(t, store, map[string]string{
+			"MINIO_ORIGIN_URL":      origin.URL,
+			"MINIO_MAX_UPLOAD_SIZE": "100000",
+		})
+
+		rec := serve(h, newRequest(http.MethodGet, "/files/big.txt?download=true", ""))
+		if rec.Code != http.StatusOK || !bytes.Equal(rec.Body.Bytes(), content) {
+			t.Errorf("chunked %v: status = %d with %d bytes, want 200 with the origin content", chunked, rec.Code, rec.Body.Len())
+		}
+		if exists, _ := store.CheckObjectExists(context.Background(), "big.txt"); exists {
+			t.Errorf("chunked %v: object over MINIO_MAX_UPLOAD_SIZE stored", chunked)
+		}
+	}
+}
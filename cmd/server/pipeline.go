@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Processor is a step run against every successfully uploaded object, such
+// as thumbnailing, scanning or notifying another system.
+type Processor interface {
+	Name() string
+	Process(ctx context.Context, info FileInfo, objectKey string) error
+}
+
+// processorFunc adapts a function to the Processor interface.
+type processorFunc struct {
+	name string
+	fn   func(ctx context.Context, info FileInfo, objectKey string) error
+}
+
+func (p processorFunc) Name() string { return p.name }
+
+func (p processorFunc) Process(ctx context.Context, info FileInfo, objectKey string) error {
+	return p.fn(ctx, info, objectKey)
+}
+
+// uploadPipeline runs the registered processors in registration order. In
+// synchronous mode the first failure is returned to the caller so it can fail
+// the upload; in asynchronous mode the processors run in the background and
+// each failing processor is retried before its error is logged and the
+// pipeline moves on.
+type uploadPipeline struct {
+	mu         sync.RWMutex
+	processors []Processor
+
+	async      bool
+	retries    int
+	retryDelay time.Duration
+
+	wg sync.WaitGroup
+}
+
+func (p *uploadPipeline) Register(processor Processor) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.processors = append(p.processors, processor)
+}
+
+func (p *uploadPipeline) snapshot() []Processor {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return append([]Processor(nil), p.processors...)
+}
+
+// Run processes an uploaded object. It only returns an error in synchronous
+// mode.
+func (p *uploadPipeline) Run(ctx context.Context, info FileInfo, objectKey string) error {
+	processors := p.snapshot()
+	if len(processors) == 0 {
+		return nil
+	}
+
+	if !p.async {
+		for _, processor := range processors {
+			if err := processor.Process(ctx, info, objectKey); err != nil {
+				return fmt.Errorf("%s: %w", processor.Name(), err)
+			}
+		}
+		return nil
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		// The request context ends with the response, so background
+		// processing gets its own.
+		ctx := context.Background()
+		for _, processor := range processors {
+			p.runWithRetry(ctx, processor, info, objectKey)
+		}
+	}()
+	return nil
+}
+
+func (p *uploadPipeline) runWithRetry(ctx context.Context, processor Processor, info FileInfo, objectKey string) {
+	delay := p.retryDelay
+	for attempt := 1; ; attempt++ {
+		err := processor.Process(ctx, info, objectKey)
+		if err == nil {
+			return
+		}
+		if attempt > p.retries {
+			slog.ErrorContext(ctx, "Post-upload processor failed", "processor", processor.Name(), "object", objectKey, "attempts", attempt, "error", err)
+			return
+		}
+		slog.WarnContext(ctx, "Post-upload processor failed, retrying", "processor", processor.Name(), "object", objectKey, "attempt", attempt, "delay", delay, "error", err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// Go runs fn in the background as part of the pipeline's work, so Wait waits
+// for it too. Processors use it for work that outlives Process.
+func (p *uploadPipeline) Go(fn func()) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		fn()
+	}()
+}
+
+// Wait blocks until all background processing has finished.
+func (p *uploadPipeline) Wait() {
+	p.wg.Wait()
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"MinIO-Learn/internal/storage"
+)
+
+// recorder collects the processor calls of a test in order.
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recorder) add(call string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+func (r *recorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.calls)
+}
+
+// flakyProcessor records each call and fails the first failures of them.
+func flakyProcessor(name string, failures int, calls *recorder) Processor {
+	attempts := 0
+	return processorFunc{name: name, fn: func(ctx context.Context, info FileInfo, objectKey string) error {
+		attempts++
+		calls.add(fmt.Sprintf("%s %s #%d", name, objectKey, attempts))
+		if attempts <= failures {
+			return errors.New("transient failure")
+		}
+		return nil
+	}}
+}
+
+func TestUploadPipeline(t *testing.T) {
+	tests := []struct {
+		name      string
+		async     bool
+		retries   int
+		failures  map[string]int
+		wantErr   bool
+		wantCalls []string
+	}{
+		{
+			name:      "sync runs in order",
+			wantCalls: []string{"first a.txt #1", "second a.txt #1", "third a.txt #1"},
+		},
+		{
+			name:      "sync failure stops the pipeline",
+			failures:  map[string]int{"second": 1},
+			wantErr:   true,
+			wantCalls: []string{"first a.txt #1", "second a.txt #1"},
+		},
+		{
+			name:      "async runs in order",
+			async:     true,
+			wantCalls: []string{"first a.txt #1", "second a.txt #1", "third a.txt #1"},
+		},
+		{
+			name:      "async failure is retried",
+			async:     true,
+			retries:   2,
+			failures:  map[string]int{"second": 2},
+			wantCalls: []string{"first a.txt #1", "second a.txt #1", "second a.txt #2", "second a.txt #3", "third a.txt #1"},
+		},
+		{
+			name:      "async gives up and moves on",
+			async:     true,
+			retries:   1,
+			failures:  map[string]int{"first": 5},
+			wantCalls: []string{"first a.txt #1", "first a.txt #2", "second a.txt #1", "third a.txt #1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls recorder
+			pipeline := &uploadPipeline{async: tt.async, retries: tt.retries, retryDelay: time.Millisecond}
+			for _, name := range []string{"first", "second", "third"} {
+				pipeline.Register(flakyProcessor(name, tt.failures[name], &calls))
+			}
+
+			err := pipeline.Run(context.Background(), FileInfo{FileName: "a.txt"}, "a.txt")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			pipeline.Wait()
+
+			if got := calls.get(); !slices.Equal(got, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestUploadPipelineAsyncDoesNotBlock(t *testing.T) {
+	release := make(chan struct{})
+	pipeline := &uploadPipeline{async: true}
+	pipeline.Register(processorFunc{name: "slow", fn: func(ctx context.Context, info FileInfo, objectKey string) error {
+		<-release
+		return nil
+	}})
+
+	done := make(chan error, 1)
+	go func() { done <- pipeline.Run(context.Background(), FileInfo{}, "a.txt") }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Run() blocked on an async processor")
+	}
+
+	close(release)
+	pipeline.Wait()
+}
+
+func TestUploadFailsOnSyncProcessor(t *testing.T) {
+	tests := []struct {
+		name        string
+		fail        bool
+		wantStatus  int
+		wantObjects int
+	}{
+		{"processor succeeds", false, http.StatusOK, 1},
+		{"processor fails", true, http.StatusInternalServerError, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStorage("test-bucket")
+			srv := NewServer(store, testConfig(t, nil))
+			var seen []string
+			srv.postUpload.Register(processorFunc{name: "scan", fn: func(ctx context.Context, info FileInfo, objectKey string) error {
+				seen = append(seen, info.FileName)
+				if tt.fail {
+					return errors.New("infected")
+				}
+				return nil
+			}})
+
+			rec := serve(srv.Handler(), newUploadRequest(t, "report.txt", []byte("hello")))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if !slices.Equal(seen, []string{"report.txt"}) {
+				t.Errorf("processor saw %v, want the upload", seen)
+			}
+			objects, err := store.ListObjects(context.Background(), "")
+			if err != nil {
+				t.Fatalf("ListObjects() error = %v", err)
+			}
+			if len(objects) != tt.wantObjects {
+				t.Errorf("%d objects stored, want %d", len(objects), tt.wantObjects)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"MinIO-Learn/internal/storage"
+)
+
+// progressRetention is how long a finished upload's progress stays available,
+// so a subscriber that connects late still sees the outcome.
+const progressRetention = time.Minute
+
+var uploadIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// uploadProgress is the payload of each progress event.
+type uploadProgress struct {
+	Transferred int64  `json:"transferred"`
+	Total       int64  `json:"total"`
+	Done        bool   `json:"done"`
+	Error       string `json:"error,omitempty"`
+}
+
+type trackedUpload struct {
+	state       uploadProgress
+	started     bool
+	subscribers int
+	// changed is closed and replaced on every update, waking subscribers.
+	changed chan struct{}
+}
+
+// progressTracker holds the progress of uploads that were given an ID by the
+// client, for subscribers on /upload/progress.
+type progressTracker struct {
+	mu      sync.Mutex
+	uploads map[string]*trackedUpload
+}
+
+func newProgressTracker() *progressTracker {
+	return &progressTracker{uploads: make(map[string]*trackedUpload)}
+}
+
+// entry returns the upload with id, creating it if needed. t.mu must be held.
+func (t *progressTracker) entry(id string) *trackedUpload {
+	upload, ok := t.uploads[id]
+	if !ok {
+		upload = &trackedUpload{changed: make(chan struct{})}
+		t.uploads[id] = upload
+	}
+	return upload
+}
+
+func (t *progressTracker) update(id string, fn func(*uploadProgress)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	upload := t.entry(id)
+	upload.started = true
+	fn(&upload.state)
+	close(upload.changed)
+	upload.changed = make(chan struct{})
+}
+
+// start begins tracking upload id, returning a callback for
+// storage.WithProgress and one to record the upload's outcome.
+func (t *progressTracker) start(id string, total int64) (storage.ProgressFunc, func(error)) {
+	t.update(id, func(p *uploadProgress) {
+		*p = uploadProgress{Total: total}
+	})
+
+	report := func(transferred, total int64) {
+		t.update(id, func(p *uploadProgress) {
+			p.Transferred = transferred
+			p.Total = total
+		})
+	}
+	end := func(err error) {
+		t.update(id, func(p *uploadProgress) {
+			p.Done = true
+			if err != nil {
+				p.Error = err.Error()
+			}
+		})
+		time.AfterFunc(progressRetention, func() { t.remove(id) })
+	}
+	return report, end
+}
+
+// remove forgets a finished upload once nobody is watching it.
+func (t *progressTracker) remove(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if upload, ok := t.uploads[id]; ok && upload.state.Done && upload.subscribers == 0 {
+		delete(t.uploads, id)
+	}
+}
+
+// subscribe registers interest in upload id, keeping it around until
+// unsubscribe is called.
+func (t *progressTracker) subscribe(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.entry(id).subscribers++
+}
+
+func (t *progressTracker) unsubscribe(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	upload, ok := t.uploads[id]
+	if !ok {
+		return
+	}
+	upload.subscribers--
+	// Drop uploads that never started, and finished ones whose retention
+	// timer already fired while this subscriber was watching.
+	if upload.subscribers == 0 && (!upload.started || upload.state.Done) {
+		delete(t.uploads, id)
+	}
+}
+
+// current returns the state of upload id, whether it has started, and a
+// channel closed on its next change.
+func (t *progressTracker) current(id string) (uploadProgress, bool, <-chan struct{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	upload := t.entry(id)
+	return upload.state, upload.started, upload.changed
+}
+
+// trackUpload returns the context to run the upload in r under. If the
+// request names an upload with ?uploadId=, progress is reported to
+// subscribers of that ID; call the returned function with the upload's
+// outcome. An invalid ID is ignored.
+func (s *Server) trackUpload(r *http.Request, total int64) (context.Context, func(error)) {
+	id := r.URL.Query().Get("uploadId")
+	if !uploadIDPattern.MatchString(id) {
+		return r.Context(), func(error) {}
+	}
+
+	report, end := s.progress.start(tenantPrefix(r)+id, total)
+	return storage.WithProgress(r.Context(), report), end
+}
+
+// uploadProgressHandler streams the progress of the upload named by
+// ?uploadId= as Server-Sent Events, one JSON uploadProgress per event, until
+// the upload finishes or the client goes away. Subscribing before the upload
+// starts is fine; events begin once it does.
+func (s *Server) uploadProgressHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendResponse(w, false, "Method not allowed", nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := r.URL.Query().Get("uploadId")
+	if !uploadIDPattern.MatchString(id) {
+		sendResponse(w, false, "uploadId must be 1-64 letters, digits, '-' or '_'", nil, http.StatusBadRequest)
+		return
+	}
+
+	// Tenants only see their own uploads, whatever IDs they pick.
+	id = tenantPrefix(r) + id
+	s.progress.subscribe(id)
+	defer s.progress.unsubscribe(id)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+	// Send the headers now, so the client knows it is subscribed.
+	rc.Flush()
+
+	for {
+		state, started, changed := s.progress.current(id)
+		if started {
+			data, err := json.Marshal(state)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Failed to encode upload progress", "uploadId", id, "error", err)
+				return
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil && err != http.ErrNotSupported {
+				return
+			}
+			if state.Done {
+				return
+			}
+		}
+
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"slices"
+	"strconv"
+	"strings"
+
+	"MinIO-Learn/internal/storage"
+
+	"github.com/minio/minio-go/v7"
+)
+
+var errRangeNotSatisfiable = errors.New("range not satisfiable")
+
+// maxRanges is the most ranges a Range header may list. Requests with more
+// are answered with the whole object, as RFC 9110 allows, rather than read
+// the object many times over.
+const maxRanges = 16
+
+// httpRange is an inclusive byte range resolved against an object size.
+type httpRange struct {
+	start, end int64
+}
+
+func (r httpRange) contentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.start, r.end, size)
+}
+
+// parseRanges parses a Range header such as "bytes=0-99,200-,-50" against an
+// object of the given size. Ranges that start past the end are dropped; if
+// none remain errRangeNotSatisfiable is returned.
+func parseRanges(header string, size int64) ([]httpRange, error) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok {
+		return nil, fmt.Errorf("unsupported range unit in %q", header)
+	}
+
+	var ranges []httpRange
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		first, last, ok := strings.Cut(part, "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid range %q", part)
+		}
+
+		var rng httpRange
+		if first == "" {
+			// Suffix range: the final n bytes.
+			n, err := strconv.ParseInt(last, 10, 64)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid range %q", part)
+			}
+			if n > size {
+				n = size
+			}
+			rng = httpRange{start: size - n, end: size - 1}
+		} else {
+			start, err := strconv.ParseInt(first, 10, 64)
+			if err != nil || start < 0 {
+				return nil, fmt.Errorf("invalid range %q", part)
+			}
+			end := size - 1
+			if last != "" {
+				end, err = strconv.ParseInt(last, 10, 64)
+				if err != nil || end < start {
+					return nil, fmt.Errorf("invalid range %q", part)
+				}
+				if end >= size {
+					end = size - 1
+				}
+			}
+			rng = httpRange{start: start, end: end}
+		}
+
+		if rng.start >= size || size == 0 {
+			continue
+		}
+		ranges = append(ranges, rng)
+	}
+
+	if len(ranges) == 0 {
+		return nil, errRangeNotSatisfiable
+	}
+	return ranges, nil
+}
+
+// mergeRanges sorts ranges by start and coalesces those that overlap or
+// touch, so no byte is sent twice.
+func mergeRanges(ranges []httpRange) []httpRange {
+	sorted := slices.Clone(ranges)
+	slices.SortFunc(sorted, func(a, b httpRange) int { return cmp.Compare(a.start, b.start) })
+
+	merged := sorted[:1]
+	for _, rng := range sorted[1:] {
+		last := &merged[len(merged)-1]
+		if rng.start <= last.end+1 {
+			last.end = max(last.end, rng.end)
+			continue
+		}
+		merged = append(merged, rng)
+	}
+	return merged
+}
+
+// serveRanges answers a Range request for objectName with 206 Partial
+// Content: a single range is sent as-is with a Content-Range header, several
+// as a multipart/byteranges body with each segment streamed separately.
+// Overlapping and adjacent ranges are merged first, and every range is read
+// from the version info describes. It returns false without writing anything
+// when the Range header is malformed, lists more than maxRanges ranges or the
+// object is stored compressed, leaving the caller to serve the full object as
+// RFC 9110 allows.
+func (s *Server) serveRanges(w http.ResponseWriter, r *http.Request, objectName string, info minio.ObjectInfo) bool {
+	header := r.Header.Get("Range")
+
+	// Ranges of a compressed object would index its compressed bytes.
+	if storage.IsCompressed(info) {
+		return false
+	}
+
+	ranges, err := parseRanges(header, info.Size)
+	if errors.Is(err, errRangeNotSatisfiable) {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", info.Size))
+		sendResponse(w, false, "Requested range not satisfiable", nil, http.StatusRequestedRangeNotSatisfiable)
+		return true
+	}
+	if err != nil || len(ranges) > maxRanges {
+		return false
+	}
+	ranges = mergeRanges(ranges)
+
+	contentType := info.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	// Every range is read from the version info describes.
+	var opts storage.ReadOptions
+	if err := opts.SetMatchETag(info.ETag); err != nil {
+		sendResponse(w, false, "Error reading object: "+err.Error(), nil, storageErrorStatus(err))
+		return true
+	}
+
+	if len(ranges) == 1 {
+		rng := ranges[0]
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Range", rng.contentRange(info.Size))
+		w.Header().Set("Content-Length", strconv.FormatInt(rng.end-rng.start+1, 10))
+
+		body := &statusWriter{ResponseWriter: w, status: http.StatusPartialContent}
+		written, err := s.storage.DownloadRangeToWriter(r.Context(), objectName, opts, rng.start, rng.end, body)
+		if err != nil && written == 0 {
+			// Nothing has been sent yet, so the error can still be reported.
+			w.Header().Del("Content-Range")
+			w.Header().Del("Content-Length")
+			if errors.Is(err, storage.ErrPreconditionFailed) {
+				sendResponse(w, false, "Object changed during download, please retry", nil, http.StatusPreconditionFailed)
+				return true
+			}
+			sendResponse(w, false, "Error reading object: "+err.Error(), nil, storageErrorStatus(err))
+			return true
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Download interrupted", "object", objectName, "range", rng.contentRange(info.Size), "written", written, "error", err)
+		}
+		return true
+	}
+
+	mw := multipart.NewWriter(w)
+	w.Header().Set("Content-Type", "multipart/byteranges; boundary="+mw.Boundary())
+	w.WriteHeader(http.StatusPartialContent)
+
+	for _, rng := range ranges {
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":  {contentType},
+			"Content-Range": {rng.contentRange(info.Size)},
+		})
+		if err != nil {
+			return true
+		}
+		if _, err := s.storage.DownloadRangeToWriter(r.Context(), objectName, opts, rng.start, rng.end, part); err != nil {
+			// Headers are already sent, so the best we can do is cut the
+			// body short and let the client see an incomplete multipart.
+			slog.ErrorContext(r.Context(), "Error reading range", "object", objectName, "range", rng.contentRange(info.Size), "error", err)
+			return true
+		}
+	}
+	mw.Close()
+
+	return true
+}
+
+// statusWriter sends status with the first byte written through it rather
+// than up front, so an error that occurs before any data is read can still
+// be answered with a different status.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	wrote  bool
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	if !w.wrote {
+		w.wrote = true
+		w.WriteHeader(w.status)
+	}
+	return w.ResponseWriter.Write(p)
+}
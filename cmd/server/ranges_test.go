@@ -0,0 +1,167 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"MinIO-Learn/internal/storage"
+)
+
+func TestParseRanges(t *testing.T) {
+	// errInvalid stands for any error other than errRangeNotSatisfiable.
+	errInvalid := errors.New("invalid range")
+
+	tests := []struct {
+		name    string
+		header  string
+		size    int64
+		want    []httpRange
+		wantErr error
+	}{
+		{"bounded", "bytes=0-99", 1000, []httpRange{{0, 99}}, nil},
+		{"open ended", "bytes=900-", 1000, []httpRange{{900, 999}}, nil},
+		{"suffix", "bytes=-50", 1000, []httpRange{{950, 999}}, nil},
+		{"suffix longer than object", "bytes=-5000", 1000, []httpRange{{0, 999}}, nil},
+		{"end past object", "bytes=990-2000", 1000, []httpRange{{990, 999}}, nil},
+		{"several", "bytes=0-9, 20-29,-5", 100, []httpRange{{0, 9}, {20, 29}, {95, 99}}, nil},
+		{"start past end dropped", "bytes=0-9,5000-", 100, []httpRange{{0, 9}}, nil},
+		{"only past end", "bytes=5000-", 100, nil, errRangeNotSatisfiable},
+		{"empty object", "bytes=0-", 0, nil, errRangeNotSatisfiable},
+		{"wrong unit", "items=0-9", 100, nil, errInvalid},
+		{"reversed", "bytes=9-0", 100, nil, errInvalid},
+		{"not a number", "bytes=a-b", 100, nil, errInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRanges(tt.header, tt.size)
+			if (err != nil) != (tt.wantErr != nil) {
+				t.Fatalf("parseRanges() error = %v, want %v", err, tt.wantErr)
+			}
+			if errors.Is(tt.wantErr, errRangeNotSatisfiable) && !errors.Is(err, errRangeNotSatisfiable) {
+				t.Errorf("parseRanges() error = %v, want errRangeNotSatisfiable", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parseRanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeRanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		ranges []httpRange
+		want   []httpRange
+	}{
+		{"disjoint", []httpRange{{0, 9}, {20, 29}}, []httpRange{{0, 9}, {20, 29}}},
+		{"unsorted", []httpRange{{20, 29}, {0, 9}}, []httpRange{{0, 9}, {20, 29}}},
+		{"overlapping", []httpRange{{0, 15}, {10, 29}}, []httpRange{{0, 29}}},
+		{"adjacent", []httpRange{{0, 9}, {10, 19}}, []httpRange{{0, 19}}},
+		{"contained", []httpRange{{0, 50}, {10, 20}}, []httpRange{{0, 50}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeRanges(tt.ranges); !slices.Equal(got, tt.want) {
+				t.Errorf("mergeRanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServeRanges(t *testing.T) {
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte('a' + i%26)
+	}
+	store := storage.NewMemoryStorage("test-bucket")
+	putObject(t, store, "data.txt", "text/plain", data)
+	h := newTestServer(t, store, nil)
+
+	manyRanges := make([]string, maxRanges+1)
+	for i := range manyRanges {
+		manyRanges[i] = fmt.Sprintf("%d-%d", i*10, i*10+1)
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+		parts  []httpRange
+	}{
+		{"single range", "bytes=100-199", http.StatusPartialContent, []httpRange{{100, 199}}},
+		{"open-ended range", "bytes=900-", http.StatusPartialContent, []httpRange{{900, 999}}},
+		{"two ranges", "bytes=0-9,500-509", http.StatusPartialContent, []httpRange{{0, 9}, {500, 509}}},
+		{"three ranges with suffix", "bytes=0-0,10-19,-5", http.StatusPartialContent, []httpRange{{0, 0}, {10, 19}, {995, 999}}},
+		{"overlapping ranges merge to one", "bytes=0-50,25-99", http.StatusPartialContent, []httpRange{{0, 99}}},
+		{"too many ranges", "bytes=" + strings.Join(manyRanges, ","), http.StatusOK, nil},
+		{"malformed", "bytes=abc", http.StatusOK, nil},
+		{"unsatisfiable", "bytes=5000-", http.StatusRequestedRangeNotSatisfiable, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newRequest(http.MethodGet, "/files/data.txt?download=true", "")
+			req.Header.Set("Range", tt.header)
+			rec := serve(h, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+
+			switch {
+			case rec.Code == http.StatusOK:
+				if rec.Body.String() != string(data) {
+					t.Errorf("body is not the whole object")
+				}
+			case rec.Code == http.StatusRequestedRangeNotSatisfiable:
+				if got := rec.Header().Get("Content-Range"); got != "bytes */1000" {
+					t.Errorf("Content-Range = %q, want bytes */1000", got)
+				}
+			case len(tt.parts) == 1:
+				rng := tt.parts[0]
+				if got := rec.Header().Get("Content-Range"); got != rng.contentRange(1000) {
+					t.Errorf("Content-Range = %q, want %q", got, rng.contentRange(1000))
+				}
+				if rec.Body.String() != string(data[rng.start:rng.end+1]) {
+					t.Errorf("body = %q, want bytes %d-%d", rec.Body, rng.start, rng.end)
+				}
+			default:
+				mediaType, params, err := mime.ParseMediaType(rec.Header().Get("Content-Type"))
+				if err != nil || mediaType != "multipart/byteranges" {
+					t.Fatalf("Content-Type = %q, want multipart/byteranges", rec.Header().Get("Content-Type"))
+				}
+				reader := multipart.NewReader(rec.Body, params["boundary"])
+				for i, rng := range tt.parts {
+					part, err := reader.NextPart()
+					if err != nil {
+						t.Fatalf("part %d: %v", i, err)
+					}
+					if got := part.Header.Get("Content-Range"); got != rng.contentRange(1000) {
+						t.Errorf("part %d Content-Range = %q, want %q", i, got, rng.contentRange(1000))
+					}
+					if got := part.Header.Get("Content-Type"); got != "text/plain" {
+						t.Errorf("part %d Content-Type = %q, want text/plain", i, got)
+					}
+					body, err := io.ReadAll(part)
+					if err != nil {
+						t.Fatalf("part %d: %v", i, err)
+					}
+					if string(body) != string(data[rng.start:rng.end+1]) {
+						t.Errorf("part %d body = %q, want bytes %d-%d", i, body, rng.start, rng.end)
+					}
+				}
+				if _, err := reader.NextPart(); err != io.EOF {
+					t.Errorf("after %d parts: %v, want io.EOF", len(tt.parts), err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"MinIO-Learn/internal/storage"
+)
+
+func TestRateLimit(t *testing.T) {
+	const burst = 3
+
+	store := storage.NewMemoryStorage("test-bucket")
+	putObject(t, store, "notes.txt", "text/plain", []byte("hello"))
+	h := newTestServer(t, store, map[string]string{
+		"MINIO_RATE_LIMIT":   "0.01",
+		"MINIO_RATE_BURST":   strconv.Itoa(burst),
+		"MINIO_CORS_ORIGINS": "https://app.example.com",
+	})
+
+	for i := range burst {
+		if rec := serve(h, newRequest(http.MethodGet, "/files/notes.txt?download=true", "")); rec.Code != http.StatusOK {
+			t.Fatalf("request %d status = %d, want 200: %s", i+1, rec.Code, rec.Body)
+		}
+	}
+
+	rec := serve(h, newRequest(http.MethodGet, "/files/notes.txt?download=true", ""))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("request %d status = %d, want 429", burst+1, rec.Code)
+	}
+	retryAfter, err := strconv.Atoi(rec.Header().Get("Retry-After"))
+	if err != nil || retryAfter < 1 {
+		t.Errorf("Retry-After = %q, want a positive number of seconds", rec.Header().Get("Retry-After"))
+	}
+
+	other := newRequest(http.MethodGet, "/files/notes.txt?download=true", "")
+	other.RemoteAddr = "198.51.100.7:4321"
+	if rec := serve(h, other); rec.Code != http.StatusOK {
+		t.Errorf("request from another IP status = %d, want 200", rec.Code)
+	}
+
+	if rec := serve(h, newRequest(http.MethodGet, "/livez", "")); rec.Code != http.StatusOK {
+		t.Errorf("/livez status = %d, want 200", rec.Code)
+	}
+
+	preflight := newRequest(http.MethodOptions, "/files/notes.txt", "")
+	preflight.Header.Set("Origin", "https://app.example.com")
+	preflight.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	if rec := serve(h, preflight); rec.Code != http.StatusNoContent {
+		t.Errorf("preflight status = %d, want 204", rec.Code)
+	}
+}
+
+func TestRateLimiterRefill(t *testing.T) {
+	limiter := newRateLimiter(2, 1)
+	now := time.Now()
+
+	if ok, _ := limiter.allow("client", now); !ok {
+		t.Fatal("first request refused")
+	}
+	ok, wait := limiter.allow("client", now)
+	if ok {
+		t.Fatal("second request allowed with the bucket empty")
+	}
+	if wait != 500*time.Millisecond {
+		t.Errorf("wait = %v, want 500ms", wait)
+	}
+	if ok, _ := limiter.allow("client", now.Add(wait)); !ok {
+		t.Error("request refused after waiting for a refill")
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"MinIO-Learn/internal/config"
+	"MinIO-Learn/internal/storage"
+)
+
+type selfTestStep struct {
+	name string
+	run  func() error
+}
+
+// errStepSkipped is returned by steps that don't apply to the configuration,
+// such as presigning when it is disabled.
+var errStepSkipped = errors.New("step skipped")
+
+// runSelfTest exercises the whole storage pipeline once against the
+// configured backend, printing each step's result and timing to stdout. It
+// stops at the first failing step and returns the process exit code.
+func runSelfTest(cfg config.MinIOConfig, stdout io.Writer) int {
+	ctx := context.Background()
+	var service *storage.MinIOService
+	objectName := fmt.Sprintf("selftest/%d.txt", time.Now().UnixNano())
+	sentinel := []byte("minio self-test " + objectName)
+	var downloaded []byte
+	var url string
+	uploaded := false
+
+	steps := []selfTestStep{
+		{"connect", func() error {
+			storageConfig, err := newStorageConfig(cfg)
+			if err != nil {
+				return err
+			}
+			service, err = storage.NewMinIOService(storageConfig)
+			return err
+		}},
+		{"ensure bucket", func() error {
+			return service.EnsureBucket(ctx)
+		}},
+		{"upload sentinel", func() error {
+			_, err := service.UploadBuffer(ctx, objectName, sentinel, "text/plain", nil)
+			uploaded = err == nil
+			return err
+		}},
+		{"stat sentinel", func() error {
+			exists, err := service.CheckObjectExists(ctx, objectName)
+			if err == nil && !exists {
+				err = fmt.Errorf("object '%s' not found after upload", objectName)
+			}
+			return err
+		}},
+		{"download sentinel", func() error {
+			var err error
+			downloaded, err = service.DownloadBuffer(ctx, objectName)
+			return err
+		}},
+		{"verify bytes", func() error {
+			if !bytes.Equal(downloaded, sentinel) {
+				return fmt.Errorf("downloaded %d bytes do not match the %d uploaded", len(downloaded), len(sentinel))
+			}
+			return nil
+		}},
+		{"presign URL", func() error {
+			if cfg.DisablePresign {
+				return errStepSkipped
+			}
+			var err error
+			url, err = service.GetObjectURL(ctx, objectName, time.Minute)
+			return err
+		}},
+		{"fetch presigned URL", func() error {
+			if cfg.DisablePresign {
+				return errStepSkipped
+			}
+			resp, err := http.Get(url)
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return fmt.Errorf("unexpected status %s", resp.Status)
+			}
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(body, sentinel) {
+				return fmt.Errorf("presigned URL returned different content")
+			}
+			return nil
+		}},
+		{"delete sentinel", func() error {
+			err := service.DeleteObject(ctx, objectName)
+			uploaded = uploaded && err != nil
+			return err
+		}},
+	}
+
+	exitCode := 0
+	for _, step := range steps {
+		start := time.Now()
+		err := step.run()
+		elapsed := time.Since(start).Round(time.Millisecond)
+		if errors.Is(err, errStepSkipped) {
+			fmt.Fprintf(stdout, "skip  %-20s\n", step.name)
+			continue
+		}
+		if err != nil {
+			fmt.Fprintf(stdout, "FAIL  %-20s %8v  %v\n", step.name, elapsed, err)
+			exitCode = 1
+			break
+		}
+		fmt.Fprintf(stdout, "ok    %-20s %8v\n", step.name, elapsed)
+	}
+
+	if uploaded {
+		if err := service.DeleteObject(ctx, objectName); err != nil {
+			fmt.Fprintf(stdout, "warning: failed to clean up sentinel '%s': %v\n", objectName, err)
+		}
+	}
+
+	if exitCode == 0 {
+		fmt.Fprintln(stdout, "self-test passed")
+	} else {
+		fmt.Fprintln(stdout, "self-test failed")
+	}
+	return exitCode
+}
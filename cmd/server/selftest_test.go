@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"MinIO-Learn/internal/storage/storagetest"
+)
+
+func TestRunSelfTest(t *testing.T) {
+	tests := []struct {
+		name     string
+		fail     func(r *http.Request) bool
+		env      map[string]string
+		wantCode int
+		wantStep string
+	}{
+		{
+			name:     "working backend",
+			wantCode: 0,
+		},
+		{
+			name:     "presigning disabled",
+			env:      map[string]string{"MINIO_DISABLE_PRESIGN": "true"},
+			wantCode: 0,
+			wantStep: "skip  presign URL",
+		},
+		{
+			name:     "unreachable bucket",
+			fail:     func(r *http.Request) bool { return true },
+			wantCode: 1,
+			wantStep: "FAIL  connect",
+		},
+		{
+			name: "uploads rejected",
+			fail: func(r *http.Request) bool {
+				return r.Method == http.MethodPut && strings.Contains(r.URL.Path, "/selftest/")
+			},
+			wantCode: 1,
+			wantStep: "FAIL  upload sentinel",
+		},
+		{
+			name: "downloads rejected",
+			fail: func(r *http.Request) bool {
+				return r.Method == http.MethodGet && strings.Contains(r.URL.Path, "/selftest/")
+			},
+			wantCode: 1,
+			wantStep: "FAIL  download sentinel",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := storagetest.NewFakeS3()
+			if tt.fail != nil {
+				fake.Before = func(w http.ResponseWriter, r *http.Request) bool {
+					if !tt.fail(r) {
+						return false
+					}
+					w.WriteHeader(http.StatusForbidden)
+					return true
+				}
+			}
+			env := fakeBackendEnv(t, fake)
+			for key, value := range tt.env {
+				env[key] = value
+			}
+
+			var stdout bytes.Buffer
+			code := runSelfTest(testConfig(t, env), &stdout)
+			if code != tt.wantCode {
+				t.Errorf("runSelfTest() = %d, want %d\n%s", code, tt.wantCode, stdout.String())
+			}
+			if tt.wantStep != "" && !strings.Contains(stdout.String(), tt.wantStep) {
+				t.Errorf("output does not report %q:\n%s", tt.wantStep, stdout.String())
+			}
+			if tt.wantCode == 0 && !strings.Contains(stdout.String(), "self-test passed") {
+				t.Errorf("output does not report success:\n%s", stdout.String())
+			}
+			if keys := fake.Keys("test-bucket"); len(keys) != 0 {
+				t.Errorf("objects left behind = %v, want the sentinel cleaned up", keys)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"MinIO-Learn/internal/config"
+	"MinIO-Learn/internal/metrics"
+	"MinIO-Learn/internal/storage"
+)
+
+// Server holds what the HTTP handlers depend on: the object store, the
+// configuration, and the limits and background work shared across requests.
+type Server struct {
+	storage storage.Storage
+	config  config.MinIOConfig
+
+	// Whole objects proxied through /raw tie up a backend connection for the
+	// full transfer, so they get a much smaller limit than ranged reads.
+	bufferedDownloads *semaphore
+	rangedDownloads   *semaphore
+
+	postUpload *uploadPipeline
+	progress   *progressTracker
+}
+
+// healthPaths are the probe endpoints, which skip authentication (if
+// AuthExemptHealth is set) and rate limiting.
+var healthPaths = []string{"/livez", "/readyz", "/health"}
+
+// NewServer returns a Server backed by store, with the download limits and
+// post-upload processors cfg configures.
+func NewServer(store storage.Storage, cfg config.MinIOConfig) *Server {
+	s := &Server{
+		storage: store,
+		config:  cfg,
+
+		bufferedDownloads: newSemaphore(cfg.MaxBufferedDownloads),
+		rangedDownloads:   newSemaphore(cfg.MaxRangedDownloads),
+
+		postUpload: &uploadPipeline{
+			async:      cfg.PostProcessAsync,
+			retries:    cfg.PostProcessRetries,
+			retryDelay: time.Second,
+		},
+		progress: newProgressTracker(),
+	}
+
+	if cfg.ThumbWidth > 0 {
+		s.postUpload.Register(newThumbnailer(store, cfg.ThumbWidth, cfg.MultiTenant))
+	}
+	if cfg.UploadWebhook != "" {
+		// Registered last so the event reflects any earlier processing.
+		s.postUpload.Register(newWebhookNotifier(store, cfg.UploadWebhook, cfg.WebhookSecret, s.postUpload.Go))
+	}
+
+	return s
+}
+
+// Handler returns the server's routes wrapped in its middleware.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/upload", s.uploadHandler)
+	mux.HandleFunc("/upload/check", s.uploadCheckHandler)
+	mux.HandleFunc("/upload/progress", s.uploadProgressHandler)
+	mux.HandleFunc("/upload/sessions", s.uploadSessionsHandler)
+	mux.HandleFunc("/upload/sessions/", s.uploadSessionsHandler)
+	mux.HandleFunc("/upload-url", s.uploadURLHandler)
+	mux.HandleFunc("/upload-policy", s.uploadPolicyHandler)
+	mux.HandleFunc("/files", s.filesRootHandler)
+	mux.HandleFunc("/files/recent", s.recentUploadsHandler)
+	mux.HandleFunc("/files/stream", s.streamFilesHandler)
+	mux.HandleFunc("/files/changes", s.changedFilesHandler)
+	mux.HandleFunc("/files/copy", s.copyFileHandler)
+	mux.HandleFunc("/files/move", s.moveFileHandler)
+	mux.HandleFunc("/files/restore", s.restoreFileHandler)
+	mux.HandleFunc("/files/", s.filesHandler)
+	// The mux prefers the longest pattern, so objects under these prefixes
+	// are checked against the expectation and all others are not.
+	expect := storage.ObjectExpectation{
+		ContentType: s.config.ExpectContentType,
+		MaxSize:     s.config.ExpectMaxSize,
+	}
+	for _, prefix := range s.config.ExpectPrefixes {
+		mux.HandleFunc("/files/"+prefix, expectObject(expect, s.filesHandler))
+	}
+	mux.HandleFunc("/livez", s.livezHandler)
+	mux.HandleFunc("/readyz", s.readyzHandler)
+	mux.HandleFunc("/health", s.readyzHandler)
+	mux.Handle("/metrics", metrics.Handler())
+	mux.HandleFunc("/admin/archive", requireAuthenticated(s.archiveHandler))
+	mux.HandleFunc("/admin/lifecycle", requireAuthenticated(s.lifecycleHandler))
+	mux.HandleFunc("/admin/trash/purge", requireAuthenticated(s.purgeTrashHandler))
+	mux.HandleFunc("/admin/buckets", requireAuthenticated(s.bucketsHandler))
+
+	// Outermost first. Recovery sits inside CORS so error responses keep
+	// their CORS headers and browsers can read them.
+	middlewares := []middleware{requestIDMiddleware}
+	if s.config.RateLimit > 0 {
+		limiter := newRateLimiter(s.config.RateLimit, s.config.RateBurst)
+		middlewares = append(middlewares, func(next http.Handler) http.Handler {
+			return rateLimitMiddleware(limiter, s.config.TrustProxy, healthPaths, next)
+		})
+	}
+	if len(s.config.CORSOrigins) > 0 {
+		middlewares = append(middlewares, func(next http.Handler) http.Handler {
+			return corsMiddleware(s.config.CORSOrigins, s.config.CORSMethods, s.config.CORSHeaders, next)
+		})
+	}
+	middlewares = append(middlewares, recoverMiddleware)
+	if s.config.GzipResponses {
+		middlewares = append(middlewares, gzipMiddleware)
+	}
+	if len(s.config.APITokens) > 0 {
+		var exempt []string
+		if s.config.AuthExemptHealth {
+			exempt = append(exempt, healthPaths...)
+		}
+		middlewares = append(middlewares, func(next http.Handler) http.Handler {
+			return authMiddleware(s.config.APITokens, s.config.AuthRequired, exempt, next)
+		})
+	}
+	if s.config.MultiTenant {
+		middlewares = append(middlewares, func(next http.Handler) http.Handler {
+			return tenantMiddleware(s.config.TenantTokens, append(healthPaths, "/metrics"), next)
+		})
+	}
+
+	return chain(mux, middlewares...)
+}
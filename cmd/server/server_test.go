@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"MinIO-Learn/internal/config"
+	"MinIO-Learn/internal/storage"
+	"MinIO-Learn/internal/storage/storagetest"
+)
+
+// testConfig loads the configuration from the environment, with env set on
+// top for the duration of the test.
+func testConfig(t *testing.T, env map[string]string) config.MinIOConfig {
+	t.Helper()
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+	cfg, err := config.LoadMinIOConfig()
+	if err != nil {
+		t.Fatalf("LoadMinIOConfig() error = %v", err)
+	}
+	return cfg
+}
+
+// fakeBackendEnv starts fake on a test server and returns the environment
+// that points the configuration at it, using the bucket "test-bucket".
+func fakeBackendEnv(t *testing.T, fake *storagetest.FakeS3) map[string]string {
+	t.Helper()
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return map[string]string{
+		"MINIO_ENDPOINT":        strings.TrimPrefix(server.URL, "http://"),
+		"MINIO_ACCESS_KEY":      "test-access-key",
+		"MINIO_SECRET_KEY":      "test-secret-key",
+		"MINIO_BUCKET":          "test-bucket",
+		"MINIO_USE_SSL":         "false",
+		"MINIO_CONNECT_TIMEOUT": "0s",
+	}
+}
+
+// newTestServer returns the routes of a Server backed by store, configured
+// by testConfig.
+func newTestServer(t *testing.T, store storage.Storage, env map[string]string) http.Handler {
+	t.Helper()
+	return NewServer(store, testConfig(t, env)).Handler()
+}
+
+// newFakeBackendServer returns the routes of a Server backed by a
+// MinIOService on fake, configured by testConfig with env on top of
+// fakeBackendEnv.
+func newFakeBackendServer(t *testing.T, fake *storagetest.FakeS3, env map[string]string) http.Handler {
+	t.Helper()
+	backendEnv := fakeBackendEnv(t, fake)
+	for key, value := range env {
+		backendEnv[key] = value
+	}
+	cfg := testConfig(t, backendEnv)
+	storageConfig, err := newStorageConfig(cfg)
+	if err != nil {
+		t.Fatalf("newStorageConfig() error = %v", err)
+	}
+	service, err := storage.NewMinIOService(storageConfig)
+	if err != nil {
+		t.Fatalf("NewMinIOService() error = %v", err)
+	}
+	if err := service.EnsureBucket(context.Background()); err != nil {
+		t.Fatalf("EnsureBucket() error = %v", err)
+	}
+	return NewServer(service, cfg).Handler()
+}
+
+// serve runs req through h and returns the recorded response.
+func serve(h http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// decodeData decodes the data field of the JSON response envelope in rec into
+// data.
+func decodeData(t *testing.T, rec *httptest.ResponseRecorder, data any) {
+	t.Helper()
+	resp := Response{Data: data}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decoding response %q: %v", rec.Body, err)
+	}
+}
+
+// putObject stores data under objectName in store, failing the test on error.
+func putObject(t *testing.T, store storage.Storage, objectName, contentType string, data []byte) {
+	t.Helper()
+	if _, err := store.UploadBuffer(context.Background(), objectName, data, contentType, nil); err != nil {
+		t.Fatalf("UploadBuffer(%q) error = %v", objectName, err)
+	}
+}
+
+// newRequest is httptest.NewRequest with a string body.
+func newRequest(method, target, body string) *http.Request {
+	return httptest.NewRequest(method, target, bytes.NewBufferString(body))
+}
+
+// newUploadRequest returns a POST /upload request carrying data as the
+// multipart file field, named fileName.
+func newUploadRequest(t *testing.T, fileName string, data []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("file", fileName)
+	if err != nil {
+		t.Fatalf("CreateFormFile() error = %v", err)
+	}
+	part.Write(data)
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+// onlyObject returns the key of the single object in store, failing the test
+// if there isn't exactly one.
+func onlyObject(t *testing.T, store storage.Storage) string {
+	t.Helper()
+	objects, err := store.ListObjects(context.Background(), "")
+	if err != nil {
+		t.Fatalf("ListObjects() error = %v", err)
+	}
+	if len(objects) != 1 {
+		t.Fatalf("got %d objects, want 1", len(objects))
+	}
+	return objects[0].Key
+}
+
+func TestIndependentServers(t *testing.T) {
+	cfg := testConfig(t, map[string]string{"MINIO_API_TOKEN": "", "MINIO_AUTH_REQUIRED": "false"})
+	securedCfg := cfg
+	securedCfg.APITokens = []string{"secret"}
+	securedCfg.AuthRequired = true
+
+	openStore := storage.NewMemoryStorage("test-bucket")
+	securedStore := storage.NewMemoryStorage("test-bucket")
+	open := NewServer(openStore, cfg).Handler()
+	secured := NewServer(securedStore, securedCfg).Handler()
+
+	if rec := serve(open, newUploadRequest(t, "open.txt", []byte("open"))); rec.Code != http.StatusOK {
+		t.Fatalf("upload to the open server status = %d: %s", rec.Code, rec.Body)
+	}
+	if rec := serve(secured, newUploadRequest(t, "secured.txt", []byte("secured"))); rec.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous upload to the secured server status = %d, want 401", rec.Code)
+	}
+	req := newUploadRequest(t, "secured.txt", []byte("secured"))
+	req.Header.Set("Authorization", "Bearer secret")
+	if rec := serve(secured, req); rec.Code != http.StatusOK {
+		t.Fatalf("upload to the secured server status = %d: %s", rec.Code, rec.Body)
+	}
+
+	// Each server sees only its own store.
+	for name, store := range map[string]storage.Storage{"open": openStore, "secured": securedStore} {
+		if key := onlyObject(t, store); !strings.HasSuffix(key, name+".txt") {
+			t.Errorf("%s store holds %q, want its own upload", name, key)
+		}
+	}
+
+	rec := serve(open, newRequest(http.MethodGet, "/files", ""))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("listing the open server status = %d: %s", rec.Code, rec.Body)
+	}
+	var page fileListPage
+	decodeData(t, rec, &page)
+	if len(page.Files) != 1 || !strings.HasSuffix(page.Files[0].FileName, "open.txt") {
+		t.Errorf("open server listed %+v, want only open.txt", page.Files)
+	}
+}
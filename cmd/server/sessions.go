@@ -0,0 +1,305 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"MinIO-Learn/internal/storage"
+)
+
+type createSessionRequest struct {
+	FileName    string `json:"fileName"`
+	ContentType string `json:"contentType,omitempty"`
+	SHA256      string `json:"sha256,omitempty"`
+}
+
+// uploadSessionsHandler serves resumable uploads:
+//
+//	POST   /upload/sessions                  {"fileName", "contentType", "sha256"} starts a session
+//	GET    /upload/sessions/{id}             returns it with the parts uploaded so far
+//	PUT    /upload/sessions/{id}/parts/{n}   uploads the body as part n
+//	POST   /upload/sessions/{id}/complete    assembles the parts into the file
+//	DELETE /upload/sessions/{id}             abandons it
+//
+// Sessions are kept in the bucket, so a client that loses its connection can
+// ask which parts arrived and send only the rest, to any server instance.
+// Only the tenant and bearer token that started a session can use it.
+// Sessions older than MINIO_UPLOAD_SESSION_TTL are aborted by
+// runSessionSweeper.
+func (s *Server) uploadSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/upload/sessions"), "/")
+	if rest == "" {
+		if r.Method != http.MethodPost {
+			sendResponse(w, false, "Method not allowed", nil, http.StatusMethodNotAllowed)
+			return
+		}
+		s.createSessionHandler(w, r)
+		return
+	}
+
+	sessionID, sub, _ := strings.Cut(rest, "/")
+	session, err := s.storage.GetUploadSession(r.Context(), sessionID)
+	// Someone else's session is reported as missing, not forbidden, so its
+	// ID can't be confirmed.
+	if errors.Is(err, storage.ErrUnknownSession) ||
+		err == nil && subtle.ConstantTimeCompare([]byte(session.Owner), []byte(sessionOwner(r))) != 1 {
+		sendResponse(w, false, "Upload session not found", nil, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendResponse(w, false, "Error reading upload session: "+err.Error(), nil, storageErrorStatus(err))
+		return
+	}
+	if !checkTenant(w, r, session.ObjectName) {
+		return
+	}
+	session.Owner = ""
+
+	switch {
+	case sub == "" && r.Method == http.MethodGet:
+		sendResponse(w, true, fmt.Sprintf("%d parts uploaded", len(session.Parts)), session, http.StatusOK)
+	case sub == "" && r.Method == http.MethodDelete:
+		s.abortSessionHandler(w, r, session)
+	case sub == "complete" && r.Method == http.MethodPost:
+		s.completeSessionHandler(w, r, session)
+	case strings.HasPrefix(sub, "parts/") && r.Method == http.MethodPut:
+		s.uploadPartHandler(w, r, session, strings.TrimPrefix(sub, "parts/"))
+	case sub == "" || sub == "complete" || strings.HasPrefix(sub, "parts/"):
+		sendResponse(w, false, "Method not allowed", nil, http.StatusMethodNotAllowed)
+	default:
+		sendResponse(w, false, "Not found", nil, http.StatusNotFound)
+	}
+}
+
+func (s *Server) createSessionHandler(w http.ResponseWriter, r *http.Request) {
+	var req createSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendResponse(w, false, "Invalid request body: "+err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	fileName := filepath.Base(req.FileName)
+	if req.FileName == "" || fileName == "." || fileName == "/" {
+		sendResponse(w, false, "fileName is required", nil, http.StatusBadRequest)
+		return
+	}
+
+	// The assembled object can't be sniffed or rewritten without copying it,
+	// so its type and checksum are fixed when the session starts.
+	contentType := req.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(fileName))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	metadata := storage.OriginalFilenameMetadata(fileName)
+	if req.SHA256 != "" {
+		checksum := strings.ToLower(req.SHA256)
+		if _, err := hex.DecodeString(checksum); err != nil || len(checksum) != sha256.Size*2 {
+			sendResponse(w, false, "sha256 must be a hex SHA-256", nil, http.StatusBadRequest)
+			return
+		}
+		maps.Copy(metadata, storage.ChecksumMetadata(checksum))
+	}
+
+	objectName := s.objectKey(r, fileName)
+	session, err := s.storage.InitUploadSession(r.Context(), objectName, contentType, sessionOwner(r), metadata)
+	if err != nil {
+		sendResponse(w, false, "Error starting upload session: "+err.Error(), nil, storageErrorStatus(err))
+		return
+	}
+	session.Owner = ""
+
+	sendResponse(w, true, "Upload session started", session, http.StatusCreated)
+}
+
+// sessionOwner identifies who may use the upload sessions r starts: its
+// tenant and bearer token. Only a hash is stored with the session.
+func sessionOwner(r *http.Request) string {
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	sum := sha256.Sum256([]byte(tenantPrefix(r) + "\x00" + token))
+	return hex.EncodeToString(sum[:])
+}
+
+// uploadPartHandler stores the request body as a part of session. Parts are
+// sent with a Content-Length and, except for the last, must be at least
+// 5 MiB. MINIO_MAX_UPLOAD_SIZE applies to the session's parts together,
+// counting a part being replaced only once.
+func (s *Server) uploadPartHandler(w http.ResponseWriter, r *http.Request, session storage.UploadSession, number string) {
+	partNumber, err := strconv.Atoi(number)
+	if err != nil || partNumber < 1 || partNumber > storage.MaxUploadParts {
+		sendResponse(w, false, fmt.Sprintf("Part number must be between 1 and %d", storage.MaxUploadParts), nil, http.StatusBadRequest)
+		return
+	}
+	if r.ContentLength < 0 {
+		sendResponse(w, false, "Content-Length is required", nil, http.StatusLengthRequired)
+		return
+	}
+
+	if !s.limitUploadBody(w, r) {
+		return
+	}
+	if limit := s.config.MaxUploadSize; limit > 0 && sessionSize(session, partNumber)+r.ContentLength > limit {
+		s.sendUploadTooLarge(w)
+		return
+	}
+	s.guardUploadBody(w, r)
+	part, err := s.storage.UploadPart(r.Context(), session.ID, partNumber, r.Body, r.ContentLength)
+	if errors.Is(err, errSlowUpload) {
+		sendResponse(w, false, "Upload aborted: "+err.Error(), nil, http.StatusRequestTimeout)
+		return
+	}
+	if bodyTooLarge(err) {
+		s.sendUploadTooLarge(w)
+		return
+	}
+	if errors.Is(err, storage.ErrUnknownSession) {
+		sendResponse(w, false, "Upload session not found", nil, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendResponse(w, false, "Error uploading part: "+err.Error(), nil, storageErrorStatus(err))
+		return
+	}
+
+	sendResponse(w, true, fmt.Sprintf("Part %d uploaded", part.PartNumber), part, http.StatusOK)
+}
+
+// sessionSize returns the total size of session's parts, leaving out part
+// number except, the part about to be replaced, or 0 for none.
+func sessionSize(session storage.UploadSession, except int) int64 {
+	var size int64
+	for _, part := range session.Parts {
+		if part.PartNumber != except {
+			size += part.Size
+		}
+	}
+	return size
+}
+
+// completeSessionHandler assembles the session's parts and then treats the
+// result like any other upload: its checksum is indexed and the post-upload
+// processors run. Parts uploaded in order are hashed as they arrive; the
+// upload is only read back, once, when MINIO_VALIDATE_CONTENT applies to its
+// type or a declared checksum couldn't be checked that way. An upload
+// failing either check is removed.
+func (s *Server) completeSessionHandler(w http.ResponseWriter, r *http.Request, session storage.UploadSession) {
+	if len(session.Parts) == 0 {
+		sendResponse(w, false, storage.ErrNoParts.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	// Parts uploaded concurrently can each pass uploadPartHandler's check.
+	size := sessionSize(session, 0)
+	if limit := s.config.MaxUploadSize; limit > 0 && size > limit {
+		s.sendUploadTooLarge(w)
+		return
+	}
+
+	uploadInfo, checksum, err := s.storage.CompleteUpload(r.Context(), session.ID)
+	if errors.Is(err, storage.ErrUnknownSession) {
+		sendResponse(w, false, "Upload session not found", nil, http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, storage.ErrChecksumMismatch) {
+		sendResponse(w, false, "Upload does not match its sha256: "+err.Error(), nil, http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		// S3 rejects parts under the minimum size only at this point.
+		sendResponse(w, false, "Error completing upload: "+err.Error(), nil, storageErrorStatus(err))
+		return
+	}
+
+	validate := s.config.ValidateContent && contentValidatorFor(session.ContentType) != nil
+	if validate || (checksum == "" && session.Checksum != "") {
+		checksum, err = s.inspectUpload(r, session.ObjectName, session.ContentType, uploadInfo.ETag, validate)
+		if err == nil && session.Checksum != "" && checksum != session.Checksum {
+			err = &uploadError{http.StatusUnprocessableEntity, fmt.Sprintf("Upload does not match its sha256: declared %s, got %s", session.Checksum, checksum)}
+		}
+		if err != nil {
+			if delErr := s.storage.DeleteObjectVersion(r.Context(), session.ObjectName, ""); delErr != nil {
+				slog.WarnContext(r.Context(), "Failed to remove rejected upload", "object", session.ObjectName, "error", delErr)
+			}
+			sendUploadError(w, err)
+			return
+		}
+	}
+
+	if checksum != "" {
+		s.indexContentHash(r, checksum, session.ObjectName)
+	}
+
+	fileInfo := FileInfo{
+		FileName:    filepath.Base(session.ObjectName),
+		Size:        size,
+		ContentType: session.ContentType,
+		URL:         s.objectURL(r, session.ObjectName, s.config.PresignExpiry),
+		SHA256:      checksum,
+		UploadedAt:  time.Now(),
+	}
+
+	if !s.runPostUpload(w, r, fileInfo, session.ObjectName) {
+		return
+	}
+
+	sendResponse(w, true, "File uploaded successfully", fileInfo, http.StatusOK)
+}
+
+// inspectUpload reads back an assembled upload, the version with etag, to
+// compute its SHA-256 and, if validate is set, check its content against the
+// validator for contentType. Invalid content is reported as an uploadError
+// with status 422.
+func (s *Server) inspectUpload(r *http.Request, objectName, contentType, etag string, validate bool) (checksum string, err error) {
+	var opts storage.ReadOptions
+	if err := opts.SetMatchETag(etag); err != nil {
+		return "", &uploadError{http.StatusInternalServerError, "Error reading upload: " + err.Error()}
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := s.storage.DownloadToWriterWithOptions(r.Context(), objectName, opts, pw)
+		pw.CloseWithError(err)
+	}()
+	defer pr.Close()
+
+	hasher := sha256.New()
+	body := io.TeeReader(pr, hasher)
+	if validate {
+		if err := contentValidatorFor(contentType)(body); err != nil {
+			return "", &uploadError{http.StatusUnprocessableEntity, "Invalid file content: " + err.Error()}
+		}
+	}
+	// Hash whatever the validator didn't read.
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		return "", &uploadError{http.StatusInternalServerError, "Error reading upload: " + err.Error()}
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+func (s *Server) abortSessionHandler(w http.ResponseWriter, r *http.Request, session storage.UploadSession) {
+	err := s.storage.AbortUpload(r.Context(), session.ID)
+	if errors.Is(err, storage.ErrUnknownSession) {
+		sendResponse(w, false, "Upload session not found", nil, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendResponse(w, false, "Error aborting upload: "+err.Error(), nil, storageErrorStatus(err))
+		return
+	}
+
+	sendResponse(w, true, "Upload session aborted", nil, http.StatusOK)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"MinIO-Learn/internal/storage"
+)
+
+// interruptedReader yields data and then fails, like a request body whose
+// connection drops partway.
+type interruptedReader struct {
+	data *bytes.Reader
+}
+
+func (r *interruptedReader) Read(p []byte) (int, error) {
+	n, _ := r.data.Read(p)
+	if n == 0 {
+		return 0, errors.New("connection reset")
+	}
+	return n, nil
+}
+
+// putPart uploads data as part number of session through h.
+func putPart(h http.Handler, sessionID string, number int, body io.Reader, size int64) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/upload/sessions/%s/parts/%d", sessionID, number), body)
+	req.ContentLength = size
+	return serve(h, req)
+}
+
+func TestUploadSessionResume(t *testing.T) {
+	parts := [][]byte{
+		bytes.Repeat([]byte("a"), 6<<20),
+		bytes.Repeat([]byte("b"), 6<<20),
+		[]byte("tail"),
+	}
+	content := bytes.Join(parts, nil)
+	sum := sha256.Sum256(content)
+	checksum := hex.EncodeToString(sum[:])
+	otherSum := sha256.Sum256([]byte("something else"))
+
+	tests := []struct {
+		name     string
+		declared string
+		want     int
+	}{
+		{"no declared checksum", "", http.StatusOK},
+		{"declared checksum", checksum, http.StatusOK},
+		{"wrong declared checksum", hex.EncodeToString(otherSum[:]), http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStorage("test-bucket")
+			h := newTestServer(t, store, nil)
+
+			rec := serve(h, newRequest(http.MethodPost, "/upload/sessions", fmt.Sprintf(`{"fileName":"big.bin","sha256":%q}`, tt.declared)))
+			if rec.Code != http.StatusCreated {
+				t.Fatalf("create status = %d: %s", rec.Code, rec.Body)
+			}
+			var session storage.UploadSession
+			decodeData(t, rec, &session)
+
+			if rec := putPart(h, session.ID, 1, bytes.NewReader(parts[0]), int64(len(parts[0]))); rec.Code != http.StatusOK {
+				t.Fatalf("part 1 status = %d: %s", rec.Code, rec.Body)
+			}
+			// The connection drops halfway through part 2.
+			half := &interruptedReader{data: bytes.NewReader(parts[1][:len(parts[1])/2])}
+			if rec := putPart(h, session.ID, 2, half, int64(len(parts[1]))); rec.Code == http.StatusOK {
+				t.Fatalf("interrupted part 2 status = 200, want an error")
+			}
+
+			// The client reconnects and asks what arrived.
+			rec = serve(h, newRequest(http.MethodGet, "/upload/sessions/"+session.ID, ""))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("get status = %d: %s", rec.Code, rec.Body)
+			}
+			var resumed storage.UploadSession
+			decodeData(t, rec, &resumed)
+			if len(resumed.Parts) != 1 || resumed.Parts[0].PartNumber != 1 || resumed.Parts[0].Size != int64(len(parts[0])) {
+				t.Fatalf("parts after interruption = %+v, want only part 1", resumed.Parts)
+			}
+
+			for number := 2; number <= len(parts); number++ {
+				data := parts[number-1]
+				if rec := putPart(h, session.ID, number, bytes.NewReader(data), int64(len(data))); rec.Code != http.StatusOK {
+					t.Fatalf("part %d status = %d: %s", number, rec.Code, rec.Body)
+				}
+			}
+
+			rec = serve(h, newRequest(http.MethodPost, "/upload/sessions/"+session.ID+"/complete", ""))
+			if rec.Code != tt.want {
+				t.Fatalf("complete status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want != http.StatusOK {
+				if exists, _ := store.CheckObjectExists(context.Background(), session.ObjectName); exists {
+					t.Errorf("%s exists after a checksum mismatch", session.ObjectName)
+				}
+				return
+			}
+
+			var info FileInfo
+			decodeData(t, rec, &info)
+			if info.SHA256 != checksum || info.Size != int64(len(content)) {
+				t.Errorf("completed upload = %d bytes with sha256 %q, want %d with %q", info.Size, info.SHA256, len(content), checksum)
+			}
+			stored, err := store.DownloadBuffer(context.Background(), session.ObjectName)
+			if err != nil {
+				t.Fatalf("DownloadBuffer() error = %v", err)
+			}
+			if !bytes.Equal(stored, content) {
+				t.Errorf("stored %d bytes, want the %d uploaded", len(stored), len(content))
+			}
+			if rec := serve(h, newRequest(http.MethodGet, "/upload/sessions/"+session.ID, "")); rec.Code != http.StatusNotFound {
+				t.Errorf("get after complete status = %d, want 404", rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// abortGrace is how long aborted requests get to unwind after the shutdown
+// timeout has passed.
+const abortGrace = 5 * time.Second
+
+// runServer serves until ctx is cancelled, then shuts down gracefully: it
+// stops accepting connections and waits up to timeout for in-flight requests
+// and for waitBackground, which blocks until background post-upload
+// processing is done. Requests still running after that have their contexts
+// cancelled, which aborts their uploads; once each handler returns net/http
+// removes the multipart temp files it created.
+func runServer(ctx context.Context, server *http.Server, timeout time.Duration, waitBackground func()) error {
+	requestCtx, abortRequests := context.WithCancel(context.Background())
+	defer abortRequests()
+	server.BaseContext = func(net.Listener) context.Context { return requestCtx }
+
+	var inflight sync.WaitGroup
+	next := server.Handler
+	server.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inflight.Add(1)
+		defer inflight.Done()
+		next.ServeHTTP(w, r)
+	})
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutdown signal received, draining in-flight requests", "timeout", timeout)
+	deadline := time.Now().Add(timeout)
+	shutdownCtx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("Requests still in flight at shutdown timeout, aborting them", "error", err)
+		abortRequests()
+		server.Close()
+		if !waitTimeout(inflight.Wait, abortGrace) {
+			slog.Warn("Aborted requests did not finish in time")
+		}
+	} else {
+		slog.Info("All requests drained")
+	}
+	if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	slog.Info("Waiting for background post-upload processing")
+	if !waitTimeout(waitBackground, max(time.Until(deadline), abortGrace)) {
+		slog.Warn("Background post-upload processing did not finish in time")
+	}
+
+	slog.Info("Server stopped")
+	return nil
+}
+
+// waitTimeout runs wait and reports whether it returned within timeout.
+func waitTimeout(wait func(), timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
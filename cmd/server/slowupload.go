@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+var errSlowUpload = errors.New("upload transfer rate too low")
+
+// throughputReader aborts a request body that trickles in below minRate
+// bytes per second, measured over windows of grace. Each read also gets a
+// connection deadline of grace, so a client that stops sending entirely is
+// cut off too instead of holding the connection open.
+type throughputReader struct {
+	body    io.ReadCloser
+	rc      *http.ResponseController
+	minRate int64
+	grace   time.Duration
+
+	windowStart time.Time
+	windowBytes int64
+}
+
+func newThroughputReader(w http.ResponseWriter, body io.ReadCloser, minRate int64, grace time.Duration) *throughputReader {
+	return &throughputReader{
+		body:        body,
+		rc:          http.NewResponseController(w),
+		minRate:     minRate,
+		grace:       grace,
+		windowStart: time.Now(),
+	}
+}
+
+func (t *throughputReader) Read(p []byte) (int, error) {
+	t.rc.SetReadDeadline(time.Now().Add(t.grace))
+
+	n, err := t.body.Read(p)
+	t.windowBytes += int64(n)
+
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return n, errSlowUpload
+	}
+	if err == io.EOF {
+		t.rc.SetReadDeadline(time.Time{})
+		return n, err
+	}
+
+	if elapsed := time.Since(t.windowStart); elapsed >= t.grace {
+		if float64(t.windowBytes)/elapsed.Seconds() < float64(t.minRate) {
+			return n, errSlowUpload
+		}
+		t.windowStart = time.Now()
+		t.windowBytes = 0
+	}
+
+	return n, err
+}
+
+func (t *throughputReader) Close() error {
+	return t.body.Close()
+}
+
+// guardUploadBody wraps the request body with the configured throughput
+// guard. It is a no-op when MINIO_UPLOAD_MIN_RATE is unset.
+func (s *Server) guardUploadBody(w http.ResponseWriter, r *http.Request) {
+	if s.config.UploadMinRate <= 0 {
+		return
+	}
+	r.Body = newThroughputReader(w, r.Body, s.config.UploadMinRate, s.config.UploadRateGrace)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"MinIO-Learn/internal/storage"
+)
+
+// tricklingReader returns size bytes, chunk at a time, sleeping delay before
+// each read.
+type tricklingReader struct {
+	remaining int
+	chunk     int
+	delay     time.Duration
+}
+
+func (r *tricklingReader) Read(p []byte) (int, error) {
+	if r.remaining == 0 {
+		return 0, io.EOF
+	}
+	time.Sleep(r.delay)
+	n := min(len(p), r.chunk, r.remaining)
+	for i := range p[:n] {
+		p[i] = 'x'
+	}
+	r.remaining -= n
+	return n, nil
+}
+
+func TestThroughputReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    io.Reader
+		wantErr error
+	}{
+		{"fast client", bytes.NewReader(make([]byte, 1<<20)), nil},
+		{"slow client", &tricklingReader{remaining: 1000, chunk: 1, delay: 5 * time.Millisecond}, errSlowUpload},
+		{"steady client above the rate", &tricklingReader{remaining: 100 << 10, chunk: 4 << 10, delay: time.Millisecond}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := newThroughputReader(httptest.NewRecorder(), io.NopCloser(tt.body), 1000, 30*time.Millisecond)
+			_, err := io.Copy(io.Discard, reader)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("reading error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSlowUploadAborted(t *testing.T) {
+	tests := []struct {
+		name       string
+		minRate    string
+		body       func() io.Reader
+		wantStatus int
+	}{
+		{
+			name:       "slow client aborted",
+			minRate:    "1000",
+			body:       func() io.Reader { return &tricklingReader{remaining: 1000, chunk: 1, delay: 5 * time.Millisecond} },
+			wantStatus: http.StatusRequestTimeout,
+		},
+		{
+			name:       "guard disabled",
+			minRate:    "0",
+			body:       func() io.Reader { return &tricklingReader{remaining: 20, chunk: 1, delay: 5 * time.Millisecond} },
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "fast client",
+			minRate:    "1000",
+			body:       func() io.Reader { return strings.NewReader("hello") },
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestServer(t, storage.NewMemoryStorage("test-bucket"), map[string]string{
+				"MINIO_UPLOAD_MIN_RATE":   tt.minRate,
+				"MINIO_UPLOAD_RATE_GRACE": "30ms",
+			})
+
+			req := httptest.NewRequest(http.MethodPut, "/upload?filename=slow.txt", tt.body())
+			rec := serve(h, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+		})
+	}
+}
+
+func TestStalledUploadAborted(t *testing.T) {
+	h := newTestServer(t, storage.NewMemoryStorage("test-bucket"), map[string]string{
+		"MINIO_UPLOAD_MIN_RATE":   "1000",
+		"MINIO_UPLOAD_RATE_GRACE": "50ms",
+	})
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	// Promise 100 bytes, send 10 and stop.
+	fmt.Fprintf(conn, "PUT /upload?filename=stalled.txt HTTP/1.1\r\nHost: test\r\nContent-Length: 100\r\n\r\n0123456789")
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf("reading response: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestTimeout {
+		t.Errorf("status = %d, want 408", resp.StatusCode)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// sniffLen is how much of an upload http.DetectContentType looks at.
+const sniffLen = 512
+
+// detectContentType returns the client's declared content type if it is
+// specific, and otherwise the type sniffed from head, the first bytes of the
+// upload.
+func detectContentType(declared string, head []byte) string {
+	mediaType, _, err := mime.ParseMediaType(declared)
+	if err == nil && mediaType != "application/octet-stream" {
+		return declared
+	}
+	return http.DetectContentType(head)
+}
+
+// sniffSeeker is detectContentType for a seekable upload, which is rewound
+// after its head has been read.
+func sniffSeeker(declared string, file io.ReadSeeker) (string, error) {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return detectContentType(declared, head[:n]), nil
+}
+
+// sniffStream is detectContentType for a stream. The returned reader yields
+// the whole stream, including the bytes that were peeked at.
+func sniffStream(declared string, body io.Reader) (string, io.Reader, error) {
+	buffered := bufio.NewReaderSize(body, sniffLen)
+	head, err := buffered.Peek(sniffLen)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return "", nil, err
+	}
+	return detectContentType(declared, head), buffered, nil
+}
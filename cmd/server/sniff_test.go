@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"MinIO-Learn/internal/storage"
+)
+
+func TestDetectContentType(t *testing.T) {
+	pngData := testPNG(t)
+	pdfData := []byte("%PDF-1.7\n1 0 obj\n<< /Type /Catalog >>\nendobj\n")
+	textData := []byte("Meeting notes\n- ship it\n")
+
+	tests := []struct {
+		name     string
+		declared string
+		data     []byte
+		want     string
+	}{
+		{"PNG undeclared", "", pngData, "image/png"},
+		{"PNG as octet-stream", "application/octet-stream", pngData, "image/png"},
+		{"PDF undeclared", "", pdfData, "application/pdf"},
+		{"PDF as octet-stream", "application/octet-stream", pdfData, "application/pdf"},
+		{"text undeclared", "", textData, "text/plain; charset=utf-8"},
+		{"text as octet-stream", "application/octet-stream", textData, "text/plain; charset=utf-8"},
+		{"specific type kept", "text/markdown", textData, "text/markdown"},
+		{"specific type kept over sniffed", "image/x-custom", pngData, "image/x-custom"},
+		{"malformed declared type", "not a type", pdfData, "application/pdf"},
+		{"empty", "", nil, "text/plain; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectContentType(tt.declared, tt.data); got != tt.want {
+				t.Errorf("detectContentType() = %q, want %q", got, tt.want)
+			}
+
+			got, err := sniffSeeker(tt.declared, bytes.NewReader(tt.data))
+			if err != nil || got != tt.want {
+				t.Errorf("sniffSeeker() = %q, %v, want %q", got, err, tt.want)
+			}
+
+			got, body, err := sniffStream(tt.declared, io.MultiReader(bytes.NewReader(tt.data)))
+			if err != nil || got != tt.want {
+				t.Errorf("sniffStream() = %q, %v, want %q", got, err, tt.want)
+			}
+			if rest, _ := io.ReadAll(body); !bytes.Equal(rest, tt.data) {
+				t.Errorf("sniffStream() body = %d bytes, want all %d", len(rest), len(tt.data))
+			}
+		})
+	}
+}
+
+func TestUploadDetectsContentType(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		data     []byte
+		declared string
+		want     string
+	}{
+		{"PNG", "image.bin", testPNG(t), "application/octet-stream", "image/png"},
+		{"PDF", "doc.bin", []byte("%PDF-1.4\n%âãÏÓ\n"), "application/octet-stream", "application/pdf"},
+		{"text", "notes", []byte("plain words"), "", "text/plain; charset=utf-8"},
+		{"declared", "notes.md", []byte("# Title"), "text/markdown", "text/markdown"},
+	}
+
+	for _, tt := range tests {
+		for _, mode := range []string{"multipart", "raw"} {
+			t.Run(tt.name+"/"+mode, func(t *testing.T) {
+				store := storage.NewMemoryStorage("test-bucket")
+				h := newTestServer(t, store, nil)
+
+				req := newTypedUploadRequest(t, tt.fileName, tt.declared, tt.data)
+				if mode == "raw" {
+					req = httptest.NewRequest(http.MethodPut, "/upload?filename="+tt.fileName, bytes.NewReader(tt.data))
+					if tt.declared != "" {
+						req.Header.Set("Content-Type", tt.declared)
+					}
+				}
+				if rec := serve(h, req); rec.Code != http.StatusOK {
+					t.Fatalf("upload status = %d: %s", rec.Code, rec.Body)
+				}
+
+				info, err := store.GetObjectInfo(context.Background(), onlyObject(t, store))
+				if err != nil {
+					t.Fatalf("GetObjectInfo() error = %v", err)
+				}
+				if info.ContentType != tt.want {
+					t.Errorf("stored content type = %q, want %q", info.ContentType, tt.want)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// flushingWriter counts what has been written since the last flush and
+// flushes the response once either threshold is crossed, so clients of a long
+// listing see data arrive incrementally instead of all at the end.
+type flushingWriter struct {
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	objects int
+	bytes   int
+
+	maxObjects int
+	maxBytes   int
+}
+
+func (f *flushingWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	f.bytes += n
+	return n, err
+}
+
+// objectWritten records one complete record and flushes if a threshold has
+// been reached.
+func (f *flushingWriter) objectWritten() {
+	f.objects++
+	if (f.maxObjects > 0 && f.objects >= f.maxObjects) || (f.maxBytes > 0 && f.bytes >= f.maxBytes) {
+		f.flush()
+	}
+}
+
+func (f *flushingWriter) flush() {
+	if err := f.rc.Flush(); err != nil && err != http.ErrNotSupported {
+		slog.Error("Error flushing listing stream", "error", err)
+	}
+	f.objects = 0
+	f.bytes = 0
+}
+
+// streamFilesHandler streams the listing under ?prefix= as newline-delimited
+// JSON, one FileInfo per line. A listing error after the stream has started
+// is reported as a final {"error": ...} line.
+func (s *Server) streamFilesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendResponse(w, false, "Method not allowed", nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	prefix := r.URL.Query().Get("prefix")
+	if prefix == "" {
+		prefix = "uploads/"
+	}
+	prefix = tenantPrefix(r) + prefix
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	fw := &flushingWriter{
+		w:          w,
+		rc:         http.NewResponseController(w),
+		maxObjects: s.config.StreamFlushObjects,
+		maxBytes:   s.config.StreamFlushBytes,
+	}
+	encoder := json.NewEncoder(fw)
+
+	err := s.storage.ForEachObject(r.Context(), prefix, func(obj minio.ObjectInfo) error {
+		err := encoder.Encode(FileInfo{
+			FileName:    filepath.Base(obj.Key),
+			Size:        obj.Size,
+			ContentType: obj.ContentType,
+			UploadedAt:  obj.LastModified,
+		})
+		if err != nil {
+			return err
+		}
+		fw.objectWritten()
+		return nil
+	})
+	if err != nil && r.Context().Err() == nil {
+		encoder.Encode(map[string]string{"error": err.Error()})
+	}
+	fw.flush()
+}
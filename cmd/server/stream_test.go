@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"MinIO-Learn/internal/storage"
+	"github.com/minio/minio-go/v7"
+)
+
+// gatedStorage holds ForEachObject after its first gateAfter objects until
+// release is closed, so a test can look at what reached the client mid-listing.
+type gatedStorage struct {
+	storage.Storage
+	gateAfter int
+	release   chan struct{}
+}
+
+func (g *gatedStorage) ForEachObject(ctx context.Context, prefix string, fn func(minio.ObjectInfo) error) error {
+	n := 0
+	return g.Storage.ForEachObject(ctx, prefix, func(obj minio.ObjectInfo) error {
+		if err := fn(obj); err != nil {
+			return err
+		}
+		if n++; n == g.gateAfter {
+			select {
+			case <-g.release:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	})
+}
+
+func TestStreamFilesFlushes(t *testing.T) {
+	const total = 10
+
+	tests := []struct {
+		name         string
+		flushObjects string
+		flushBytes   string
+		gateAfter    int
+	}{
+		{"after N objects", "3", "0", 3},
+		{"after M bytes", "0", "1", 1},
+		{"first threshold reached", "4", "1000000", 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memory := storage.NewMemoryStorage("test-bucket")
+			for i := range total {
+				putObject(t, memory, fmt.Sprintf("uploads/file-%02d.txt", i), "text/plain", []byte("data"))
+			}
+			store := &gatedStorage{Storage: memory, gateAfter: tt.gateAfter, release: make(chan struct{})}
+			server := httptest.NewServer(newTestServer(t, store, map[string]string{
+				"MINIO_STREAM_FLUSH_OBJECTS": tt.flushObjects,
+				"MINIO_STREAM_FLUSH_BYTES":   tt.flushBytes,
+			}))
+			t.Cleanup(server.Close)
+			released := false
+			release := func() {
+				if !released {
+					close(store.release)
+					released = true
+				}
+			}
+			defer release()
+
+			resp, err := http.Get(server.URL + "/files/stream")
+			if err != nil {
+				t.Fatalf("GET /files/stream: %v", err)
+			}
+			defer resp.Body.Close()
+			if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+				t.Errorf("Content-Type = %q, want application/x-ndjson", ct)
+			}
+
+			lines := bufio.NewReader(resp.Body)
+			partial := make(chan error, 1)
+			go func() {
+				for range tt.gateAfter {
+					line, err := lines.ReadBytes('\n')
+					if err != nil {
+						partial <- err
+						return
+					}
+					var info FileInfo
+					if err := json.Unmarshal(line, &info); err != nil {
+						partial <- err
+						return
+					}
+				}
+				partial <- nil
+			}()
+			select {
+			case err := <-partial:
+				if err != nil {
+					t.Fatalf("reading the first %d records: %v", tt.gateAfter, err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("first %d records not flushed before the listing completed", tt.gateAfter)
+			}
+
+			release()
+			rest, err := io.ReadAll(lines)
+			if err != nil {
+				t.Fatalf("reading the rest of the listing: %v", err)
+			}
+			remaining := 0
+			for _, b := range rest {
+				if b == '\n' {
+					remaining++
+				}
+			}
+			if got := tt.gateAfter + remaining; got != total {
+				t.Errorf("streamed %d records, want %d", got, total)
+			}
+		})
+	}
+}
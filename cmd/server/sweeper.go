@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// runRetentionSweeper periodically deletes objects whose delete-after tag has
+// passed, until ctx is cancelled.
+func (s *Server) runRetentionSweeper(ctx context.Context, interval time.Duration, prefix string, dryRun bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		result, err := s.storage.SweepExpired(ctx, prefix, time.Now(), dryRun)
+		if err != nil {
+			slog.ErrorContext(ctx, "Retention sweep failed", "error", err)
+			continue
+		}
+
+		if dryRun {
+			slog.InfoContext(ctx, "Retention sweep (dry run)", "scanned", result.Scanned, "expired", len(result.Expired))
+			continue
+		}
+		slog.InfoContext(ctx, "Retention sweep", "scanned", result.Scanned, "deleted", result.Deleted, "failed", len(result.Failed))
+		for key, err := range result.Failed {
+			slog.WarnContext(ctx, "Retention sweep failed to delete object", "object", key, "error", err)
+		}
+	}
+}
+
+// runSessionSweeper periodically aborts upload sessions started more than
+// ttl ago, so abandoned uploads don't keep their parts forever, until ctx is
+// cancelled.
+func (s *Server) runSessionSweeper(ctx context.Context, interval, ttl time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		expired, err := s.storage.ExpireUploadSessions(ctx, ttl)
+		if err != nil {
+			slog.ErrorContext(ctx, "Upload session sweep failed", "expired", expired, "error", err)
+			continue
+		}
+		if expired > 0 {
+			slog.InfoContext(ctx, "Upload session sweep", "expired", expired)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"MinIO-Learn/internal/storage"
+)
+
+func TestRetentionSweeper(t *testing.T) {
+	tests := []struct {
+		name       string
+		dryRun     bool
+		wantPast   bool
+		wantFuture bool
+	}{
+		{"deletes past objects", false, false, true},
+		{"dry run deletes nothing", true, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStorage("test-bucket")
+			ctx := context.Background()
+			deleteAfter := map[string]time.Time{
+				"past.txt":   time.Now().Add(-time.Minute),
+				"future.txt": time.Now().Add(time.Hour),
+			}
+			for key, at := range deleteAfter {
+				putObject(t, store, key, "text/plain", []byte(key))
+				if err := store.SetObjectTags(ctx, key, map[string]string{storage.DeleteAfterTag: at.Format(time.RFC3339)}); err != nil {
+					t.Fatalf("SetObjectTags() error = %v", err)
+				}
+			}
+
+			srv := NewServer(store, testConfig(t, nil))
+			sweepCtx, cancel := context.WithCancel(ctx)
+			done := make(chan struct{})
+			go func() {
+				srv.runRetentionSweeper(sweepCtx, 5*time.Millisecond, "", tt.dryRun)
+				close(done)
+			}()
+			time.Sleep(50 * time.Millisecond)
+			cancel()
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("sweeper did not stop when its context was cancelled")
+			}
+
+			for key, want := range map[string]bool{"past.txt": tt.wantPast, "future.txt": tt.wantFuture} {
+				exists, err := store.CheckObjectExists(ctx, key)
+				if err != nil {
+					t.Fatalf("CheckObjectExists() error = %v", err)
+				}
+				if exists != want {
+					t.Errorf("%s exists = %v, want %v", key, exists, want)
+				}
+			}
+		})
+	}
+}
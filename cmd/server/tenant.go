@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"slices"
+	"strings"
+
+	"MinIO-Learn/internal/config"
+	"MinIO-Learn/internal/storage"
+)
+
+const tenantHeader = "X-Tenant-ID"
+
+type tenantKey struct{}
+
+// tenantMiddleware attaches the tenant of each request to its context. A
+// token bound to a tenant in tokenTenants decides it, and an X-Tenant-ID
+// header naming another tenant is refused; otherwise the header is trusted,
+// so without bound tokens it must be set by a proxy that authenticates
+// clients. Requests without a tenant get 400 unless their path is in exempt.
+// Admin endpoints act on the whole bucket and are refused to tenants.
+func tenantMiddleware(tokenTenants map[string]string, exempt []string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant := r.Header.Get(tenantHeader)
+		if bound := tokenTenant(r, tokenTenants); bound != "" {
+			if tenant != "" && tenant != bound {
+				sendResponse(w, false, "X-Tenant-ID does not match the token's tenant", nil, http.StatusForbidden)
+				return
+			}
+			tenant = bound
+		}
+
+		isAdmin := strings.HasPrefix(r.URL.Path, "/admin/")
+		switch {
+		case tenant == "" && (isAdmin || slices.Contains(exempt, r.URL.Path)):
+			next.ServeHTTP(w, r)
+			return
+		case tenant == "":
+			sendResponse(w, false, "X-Tenant-ID header is required", nil, http.StatusBadRequest)
+			return
+		case isAdmin:
+			sendResponse(w, false, "Admin endpoints are not available to tenants", nil, http.StatusForbidden)
+			return
+		}
+		if err := config.ValidateTenantID(tenant); err != nil {
+			sendResponse(w, false, err.Error(), nil, http.StatusBadRequest)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tenantKey{}, tenant)))
+	})
+}
+
+// tokenTenant returns the tenant r's bearer token is bound to, or "".
+func tokenTenant(r *http.Request, tokenTenants map[string]string) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return ""
+	}
+
+	var tenant string
+	for candidate, id := range tokenTenants {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(candidate)) == 1 {
+			tenant = id
+		}
+	}
+	return tenant
+}
+
+// tenantPrefix returns the key prefix of r's tenant, such as "acme/", or ""
+// when multi-tenancy is off.
+func tenantPrefix(r *http.Request) string {
+	tenant, _ := r.Context().Value(tenantKey{}).(string)
+	if tenant == "" {
+		return ""
+	}
+	return tenant + "/"
+}
+
+// ownsObject reports whether objectName belongs to r's tenant. Keys under
+// storage.SystemPrefix belong to no one.
+func ownsObject(r *http.Request, objectName string) bool {
+	return strings.HasPrefix(objectName, tenantPrefix(r)) && !storage.IsSystemKey(objectName)
+}
+
+// checkTenant answers 404 and returns false unless every key belongs to r's
+// tenant. Other tenants' keys are reported as missing, not forbidden, so
+// whether they exist can't be confirmed.
+func checkTenant(w http.ResponseWriter, r *http.Request, objectNames ...string) bool {
+	for _, objectName := range objectNames {
+		if !ownsObject(r, objectName) {
+			sendResponse(w, false, "File not found", nil, http.StatusNotFound)
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"log/slog"
+	"mime"
+	"strings"
+
+	"MinIO-Learn/internal/storage"
+)
+
+// Limits on what thumbnailer will decode, so a small upload can't expand
+// into an enormous bitmap.
+const (
+	maxThumbnailSourceBytes  = 32 << 20
+	maxThumbnailSourcePixels = 50_000_000
+)
+
+const thumbnailPrefix = "thumbnails/"
+
+// thumbnailer is a post-upload processor that stores a copy of JPEG and PNG
+// uploads scaled down to width pixels wide under thumbnails/{objectKey}, or
+// with multiTenant under the tenant's own thumbnails/. Other content is
+// skipped. Failures are logged rather than returned, so a broken image never
+// fails its upload.
+type thumbnailer struct {
+	storage     storage.Storage
+	width       int
+	multiTenant bool
+}
+
+func newThumbnailer(store storage.Storage, width int, multiTenant bool) *thumbnailer {
+	return &thumbnailer{storage: store, width: width, multiTenant: multiTenant}
+}
+
+// thumbnailKey returns where the thumbnail of objectKey is stored, keeping
+// it within the tenant prefix objectKey starts with, if any: the thumbnail
+// of "acme/uploads/a.png" is "acme/thumbnails/uploads/a.png".
+func (t *thumbnailer) thumbnailKey(objectKey string) string {
+	if tenant, rest, ok := strings.Cut(objectKey, "/"); t.multiTenant && ok {
+		return tenant + "/" + thumbnailPrefix + rest
+	}
+	return thumbnailPrefix + objectKey
+}
+
+func (t *thumbnailer) Name() string { return "thumbnail" }
+
+func (t *thumbnailer) Process(ctx context.Context, info FileInfo, objectKey string) error {
+	mediaType, _, _ := mime.ParseMediaType(info.ContentType)
+	if mediaType != "image/jpeg" && mediaType != "image/png" {
+		return nil
+	}
+	if info.Size > maxThumbnailSourceBytes {
+		slog.InfoContext(ctx, "Skipping thumbnail for large image", "object", objectKey, "size", info.Size)
+		return nil
+	}
+
+	if err := t.generate(ctx, objectKey, mediaType); err != nil {
+		slog.WarnContext(ctx, "Failed to generate thumbnail", "object", objectKey, "error", err)
+	}
+	return nil
+}
+
+func (t *thumbnailer) generate(ctx context.Context, objectKey, mediaType string) error {
+	data, err := t.storage.DownloadBuffer(ctx, objectKey)
+	if err != nil {
+		return err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to read image header: %w", err)
+	}
+	if config.Width*config.Height > maxThumbnailSourcePixels {
+		return fmt.Errorf("image is %dx%d, too large to thumbnail", config.Width, config.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to decode image: %w", err)
+	}
+	thumb := resizeToWidth(src, t.width)
+
+	var buf bytes.Buffer
+	if mediaType == "image/png" {
+		err = png.Encode(&buf, thumb)
+	} else {
+		err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 85})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+
+	_, err = t.storage.UploadBuffer(ctx, t.thumbnailKey(objectKey), buf.Bytes(), mediaType, nil)
+	return err
+}
+
+// resizeToWidth scales src down to width pixels wide, keeping its aspect
+// ratio, by averaging the block of source pixels behind each output pixel.
+// Images already at most width wide are returned unchanged.
+func resizeToWidth(src image.Image, width int) image.Image {
+	bounds := src.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	if srcW <= width || width <= 0 {
+		return src
+	}
+	height := max(1, srcH*width/srcW)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*srcH/height
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*srcH/height)
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*srcW/width
+			x1 := max(x0+1, bounds.Min.X+(x+1)*srcW/width)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+	return dst
+}
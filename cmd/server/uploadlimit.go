@@ -0,0 +1,33 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// limitUploadBody caps r's body at MINIO_MAX_UPLOAD_SIZE, so reads past it
+// fail with *http.MaxBytesError. Bodies whose Content-Length already exceeds
+// the limit are rejected up front with 413; it returns false in that case.
+func (s *Server) limitUploadBody(w http.ResponseWriter, r *http.Request) bool {
+	limit := s.config.MaxUploadSize
+	if limit <= 0 {
+		return true
+	}
+	if r.ContentLength > limit {
+		s.sendUploadTooLarge(w)
+		return false
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+	return true
+}
+
+func bodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
+
+func (s *Server) sendUploadTooLarge(w http.ResponseWriter) {
+	sendResponse(w, false, fmt.Sprintf("Upload exceeds the maximum size of %d bytes", s.config.MaxUploadSize),
+		nil, http.StatusRequestEntityTooLarge)
+}
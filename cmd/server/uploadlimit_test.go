@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"MinIO-Learn/internal/storage"
+)
+
+func TestMaxUploadSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   string
+		size    int
+		chunked bool
+		want    int
+	}{
+		{"under the limit", "4096", 1000, false, http.StatusOK},
+		{"over the limit", "4096", 10000, false, http.StatusRequestEntityTooLarge},
+		{"over the limit without a length", "4096", 10000, true, http.StatusRequestEntityTooLarge},
+		{"under the limit without a length", "4096", 1000, true, http.StatusOK},
+		{"no limit", "0", 100000, false, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		for _, mode := range []string{"multipart", "raw"} {
+			t.Run(tt.name+"/"+mode, func(t *testing.T) {
+				store := storage.NewMemoryStorage("test-bucket")
+				h := newTestServer(t, store, map[string]string{"MINIO_MAX_UPLOAD_SIZE": tt.limit})
+				data := bytes.Repeat([]byte("x"), tt.size)
+
+				req := newUploadRequest(t, "big.txt", data)
+				if mode == "raw" {
+					req = httptest.NewRequest(http.MethodPut, "/upload?filename=big.txt", bytes.NewReader(data))
+				}
+				if tt.chunked {
+					req.Body = io.NopCloser(io.MultiReader(req.Body))
+					req.ContentLength = -1
+					req.TransferEncoding = []string{"chunked"}
+				}
+
+				rec := serve(h, req)
+				if rec.Code != tt.want {
+					t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+				}
+
+				objects, err := store.ListObjects(context.Background(), "")
+				if err != nil {
+					t.Fatalf("ListObjects() error = %v", err)
+				}
+				if stored := len(objects) > 0; stored != (tt.want == http.StatusOK) {
+					t.Errorf("stored = %v after status %d", stored, rec.Code)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"MinIO-Learn/internal/storage"
+	"MinIO-Learn/internal/storage/storagetest"
+)
+
+func TestUploadURLEnforcesKey(t *testing.T) {
+	tests := []struct {
+		name string
+		// tamper rewrites the presigned URL before the client uses it.
+		tamper     func(u *url.URL)
+		wantStatus int
+	}{
+		{"signed key", func(u *url.URL) {}, http.StatusOK},
+		{"other key", func(u *url.URL) { u.Path = path.Dir(u.Path) + "/chosen-by-client.txt" }, http.StatusForbidden},
+		{"other prefix", func(u *url.URL) { u.Path = strings.Replace(u.Path, "/uploads/", "/public/", 1) }, http.StatusForbidden},
+		{"extended expiry", func(u *url.URL) {
+			query := u.Query()
+			query.Set("X-Amz-Expires", "604800")
+			u.RawQuery = query.Encode()
+		}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := storagetest.NewFakeS3()
+			fake.SecretKey = "test-secret-key"
+			h := newFakeBackendServer(t, fake, nil)
+
+			rec := serve(h, newRequest(http.MethodPost, "/upload-url?filename=notes.txt", ""))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+			}
+			var info uploadURLInfo
+			decodeData(t, rec, &info)
+			if !strings.HasPrefix(info.Key, "uploads/") || !strings.HasSuffix(info.Key, ".txt") {
+				t.Errorf("key = %q, want a server-chosen key under uploads/", info.Key)
+			}
+
+			u, err := url.Parse(info.URL)
+			if err != nil {
+				t.Fatalf("parsing URL %q: %v", info.URL, err)
+			}
+			tt.tamper(u)
+			req, err := http.NewRequest(http.MethodPut, u.String(), bytes.NewReader([]byte("notes")))
+			if err != nil {
+				t.Fatalf("NewRequest() error = %v", err)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("PUT presigned URL: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("PUT status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+
+			var wantKeys []string
+			if tt.wantStatus == http.StatusOK {
+				wantKeys = []string{info.Key}
+			}
+			var keys []string
+			for _, key := range fake.Keys("test-bucket") {
+				if !storage.IsSystemKey(key) {
+					keys = append(keys, key)
+				}
+			}
+			if !slices.Equal(keys, wantKeys) {
+				t.Errorf("stored keys = %v, want %v", keys, wantKeys)
+			}
+		})
+	}
+}
+
+func TestUploadURLKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy string
+		filename string
+		want     *regexp.Regexp
+	}{
+		{"timestamp", "timestamp", "notes.txt", regexp.MustCompile(`^uploads/\d+-[0-9a-f]{8}-notes\.txt$`)},
+		{"uuid", "uuid", "Notes.TXT", regexp.MustCompile(`^uploads/[0-9a-f-]{36}\.txt$`)},
+		{"date", "date", "notes.txt", regexp.MustCompile(`^uploads/\d{4}/\d{2}/\d{2}/[0-9a-f-]{36}\.txt$`)},
+		{"path in filename", "timestamp", "../../private/notes.txt", regexp.MustCompile(`^uploads/\d+-[0-9a-f]{8}-notes\.txt$`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestServer(t, storage.NewMemoryStorage("test-bucket"), map[string]string{"MINIO_KEY_STRATEGY": tt.strategy})
+
+			rec := serve(h, newRequest(http.MethodPost, "/upload-url?filename="+url.QueryEscape(tt.filename), ""))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+			}
+			var info uploadURLInfo
+			decodeData(t, rec, &info)
+			if !tt.want.MatchString(info.Key) {
+				t.Errorf("key = %q, want it to match %s", info.Key, tt.want)
+			}
+		})
+	}
+
+	h := newTestServer(t, storage.NewMemoryStorage("test-bucket"), nil)
+	if rec := serve(h, newRequest(http.MethodPost, "/upload-url", "")); rec.Code != http.StatusBadRequest {
+		t.Errorf("without a filename: status = %d, want 400: %s", rec.Code, rec.Body)
+	}
+}
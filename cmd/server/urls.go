@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"MinIO-Learn/internal/storage"
+)
+
+// proxyURL returns the absolute URL of objectName's /raw endpoint on this
+// server, used when presigned URLs can't be handed out. X-Forwarded-Proto is
+// only honoured with trustProxy set, as clients can send it too.
+func proxyURL(r *http.Request, objectName string, download, trustProxy bool) string {
+	scheme := "http"
+	if r.TLS != nil || (trustProxy && strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")) {
+		scheme = "https"
+	}
+
+	u := url.URL{
+		Scheme: scheme,
+		Host:   r.Host,
+		Path:   "/files/" + objectName + "/raw",
+	}
+	if download {
+		u.RawQuery = "download=true"
+	}
+	return u.String()
+}
+
+// objectURL returns a presigned URL for objectName, or its proxy URL when
+// presigning is disabled or fails and the fallback is enabled. It returns ""
+// if no URL can be produced.
+func (s *Server) objectURL(r *http.Request, objectName string, expiry time.Duration) string {
+	if !s.config.DisablePresign {
+		url, err := s.storage.GetObjectURL(r.Context(), objectName, expiry)
+		if err == nil {
+			return url
+		}
+		slog.WarnContext(r.Context(), "Failed to generate presigned URL", "object", objectName, "error", err)
+		if !s.config.PresignFallback {
+			return ""
+		}
+	}
+	return proxyURL(r, objectName, false, s.config.TrustProxy)
+}
+
+// downloadURL is objectURL for attachment downloads named fileName.
+func (s *Server) downloadURL(r *http.Request, objectName, fileName string, expiry time.Duration) (string, error) {
+	if !s.config.DisablePresign {
+		url, err := s.storage.GetDownloadURL(r.Context(), objectName, fileName, expiry)
+		if err == nil || !s.config.PresignFallback {
+			return url, err
+		}
+		slog.WarnContext(r.Context(), "Failed to generate presigned URL, falling back to proxy", "object", objectName, "error", err)
+	}
+	return proxyURL(r, objectName, true, s.config.TrustProxy), nil
+}
+
+// rawFileHandler serves GET /files/{objectName}/raw by proxying the object's
+// bytes through this server, for clients that can't be given presigned URLs.
+// Objects are shown inline unless ?download=true, sandboxed like inline
+// downloads from getFileHandler. If-Match and If-None-Match are honoured.
+func (s *Server) rawFileHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendResponse(w, false, "Method not allowed", nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	objectName := strings.TrimSuffix(r.URL.Path[len("/files/"):], "/raw")
+	if objectName == "" {
+		sendResponse(w, false, "Object name is required", nil, http.StatusBadRequest)
+		return
+	}
+	if err := storage.ValidateObjectName(objectName); err != nil {
+		sendResponse(w, false, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+
+	exists, err := s.storage.CheckObjectExists(r.Context(), objectName)
+	if err != nil {
+		sendResponse(w, false, "Error checking object: "+err.Error(), nil, storageErrorStatus(err))
+		return
+	}
+	if !exists {
+		sendResponse(w, false, "File not found", nil, http.StatusNotFound)
+		return
+	}
+
+	info, err := s.storage.GetObjectInfo(r.Context(), objectName)
+	if err != nil {
+		sendResponse(w, false, "Error checking object: "+err.Error(), nil, storageErrorStatus(err))
+		return
+	}
+	if !checkExpectation(w, r, info) {
+		return
+	}
+	if !checkPreconditions(w, r, info.ETag, true) {
+		return
+	}
+	w.Header().Set("ETag", quoteETag(info.ETag))
+
+	if !s.acquireDownload(w, r, s.bufferedDownloads) {
+		return
+	}
+	defer s.bufferedDownloads.release()
+
+	// Pin the read to the ETag of info, as getFileHandler does.
+	var opts storage.ReadOptions
+	if err := opts.SetMatchETag(info.ETag); err != nil {
+		sendResponse(w, false, "Error downloading file: "+err.Error(), nil, storageErrorStatus(err))
+		return
+	}
+
+	contentType := info.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	disposition := "inline"
+	if r.URL.Query().Get("download") == "true" {
+		disposition = "attachment"
+	} else {
+		// Whoever uploaded the object chose its type; keep an HTML upload
+		// from running scripts on this origin.
+		w.Header().Set("Content-Security-Policy", "sandbox")
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Disposition", contentDisposition(disposition, storage.OriginalFilename(info)))
+	if !storage.IsCompressed(info) {
+		w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	}
+
+	written, err := s.storage.DownloadToWriterWithOptions(r.Context(), objectName, opts, expectedSizeWriter(r, w))
+	if err != nil && written == 0 {
+		w.Header().Del("Content-Length")
+		w.Header().Del("Content-Security-Policy")
+		if errors.Is(err, storage.ErrPreconditionFailed) {
+			sendResponse(w, false, "Object changed during download, please retry", nil, http.StatusPreconditionFailed)
+			return
+		}
+		if errors.Is(err, storage.ErrUnexpectedObject) {
+			sendResponse(w, false, "Refusing to serve object: "+err.Error(), nil, http.StatusConflict)
+			return
+		}
+		sendResponse(w, false, "Error downloading file: "+err.Error(), nil, storageErrorStatus(err))
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Download interrupted", "object", objectName, "written", written, "error", err)
+		if errors.Is(err, storage.ErrUnexpectedObject) {
+			panic(http.ErrAbortHandler)
+		}
+	}
+}
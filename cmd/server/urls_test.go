@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"MinIO-Learn/internal/storage"
+)
+
+// unsignableStorage fails every presign, like a backend whose credentials
+// can't sign.
+type unsignableStorage struct {
+	storage.Storage
+}
+
+var errCannotSign = errors.New("signing unavailable")
+
+func (unsignableStorage) GetObjectURL(ctx context.Context, objectName string, expiry time.Duration) (string, error) {
+	return "", errCannotSign
+}
+
+func (unsignableStorage) GetDownloadURL(ctx context.Context, objectName, fileName string, expiry time.Duration) (string, error) {
+	return "", errCannotSign
+}
+
+func TestPresignFallback(t *testing.T) {
+	tests := []struct {
+		name           string
+		disablePresign bool
+		fallback       bool
+		signFails      bool
+		wantListURL    string
+		wantRedirect   string
+	}{
+		{"presigned", false, true, false, "memory://", "memory://"},
+		{"presigning disabled", true, false, false, "http://example.com/files/uploads/report.txt/raw", "http://example.com/files/uploads/report.txt/raw?download=true"},
+		{"signing fails with fallback", false, true, true, "http://example.com/files/uploads/report.txt/raw", "http://example.com/files/uploads/report.txt/raw?download=true"},
+		{"signing fails without fallback", false, false, true, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memory := storage.NewMemoryStorage("test-bucket")
+			putObject(t, memory, "uploads/report.txt", "text/plain", []byte("quarterly report"))
+			var store storage.Storage = memory
+			if tt.signFails {
+				store = unsignableStorage{memory}
+			}
+			h := newTestServer(t, store, map[string]string{
+				"MINIO_DISABLE_PRESIGN":  strconv.FormatBool(tt.disablePresign),
+				"MINIO_PRESIGN_FALLBACK": strconv.FormatBool(tt.fallback),
+			})
+
+			rec := serve(h, newRequest(http.MethodGet, "/files?urls=true", ""))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("list status = %d: %s", rec.Code, rec.Body)
+			}
+			var page fileListPage
+			decodeData(t, rec, &page)
+			if len(page.Files) != 1 {
+				t.Fatalf("listed %d files, want 1", len(page.Files))
+			}
+			listURL := page.Files[0].URL
+			if !strings.HasPrefix(listURL, tt.wantListURL) || (tt.wantListURL == "" && listURL != "") {
+				t.Errorf("FileInfo.URL = %q, want %q", listURL, tt.wantListURL)
+			}
+
+			rec = serve(h, newRequest(http.MethodGet, "/files/uploads/report.txt", ""))
+			if tt.wantRedirect == "" {
+				if rec.Code != http.StatusInternalServerError {
+					t.Errorf("get status = %d, want 500", rec.Code)
+				}
+				return
+			}
+			if rec.Code != http.StatusFound {
+				t.Fatalf("get status = %d, want 302: %s", rec.Code, rec.Body)
+			}
+			location := rec.Header().Get("Location")
+			if !strings.HasPrefix(location, tt.wantRedirect) {
+				t.Errorf("Location = %q, want %q", location, tt.wantRedirect)
+			}
+
+			// Proxy URLs must serve the object from this server.
+			for _, raw := range []string{listURL, location} {
+				u, err := url.Parse(raw)
+				if err != nil || u.Scheme != "http" {
+					continue
+				}
+				rec := serve(h, newRequest(http.MethodGet, u.RequestURI(), ""))
+				if rec.Code != http.StatusOK || rec.Body.String() != "quarterly report" {
+					t.Errorf("GET %s = %d %q, want the object", u.RequestURI(), rec.Code, rec.Body)
+				}
+			}
+		})
+	}
+}
+
+func TestProxyURLForwardedProto(t *testing.T) {
+	tests := []struct {
+		name       string
+		trustProxy bool
+		proto      string
+		want       string
+	}{
+		{"untrusted", false, "https", "http://example.com/files/report.txt/raw"},
+		{"trusted", true, "https", "https://example.com/files/report.txt/raw"},
+		{"trusted plain", true, "http", "http://example.com/files/report.txt/raw"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStorage("test-bucket")
+			putObject(t, store, "report.txt", "text/plain", []byte("quarterly report"))
+			h := newTestServer(t, store, map[string]string{
+				"MINIO_DISABLE_PRESIGN": "true",
+				"MINIO_TRUST_PROXY":     strconv.FormatBool(tt.trustProxy),
+			})
+
+			req := newRequest(http.MethodGet, "/files/report.txt", "")
+			req.Header.Set("X-Forwarded-Proto", tt.proto)
+			rec := serve(h, req)
+			if rec.Code != http.StatusFound {
+				t.Fatalf("status = %d, want 302: %s", rec.Code, rec.Body)
+			}
+			if want := tt.want + "?download=true"; rec.Header().Get("Location") != want {
+				t.Errorf("Location = %q, want %q", rec.Header().Get("Location"), want)
+			}
+		})
+	}
+}
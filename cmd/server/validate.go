@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"mime"
+	"os"
+	"strings"
+	"sync"
+)
+
+// maxValidatedImagePixels bounds the images validateImage will decode, so a
+// small file claiming huge dimensions can't exhaust memory.
+const maxValidatedImagePixels = 50_000_000
+
+// ContentValidator reports whether an upload's bytes are valid for its
+// declared content type.
+type ContentValidator func(r io.Reader) error
+
+var (
+	contentValidatorsMu sync.RWMutex
+	contentValidators   = map[string]ContentValidator{
+		"application/json": validateJSON,
+		"image/*":          validateImage,
+	}
+)
+
+// registerContentValidator installs validator for mediaType, which is either
+// an exact type such as "application/json" or a wildcard such as "image/*".
+func registerContentValidator(mediaType string, validator ContentValidator) {
+	contentValidatorsMu.Lock()
+	defer contentValidatorsMu.Unlock()
+	contentValidators[mediaType] = validator
+}
+
+// contentValidatorFor returns the validator for contentType, preferring an
+// exact match over a wildcard, or nil if there is none.
+func contentValidatorFor(contentType string) ContentValidator {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil
+	}
+
+	contentValidatorsMu.RLock()
+	defer contentValidatorsMu.RUnlock()
+
+	if validator, ok := contentValidators[mediaType]; ok {
+		return validator
+	}
+	if major, _, ok := strings.Cut(mediaType, "/"); ok {
+		return contentValidators[major+"/*"]
+	}
+	return nil
+}
+
+func validateJSON(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if !json.Valid(data) {
+		return fmt.Errorf("content is not valid JSON")
+	}
+	return nil
+}
+
+// validateImage fully decodes the image, so truncated or corrupt pixel data
+// is caught and not just a bad header. The dimensions in the header are
+// checked first and images over maxValidatedImagePixels are rejected without
+// decoding. The decoded image is discarded.
+func validateImage(r io.Reader) error {
+	var header bytes.Buffer
+	config, format, err := image.DecodeConfig(io.TeeReader(r, &header))
+	if err != nil {
+		return fmt.Errorf("content is not a decodable image: %w", err)
+	}
+	if config.Width*config.Height > maxValidatedImagePixels {
+		return fmt.Errorf("%s image is %dx%d, more than %d pixels", format, config.Width, config.Height, maxValidatedImagePixels)
+	}
+
+	if _, format, err := image.Decode(io.MultiReader(&header, r)); err != nil {
+		if format == "" {
+			return fmt.Errorf("content is not a decodable image: %w", err)
+		}
+		return fmt.Errorf("content is not a valid %s image: %w", format, err)
+	}
+	return nil
+}
+
+// spoolUpload copies a streamed upload to a temporary file, so it can be read
+// once to validate it and again to store it. cleanup closes and removes the
+// file.
+func spoolUpload(body io.Reader) (file *os.File, cleanup func(), err error) {
+	file, err = os.CreateTemp("", "upload-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup = func() {
+		file.Close()
+		os.Remove(file.Name())
+	}
+
+	if _, err := io.Copy(file, body); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return file, cleanup, nil
+}
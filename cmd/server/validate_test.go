@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strconv"
+	"testing"
+
+	"MinIO-Learn/internal/storage"
+)
+
+// testPNG returns a valid 4x4 PNG.
+func testPNG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+// newTypedUploadRequest returns a POST /upload request carrying data as the
+// multipart file field with the given part Content-Type.
+func newTypedUploadRequest(t *testing.T, fileName, contentType string, data []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="file"; filename="`+fileName+`"`)
+	header.Set("Content-Type", contentType)
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		t.Fatalf("CreatePart() error = %v", err)
+	}
+	part.Write(data)
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestUploadContentValidation(t *testing.T) {
+	validPNG := testPNG(t)
+	corruptPNG := append(bytes.Clone(validPNG[:len(validPNG)/2]), bytes.Repeat([]byte{0xff}, 16)...)
+
+	tests := []struct {
+		name        string
+		enabled     bool
+		fileName    string
+		contentType string
+		data        []byte
+		want        int
+	}{
+		{"valid PNG", true, "pixel.png", "image/png", validPNG, http.StatusOK},
+		{"corrupt PNG", true, "pixel.png", "image/png", corruptPNG, http.StatusUnprocessableEntity},
+		{"not an image", true, "pixel.png", "image/png", []byte("plain text"), http.StatusUnprocessableEntity},
+		{"valid JSON", true, "data.json", "application/json", []byte(`{"items":[1,2,3]}`), http.StatusOK},
+		{"malformed JSON", true, "data.json", "application/json", []byte(`{"items":[1,2,`), http.StatusUnprocessableEntity},
+		{"no validator for type", true, "notes.txt", "text/plain", []byte(`{"items":`), http.StatusOK},
+		{"disabled", false, "data.json", "application/json", []byte(`{"items":[1,2,`), http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		for _, mode := range []string{"multipart", "raw"} {
+			t.Run(tt.name+"/"+mode, func(t *testing.T) {
+				store := storage.NewMemoryStorage("test-bucket")
+				h := newTestServer(t, store, map[string]string{"MINIO_VALIDATE_CONTENT": strconv.FormatBool(tt.enabled)})
+
+				req := newTypedUploadRequest(t, tt.fileName, tt.contentType, tt.data)
+				if mode == "raw" {
+					req = httptest.NewRequest(http.MethodPut, "/upload?filename="+tt.fileName, bytes.NewReader(tt.data))
+					req.Header.Set("Content-Type", tt.contentType)
+				}
+				rec := serve(h, req)
+				if rec.Code != tt.want {
+					t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+				}
+
+				objects, err := store.ListObjects(req.Context(), "")
+				if err != nil {
+					t.Fatalf("ListObjects() error = %v", err)
+				}
+				if stored := len(objects) > 0; stored != (tt.want == http.StatusOK) {
+					t.Errorf("stored = %v after status %d", stored, rec.Code)
+				}
+			})
+		}
+	}
+}
+
+func TestRegisterContentValidator(t *testing.T) {
+	errNoHeader := errors.New("missing header row")
+	registerContentValidator("text/csv", func(r io.Reader) error {
+		data, _ := io.ReadAll(r)
+		if !bytes.HasPrefix(data, []byte("id,")) {
+			return errNoHeader
+		}
+		return nil
+	})
+	t.Cleanup(func() {
+		contentValidatorsMu.Lock()
+		delete(contentValidators, "text/csv")
+		contentValidatorsMu.Unlock()
+	})
+
+	tests := []struct {
+		contentType string
+		data        string
+		wantErr     error
+		wantNone    bool
+	}{
+		{contentType: "text/csv", data: "id,name\n1,a\n"},
+		{contentType: "text/csv; charset=utf-8", data: "1,a\n", wantErr: errNoHeader},
+		{contentType: "application/json; charset=utf-8", data: `{}`},
+		{contentType: "text/plain", wantNone: true},
+		{contentType: "not a media type", wantNone: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			validator := contentValidatorFor(tt.contentType)
+			if (validator == nil) != tt.wantNone {
+				t.Fatalf("contentValidatorFor(%q) = %v, want none %v", tt.contentType, validator != nil, tt.wantNone)
+			}
+			if validator == nil {
+				return
+			}
+			if err := validator(bytes.NewReader([]byte(tt.data))); !errors.Is(err, tt.wantErr) {
+				t.Errorf("validator() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
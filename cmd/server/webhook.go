@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"MinIO-Learn/internal/storage"
+)
+
+// uploadEvent is the JSON body POSTed to the upload webhook.
+type uploadEvent struct {
+	Key         string            `json:"key"`
+	FileName    string            `json:"fileName"`
+	Size        int64             `json:"size"`
+	ContentType string            `json:"contentType"`
+	ETag        string            `json:"etag"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Timestamp   time.Time         `json:"timestamp"`
+}
+
+// webhookNotifier delivers upload events to a URL. Each body is signed with
+// HMAC-SHA256 over the raw bytes using the shared secret and sent as
+// "X-Webhook-Signature: sha256=<hex>", so receivers can verify it came from us.
+// Deliveries run through background, so graceful shutdown waits for them.
+type webhookNotifier struct {
+	storage    storage.Storage
+	background func(func())
+	url        string
+	secret     []byte
+	client     *http.Client
+	attempts   int
+	retryDelay time.Duration
+}
+
+func newWebhookNotifier(store storage.Storage, url, secret string, background func(func())) *webhookNotifier {
+	return &webhookNotifier{
+		storage:    store,
+		background: background,
+		url:        url,
+		secret:     []byte(secret),
+		client:     &http.Client{Timeout: 10 * time.Second},
+		attempts:   5,
+		retryDelay: time.Second,
+	}
+}
+
+func (n *webhookNotifier) Name() string { return "upload-webhook" }
+
+// Process builds the event and delivers it in the background, so a slow or
+// unavailable receiver never fails or delays the upload.
+func (n *webhookNotifier) Process(ctx context.Context, info FileInfo, objectKey string) error {
+	event := uploadEvent{
+		Key:         objectKey,
+		FileName:    info.FileName,
+		Size:        info.Size,
+		ContentType: info.ContentType,
+		Timestamp:   time.Now().UTC(),
+	}
+	if stat, err := n.storage.GetObjectInfo(ctx, objectKey); err == nil {
+		event.ETag = stat.ETag
+		event.Tags = stat.UserTags
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook event: %w", err)
+	}
+
+	n.background(func() { n.deliver(body, objectKey) })
+	return nil
+}
+
+func (n *webhookNotifier) sign(body []byte) string {
+	mac := hmac.New(sha256.New, n.secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (n *webhookNotifier) deliver(body []byte, objectKey string) {
+	delay := n.retryDelay
+	for attempt := 1; attempt <= n.attempts; attempt++ {
+		err := n.send(body)
+		if err == nil {
+			return
+		}
+		if attempt == n.attempts {
+			slog.Error("Webhook delivery failed, giving up", "url", n.url, "object", objectKey, "attempts", attempt, "error", err)
+			return
+		}
+		slog.Warn("Webhook delivery failed, retrying", "url", n.url, "object", objectKey, "attempt", attempt, "delay", delay, "error", err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+func (n *webhookNotifier) send(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Signature", n.sign(body))
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return nil
+}
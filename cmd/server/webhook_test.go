@@ -0,0 +1,95 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"MinIO-Learn/internal/storage"
+)
+
+// webhookDelivery is one request received by a test webhook receiver.
+type webhookDelivery struct {
+	header http.Header
+	body   []byte
+}
+
+// newWebhookReceiver starts a server that accepts every POST and passes it on
+// the returned channel.
+func newWebhookReceiver(t *testing.T) (string, <-chan webhookDelivery) {
+	t.Helper()
+	deliveries := make(chan webhookDelivery, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method == http.MethodPost {
+			deliveries <- webhookDelivery{header: r.Header.Clone(), body: body}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, deliveries
+}
+
+func TestUploadWebhook(t *testing.T) {
+	tests := []struct {
+		name            string
+		fileName        string
+		data            []byte
+		wantContentType string
+	}{
+		{"text", "notes.txt", []byte("meeting notes"), "text/plain; charset=utf-8"},
+		{"html", "page.html", []byte("<html><body>hi</body></html>"), "text/html; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const secret = "webhook-secret"
+			url, deliveries := newWebhookReceiver(t)
+			store := storage.NewMemoryStorage("test-bucket")
+			h := newTestServer(t, store, map[string]string{
+				"MINIO_UPLOAD_WEBHOOK": url,
+				"MINIO_WEBHOOK_SECRET": secret,
+			})
+
+			if rec := serve(h, newUploadRequest(t, tt.fileName, tt.data)); rec.Code != http.StatusOK {
+				t.Fatalf("upload status = %d: %s", rec.Code, rec.Body)
+			}
+			key := onlyObject(t, store)
+
+			var delivery webhookDelivery
+			select {
+			case delivery = <-deliveries:
+			case <-time.After(5 * time.Second):
+				t.Fatal("no webhook delivered after the upload")
+			}
+
+			mac := hmac.New(sha256.New, []byte(secret))
+			mac.Write(delivery.body)
+			if got, want := delivery.header.Get("X-Webhook-Signature"), "sha256="+hex.EncodeToString(mac.Sum(nil)); got != want {
+				t.Errorf("X-Webhook-Signature = %q, want %q", got, want)
+			}
+			if got := delivery.header.Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+
+			var event uploadEvent
+			if err := json.Unmarshal(delivery.body, &event); err != nil {
+				t.Fatalf("decoding event %q: %v", delivery.body, err)
+			}
+			if event.Key != key || event.FileName != tt.fileName || event.Size != int64(len(tt.data)) {
+				t.Errorf("event = %+v, want key %q, file %q, size %d", event, key, tt.fileName, len(tt.data))
+			}
+			if event.ContentType != tt.wantContentType {
+				t.Errorf("event content type = %q, want %q", event.ContentType, tt.wantContentType)
+			}
+			if event.ETag == "" || event.Timestamp.IsZero() {
+				t.Errorf("event = %+v, want an ETag and timestamp", event)
+			}
+		})
+	}
+}
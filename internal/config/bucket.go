@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ValidateBucketName checks name against the S3 bucket naming rules and
+// reports the first rule it breaks.
+func ValidateBucketName(name string) error {
+	if len(name) < 3 || len(name) > 63 {
+		return fmt.Errorf("bucket name %q must be between 3 and 63 characters long", name)
+	}
+
+	for i, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '.':
+		case c >= 'A' && c <= 'Z':
+			return fmt.Errorf("bucket name %q must not contain uppercase letters (found %q at position %d)", name, c, i)
+		default:
+			return fmt.Errorf("bucket name %q may only contain lowercase letters, digits, dots and hyphens (found %q at position %d)", name, c, i)
+		}
+	}
+
+	if !isAlphanumeric(name[0]) || !isAlphanumeric(name[len(name)-1]) {
+		return fmt.Errorf("bucket name %q must begin and end with a letter or digit", name)
+	}
+	if strings.Contains(name, "..") {
+		return fmt.Errorf("bucket name %q must not contain consecutive dots", name)
+	}
+	if strings.Contains(name, ".-") || strings.Contains(name, "-.") {
+		return fmt.Errorf("bucket name %q must not have a dot adjacent to a hyphen", name)
+	}
+	if net.ParseIP(name) != nil {
+		return fmt.Errorf("bucket name %q must not be formatted as an IP address", name)
+	}
+	if strings.HasPrefix(name, "xn--") {
+		return fmt.Errorf("bucket name %q must not start with the reserved prefix \"xn--\"", name)
+	}
+	if strings.HasSuffix(name, "-s3alias") || strings.HasSuffix(name, "--ol-s3") {
+		return fmt.Errorf("bucket name %q must not end with a reserved suffix", name)
+	}
+
+	return nil
+}
+
+func isAlphanumeric(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateBucketName(t *testing.T) {
+	tests := []struct {
+		name     string
+		bucket   string
+		wantRule string
+	}{
+		{"simple", "uploads", ""},
+		{"digits dots and hyphens", "my-app.logs-2024", ""},
+		{"minimum length", "abc", ""},
+		{"maximum length", strings.Repeat("a", 63), ""},
+		{"too short", "ab", "between 3 and 63 characters"},
+		{"too long", strings.Repeat("a", 64), "between 3 and 63 characters"},
+		{"uppercase", "MyBucket", "uppercase letters"},
+		{"underscore", "my_bucket", "lowercase letters, digits, dots and hyphens"},
+		{"space", "my bucket", "lowercase letters, digits, dots and hyphens"},
+		{"leading hyphen", "-bucket", "begin and end with a letter or digit"},
+		{"trailing dot", "bucket.", "begin and end with a letter or digit"},
+		{"consecutive dots", "my..bucket", "consecutive dots"},
+		{"dot next to hyphen", "my.-bucket", "dot adjacent to a hyphen"},
+		{"IP address", "192.168.1.1", "IP address"},
+		{"reserved prefix", "xn--bucket", "reserved prefix"},
+		{"reserved suffix", "bucket-s3alias", "reserved suffix"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateBucketName(tt.bucket)
+			if tt.wantRule == "" {
+				if err != nil {
+					t.Errorf("ValidateBucketName(%q) error = %v, want nil", tt.bucket, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantRule) {
+				t.Errorf("ValidateBucketName(%q) error = %v, want it to name the rule %q", tt.bucket, err, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestLoadMinIOConfigValidatesBucket(t *testing.T) {
+	tests := []struct {
+		bucket  string
+		wantErr bool
+	}{
+		{"uploads", false},
+		{"Uploads", true},
+		{"up_loads", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.bucket, func(t *testing.T) {
+			_, err := loadMinIOConfig(mapSource(map[string]string{"MINIO_BUCKET": tt.bucket}))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("loadMinIOConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "MINIO_BUCKET") {
+				t.Errorf("loadMinIOConfig() error = %v, want it to name MINIO_BUCKET", err)
+			}
+		})
+	}
+}
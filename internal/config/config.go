@@ -1,64 +1,736 @@
 package config
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 )
 
+// BucketConfig is one entry of MINIO_BUCKETS, a JSON array of buckets to
+// provision at startup, e.g.
+//
+//	[{"name":"logs","versioning":true,"expireDays":30,"expirePrefix":"tmp/"}]
+type BucketConfig struct {
+	Name             string `json:"name"`
+	Region           string `json:"region"`
+	Versioning       bool   `json:"versioning"`
+	ExpireDays       int    `json:"expireDays"`
+	ExpirePrefix     string `json:"expirePrefix"`
+	PublicReadPrefix string `json:"publicReadPrefix"`
+}
+
 type MinIOConfig struct {
+	// Port is the port the HTTP server listens on. SelfTest runs the
+	// self-test instead of serving, like the selftest subcommand.
+	Port     string
+	SelfTest bool
+
 	Endpoint        string
 	AccessKeyID     string
 	SecretAccessKey string
 	UseSSL          bool
 	BucketName      string
 	Location        string
+
+	// CredsMode is "static", "iam" or "sts". STS assumes RoleARN at
+	// STSEndpoint using the static keys; IAM ignores them.
+	CredsMode   string
+	IAMEndpoint string
+	STSEndpoint string
+	RoleARN     string
+
+	// SignatureVersion is "v4", or "v2" for older S3-compatible backends.
+	// Only static credentials can sign with v2.
+	SignatureVersion string
+
+	ConnectTimeout time.Duration
+
+	// DialTimeout, ResponseHeaderTimeout and IdleConnTimeout bound the
+	// client's connections to MinIO. CACertFile is a PEM CA certificate to
+	// trust for MinIO serving TLS with a self-signed certificate.
+	DialTimeout           time.Duration
+	ResponseHeaderTimeout time.Duration
+	IdleConnTimeout       time.Duration
+	CACertFile            string
+
+	// RegionEndpoints maps regions to alternate MinIO endpoints, for
+	// federated setups. They share the credentials and TLS settings above.
+	RegionEndpoints map[string]string
+
+	// ObjectLock creates the bucket with object locking (WORM) enabled. It
+	// has no effect on a bucket that already exists.
+	ObjectLock bool
+
+	// AllowPublic permits publishing objects under a prefix for anonymous
+	// download through a bucket policy.
+	AllowPublic bool
+
+	// SoftDelete makes deletes move objects under the trash/ prefix, from
+	// where they can be restored until purged.
+	SoftDelete bool
+
+	PartSize      int64
+	UploadThreads int
+
+	// StorageClass is the default storage class for uploads. Empty leaves it
+	// to the backend.
+	StorageClass string
+
+	// CompressUploads gzips uploads with a compressible content type before
+	// storing them; they are decompressed again on download.
+	CompressUploads bool
+
+	// GzipResponses compresses responses for clients that accept gzip,
+	// except for already-compressed content types.
+	GzipResponses bool
+
+	// ExpectContentType and ExpectMaxSize describe the objects that may be
+	// served from keys under ExpectPrefixes; others are refused with 409.
+	// Routes outside those prefixes are not checked.
+	ExpectContentType string
+	ExpectMaxSize     int64
+	ExpectPrefixes    []string
+
+	CaseInsensitiveKeys bool
+
+	// FillContentTypes stats listed objects the listing gave no content
+	// type for, falling back to a guess from the key's extension.
+	FillContentTypes bool
+
+	Buckets []BucketConfig
+
+	PostProcessAsync   bool
+	PostProcessRetries int
+
+	UploadMinRate   int64
+	UploadRateGrace time.Duration
+
+	SweepInterval time.Duration
+	SweepPrefix   string
+	SweepDryRun   bool
+
+	// UploadSessionTTL is how long a resumable upload session may stay
+	// unfinished before it is aborted and its parts discarded; 0 keeps
+	// sessions until they are completed or aborted.
+	UploadSessionTTL time.Duration
+
+	APITokens         []string
+	CORSOrigins       []string
+	CORSMethods       []string
+	CORSHeaders       []string
+	PresignExpiry     time.Duration
+	PresignExpiryAnon time.Duration
+	PresignExpiryAuth time.Duration
+
+	// AuthRequired rejects requests without a valid API token with 401
+	// instead of serving them as anonymous. AuthExemptHealth leaves /livez,
+	// /readyz and /health open either way, for load balancer probes.
+	AuthRequired     bool
+	AuthExemptHealth bool
+
+	// MultiTenant namespaces object keys by tenant, taken from the tenant
+	// TenantTokens binds the request's token to or else the X-Tenant-ID
+	// header. TenantTokens maps API tokens to tenant IDs; they are accepted
+	// as API tokens too.
+	MultiTenant  bool
+	TenantTokens map[string]string
+
+	// RateLimit is the sustained requests per second allowed per client IP,
+	// with bursts of up to RateBurst; 0 disables rate limiting. TrustProxy
+	// takes the client IP from X-Forwarded-For, and the scheme of proxy
+	// URLs from X-Forwarded-Proto.
+	RateLimit  float64
+	RateBurst  int
+	TrustProxy bool
+
+	// StatCacheTTL caches StatObject results, including misses, for this
+	// long. Zero, the default, disables the cache; enable it only when every
+	// write goes through this instance, since objects written by presigned
+	// URLs or other instances look missing until their entry expires.
+	StatCacheTTL time.Duration
+
+	StreamFlushObjects int
+	StreamFlushBytes   int
+
+	UploadWebhook string
+	WebhookSecret string
+
+	// EventWebhook receives the bucket's object created and removed
+	// notifications, filtered by EventPrefix and EventSuffix, however the
+	// objects were written.
+	EventWebhook string
+	EventPrefix  string
+	EventSuffix  string
+
+	MaxBufferedDownloads int
+	MaxRangedDownloads   int
+	DownloadQueueTimeout time.Duration
+
+	ValidateContent bool
+
+	OriginURL string
+
+	DisablePresign  bool
+	PresignFallback bool
+
+	PostPolicyMaxSize int64
+	PostPolicyExpiry  time.Duration
+
+	LogLevel  string
+	LogFormat string
+
+	ShutdownTimeout time.Duration
+
+	// MaxUploadSize caps upload request bodies in bytes; 0 disables the cap.
+	MaxUploadSize int64
+
+	// MaxDownloadBuffer caps, in bytes, the objects read whole into memory,
+	// as for /files/{name}/raw and thumbnails; 0 disables the cap.
+	MaxDownloadBuffer int64
+
+	// TempDir is where large multipart uploads are spooled while being
+	// parsed, instead of the system temp directory. It is created if needed.
+	TempDir string
+
+	UploadConcurrency int
+
+	// KeyStrategy names how upload keys are generated: "timestamp",
+	// "uuid", "date" or "hash".
+	KeyStrategy string
+
+	// ThumbWidth enables JPEG/PNG thumbnails of this width; 0 disables them.
+	ThumbWidth int
+
+	// SSE is the server-side encryption applied to uploads: "none", "s3"
+	// for SSE-S3, or "c" for SSE-C with SSECKey, the 32-byte key decoded
+	// from base64 MINIO_SSE_C_KEY.
+	SSE     string
+	SSECKey []byte
 }
 
+// MaxPresignExpiry is the longest lifetime S3 allows for a presigned URL.
+const MaxPresignExpiry = 7 * 24 * time.Hour
+
+// LoadMinIOConfig reads the configuration from environment variables.
 func LoadMinIOConfig() (MinIOConfig, error) {
+	return loadMinIOConfig(os.Getenv)
+}
+
+func loadMinIOConfig(src envSource) (MinIOConfig, error) {
+	env := &envReader{src: src}
 	config := MinIOConfig{
-		Endpoint:        getEnv("MINIO_ENDPOINT", "localhost:9000"),
-		AccessKeyID:     getEnv("MINIO_ACCESS_KEY", "minio_admin"),
-		SecretAccessKey: getEnv("MINIO_SECRET_KEY", "minio_password"),
-		UseSSL:          getEnvBool("MINIO_USE_SSL", false),
-		BucketName:      getEnv("MINIO_BUCKET", "mybucket"),
-		Location:        getEnv("MINIO_LOCATION", "us-east-1"),
+		Port:     env.getEnv("PORT", "8080"),
+		SelfTest: env.getEnvBool("MINIO_SELFTEST", false),
+
+		Endpoint:        env.getEnv("MINIO_ENDPOINT", "localhost:9000"),
+		AccessKeyID:     env.getEnv("MINIO_ACCESS_KEY", "minio_admin"),
+		SecretAccessKey: env.getEnv("MINIO_SECRET_KEY", "minio_password"),
+		UseSSL:          env.getEnvBool("MINIO_USE_SSL", false),
+		BucketName:      env.getEnv("MINIO_BUCKET", "mybucket"),
+		Location:        env.getEnv("MINIO_LOCATION", "us-east-1"),
+
+		CredsMode:   strings.ToLower(env.getEnv("MINIO_CREDS_MODE", "static")),
+		IAMEndpoint: env.getEnv("MINIO_IAM_ENDPOINT", ""),
+		STSEndpoint: env.getEnv("MINIO_STS_ENDPOINT", ""),
+		RoleARN:     env.getEnv("MINIO_ROLE_ARN", ""),
+
+		SignatureVersion: strings.ToLower(env.getEnv("MINIO_SIGNATURE_VERSION", "v4")),
+
+		ConnectTimeout: env.getEnvDuration("MINIO_CONNECT_TIMEOUT", 30*time.Second),
+
+		DialTimeout:           env.getEnvDuration("MINIO_DIAL_TIMEOUT", 30*time.Second),
+		ResponseHeaderTimeout: env.getEnvDuration("MINIO_RESPONSE_HEADER_TIMEOUT", time.Minute),
+		IdleConnTimeout:       env.getEnvDuration("MINIO_IDLE_CONN_TIMEOUT", time.Minute),
+		CACertFile:            env.getEnv("MINIO_CA_CERT", ""),
+
+		ObjectLock: env.getEnvBool("MINIO_OBJECT_LOCK", false),
+
+		AllowPublic: env.getEnvBool("MINIO_ALLOW_PUBLIC", false),
+		SoftDelete:  env.getEnvBool("MINIO_SOFT_DELETE", false),
+
+		PartSize:      env.getEnvInt64("MINIO_PART_SIZE", 64<<20),
+		UploadThreads: env.getEnvInt("MINIO_UPLOAD_THREADS", 4),
+
+		StorageClass:    env.getEnv("MINIO_STORAGE_CLASS", ""),
+		CompressUploads: env.getEnvBool("MINIO_COMPRESS_UPLOADS", false),
+		GzipResponses:   env.getEnvBool("MINIO_GZIP_RESPONSES", false),
+
+		ExpectContentType: env.getEnv("MINIO_EXPECT_CONTENT_TYPE", ""),
+		ExpectMaxSize:     env.getEnvInt64("MINIO_EXPECT_MAX_SIZE", 0),
+		ExpectPrefixes:    env.getEnvList("MINIO_EXPECT_PREFIXES"),
+
+		CaseInsensitiveKeys: env.getEnvBool("MINIO_CASE_INSENSITIVE_KEYS", false),
+		FillContentTypes:    env.getEnvBool("MINIO_FILL_CONTENT_TYPES", false),
+
+		PostProcessAsync:   env.getEnvBool("MINIO_POSTPROCESS_ASYNC", false),
+		PostProcessRetries: env.getEnvInt("MINIO_POSTPROCESS_RETRIES", 3),
+
+		UploadMinRate:   env.getEnvInt64("MINIO_UPLOAD_MIN_RATE", 0),
+		UploadRateGrace: env.getEnvDuration("MINIO_UPLOAD_RATE_GRACE", 10*time.Second),
+
+		SweepInterval: env.getEnvDuration("MINIO_SWEEP_INTERVAL", 0),
+		SweepPrefix:   env.getEnv("MINIO_SWEEP_PREFIX", ""),
+		SweepDryRun:   env.getEnvBool("MINIO_SWEEP_DRY_RUN", false),
+
+		UploadSessionTTL: env.getEnvDuration("MINIO_UPLOAD_SESSION_TTL", 24*time.Hour),
+
+		APITokens:         env.getEnvList("MINIO_API_TOKENS"),
+		CORSOrigins:       env.getEnvList("MINIO_CORS_ORIGINS"),
+		CORSMethods:       env.getEnvList("MINIO_CORS_METHODS"),
+		CORSHeaders:       env.getEnvList("MINIO_CORS_HEADERS"),
+		PresignExpiry:     env.getEnvDuration("MINIO_PRESIGN_EXPIRY", 24*time.Hour),
+		PresignExpiryAnon: env.getEnvDuration("MINIO_PRESIGN_EXPIRY_ANON", time.Hour),
+
+		RateLimit:  env.getEnvFloat("MINIO_RATE_LIMIT", 0),
+		RateBurst:  env.getEnvInt("MINIO_RATE_BURST", 20),
+		TrustProxy: env.getEnvBool("MINIO_TRUST_PROXY", false),
+
+		StatCacheTTL: env.getEnvDuration("MINIO_STAT_CACHE_TTL", 0),
+
+		StreamFlushObjects: env.getEnvInt("MINIO_STREAM_FLUSH_OBJECTS", 100),
+		StreamFlushBytes:   env.getEnvInt("MINIO_STREAM_FLUSH_BYTES", 32<<10),
+
+		UploadWebhook: env.getEnv("MINIO_UPLOAD_WEBHOOK", ""),
+		WebhookSecret: env.getEnv("MINIO_WEBHOOK_SECRET", ""),
+
+		EventWebhook: env.getEnv("MINIO_EVENT_WEBHOOK", ""),
+		EventPrefix:  env.getEnv("MINIO_EVENT_PREFIX", ""),
+		EventSuffix:  env.getEnv("MINIO_EVENT_SUFFIX", ""),
+
+		MaxBufferedDownloads: env.getEnvInt("MINIO_MAX_BUFFERED_DOWNLOADS", 16),
+		MaxRangedDownloads:   env.getEnvInt("MINIO_MAX_RANGED_DOWNLOADS", 64),
+		DownloadQueueTimeout: env.getEnvDuration("MINIO_DOWNLOAD_QUEUE_TIMEOUT", 5*time.Second),
+
+		ValidateContent: env.getEnvBool("MINIO_VALIDATE_CONTENT", false),
+
+		OriginURL: env.getEnv("MINIO_ORIGIN_URL", ""),
+
+		DisablePresign:  env.getEnvBool("MINIO_DISABLE_PRESIGN", false),
+		PresignFallback: env.getEnvBool("MINIO_PRESIGN_FALLBACK", true),
+
+		PostPolicyMaxSize: env.getEnvInt64("MINIO_POST_POLICY_MAX_SIZE", 10<<20),
+		PostPolicyExpiry:  env.getEnvDuration("MINIO_POST_POLICY_EXPIRY", 15*time.Minute),
+
+		LogLevel:  strings.ToLower(env.getEnv("MINIO_LOG_LEVEL", "info")),
+		LogFormat: strings.ToLower(env.getEnv("MINIO_LOG_FORMAT", "text")),
+
+		ShutdownTimeout: env.getEnvDuration("MINIO_SHUTDOWN_TIMEOUT", 30*time.Second),
+
+		MaxUploadSize:     env.getEnvInt64("MINIO_MAX_UPLOAD_SIZE", 1<<30),
+		MaxDownloadBuffer: env.getEnvInt64("MINIO_MAX_DOWNLOAD_BUFFER", 1<<30),
+		TempDir:           env.getEnv("MINIO_TEMP_DIR", ""),
+
+		UploadConcurrency: env.getEnvInt("MINIO_UPLOAD_CONCURRENCY", 4),
+
+		KeyStrategy: strings.ToLower(env.getEnv("MINIO_KEY_STRATEGY", "timestamp")),
+
+		ThumbWidth: env.getEnvInt("MINIO_THUMB_WIDTH", 0),
+	}
+	config.PresignExpiryAuth = env.getEnvDuration("MINIO_PRESIGN_EXPIRY_AUTH", config.PresignExpiry)
+	if env.err != nil {
+		return config, env.err
 	}
 
 	if config.Endpoint == "" {
 		return config, fmt.Errorf("MINIO_ENDPOINT is required")
 	}
-	if config.AccessKeyID == "" {
-		return config, fmt.Errorf("MINIO_ACCESS_KEY is required")
-	}
-	if config.SecretAccessKey == "" {
-		return config, fmt.Errorf("MINIO_SECRET_KEY is required")
+	if err := validateCreds(config); err != nil {
+		return config, err
 	}
 	if config.BucketName == "" {
 		return config, fmt.Errorf("MINIO_BUCKET is required")
 	}
+	if err := ValidateBucketName(config.BucketName); err != nil {
+		return config, fmt.Errorf("MINIO_BUCKET: %w", err)
+	}
+
+	if config.ConnectTimeout < 0 {
+		return config, fmt.Errorf("MINIO_CONNECT_TIMEOUT must not be negative, got %s", config.ConnectTimeout)
+	}
+	if config.DialTimeout < 0 {
+		return config, fmt.Errorf("MINIO_DIAL_TIMEOUT must not be negative, got %s", config.DialTimeout)
+	}
+	if config.ResponseHeaderTimeout < 0 {
+		return config, fmt.Errorf("MINIO_RESPONSE_HEADER_TIMEOUT must not be negative, got %s", config.ResponseHeaderTimeout)
+	}
+	if config.IdleConnTimeout < 0 {
+		return config, fmt.Errorf("MINIO_IDLE_CONN_TIMEOUT must not be negative, got %s", config.IdleConnTimeout)
+	}
+	if config.CACertFile != "" && !config.UseSSL {
+		return config, fmt.Errorf("MINIO_CA_CERT requires MINIO_USE_SSL=true")
+	}
+
+	if config.PartSize < 5<<20 || config.PartSize > 5<<30 {
+		return config, fmt.Errorf("MINIO_PART_SIZE must be between 5MiB and 5GiB, got %d", config.PartSize)
+	}
+	if config.UploadThreads < 1 {
+		return config, fmt.Errorf("MINIO_UPLOAD_THREADS must be at least 1, got %d", config.UploadThreads)
+	}
+
+	if config.PresignExpiry <= 0 || config.PresignExpiry > MaxPresignExpiry {
+		return config, fmt.Errorf("MINIO_PRESIGN_EXPIRY must be between 1s and 7 days, got %s", config.PresignExpiry)
+	}
+	if config.PresignExpiryAnon <= 0 || config.PresignExpiryAnon > MaxPresignExpiry {
+		return config, fmt.Errorf("MINIO_PRESIGN_EXPIRY_ANON must be between 1s and 7 days, got %s", config.PresignExpiryAnon)
+	}
+	if config.PresignExpiryAuth <= 0 || config.PresignExpiryAuth > MaxPresignExpiry {
+		return config, fmt.Errorf("MINIO_PRESIGN_EXPIRY_AUTH must be between 1s and 7 days, got %s", config.PresignExpiryAuth)
+	}
+
+	switch config.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		return config, fmt.Errorf("MINIO_LOG_LEVEL must be one of debug, info, warn, error, got %q", config.LogLevel)
+	}
+	if config.LogFormat != "text" && config.LogFormat != "json" {
+		return config, fmt.Errorf("MINIO_LOG_FORMAT must be text or json, got %q", config.LogFormat)
+	}
+
+	if config.UploadSessionTTL < 0 {
+		return config, fmt.Errorf("MINIO_UPLOAD_SESSION_TTL must not be negative, got %s", config.UploadSessionTTL)
+	}
+
+	if config.UploadConcurrency < 1 {
+		return config, fmt.Errorf("MINIO_UPLOAD_CONCURRENCY must be at least 1, got %d", config.UploadConcurrency)
+	}
+	switch config.KeyStrategy {
+	case "timestamp", "uuid", "date", "hash":
+	default:
+		return config, fmt.Errorf("MINIO_KEY_STRATEGY must be one of timestamp, uuid, date, hash, got %q", config.KeyStrategy)
+	}
+	if config.RateLimit < 0 {
+		return config, fmt.Errorf("MINIO_RATE_LIMIT must not be negative, got %g", config.RateLimit)
+	}
+	if config.RateLimit > 0 && config.RateBurst < 1 {
+		return config, fmt.Errorf("MINIO_RATE_BURST must be at least 1, got %d", config.RateBurst)
+	}
+	if config.StatCacheTTL < 0 {
+		return config, fmt.Errorf("MINIO_STAT_CACHE_TTL must not be negative, got %s", config.StatCacheTTL)
+	}
+	if config.ThumbWidth < 0 {
+		return config, fmt.Errorf("MINIO_THUMB_WIDTH must not be negative, got %d", config.ThumbWidth)
+	}
+	if err := validateExpectation(config); err != nil {
+		return config, err
+	}
+
+	if err := loadSSE(env, &config); err != nil {
+		return config, err
+	}
+
+	if err := loadCORS(&config); err != nil {
+		return config, err
+	}
+
+	if config.UploadWebhook != "" && config.WebhookSecret == "" {
+		return config, fmt.Errorf("MINIO_WEBHOOK_SECRET is required when MINIO_UPLOAD_WEBHOOK is set")
+	}
+	if config.EventWebhook != "" && config.WebhookSecret == "" {
+		return config, fmt.Errorf("MINIO_WEBHOOK_SECRET is required when MINIO_EVENT_WEBHOOK is set")
+	}
+
+	if value := src("MINIO_REGION_ENDPOINTS"); value != "" {
+		if err := json.Unmarshal([]byte(value), &config.RegionEndpoints); err != nil {
+			return config, fmt.Errorf("MINIO_REGION_ENDPOINTS must be a JSON object mapping regions to endpoints: %w", err)
+		}
+		for region, endpoint := range config.RegionEndpoints {
+			if region == "" || endpoint == "" {
+				return config, fmt.Errorf("MINIO_REGION_ENDPOINTS must not contain an empty region or endpoint")
+			}
+		}
+	}
+
+	if token := src("MINIO_API_TOKEN"); token != "" {
+		config.APITokens = append(config.APITokens, token)
+	}
+	if value := src("MINIO_TENANT_TOKENS"); value != "" {
+		if err := json.Unmarshal([]byte(value), &config.TenantTokens); err != nil {
+			return config, fmt.Errorf("MINIO_TENANT_TOKENS must be a JSON object mapping tokens to tenant IDs: %w", err)
+		}
+		for token, tenant := range config.TenantTokens {
+			if token == "" {
+				return config, fmt.Errorf("MINIO_TENANT_TOKENS must not contain an empty token")
+			}
+			if err := ValidateTenantID(tenant); err != nil {
+				return config, fmt.Errorf("MINIO_TENANT_TOKENS: %w", err)
+			}
+			config.APITokens = append(config.APITokens, token)
+		}
+	}
+	config.MultiTenant = env.getEnvBool("MINIO_MULTI_TENANT", len(config.TenantTokens) > 0)
+	config.AuthRequired = env.getEnvBool("MINIO_AUTH_REQUIRED", len(config.APITokens) > 0)
+	config.AuthExemptHealth = env.getEnvBool("MINIO_AUTH_EXEMPT_HEALTH", true)
+	if env.err != nil {
+		return config, env.err
+	}
+	if config.AuthRequired && len(config.APITokens) == 0 {
+		return config, fmt.Errorf("MINIO_AUTH_REQUIRED needs MINIO_API_TOKEN or MINIO_API_TOKENS to be set")
+	}
+
+	if value := src("MINIO_BUCKETS"); value != "" {
+		if err := json.Unmarshal([]byte(value), &config.Buckets); err != nil {
+			return config, fmt.Errorf("MINIO_BUCKETS must be a JSON array of buckets: %w", err)
+		}
+		for i, bucket := range config.Buckets {
+			if err := ValidateBucketName(bucket.Name); err != nil {
+				return config, fmt.Errorf("MINIO_BUCKETS[%d]: %w", i, err)
+			}
+			if bucket.ExpireDays < 0 {
+				return config, fmt.Errorf("MINIO_BUCKETS[%d]: expireDays must not be negative", i)
+			}
+			if bucket.PublicReadPrefix != "" && !config.AllowPublic {
+				return config, fmt.Errorf("MINIO_BUCKETS[%d]: publicReadPrefix requires MINIO_ALLOW_PUBLIC", i)
+			}
+		}
+	}
 
 	return config, nil
 }
 
-func getEnv(key, defaultValue string) string {
-	value := os.Getenv(key)
+// validateCreds checks that the fields MINIO_CREDS_MODE needs are set and
+// that MINIO_SIGNATURE_VERSION works with it.
+func validateCreds(config MinIOConfig) error {
+	switch config.SignatureVersion {
+	case "v4":
+	case "v2":
+		if config.CredsMode != "static" {
+			return fmt.Errorf("MINIO_SIGNATURE_VERSION v2 requires MINIO_CREDS_MODE static")
+		}
+	default:
+		return fmt.Errorf("MINIO_SIGNATURE_VERSION must be v2 or v4, got %q", config.SignatureVersion)
+	}
+
+	switch config.CredsMode {
+	case "iam":
+		return nil
+	case "static", "sts":
+	default:
+		return fmt.Errorf("MINIO_CREDS_MODE must be one of static, iam, sts, got %q", config.CredsMode)
+	}
+
+	if config.AccessKeyID == "" {
+		return fmt.Errorf("MINIO_ACCESS_KEY is required")
+	}
+	if config.SecretAccessKey == "" {
+		return fmt.Errorf("MINIO_SECRET_KEY is required")
+	}
+	if config.CredsMode == "sts" {
+		if config.STSEndpoint == "" {
+			return fmt.Errorf("MINIO_STS_ENDPOINT is required when MINIO_CREDS_MODE is sts")
+		}
+		if config.RoleARN == "" {
+			return fmt.Errorf("MINIO_ROLE_ARN is required when MINIO_CREDS_MODE is sts")
+		}
+	}
+	return nil
+}
+
+// Defaults for MINIO_CORS_METHODS and MINIO_CORS_HEADERS.
+var (
+	defaultCORSMethods = []string{"GET", "HEAD", "POST", "PUT", "DELETE"}
+	defaultCORSHeaders = []string{"Authorization", "Content-Type", "If-Match", "If-None-Match", "Range", "X-Request-ID"}
+)
+
+// loadCORS validates MINIO_CORS_ORIGINS, which is empty (no cross-origin
+// access), "*", or a list of scheme://host[:port] origins, and fills in the
+// default methods and headers.
+func loadCORS(config *MinIOConfig) error {
+	for _, origin := range config.CORSOrigins {
+		if origin == "*" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+			u.Path != "" || u.RawQuery != "" || u.User != nil {
+			return fmt.Errorf("MINIO_CORS_ORIGINS: invalid origin %q, expected scheme://host[:port]", origin)
+		}
+	}
+
+	if len(config.CORSMethods) == 0 {
+		config.CORSMethods = slices.Clone(defaultCORSMethods)
+	}
+	for i, method := range config.CORSMethods {
+		config.CORSMethods[i] = strings.ToUpper(method)
+	}
+	if len(config.CORSHeaders) == 0 {
+		config.CORSHeaders = defaultCORSHeaders
+	}
+	return nil
+}
+
+// loadSSE reads MINIO_SSE and MINIO_SSE_C_KEY. MINIO_SSE defaults to "c"
+// when a key is given and "none" otherwise. SSE-C keys can't be carried in
+// presigned URLs, so enabling SSE-C also disables presigning.
+func loadSSE(env *envReader, config *MinIOConfig) error {
+	encodedKey := env.getEnv("MINIO_SSE_C_KEY", "")
+	defaultSSE := "none"
+	if encodedKey != "" {
+		defaultSSE = "c"
+	}
+	config.SSE = strings.ToLower(env.getEnv("MINIO_SSE", defaultSSE))
+
+	switch config.SSE {
+	case "none", "s3":
+		if encodedKey != "" {
+			return fmt.Errorf("MINIO_SSE_C_KEY is set but MINIO_SSE is %q", config.SSE)
+		}
+	case "c":
+		key, err := base64.StdEncoding.DecodeString(encodedKey)
+		if err != nil {
+			return fmt.Errorf("MINIO_SSE_C_KEY must be base64: %w", err)
+		}
+		if len(key) != 32 {
+			return fmt.Errorf("MINIO_SSE_C_KEY must decode to 32 bytes, got %d", len(key))
+		}
+		config.SSECKey = key
+		config.DisablePresign = true
+	default:
+		return fmt.Errorf("MINIO_SSE must be one of none, s3, c, got %q", config.SSE)
+	}
+	return nil
+}
+
+// validateExpectation checks that an object expectation and the key
+// prefixes it applies to are configured together.
+func validateExpectation(config MinIOConfig) error {
+	if config.ExpectMaxSize < 0 {
+		return fmt.Errorf("MINIO_EXPECT_MAX_SIZE must not be negative, got %d", config.ExpectMaxSize)
+	}
+	expecting := config.ExpectContentType != "" || config.ExpectMaxSize > 0
+	if expecting && len(config.ExpectPrefixes) == 0 {
+		return fmt.Errorf("MINIO_EXPECT_PREFIXES is required when MINIO_EXPECT_CONTENT_TYPE or MINIO_EXPECT_MAX_SIZE is set")
+	}
+	if !expecting && len(config.ExpectPrefixes) > 0 {
+		return fmt.Errorf("MINIO_EXPECT_PREFIXES requires MINIO_EXPECT_CONTENT_TYPE or MINIO_EXPECT_MAX_SIZE")
+	}
+
+	seen := make(map[string]bool, len(config.ExpectPrefixes))
+	for _, prefix := range config.ExpectPrefixes {
+		if strings.HasPrefix(prefix, "/") || !strings.HasSuffix(prefix, "/") {
+			return fmt.Errorf("MINIO_EXPECT_PREFIXES entries must be key prefixes ending in /, got %q", prefix)
+		}
+		if seen[prefix] {
+			return fmt.Errorf("MINIO_EXPECT_PREFIXES lists %q more than once", prefix)
+		}
+		seen[prefix] = true
+	}
+	return nil
+}
+
+// envSource looks up a configuration variable by its environment variable
+// name, returning "" if it is unset.
+type envSource func(key string) string
+
+// envReader reads typed configuration variables from src. A value that
+// doesn't parse leaves the default in place and is reported by err.
+type envReader struct {
+	src envSource
+	err error
+}
+
+// invalid records that key's value isn't a valid want, keeping only the
+// first such error.
+func (env *envReader) invalid(key, value, want string) {
+	if env.err == nil {
+		env.err = fmt.Errorf("%s must be %s, got %q", key, want, value)
+	}
+}
+
+func (env *envReader) getEnv(key, defaultValue string) string {
+	value := env.src(key)
 	if value == "" {
 		return defaultValue
 	}
 	return value
 }
 
-func getEnvBool(key string, defaultValue bool) bool {
-	value := os.Getenv(key)
+func (env *envReader) getEnvBool(key string, defaultValue bool) bool {
+	value := env.src(key)
 	if value == "" {
 		return defaultValue
 	}
 
 	boolValue, err := strconv.ParseBool(value)
 	if err != nil {
+		env.invalid(key, value, "a boolean")
 		return defaultValue
 	}
 
 	return boolValue
 }
+
+func (env *envReader) getEnvInt(key string, defaultValue int) int {
+	value := env.src(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	intValue, err := strconv.Atoi(value)
+	if err != nil {
+		env.invalid(key, value, "an integer")
+		return defaultValue
+	}
+
+	return intValue
+}
+
+func (env *envReader) getEnvInt64(key string, defaultValue int64) int64 {
+	value := env.src(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	intValue, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		env.invalid(key, value, "an integer")
+		return defaultValue
+	}
+
+	return intValue
+}
+
+func (env *envReader) getEnvFloat(key string, defaultValue float64) float64 {
+	value := env.src(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	floatValue, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		env.invalid(key, value, "a number")
+		return defaultValue
+	}
+
+	return floatValue
+}
+
+func (env *envReader) getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := env.src(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		env.invalid(key, value, "a duration such as 30s or 5m")
+		return defaultValue
+	}
+
+	return duration
+}
+
+func (env *envReader) getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(env.src(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
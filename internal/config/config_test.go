@@ -0,0 +1,268 @@
+package config
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mapSource returns an envSource reading from env instead of the process
+// environment.
+func mapSource(env map[string]string) envSource {
+	return func(key string) string { return env[key] }
+}
+
+func TestLoadMinIOConfigGzipResponses(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want bool
+	}{
+		{"default", nil, false},
+		{"enabled", map[string]string{"MINIO_GZIP_RESPONSES": "true"}, true},
+		{"disabled", map[string]string{"MINIO_GZIP_RESPONSES": "false"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := loadMinIOConfig(mapSource(tt.env))
+			if err != nil {
+				t.Fatalf("loadMinIOConfig() error = %v", err)
+			}
+			if config.GzipResponses != tt.want {
+				t.Errorf("GzipResponses = %v, want %v", config.GzipResponses, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadMinIOConfigBuckets(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    []BucketConfig
+		wantErr bool
+	}{
+		{name: "unset", env: nil, want: nil},
+		{
+			name: "list",
+			env:  map[string]string{"MINIO_BUCKETS": `[{"name":"logs","expireDays":30,"expirePrefix":"tmp/"},{"name":"media","region":"eu-west-1","versioning":true}]`},
+			want: []BucketConfig{
+				{Name: "logs", ExpireDays: 30, ExpirePrefix: "tmp/"},
+				{Name: "media", Region: "eu-west-1", Versioning: true},
+			},
+		},
+		{
+			name: "public with MINIO_ALLOW_PUBLIC",
+			env:  map[string]string{"MINIO_BUCKETS": `[{"name":"assets","publicReadPrefix":"img/"}]`, "MINIO_ALLOW_PUBLIC": "true"},
+			want: []BucketConfig{{Name: "assets", PublicReadPrefix: "img/"}},
+		},
+		{name: "public without MINIO_ALLOW_PUBLIC", env: map[string]string{"MINIO_BUCKETS": `[{"name":"assets","publicReadPrefix":"img/"}]`}, wantErr: true},
+		{name: "invalid name", env: map[string]string{"MINIO_BUCKETS": `[{"name":"Bad_Bucket"}]`}, wantErr: true},
+		{name: "negative expiry", env: map[string]string{"MINIO_BUCKETS": `[{"name":"logs","expireDays":-1}]`}, wantErr: true},
+		{name: "not JSON", env: map[string]string{"MINIO_BUCKETS": "logs,media"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := loadMinIOConfig(mapSource(tt.env))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadMinIOConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !slices.Equal(config.Buckets, tt.want) {
+				t.Errorf("Buckets = %+v, want %+v", config.Buckets, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadMinIOConfigAPITokens(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          map[string]string
+		wantTokens   []string
+		wantRequired bool
+		wantErr      bool
+	}{
+		{name: "none", env: nil, wantTokens: nil, wantRequired: false},
+		{name: "single", env: map[string]string{"MINIO_API_TOKEN": "gamma"}, wantTokens: []string{"gamma"}, wantRequired: true},
+		{name: "list", env: map[string]string{"MINIO_API_TOKENS": "alpha, beta"}, wantTokens: []string{"alpha", "beta"}, wantRequired: true},
+		{
+			name:         "list and single",
+			env:          map[string]string{"MINIO_API_TOKENS": "alpha,beta", "MINIO_API_TOKEN": "gamma"},
+			wantTokens:   []string{"alpha", "beta", "gamma"},
+			wantRequired: true,
+		},
+		{
+			name:         "optional",
+			env:          map[string]string{"MINIO_API_TOKENS": "alpha", "MINIO_AUTH_REQUIRED": "false"},
+			wantTokens:   []string{"alpha"},
+			wantRequired: false,
+		},
+		{name: "required without tokens", env: map[string]string{"MINIO_AUTH_REQUIRED": "true"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := loadMinIOConfig(mapSource(tt.env))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadMinIOConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !slices.Equal(config.APITokens, tt.wantTokens) {
+				t.Errorf("APITokens = %q, want %q", config.APITokens, tt.wantTokens)
+			}
+			if config.AuthRequired != tt.wantRequired {
+				t.Errorf("AuthRequired = %v, want %v", config.AuthRequired, tt.wantRequired)
+			}
+		})
+	}
+}
+
+func TestLoadMinIOConfigStatCacheTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "default", env: nil, want: 0},
+		{name: "enabled", env: map[string]string{"MINIO_STAT_CACHE_TTL": "30s"}, want: 30 * time.Second},
+		{name: "disabled", env: map[string]string{"MINIO_STAT_CACHE_TTL": "0s"}, want: 0},
+		{name: "negative", env: map[string]string{"MINIO_STAT_CACHE_TTL": "-1s"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := loadMinIOConfig(mapSource(tt.env))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadMinIOConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && config.StatCacheTTL != tt.want {
+				t.Errorf("StatCacheTTL = %v, want %v", config.StatCacheTTL, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadMinIOConfigSignatureVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    string
+		wantErr bool
+	}{
+		{name: "default", env: nil, want: "v4"},
+		{name: "v2", env: map[string]string{"MINIO_SIGNATURE_VERSION": "v2"}, want: "v2"},
+		{name: "uppercase", env: map[string]string{"MINIO_SIGNATURE_VERSION": "V2"}, want: "v2"},
+		{name: "unknown", env: map[string]string{"MINIO_SIGNATURE_VERSION": "v3"}, wantErr: true},
+		{name: "v2 with IAM", env: map[string]string{"MINIO_SIGNATURE_VERSION": "v2", "MINIO_CREDS_MODE": "iam"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := loadMinIOConfig(mapSource(tt.env))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadMinIOConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && config.SignatureVersion != tt.want {
+				t.Errorf("SignatureVersion = %q, want %q", config.SignatureVersion, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadMinIOConfigPresignExpiry(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		wantAnon time.Duration
+		wantAuth time.Duration
+		wantErr  bool
+	}{
+		{name: "defaults", env: nil, wantAnon: time.Hour, wantAuth: 24 * time.Hour},
+		{name: "auth follows the default expiry", env: map[string]string{"MINIO_PRESIGN_EXPIRY": "2h"}, wantAnon: time.Hour, wantAuth: 2 * time.Hour},
+		{name: "set", env: map[string]string{"MINIO_PRESIGN_EXPIRY_ANON": "5m", "MINIO_PRESIGN_EXPIRY_AUTH": "168h"}, wantAnon: 5 * time.Minute, wantAuth: 168 * time.Hour},
+		{name: "anon zero", env: map[string]string{"MINIO_PRESIGN_EXPIRY_ANON": "0s"}, wantErr: true},
+		{name: "anon negative", env: map[string]string{"MINIO_PRESIGN_EXPIRY_ANON": "-5m"}, wantErr: true},
+		{name: "anon too long", env: map[string]string{"MINIO_PRESIGN_EXPIRY_ANON": "169h"}, wantErr: true},
+		{name: "auth zero", env: map[string]string{"MINIO_PRESIGN_EXPIRY_AUTH": "0s"}, wantErr: true},
+		{name: "auth too long", env: map[string]string{"MINIO_PRESIGN_EXPIRY_AUTH": "200h"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := loadMinIOConfig(mapSource(tt.env))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadMinIOConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if config.PresignExpiryAnon != tt.wantAnon || config.PresignExpiryAuth != tt.wantAuth {
+				t.Errorf("expiries = %s anonymous, %s authenticated, want %s and %s",
+					config.PresignExpiryAnon, config.PresignExpiryAuth, tt.wantAnon, tt.wantAuth)
+			}
+		})
+	}
+}
+
+func TestLoadMinIOConfigExpectPrefixes(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    []string
+		wantErr bool
+	}{
+		{name: "unset", env: nil},
+		{name: "set", env: map[string]string{"MINIO_EXPECT_MAX_SIZE": "100", "MINIO_EXPECT_PREFIXES": "configs/, feeds/json/"}, want: []string{"configs/", "feeds/json/"}},
+		{name: "expectation without prefixes", env: map[string]string{"MINIO_EXPECT_CONTENT_TYPE": "application/json"}, wantErr: true},
+		{name: "prefixes without expectation", env: map[string]string{"MINIO_EXPECT_PREFIXES": "configs/"}, wantErr: true},
+		{name: "missing trailing slash", env: map[string]string{"MINIO_EXPECT_MAX_SIZE": "100", "MINIO_EXPECT_PREFIXES": "configs"}, wantErr: true},
+		{name: "leading slash", env: map[string]string{"MINIO_EXPECT_MAX_SIZE": "100", "MINIO_EXPECT_PREFIXES": "/configs/"}, wantErr: true},
+		{name: "duplicate", env: map[string]string{"MINIO_EXPECT_MAX_SIZE": "100", "MINIO_EXPECT_PREFIXES": "configs/,configs/"}, wantErr: true},
+		{name: "negative size", env: map[string]string{"MINIO_EXPECT_MAX_SIZE": "-1", "MINIO_EXPECT_PREFIXES": "configs/"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := loadMinIOConfig(mapSource(tt.env))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadMinIOConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !slices.Equal(config.ExpectPrefixes, tt.want) {
+				t.Errorf("ExpectPrefixes = %q, want %q", config.ExpectPrefixes, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadMinIOConfigInvalidValues(t *testing.T) {
+	tests := []struct {
+		key   string
+		value string
+	}{
+		{"MINIO_USE_SSL", "sometimes"},
+		{"MINIO_UPLOAD_THREADS", "four"},
+		{"MINIO_PART_SIZE", "64MiB"},
+		{"MINIO_RATE_LIMIT", "fast"},
+		{"MINIO_PRESIGN_EXPIRY", "24"},
+		{"MINIO_AUTH_EXEMPT_HEALTH", "nope"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			_, err := loadMinIOConfig(mapSource(map[string]string{tt.key: tt.value}))
+			if err == nil {
+				t.Fatalf("loadMinIOConfig() with %s=%q succeeded, want an error", tt.key, tt.value)
+			}
+			if msg := err.Error(); !strings.Contains(msg, tt.key) || !strings.Contains(msg, strconv.Quote(tt.value)) {
+				t.Errorf("error = %q, want it to name %s and %q", msg, tt.key, tt.value)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadMinIOConfigFromFile reads the configuration from a YAML or JSON file
+// whose keys are the environment variable names, e.g.
+//
+//	MINIO_ENDPOINT: minio:9000
+//	MINIO_API_TOKENS: [token-a, token-b]
+//	MINIO_BUCKETS:
+//	  - name: reports
+//	    expireDays: 30
+//	MINIO_REGION_ENDPOINTS:
+//	  eu-west-1: minio-eu:9000
+//
+// Environment variables that are set take precedence over the file.
+func LoadMinIOConfigFromFile(path string) (MinIOConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return MinIOConfig{}, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	values, err := parseConfigFile(data)
+	if err != nil {
+		return MinIOConfig{}, fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	config, err := loadMinIOConfig(func(key string) string {
+		if value := os.Getenv(key); value != "" {
+			return value
+		}
+		return values[key]
+	})
+	if err != nil {
+		return config, fmt.Errorf("config file %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// parseConfigFile flattens a config file into environment variable values:
+// scalars as their string form, lists of scalars comma-separated, and
+// MINIO_BUCKETS and mappings as JSON.
+func parseConfigFile(data []byte) (map[string]string, error) {
+	// JSON is a subset of YAML, so one decoder handles both.
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid YAML or JSON: %w", err)
+	}
+
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	known := knownKeys()
+	values := make(map[string]string, len(raw))
+	for _, key := range keys {
+		if !known[key] {
+			return nil, fmt.Errorf("%s: unknown key, expected a variable name such as PORT or MINIO_ENDPOINT", key)
+		}
+
+		switch value := raw[key].(type) {
+		case nil:
+		case map[string]any:
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			values[key] = string(encoded)
+		case []any:
+			if key == "MINIO_BUCKETS" {
+				encoded, err := json.Marshal(value)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", key, err)
+				}
+				values[key] = string(encoded)
+				continue
+			}
+			items := make([]string, 0, len(value))
+			for i, item := range value {
+				if !isScalar(item) {
+					return nil, fmt.Errorf("%s[%d]: expected a scalar value", key, i)
+				}
+				items = append(items, fmt.Sprint(item))
+			}
+			values[key] = strings.Join(items, ",")
+		default:
+			if !isScalar(value) {
+				return nil, fmt.Errorf("%s: expected a scalar value, a list or a mapping", key)
+			}
+			values[key] = fmt.Sprint(value)
+		}
+	}
+	return values, nil
+}
+
+// knownKeys returns the variable names loadMinIOConfig reads, which are the
+// keys a config file may set.
+func knownKeys() map[string]bool {
+	keys := make(map[string]bool)
+	loadMinIOConfig(func(key string) string {
+		keys[key] = true
+		return ""
+	})
+	return keys
+}
+
+func isScalar(value any) bool {
+	switch value.(type) {
+	case string, bool, int, int64, uint64, float64:
+		return true
+	}
+	return false
+}
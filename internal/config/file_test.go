@@ -0,0 +1,65 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadMinIOConfigFromFile(t *testing.T) {
+	tests := []struct {
+		name         string
+		file         string
+		wantPort     string
+		wantSelfTest bool
+		wantErr      string
+	}{
+		{"defaults", "MINIO_BUCKET: reports\n", "8080", false, ""},
+		{"port and self-test", "PORT: 9090\nMINIO_SELFTEST: true\n", "9090", true, ""},
+		{"JSON", `{"PORT": "7070"}`, "7070", false, ""},
+		{"misspelled key", "MINIO_ENDPIONT: minio:9000\n", "", false, "MINIO_ENDPIONT: unknown key"},
+		{"foreign key", "HOME: /root\n", "", false, "HOME: unknown key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("PORT", "")
+			t.Setenv("MINIO_SELFTEST", "")
+
+			config, err := LoadMinIOConfigFromFile(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadMinIOConfigFromFile() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadMinIOConfigFromFile() error = %v", err)
+			}
+			if config.Port != tt.wantPort || config.SelfTest != tt.wantSelfTest {
+				t.Errorf("Port = %q, SelfTest = %v, want %q, %v", config.Port, config.SelfTest, tt.wantPort, tt.wantSelfTest)
+			}
+		})
+	}
+}
+
+func TestLoadMinIOConfigFromFileEnvOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("PORT: 9090\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PORT", "6060")
+
+	config, err := LoadMinIOConfigFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadMinIOConfigFromFile() error = %v", err)
+	}
+	if config.Port != "6060" {
+		t.Errorf("Port = %q, want the environment's 6060", config.Port)
+	}
+}
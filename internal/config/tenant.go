@@ -0,0 +1,21 @@
+package config
+
+import "fmt"
+
+// ValidateTenantID checks that id can be used as the first segment of object
+// keys: 1 to 63 letters, digits, hyphens and underscores, starting with a
+// letter or digit.
+func ValidateTenantID(id string) error {
+	if id == "" || len(id) > 63 {
+		return fmt.Errorf("tenant ID %q must be between 1 and 63 characters long", id)
+	}
+	for i, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case (c == '-' || c == '_') && i > 0:
+		default:
+			return fmt.Errorf("tenant ID %q may only contain letters, digits, hyphens and underscores, starting with a letter or digit", id)
+		}
+	}
+	return nil
+}
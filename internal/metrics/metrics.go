@@ -0,0 +1,65 @@
+// Package metrics holds the Prometheus metrics exported on /metrics.
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Operation names used as the "operation" label.
+const (
+	OpUpload   = "upload"
+	OpDownload = "download"
+	OpList     = "list"
+	OpDelete   = "delete"
+)
+
+var (
+	operations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "minio_operations_total",
+		Help: "Storage operations by operation and status.",
+	}, []string{"operation", "status"})
+
+	operationErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "minio_operation_errors_total",
+		Help: "Failed storage operations by operation.",
+	}, []string{"operation"})
+
+	operationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "minio_operation_duration_seconds",
+		Help:    "Storage operation latency by operation and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"operation", "status"})
+
+	uploadedBytes = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "minio_uploaded_bytes",
+		Help:    "Size of successfully uploaded objects.",
+		Buckets: prometheus.ExponentialBuckets(1<<10, 4, 12),
+	})
+)
+
+// ObserveOperation records one operation that started at start. err points
+// at the operation's result so it can be passed to defer before it is set.
+func ObserveOperation(operation string, start time.Time, err *error) {
+	status := "success"
+	if *err != nil {
+		status = "failure"
+		operationErrors.WithLabelValues(operation).Inc()
+	}
+	operations.WithLabelValues(operation, status).Inc()
+	operationDuration.WithLabelValues(operation, status).Observe(time.Since(start).Seconds())
+}
+
+// ObserveUploadedBytes records the size of a successful upload.
+func ObserveUploadedBytes(size int64) {
+	uploadedBytes.Observe(float64(size))
+}
+
+// Handler serves the registered metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// archiveConcurrency bounds the server-side copies ArchiveByDate has in
+// flight at once.
+const archiveConcurrency = 8
+
+// ArchiveByDate copies every object under srcPrefix last modified more than
+// olderThan ago to archivePrefix/YYYY/MM/DD/<key relative to srcPrefix>,
+// partitioned by its LastModified date in UTC. Copies are server-side and the
+// listing is streamed, so nothing is buffered. With deleteSource set each
+// source is removed once its copy succeeds. It returns the number of objects
+// archived; on error some objects may already have been archived.
+func (s *MinIOService) ArchiveByDate(ctx context.Context, srcPrefix, archivePrefix string, olderThan time.Duration, deleteSource bool) (int, error) {
+	if archivePrefix == "" {
+		return 0, fmt.Errorf("archive prefix is required")
+	}
+	if !strings.HasSuffix(archivePrefix, "/") {
+		archivePrefix += "/"
+	}
+	if strings.HasPrefix(archivePrefix, srcPrefix) || strings.HasPrefix(srcPrefix, archivePrefix) {
+		return 0, fmt.Errorf("archive prefix '%s' must not overlap source prefix '%s'", archivePrefix, srcPrefix)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	cutoff := time.Now().Add(-olderThan)
+	jobs := make(chan minio.ObjectInfo)
+	var archived atomic.Int64
+	var firstErr error
+	var errOnce sync.Once
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < archiveConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for object := range jobs {
+				if err := s.archiveObject(ctx, object, srcPrefix, archivePrefix, deleteSource); err != nil {
+					fail(err)
+					continue
+				}
+				archived.Add(1)
+			}
+		}()
+	}
+
+	objectCh := s.Client.ListObjects(ctx, s.BucketName, minio.ListObjectsOptions{
+		Prefix:    srcPrefix,
+		Recursive: true,
+	})
+	for object := range objectCh {
+		if object.Err != nil {
+			fail(fmt.Errorf("error listing objects: %w", object.Err))
+			break
+		}
+		if IsSystemKey(object.Key) || !object.LastModified.Before(cutoff) {
+			continue
+		}
+		select {
+		case jobs <- object:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return int(archived.Load()), firstErr
+}
+
+func (s *MinIOService) archiveObject(ctx context.Context, object minio.ObjectInfo, srcPrefix, archivePrefix string, deleteSource bool) error {
+	dst := archivePrefix + object.LastModified.UTC().Format("2006/01/02/") + strings.TrimPrefix(object.Key, srcPrefix)
+
+	dstOpts, srcOpts := s.copyOptions(object.Key, dst)
+	_, err := s.Client.CopyObject(ctx, dstOpts, srcOpts)
+	if err != nil {
+		return fmt.Errorf("failed to archive '%s': %w", object.Key, err)
+	}
+
+	if deleteSource {
+		defer s.stats.invalidate(object.Key)
+		err = s.Client.RemoveObject(ctx, s.BucketName, object.Key, minio.RemoveObjectOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to remove archived source '%s': %w", object.Key, err)
+		}
+	}
+
+	return nil
+}
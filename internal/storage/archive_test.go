@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"MinIO-Learn/internal/storage/storagetest"
+)
+
+// backdatedBackends returns the test backends with a function that stores
+// an object last modified at the given time.
+func backdatedBackends(t *testing.T) map[string]struct {
+	store Storage
+	put   func(key string, modified time.Time)
+} {
+	t.Helper()
+	fake := storagetest.NewFakeS3()
+	memory := NewMemoryStorage("test-bucket")
+	return map[string]struct {
+		store Storage
+		put   func(key string, modified time.Time)
+	}{
+		"minio": {newTestService(t, fake, Config{}), func(key string, modified time.Time) {
+			object := fake.PutObject("test-bucket", key, []byte(key), http.Header{"Content-Type": {"text/plain"}})
+			object.LastModified = modified
+		}},
+		"memory": {memory, func(key string, modified time.Time) {
+			if _, err := memory.UploadBuffer(context.Background(), key, []byte(key), "text/plain", nil); err != nil {
+				t.Fatalf("UploadBuffer() error = %v", err)
+			}
+			memory.mu.Lock()
+			versions := memory.objects[key]
+			versions[len(versions)-1].info.LastModified = modified
+			memory.mu.Unlock()
+		}},
+	}
+}
+
+func TestArchiveByDate(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	objects := map[string]time.Time{
+		"uploads/old.txt":          time.Date(2025, 3, 4, 10, 0, 0, 0, time.UTC),
+		"uploads/nested/older.txt": time.Date(2024, 12, 31, 23, 30, 0, 0, time.UTC),
+		"uploads/recent.txt":       now.Add(-time.Hour),
+		"other/old.txt":            time.Date(2025, 3, 4, 10, 0, 0, 0, time.UTC),
+	}
+	archived := []string{"archive/2024/12/31/nested/older.txt", "archive/2025/03/04/old.txt"}
+
+	tests := []struct {
+		name          string
+		archivePrefix string
+		deleteSource  bool
+		wantArchived  int
+		wantKeys      []string
+		wantErr       bool
+	}{
+		{
+			name:          "copies old objects",
+			archivePrefix: "archive",
+			wantArchived:  2,
+			wantKeys:      append(slices.Clone(archived), "other/old.txt", "uploads/nested/older.txt", "uploads/old.txt", "uploads/recent.txt"),
+		},
+		{
+			name:          "moves old objects",
+			archivePrefix: "archive/",
+			deleteSource:  true,
+			wantArchived:  2,
+			wantKeys:      append(slices.Clone(archived), "other/old.txt", "uploads/recent.txt"),
+		},
+		{
+			name:          "overlapping prefixes",
+			archivePrefix: "uploads/archive/",
+			wantErr:       true,
+			wantKeys:      []string{"other/old.txt", "uploads/nested/older.txt", "uploads/old.txt", "uploads/recent.txt"},
+		},
+		{
+			name:     "missing archive prefix",
+			wantErr:  true,
+			wantKeys: []string{"other/old.txt", "uploads/nested/older.txt", "uploads/old.txt", "uploads/recent.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, backend := range backdatedBackends(t) {
+				t.Run(name, func(t *testing.T) {
+					ctx := context.Background()
+					for key, modified := range objects {
+						backend.put(key, modified)
+					}
+
+					n, err := backend.store.ArchiveByDate(ctx, "uploads/", tt.archivePrefix, 24*time.Hour, tt.deleteSource)
+					if (err != nil) != tt.wantErr {
+						t.Fatalf("ArchiveByDate() error = %v, wantErr %v", err, tt.wantErr)
+					}
+					if n != tt.wantArchived {
+						t.Errorf("archived %d objects, want %d", n, tt.wantArchived)
+					}
+
+					listed, err := backend.store.ListObjects(ctx, "")
+					if err != nil {
+						t.Fatalf("ListObjects() error = %v", err)
+					}
+					var keys []string
+					for _, object := range listed {
+						keys = append(keys, object.Key)
+					}
+					slices.Sort(keys)
+					if !slices.Equal(keys, tt.wantKeys) {
+						t.Errorf("keys = %v, want %v", keys, tt.wantKeys)
+					}
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
+)
+
+var ErrBucketExists = errors.New("bucket already exists")
+
+// BucketSpec describes a bucket and the settings it should have.
+type BucketSpec struct {
+	Name             string
+	Region           string
+	Versioning       bool
+	ExpireDays       int
+	ExpirePrefix     string
+	PublicReadPrefix string
+}
+
+// BucketProvisionResult reports what EnsureBuckets did for one bucket.
+type BucketProvisionResult struct {
+	Name    string
+	Created bool
+}
+
+// EnsureBuckets creates every missing bucket in specs and applies its
+// versioning, lifecycle and public-read settings. Each setting is applied
+// idempotently, so running it against an already provisioned backend changes
+// nothing.
+func (s *MinIOService) EnsureBuckets(ctx context.Context, specs []BucketSpec) ([]BucketProvisionResult, error) {
+	results := make([]BucketProvisionResult, 0, len(specs))
+	for _, spec := range specs {
+		created, err := s.ensureBucketSpec(ctx, spec)
+		if err != nil {
+			return results, fmt.Errorf("bucket '%s': %w", spec.Name, err)
+		}
+		results = append(results, BucketProvisionResult{Name: spec.Name, Created: created})
+	}
+
+	return results, nil
+}
+
+func (s *MinIOService) ensureBucketSpec(ctx context.Context, spec BucketSpec) (bool, error) {
+	region := spec.Region
+	if region == "" {
+		region = s.Location
+	}
+
+	exists, err := s.Client.BucketExists(ctx, spec.Name)
+	if err != nil {
+		return false, fmt.Errorf("failed to check if bucket exists: %w", err)
+	}
+	if !exists {
+		err = s.Client.MakeBucket(ctx, spec.Name, minio.MakeBucketOptions{Region: region})
+		if err != nil && !bucketAlreadyCreated(err) {
+			return false, fmt.Errorf("failed to create bucket: %w", classifyError(err))
+		}
+	}
+
+	if spec.Versioning {
+		versioning, err := s.Client.GetBucketVersioning(ctx, spec.Name)
+		if err != nil {
+			return !exists, fmt.Errorf("failed to get versioning: %w", err)
+		}
+		if !versioning.Enabled() {
+			if err := s.Client.EnableVersioning(ctx, spec.Name); err != nil {
+				return !exists, fmt.Errorf("failed to enable versioning: %w", err)
+			}
+		}
+	}
+
+	if spec.ExpireDays > 0 {
+		config := lifecycle.NewConfiguration()
+		config.Rules = []lifecycle.Rule{expirationRule(spec.ExpirePrefix, spec.ExpireDays)}
+		if err := s.Client.SetBucketLifecycle(ctx, spec.Name, config); err != nil {
+			return !exists, fmt.Errorf("failed to set lifecycle: %w", err)
+		}
+	}
+
+	if spec.PublicReadPrefix != "" {
+		if err := s.setPublicRead(ctx, spec.Name, spec.PublicReadPrefix); err != nil {
+			return !exists, err
+		}
+	}
+
+	return !exists, nil
+}
+
+// bucketAlreadyCreated reports whether MakeBucket failed because the bucket
+// exists, typically because it was created between our existence check and
+// the call.
+func bucketAlreadyCreated(err error) bool {
+	switch minio.ToErrorResponse(err).Code {
+	case "BucketAlreadyOwnedByYou", "BucketAlreadyExists":
+		return true
+	}
+	return false
+}
+
+func expirationRule(prefix string, days int) lifecycle.Rule {
+	return lifecycle.Rule{
+		ID:         "expire-" + prefix,
+		Status:     "Enabled",
+		RuleFilter: lifecycle.Filter{Prefix: prefix},
+		Expiration: lifecycle.Expiration{Days: lifecycle.ExpirationDays(days)},
+	}
+}
+
+// ListBuckets returns every bucket visible to the service's credentials.
+func (s *MinIOService) ListBuckets(ctx context.Context) ([]minio.BucketInfo, error) {
+	buckets, err := s.Client.ListBuckets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list buckets: %w", classifyError(err))
+	}
+	return buckets, nil
+}
+
+// CreateBucket creates a bucket named name in region, or in the service's
+// location if region is empty. It returns ErrInvalidBucketName for names S3
+// would reject and ErrBucketExists if the bucket is already there.
+func (s *MinIOService) CreateBucket(ctx context.Context, name, region string) error {
+	if err := ValidateBucketName(name); err != nil {
+		return err
+	}
+	if region == "" {
+		region = s.Location
+	}
+
+	err := s.Client.MakeBucket(ctx, name, minio.MakeBucketOptions{Region: region})
+	if bucketAlreadyCreated(err) {
+		return fmt.Errorf("%w: '%s'", ErrBucketExists, name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create bucket: %w", classifyError(err))
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"MinIO-Learn/internal/storage/storagetest"
+)
+
+func TestEnsureBuckets(t *testing.T) {
+	specs := []BucketSpec{
+		{Name: "plain"},
+		{Name: "versioned", Region: "eu-west-1", Versioning: true},
+		{Name: "expiring", ExpireDays: 7, ExpirePrefix: "tmp/"},
+		{Name: "public", PublicReadPrefix: "assets/"},
+	}
+
+	fake := storagetest.NewFakeS3()
+	service := newTestService(t, fake, Config{AllowPublic: true})
+	ctx := context.Background()
+
+	results, err := service.EnsureBuckets(ctx, specs)
+	if err != nil {
+		t.Fatalf("EnsureBuckets() error = %v", err)
+	}
+	for i, result := range results {
+		if result.Name != specs[i].Name || !result.Created {
+			t.Errorf("first run result %d = %+v, want %s created", i, result, specs[i].Name)
+		}
+	}
+	if got, want := fake.Buckets(), []string{"expiring", "plain", "public", "test-bucket", "versioned"}; !slices.Equal(got, want) {
+		t.Errorf("buckets = %v, want %v", got, want)
+	}
+
+	settings := []struct {
+		bucket, subresource, want string
+	}{
+		{"versioned", "location", "eu-west-1"},
+		{"versioned", "versioning", "<Status>Enabled</Status>"},
+		{"expiring", "lifecycle", "<Days>7</Days>"},
+		{"expiring", "lifecycle", "<Prefix>tmp/</Prefix>"},
+		{"public", "policy", "arn:aws:s3:::public/assets/*"},
+	}
+	for _, setting := range settings {
+		body, ok := fake.BucketConfig(setting.bucket, setting.subresource)
+		if !ok || !strings.Contains(string(body), setting.want) {
+			t.Errorf("%s %s = %q, want it to contain %q", setting.bucket, setting.subresource, body, setting.want)
+		}
+	}
+	if _, ok := fake.BucketConfig("plain", "versioning"); ok {
+		t.Error("versioning was configured on a bucket that didn't ask for it")
+	}
+
+	creates := fake.Count(http.MethodPut, "plain", "")
+	results, err = service.EnsureBuckets(ctx, specs)
+	if err != nil {
+		t.Fatalf("second EnsureBuckets() error = %v", err)
+	}
+	for i, result := range results {
+		if result.Created {
+			t.Errorf("second run result %d = %+v, want already present", i, result)
+		}
+	}
+	if got := fake.Count(http.MethodPut, "plain", ""); got != creates {
+		t.Errorf("second run sent %d more PUTs to an existing bucket", got-creates)
+	}
+}
+
+func TestEnsureBucketsPublicDisabled(t *testing.T) {
+	service := newTestService(t, storagetest.NewFakeS3(), Config{})
+
+	_, err := service.EnsureBuckets(context.Background(), []BucketSpec{{Name: "public", PublicReadPrefix: "assets/"}})
+	if !errors.Is(err, ErrPublicAccessDisabled) {
+		t.Errorf("EnsureBuckets() error = %v, want ErrPublicAccessDisabled", err)
+	}
+}
+
+func TestMemoryEnsureBuckets(t *testing.T) {
+	m := NewMemoryStorage("test-bucket")
+	specs := []BucketSpec{{Name: "alpha"}, {Name: "test-bucket"}}
+
+	tests := []struct {
+		name        string
+		wantCreated []bool
+	}{
+		{"first run", []bool{true, false}},
+		{"second run", []bool{false, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := m.EnsureBuckets(context.Background(), specs)
+			if err != nil {
+				t.Fatalf("EnsureBuckets() error = %v", err)
+			}
+			for i, result := range results {
+				if result.Created != tt.wantCreated[i] {
+					t.Errorf("%s created = %v, want %v", result.Name, result.Created, tt.wantCreated[i])
+				}
+			}
+		})
+	}
+}
+
+func TestEnsureBucketConcurrentCreate(t *testing.T) {
+	fake := storagetest.NewFakeS3()
+	service := newTestService(t, fake, Config{})
+	// Both instances see the bucket missing, as they would if each checked
+	// before either created it, so one of the two creates is refused with
+	// BucketAlreadyOwnedByYou.
+	fake.Before = func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method == http.MethodHead && strings.TrimSuffix(r.URL.Path, "/") == "/racing" {
+			w.WriteHeader(http.StatusNotFound)
+			return true
+		}
+		return false
+	}
+
+	errs := make([]error, 2)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = service.WithBucket("racing").EnsureBucket(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("EnsureBucket() on instance %d error = %v", i, err)
+		}
+	}
+	if got := fake.Count(http.MethodPut, "racing", ""); got != 2 {
+		t.Errorf("bucket creates = %d, want 2", got)
+	}
+}
+
+func TestCreateBucketConcurrent(t *testing.T) {
+	for name, store := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			errs := make([]error, 2)
+			var wg sync.WaitGroup
+			for i := range errs {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					errs[i] = store.CreateBucket(context.Background(), "shared", "")
+				}()
+			}
+			wg.Wait()
+
+			var created, exists int
+			for _, err := range errs {
+				switch {
+				case err == nil:
+					created++
+				case errors.Is(err, ErrBucketExists):
+					exists++
+				default:
+					t.Errorf("CreateBucket() error = %v, want nil or ErrBucketExists", err)
+				}
+			}
+			if created != 1 || exists != 1 {
+				t.Errorf("%d creates succeeded and %d found the bucket, want one each", created, exists)
+			}
+		})
+	}
+}
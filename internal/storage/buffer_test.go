@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"MinIO-Learn/internal/storage/storagetest"
+)
+
+func TestDownloadBufferTooLarge(t *testing.T) {
+	const limit = 1 << 10
+
+	t.Run("minio", func(t *testing.T) {
+		fake := storagetest.NewFakeS3()
+		object := fake.PutObject("test-bucket", "huge.bin", bytes.Repeat([]byte("x"), 4<<20), http.Header{
+			"Content-Type": {"application/octet-stream"},
+		})
+		// The object's body is never sent: reading it would hang until the
+		// client gives up, so only a size check on the headers can pass.
+		fake.Before = func(w http.ResponseWriter, r *http.Request) bool {
+			if r.Method != http.MethodGet || r.URL.Path != "/test-bucket/huge.bin" {
+				return false
+			}
+			w.Header().Set("Content-Length", strconv.Itoa(len(object.Data)))
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("ETag", `"`+object.ETag+`"`)
+			w.Header().Set("Last-Modified", object.LastModified.UTC().Format(http.TimeFormat))
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+			return true
+		}
+		service := newTestService(t, fake, Config{MaxDownloadBuffer: limit})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		data, err := service.DownloadBuffer(ctx, "huge.bin")
+		if !errors.Is(err, ErrObjectTooLarge) {
+			t.Fatalf("DownloadBuffer() error = %v, want ErrObjectTooLarge", err)
+		}
+		if ctx.Err() != nil {
+			t.Errorf("DownloadBuffer() waited for the body before failing")
+		}
+		if data != nil {
+			t.Errorf("DownloadBuffer() returned %d bytes, want none", len(data))
+		}
+	})
+
+	t.Run("memory", func(t *testing.T) {
+		ctx := context.Background()
+		store := NewMemoryStorage("test-bucket")
+		store.SetMaxDownloadBuffer(limit)
+		if _, err := store.UploadBuffer(ctx, "huge.bin", bytes.Repeat([]byte("x"), limit+1), "application/octet-stream", nil); err != nil {
+			t.Fatalf("UploadBuffer() error = %v", err)
+		}
+		if _, err := store.UploadBuffer(ctx, "fits.bin", bytes.Repeat([]byte("x"), limit), "application/octet-stream", nil); err != nil {
+			t.Fatalf("UploadBuffer() error = %v", err)
+		}
+
+		if data, err := store.DownloadBuffer(ctx, "huge.bin"); !errors.Is(err, ErrObjectTooLarge) || data != nil {
+			t.Errorf("DownloadBuffer() over the limit = %d bytes, %v, want ErrObjectTooLarge", len(data), err)
+		}
+		if data, err := store.DownloadBuffer(ctx, "fits.bin"); err != nil || len(data) != limit {
+			t.Errorf("DownloadBuffer() at the limit = %d bytes, %v, want %d", len(data), err, limit)
+		}
+	})
+}
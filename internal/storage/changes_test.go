@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestListModifiedSince(t *testing.T) {
+	base := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	objects := map[string]time.Time{
+		"uploads/a.txt":      base.Add(-time.Hour),
+		"uploads/b.txt":      base,
+		"uploads/c.txt":      base.Add(2 * time.Hour),
+		"uploads/sub/d.txt":  base.Add(time.Hour),
+		"other/e.txt":        base.Add(3 * time.Hour),
+		SystemPrefix + "f":   base.Add(3 * time.Hour),
+		"uploads/z-old.txt":  base.Add(-48 * time.Hour),
+		"uploads/m-late.txt": base.Add(30 * time.Minute),
+	}
+
+	tests := []struct {
+		name   string
+		prefix string
+		since  time.Time
+		want   []string
+	}{
+		{"after timestamp, oldest first", "uploads/", base.Add(time.Minute), []string{"uploads/m-late.txt", "uploads/sub/d.txt", "uploads/c.txt"}},
+		{"at timestamp included", "uploads/", base, []string{"uploads/b.txt", "uploads/m-late.txt", "uploads/sub/d.txt", "uploads/c.txt"}},
+		{"nothing newer", "uploads/", base.Add(24 * time.Hour), nil},
+		{"whole bucket", "", base.Add(150 * time.Minute), []string{"other/e.txt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, backend := range backdatedBackends(t) {
+				t.Run(name, func(t *testing.T) {
+					for key, modified := range objects {
+						backend.put(key, modified)
+					}
+
+					changed, err := backend.store.ListModifiedSince(context.Background(), tt.prefix, tt.since)
+					if err != nil {
+						t.Fatalf("ListModifiedSince() error = %v", err)
+					}
+					var keys []string
+					for _, object := range changed {
+						keys = append(keys, object.Key)
+					}
+					if !slices.Equal(keys, tt.want) {
+						t.Errorf("keys = %v, want %v", keys, tt.want)
+					}
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"MinIO-Learn/internal/metrics"
+	"github.com/minio/minio-go/v7"
+)
+
+var (
+	ErrChecksumMismatch = errors.New("object checksum mismatch")
+	ErrNoChecksum       = errors.New("object has no stored checksum")
+)
+
+// checksumKey is the user metadata key holding the lowercase hex SHA-256 of
+// an object's content, recorded at upload.
+const checksumKey = "Content-Sha256"
+
+// ChecksumMetadata returns user metadata recording checksum, the lowercase
+// hex SHA-256 of the content, for callers that hash while they read.
+func ChecksumMetadata(checksum string) map[string]string {
+	return map[string]string{checksumKey: checksum}
+}
+
+// ObjectChecksum returns the SHA-256 recorded for an object at upload, or ""
+// if it was stored without one.
+func ObjectChecksum(info minio.ObjectInfo) string {
+	return info.UserMetadata[checksumKey]
+}
+
+// withChecksum returns metadata plus the SHA-256 of content, then rewinds
+// content. Metadata that already carries a checksum is returned as-is.
+func withChecksum(metadata map[string]string, content io.ReadSeeker) (map[string]string, error) {
+	if metadata[checksumKey] != "" {
+		return metadata, nil
+	}
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, content); err != nil {
+		return nil, fmt.Errorf("failed to compute checksum: %w", err)
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind after checksum: %w", err)
+	}
+
+	withSum := make(map[string]string, len(metadata)+1)
+	for k, v := range metadata {
+		withSum[k] = v
+	}
+	withSum[checksumKey] = hex.EncodeToString(hasher.Sum(nil))
+	return withSum, nil
+}
+
+// VerifyObject reads the object back and compares its SHA-256 with the one
+// recorded at upload. It returns ErrChecksumMismatch if they differ and
+// ErrNoChecksum if the object was stored without one.
+func (s *MinIOService) VerifyObject(ctx context.Context, objectName string) (err error) {
+	defer s.observe(ctx, metrics.OpDownload, slog.String("object", objectName), time.Now(), nil, &err)
+
+	obj, err := s.Client.GetObject(ctx, s.BucketName, objectName, s.getOptions())
+	if err != nil {
+		return fmt.Errorf("failed to get object: %w", err)
+	}
+	defer obj.Close()
+
+	info, err := obj.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat object: %w", err)
+	}
+	expected := ObjectChecksum(info)
+	if expected == "" {
+		return ErrNoChecksum
+	}
+
+	content, err := decodeContent(info, obj)
+	if err != nil {
+		return err
+	}
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, content); err != nil {
+		return fmt.Errorf("failed to read object data: %w", err)
+	}
+
+	if actual := hex.EncodeToString(hasher.Sum(nil)); actual != expected {
+		return fmt.Errorf("%w: stored %s, computed %s", ErrChecksumMismatch, expected, actual)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"MinIO-Learn/internal/storage/storagetest"
+)
+
+func TestVerifyObject(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(object *storagetest.Object, memory *memoryObject)
+		wantErr error
+	}{
+		{"intact", nil, nil},
+		{"content changed", func(object *storagetest.Object, memory *memoryObject) {
+			if object != nil {
+				object.Data[0] ^= 0xff
+			} else {
+				memory.data[0] ^= 0xff
+			}
+		}, ErrChecksumMismatch},
+		{"checksum changed", func(object *storagetest.Object, memory *memoryObject) {
+			const other = "0000000000000000000000000000000000000000000000000000000000000000"
+			if object != nil {
+				object.Header.Set("X-Amz-Meta-"+checksumKey, other)
+			} else {
+				memory.info.UserMetadata[checksumKey] = other
+			}
+		}, ErrChecksumMismatch},
+		{"checksum missing", func(object *storagetest.Object, memory *memoryObject) {
+			if object != nil {
+				object.Header.Del("X-Amz-Meta-" + checksumKey)
+			} else {
+				delete(memory.info.UserMetadata, checksumKey)
+			}
+		}, ErrNoChecksum},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := storagetest.NewFakeS3()
+			memory := NewMemoryStorage("test-bucket")
+			backends := map[string]struct {
+				store   Storage
+				corrupt func()
+			}{
+				"minio": {newTestService(t, fake, Config{}), func() {
+					tt.corrupt(fake.Object("test-bucket", "report.txt"), nil)
+				}},
+				"memory": {memory, func() {
+					memory.mu.Lock()
+					defer memory.mu.Unlock()
+					versions := memory.objects["report.txt"]
+					tt.corrupt(nil, versions[len(versions)-1])
+				}},
+			}
+
+			for name, backend := range backends {
+				t.Run(name, func(t *testing.T) {
+					ctx := context.Background()
+					if _, err := backend.store.UploadBuffer(ctx, "report.txt", []byte("quarterly numbers"), "text/plain", nil); err != nil {
+						t.Fatalf("UploadBuffer() error = %v", err)
+					}
+					if tt.corrupt != nil {
+						backend.corrupt()
+					}
+
+					err := backend.store.VerifyObject(ctx, "report.txt")
+					if tt.wantErr == nil && err != nil {
+						t.Fatalf("VerifyObject() error = %v, want nil", err)
+					}
+					if !errors.Is(err, tt.wantErr) {
+						t.Errorf("VerifyObject() error = %v, want %v", err, tt.wantErr)
+					}
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	io "io"
+	"mime"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+)
+
+var compressibleTypes = map[string]bool{
+	"application/json":       true,
+	"application/javascript": true,
+	"application/xml":        true,
+	"application/x-yaml":     true,
+	"image/svg+xml":          true,
+}
+
+// IsCompressibleType reports whether content of this type is worth
+// compressing: text/* and a handful of structured text formats.
+func IsCompressibleType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if strings.HasPrefix(mediaType, "text/") {
+		return true
+	}
+	return compressibleTypes[mediaType]
+}
+
+// IsCompressed reports whether the object was stored gzip-compressed. Byte
+// ranges and the reported size of such objects refer to the compressed data.
+func IsCompressed(info minio.ObjectInfo) bool {
+	return strings.EqualFold(info.Metadata.Get("Content-Encoding"), "gzip")
+}
+
+func (s *MinIOService) shouldCompress(contentType string) bool {
+	return s.compress && IsCompressibleType(contentType)
+}
+
+// gzipBytes returns data gzip-compressed.
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to compress data: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress data: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// compressUpload gzips reader on the fly when compression is enabled and
+// contentType is compressible, adjusting opts to match. The returned size is
+// -1 when compressing, as the compressed length isn't known up front. The
+// caller must call done once PutObject returns so the compressing goroutine
+// exits even if the upload stopped reading early.
+func (s *MinIOService) compressUpload(reader io.Reader, size int64, contentType string, opts *minio.PutObjectOptions) (io.Reader, int64, func()) {
+	if !s.shouldCompress(contentType) {
+		return reader, size, func() {}
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		gz := gzip.NewWriter(pw)
+		_, err := io.Copy(gz, reader)
+		if err == nil {
+			err = gz.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	opts.ContentEncoding = "gzip"
+	if opts.PartSize == 0 {
+		opts.PartSize = streamPartSize
+	}
+	return pr, -1, func() { pr.Close() }
+}
+
+// decodeContent returns a reader of the object's original content,
+// decompressing it if it was stored gzip-compressed.
+func decodeContent(info minio.ObjectInfo, r io.Reader) (io.Reader, error) {
+	if !IsCompressed(info) {
+		return r, nil
+	}
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress object: %w", err)
+	}
+	return gz, nil
+}
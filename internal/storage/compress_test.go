@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"MinIO-Learn/internal/storage/storagetest"
+)
+
+func TestCompressedRoundTrip(t *testing.T) {
+	text := bytes.Repeat([]byte("the same line of text, over and over\n"), 1000)
+	png := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0x42}, 4096)...)
+
+	tests := []struct {
+		name         string
+		compress     bool
+		contentType  string
+		data         []byte
+		wantEncoding string
+	}{
+		{"compressible", true, "text/plain", text, "gzip"},
+		{"compressible JSON", true, "application/json; charset=utf-8", []byte(`{"rows":"` + string(text[:200]) + `"}`), "gzip"},
+		{"not compressible", true, "image/png", png, ""},
+		{"compression off", false, "text/plain", text, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			fake := storagetest.NewFakeS3()
+			service := newTestService(t, fake, Config{Compress: tt.compress})
+			if err := service.EnsureBucket(ctx); err != nil {
+				t.Fatalf("EnsureBucket() error = %v", err)
+			}
+
+			if _, err := service.UploadBuffer(ctx, "object", tt.data, tt.contentType, nil); err != nil {
+				t.Fatalf("UploadBuffer() error = %v", err)
+			}
+
+			stored := fake.Object("test-bucket", "object")
+			if got := stored.Header.Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Errorf("stored Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			if tt.wantEncoding == "gzip" && len(stored.Data) >= len(tt.data) {
+				t.Errorf("stored %d bytes, want fewer than the %d uploaded", len(stored.Data), len(tt.data))
+			}
+			if tt.wantEncoding == "" && !bytes.Equal(stored.Data, tt.data) {
+				t.Errorf("stored data differs from the upload")
+			}
+
+			got, err := service.DownloadBuffer(ctx, "object")
+			if err != nil {
+				t.Fatalf("DownloadBuffer() error = %v", err)
+			}
+			if !bytes.Equal(got, tt.data) {
+				t.Errorf("DownloadBuffer() returned %d bytes, want the %d uploaded", len(got), len(tt.data))
+			}
+		})
+	}
+}
+
+func TestIsCompressibleType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        bool
+	}{
+		{"text/plain", true},
+		{"text/csv; charset=utf-8", true},
+		{"application/json", true},
+		{"image/svg+xml", true},
+		{"image/png", false},
+		{"application/zip", false},
+		{"application/octet-stream", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsCompressibleType(tt.contentType); got != tt.want {
+			t.Errorf("IsCompressibleType(%q) = %v, want %v", tt.contentType, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/minio/minio-go/v7"
+)
+
+var (
+	ErrNotModified        = errors.New("object not modified")
+	ErrPreconditionFailed = errors.New("object precondition failed")
+)
+
+// ReadOptions wraps minio.GetObjectOptions for reads that target a version or
+// carry ETag preconditions. The zero value reads the latest version
+// unconditionally.
+type ReadOptions struct {
+	opts minio.GetObjectOptions
+}
+
+// SetVersionID reads a specific version instead of the latest.
+func (o *ReadOptions) SetVersionID(versionID string) {
+	o.opts.VersionID = versionID
+}
+
+// SetMatchETag makes the read fail with ErrPreconditionFailed unless the
+// object's ETag is etag.
+func (o *ReadOptions) SetMatchETag(etag string) error {
+	return o.opts.SetMatchETag(etag)
+}
+
+// SetMatchETagExcept makes the read fail with ErrNotModified if the object's
+// ETag is etag.
+func (o *ReadOptions) SetMatchETagExcept(etag string) error {
+	return o.opts.SetMatchETagExcept(etag)
+}
+
+// getObjectOptions merges o with the service-wide read options.
+func (s *MinIOService) getObjectOptions(o ReadOptions) minio.GetObjectOptions {
+	opts := o.opts
+	opts.ServerSideEncryption = s.readEncryption()
+	return opts
+}
+
+// conditionError maps the responses MinIO gives to failed preconditions to
+// ErrNotModified and ErrPreconditionFailed, and returns other errors as-is.
+func conditionError(err error) error {
+	switch minio.ToErrorResponse(err).StatusCode {
+	case http.StatusNotModified:
+		return ErrNotModified
+	case http.StatusPreconditionFailed:
+		return ErrPreconditionFailed
+	}
+	return err
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"bytes"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"MinIO-Learn/internal/storage/storagetest"
+)
+
+// closedEndpoint returns a local address nothing is listening on.
+func closedEndpoint(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+	return addr
+}
+
+func TestConnectRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		timeout      time.Duration
+		unreachable  bool
+		failFirst    int64
+		wantErr      bool
+		minElapsed   time.Duration
+		minFailedLog int
+	}{
+		{name: "unreachable gives up after timeout", timeout: 1200 * time.Millisecond, unreachable: true, wantErr: true, minElapsed: 1200 * time.Millisecond, minFailedLog: 2},
+		{name: "unreachable without timeout tries once", unreachable: true, wantErr: true},
+		{name: "backend comes up in time", timeout: 10 * time.Second, failFirst: 2, minFailedLog: 2},
+		{name: "backend down without timeout", failFirst: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := closedEndpoint(t)
+			if !tt.unreachable {
+				fake := storagetest.NewFakeS3()
+				var failed atomic.Int64
+				fake.Before = func(w http.ResponseWriter, r *http.Request) bool {
+					if failed.Add(1) > tt.failFirst {
+						return false
+					}
+					// Drop the connection, as a backend still booting would.
+					conn, _, _ := w.(http.Hijacker).Hijack()
+					conn.Close()
+					return true
+				}
+				server := httptest.NewServer(fake)
+				t.Cleanup(server.Close)
+				endpoint = strings.TrimPrefix(server.URL, "http://")
+			}
+
+			var logs bytes.Buffer
+			start := time.Now()
+			_, err := NewMinIOService(Config{
+				Endpoint:        endpoint,
+				AccessKeyID:     "test-access-key",
+				SecretAccessKey: "test-secret-key",
+				BucketName:      "test-bucket",
+				ConnectTimeout:  tt.timeout,
+				Logger:          slog.New(slog.NewTextHandler(&logs, nil)),
+			})
+			elapsed := time.Since(start)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewMinIOService() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if elapsed < tt.minElapsed-50*time.Millisecond || elapsed > tt.minElapsed+5*time.Second {
+				t.Errorf("took %v, want about %v", elapsed, tt.minElapsed)
+			}
+			if got := strings.Count(logs.String(), "MinIO connection attempt failed"); got < tt.minFailedLog {
+				t.Errorf("logged %d failed attempts, want at least %d:\n%s", got, tt.minFailedLog, logs.String())
+			}
+		})
+	}
+}
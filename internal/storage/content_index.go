@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// contentIndexPrefix holds one small pointer object per known SHA-256,
+// whose body is the key of an object with that content. It lets a client
+// ask "do you already have these bytes?" without the server hashing anything.
+const contentIndexPrefix = SystemPrefix + "hashes/sha256/"
+
+// contentIndexKey returns the index entry for hash within namespace, the key
+// prefix the indexed objects live under, such as a tenant's "acme/". Each
+// namespace has its own entries, so one can't learn what another uploaded or
+// overwrite its entries.
+func contentIndexKey(namespace, hash string) string {
+	return contentIndexPrefix + namespace + hash
+}
+
+func validSHA256(hash string) bool {
+	if len(hash) != 64 || strings.ToLower(hash) != hash {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
+
+// IndexContentHash records that objectName, which must be under namespace,
+// holds content with the given lowercase hex SHA-256.
+func (s *MinIOService) IndexContentHash(ctx context.Context, namespace, hash, objectName string) error {
+	if !validSHA256(hash) {
+		return fmt.Errorf("invalid sha256 %q", hash)
+	}
+	if !strings.HasPrefix(objectName, namespace) {
+		return fmt.Errorf("object %q is outside namespace %q", objectName, namespace)
+	}
+
+	body := strings.NewReader(objectName)
+	_, err := s.Client.PutObject(ctx, s.BucketName, contentIndexKey(namespace, hash), body, body.Size(),
+		minio.PutObjectOptions{ContentType: "text/plain"})
+	if err != nil {
+		return fmt.Errorf("failed to index content hash: %w", err)
+	}
+
+	return nil
+}
+
+// LookupContentHash returns the object previously indexed under hash in
+// namespace. The boolean is false if the hash is unknown, the indexed object
+// has since been removed, or size is non-negative and doesn't match the
+// object's size.
+func (s *MinIOService) LookupContentHash(ctx context.Context, namespace, hash string, size int64) (minio.ObjectInfo, bool, error) {
+	if !validSHA256(hash) {
+		return minio.ObjectInfo{}, false, fmt.Errorf("invalid sha256 %q", hash)
+	}
+
+	obj, err := s.Client.GetObject(ctx, s.BucketName, contentIndexKey(namespace, hash), minio.GetObjectOptions{})
+	if err != nil {
+		return minio.ObjectInfo{}, false, fmt.Errorf("failed to read content index: %w", err)
+	}
+	defer obj.Close()
+
+	key, err := io.ReadAll(io.LimitReader(obj, 1024))
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return minio.ObjectInfo{}, false, nil
+		}
+		return minio.ObjectInfo{}, false, fmt.Errorf("failed to read content index: %w", err)
+	}
+	if !strings.HasPrefix(string(key), namespace) {
+		return minio.ObjectInfo{}, false, nil
+	}
+
+	info, err := s.Client.StatObject(ctx, s.BucketName, string(key), s.getOptions())
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return minio.ObjectInfo{}, false, nil
+		}
+		return minio.ObjectInfo{}, false, fmt.Errorf("failed to stat indexed object: %w", err)
+	}
+
+	if size >= 0 && info.Size != size {
+		return info, false, nil
+	}
+
+	return info, true, nil
+}
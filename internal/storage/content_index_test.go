@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestContentHashIndex(t *testing.T) {
+	content := []byte("quarterly report")
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+	otherSum := sha256.Sum256([]byte("other"))
+	otherHash := hex.EncodeToString(otherSum[:])
+
+	tests := []struct {
+		name      string
+		namespace string
+		hash      string
+		size      int64
+		wantKey   string
+		wantErr   bool
+	}{
+		{name: "indexed", namespace: "alpha/", hash: hash, size: -1, wantKey: "alpha/report.txt"},
+		{name: "matching size", namespace: "alpha/", hash: hash, size: int64(len(content)), wantKey: "alpha/report.txt"},
+		{name: "different size", namespace: "alpha/", hash: hash, size: 1},
+		{name: "unknown hash", namespace: "alpha/", hash: otherHash, size: -1},
+		{name: "other namespace", namespace: "beta/", hash: hash, size: -1},
+		{name: "removed object", namespace: "gamma/", hash: hash, size: -1},
+		{name: "invalid hash", namespace: "alpha/", hash: "not-a-hash", size: -1, wantErr: true},
+	}
+
+	for name, store := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			for _, key := range []string{"alpha/report.txt", "gamma/report.txt"} {
+				if _, err := store.UploadBuffer(ctx, key, content, "text/plain", nil); err != nil {
+					t.Fatalf("UploadBuffer() error = %v", err)
+				}
+				namespace := key[:len(key)-len("report.txt")]
+				if err := store.IndexContentHash(ctx, namespace, hash, key); err != nil {
+					t.Fatalf("IndexContentHash() error = %v", err)
+				}
+			}
+			if err := store.DeleteObject(ctx, "gamma/report.txt"); err != nil {
+				t.Fatalf("DeleteObject() error = %v", err)
+			}
+			if err := store.IndexContentHash(ctx, "beta/", hash, "alpha/report.txt"); err == nil {
+				t.Error("IndexContentHash() accepted an object outside its namespace")
+			}
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					info, found, err := store.LookupContentHash(ctx, tt.namespace, tt.hash, tt.size)
+					if (err != nil) != tt.wantErr {
+						t.Fatalf("LookupContentHash() error = %v, wantErr %v", err, tt.wantErr)
+					}
+					if found != (tt.wantKey != "") {
+						t.Fatalf("LookupContentHash() found = %v, want %v", found, tt.wantKey != "")
+					}
+					if found && info.Key != tt.wantKey {
+						t.Errorf("LookupContentHash() key = %q, want %q", info.Key, tt.wantKey)
+					}
+				})
+			}
+
+			objects, err := store.ListObjects(ctx, "")
+			if err != nil {
+				t.Fatalf("ListObjects() error = %v", err)
+			}
+			if len(objects) != 1 {
+				t.Errorf("ListObjects() = %d objects, want the index entries hidden", len(objects))
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"MinIO-Learn/internal/storage/storagetest"
+)
+
+func TestCancelledContext(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "source.txt")
+	if err := os.WriteFile(source, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	operations := []struct {
+		name string
+		run  func(ctx context.Context, s *MinIOService) error
+	}{
+		{"UploadFile", func(ctx context.Context, s *MinIOService) error {
+			_, err := s.UploadFile(ctx, "new.txt", source, "text/plain", nil)
+			return err
+		}},
+		{"UploadBuffer", func(ctx context.Context, s *MinIOService) error {
+			_, err := s.UploadBuffer(ctx, "new.txt", []byte("hello"), "text/plain", nil)
+			return err
+		}},
+		{"DownloadFile", func(ctx context.Context, s *MinIOService) error {
+			return s.DownloadFile(ctx, "existing.txt", filepath.Join(dir, "download.txt"))
+		}},
+		{"DownloadBuffer", func(ctx context.Context, s *MinIOService) error {
+			_, err := s.DownloadBuffer(ctx, "existing.txt")
+			return err
+		}},
+		{"ListObjects", func(ctx context.Context, s *MinIOService) error {
+			_, err := s.ListObjects(ctx, "")
+			return err
+		}},
+		{"DeleteObject", func(ctx context.Context, s *MinIOService) error {
+			return s.DeleteObject(ctx, "existing.txt")
+		}},
+		{"CheckObjectExists", func(ctx context.Context, s *MinIOService) error {
+			_, err := s.CheckObjectExists(ctx, "existing.txt")
+			return err
+		}},
+	}
+
+	contexts := []struct {
+		name    string
+		context func() (context.Context, context.CancelFunc)
+		wantErr error
+	}{
+		{"cancelled", func() (context.Context, context.CancelFunc) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			return ctx, cancel
+		}, context.Canceled},
+		{"deadline while backend hangs", func() (context.Context, context.CancelFunc) {
+			return context.WithTimeout(context.Background(), 50*time.Millisecond)
+		}, context.DeadlineExceeded},
+	}
+
+	for _, op := range operations {
+		for _, c := range contexts {
+			t.Run(op.name+"/"+c.name, func(t *testing.T) {
+				fake := storagetest.NewFakeS3()
+				var hang atomic.Bool
+				stop := make(chan struct{})
+				fake.Before = func(w http.ResponseWriter, r *http.Request) bool {
+					if !hang.Load() {
+						return false
+					}
+					// The server only notices the client going away once
+					// the body has been read.
+					io.Copy(io.Discard, r.Body)
+					select {
+					case <-r.Context().Done():
+					case <-stop:
+					}
+					return true
+				}
+				service := newTestService(t, fake, Config{})
+				t.Cleanup(func() { close(stop) })
+				fake.PutObject("test-bucket", "existing.txt", []byte("hello"), nil)
+				hang.Store(true)
+
+				ctx, cancel := c.context()
+				defer cancel()
+				start := time.Now()
+				err := op.run(ctx, service)
+				if elapsed := time.Since(start); elapsed > 2*time.Second {
+					t.Errorf("returned after %v, want promptly", elapsed)
+				}
+				if !errors.Is(err, c.wantErr) {
+					t.Errorf("error = %v, want %v", err, c.wantErr)
+				}
+			})
+		}
+	}
+}
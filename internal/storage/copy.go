@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// lockPair locks two keys in a fixed order so concurrent operations on the
+// same pair in opposite directions can't deadlock.
+func (s *MinIOService) lockPair(a, b string) func() {
+	if a == b {
+		return s.locks.lock(a)
+	}
+	if b < a {
+		a, b = b, a
+	}
+	unlockA := s.locks.lock(a)
+	unlockB := s.locks.lock(b)
+	return func() {
+		unlockB()
+		unlockA()
+	}
+}
+
+// CopyObject copies srcObject to dstObject server-side. Content type and user
+// metadata are carried over from the source.
+func (s *MinIOService) CopyObject(ctx context.Context, srcObject, dstObject string) (minio.UploadInfo, error) {
+	if srcObject == dstObject {
+		return minio.UploadInfo{}, fmt.Errorf("source and destination are the same object")
+	}
+
+	defer s.lockPair(srcObject, dstObject)()
+	defer s.stats.invalidate(dstObject)
+
+	return s.copyObject(ctx, srcObject, dstObject)
+}
+
+func (s *MinIOService) copyObject(ctx context.Context, srcObject, dstObject string) (minio.UploadInfo, error) {
+	dstOpts, srcOpts := s.copyOptions(srcObject, dstObject)
+	uploadInfo, err := s.Client.CopyObject(ctx, dstOpts, srcOpts)
+	if err != nil {
+		return minio.UploadInfo{}, fmt.Errorf("failed to copy object: %w", classifyError(err))
+	}
+
+	return uploadInfo, nil
+}
+
+// CopyObjectToBucket copies srcObject to dstObject in dstBucket server-side,
+// for example to back it up. It fails with ErrBucketNotFound if dstBucket
+// doesn't exist. The copy is encrypted with the service's settings, like any
+// object it writes.
+func (s *MinIOService) CopyObjectToBucket(ctx context.Context, srcObject, dstBucket, dstObject string) (minio.UploadInfo, error) {
+	if dstBucket == s.BucketName {
+		return s.CopyObject(ctx, srcObject, dstObject)
+	}
+
+	exists, err := s.Client.BucketExists(ctx, dstBucket)
+	if err != nil {
+		return minio.UploadInfo{}, fmt.Errorf("failed to check if bucket exists: %w", classifyError(err))
+	}
+	if !exists {
+		return minio.UploadInfo{}, fmt.Errorf("%w: '%s'", ErrBucketNotFound, dstBucket)
+	}
+
+	defer s.locks.lock(srcObject)()
+
+	dstOpts, srcOpts := s.copyOptions(srcObject, dstObject)
+	dstOpts.Bucket = dstBucket
+	uploadInfo, err := s.Client.CopyObject(ctx, dstOpts, srcOpts)
+	if err != nil {
+		return minio.UploadInfo{}, fmt.Errorf("failed to copy object: %w", classifyError(err))
+	}
+
+	return uploadInfo, nil
+}
+
+// MoveObject copies srcObject to dstObject and then removes the source. If
+// the removal fails the copy is left in place and the error is returned.
+func (s *MinIOService) MoveObject(ctx context.Context, srcObject, dstObject string) (minio.UploadInfo, error) {
+	if srcObject == dstObject {
+		return minio.UploadInfo{}, fmt.Errorf("source and destination are the same object")
+	}
+
+	defer s.lockPair(srcObject, dstObject)()
+	defer s.stats.invalidate(srcObject)
+	defer s.stats.invalidate(dstObject)
+
+	uploadInfo, err := s.copyObject(ctx, srcObject, dstObject)
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+
+	err = s.Client.RemoveObject(ctx, s.BucketName, srcObject, minio.RemoveObjectOptions{})
+	if err != nil {
+		return uploadInfo, fmt.Errorf("copied but failed to remove source: %w", classifyError(err))
+	}
+
+	return uploadInfo, nil
+}
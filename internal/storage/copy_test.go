@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// bucketView returns store bound to bucketName.
+func bucketView(t *testing.T, store Storage, bucketName string) Storage {
+	t.Helper()
+	switch s := store.(type) {
+	case *MinIOService:
+		return s.WithBucket(bucketName)
+	case *MemoryStorage:
+		return s.WithBucket(bucketName)
+	}
+	t.Fatalf("no bucket views for %T", store)
+	return nil
+}
+
+func TestCopyObjectToBucket(t *testing.T) {
+	for name, store := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if err := store.CreateBucket(ctx, "backup", ""); err != nil {
+				t.Fatalf("CreateBucket() error = %v", err)
+			}
+			if _, err := store.UploadBuffer(ctx, "reports/q3.csv", []byte("a,b\n1,2\n"), "text/csv", nil); err != nil {
+				t.Fatalf("UploadBuffer() error = %v", err)
+			}
+
+			if _, err := store.CopyObjectToBucket(ctx, "reports/q3.csv", "backup", "2024/q3.csv"); err != nil {
+				t.Fatalf("CopyObjectToBucket() error = %v", err)
+			}
+
+			backup := bucketView(t, store, "backup")
+			data, err := backup.DownloadBuffer(ctx, "2024/q3.csv")
+			if err != nil {
+				t.Fatalf("DownloadBuffer() from the backup bucket error = %v", err)
+			}
+			if string(data) != "a,b\n1,2\n" {
+				t.Errorf("copy = %q, want the source's content", data)
+			}
+			info, err := backup.GetObjectInfo(ctx, "2024/q3.csv")
+			if err != nil {
+				t.Fatalf("GetObjectInfo() error = %v", err)
+			}
+			if info.ContentType != "text/csv" {
+				t.Errorf("copy ContentType = %q, want text/csv", info.ContentType)
+			}
+
+			if exists, err := store.CheckObjectExists(ctx, "reports/q3.csv"); err != nil || !exists {
+				t.Errorf("source exists = %v, %v, want it kept", exists, err)
+			}
+			if exists, err := store.CheckObjectExists(ctx, "2024/q3.csv"); err != nil || exists {
+				t.Errorf("copy in the source bucket exists = %v, %v, want false", exists, err)
+			}
+		})
+	}
+}
+
+func TestCopyObjectToBucketErrors(t *testing.T) {
+	for name, store := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if err := store.CreateBucket(ctx, "backup", ""); err != nil {
+				t.Fatalf("CreateBucket() error = %v", err)
+			}
+			if _, err := store.UploadBuffer(ctx, "notes.txt", []byte("hello"), "text/plain", nil); err != nil {
+				t.Fatalf("UploadBuffer() error = %v", err)
+			}
+
+			if _, err := store.CopyObjectToBucket(ctx, "notes.txt", "no-such-bucket", "notes.txt"); !errors.Is(err, ErrBucketNotFound) {
+				t.Errorf("CopyObjectToBucket() to a missing bucket error = %v, want ErrBucketNotFound", err)
+			}
+			if _, err := store.CopyObjectToBucket(ctx, "missing.txt", "backup", "missing.txt"); !errors.Is(err, ErrObjectNotFound) {
+				t.Errorf("CopyObjectToBucket() of a missing object error = %v, want ErrObjectNotFound", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// Credential modes for Config.CredsMode.
+const (
+	CredsStatic = "static"
+	CredsIAM    = "iam"
+	CredsSTS    = "sts"
+)
+
+// Signature versions for Config.SignatureVersion.
+const (
+	SignatureV2 = "v2"
+	SignatureV4 = "v4"
+)
+
+// newCredentials returns the credentials provider selected by
+// config.CredsMode. An empty mode means static keys, and an empty signature
+// version means V4; only static keys can sign with V2.
+func newCredentials(config Config) (*credentials.Credentials, error) {
+	switch config.SignatureVersion {
+	case "", SignatureV4:
+	case SignatureV2:
+		if config.CredsMode != "" && config.CredsMode != CredsStatic {
+			return nil, fmt.Errorf("signature version %s requires static credentials", SignatureV2)
+		}
+		return credentials.NewStaticV2(config.AccessKeyID, config.SecretAccessKey, ""), nil
+	default:
+		return nil, fmt.Errorf("unknown signature version %q", config.SignatureVersion)
+	}
+
+	switch config.CredsMode {
+	case "", CredsStatic:
+		return credentials.NewStaticV4(config.AccessKeyID, config.SecretAccessKey, ""), nil
+	case CredsIAM:
+		// An empty endpoint lets minio-go discover the instance metadata or
+		// container credentials endpoint.
+		return credentials.NewIAM(config.IAMEndpoint), nil
+	case CredsSTS:
+		creds, err := credentials.NewSTSAssumeRole(config.STSEndpoint, credentials.STSAssumeRoleOptions{
+			AccessKey: config.AccessKeyID,
+			SecretKey: config.SecretAccessKey,
+			Location:  config.Location,
+			RoleARN:   config.RoleARN,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize STS credentials: %w", err)
+		}
+		return creds, nil
+	default:
+		return nil, fmt.Errorf("unknown credentials mode %q", config.CredsMode)
+	}
+}
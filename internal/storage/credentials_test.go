@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"MinIO-Learn/internal/storage/storagetest"
+
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+func TestNewCredentialsSignatureVersion(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     Config
+		wantSigner credentials.SignatureType
+		wantErr    string
+	}{
+		{"default", Config{}, credentials.SignatureV4, ""},
+		{"v4", Config{SignatureVersion: SignatureV4}, credentials.SignatureV4, ""},
+		{"v2", Config{SignatureVersion: SignatureV2}, credentials.SignatureV2, ""},
+		{"v2 with static credentials", Config{SignatureVersion: SignatureV2, CredsMode: CredsStatic}, credentials.SignatureV2, ""},
+		{"v2 with IAM", Config{SignatureVersion: SignatureV2, CredsMode: CredsIAM}, 0, "requires static credentials"},
+		{"unknown", Config{SignatureVersion: "v3"}, 0, `unknown signature version "v3"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.AccessKeyID = "test-access-key"
+			tt.config.SecretAccessKey = "test-secret-key"
+
+			creds, err := newCredentials(tt.config)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("newCredentials() error = %v, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newCredentials() error = %v", err)
+			}
+
+			value, err := creds.Get()
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if value.SignerType != tt.wantSigner {
+				t.Errorf("SignerType = %v, want %v", value.SignerType, tt.wantSigner)
+			}
+			if value.AccessKeyID != "test-access-key" || value.SecretAccessKey != "test-secret-key" {
+				t.Errorf("credentials = %q/%q, want the configured keys", value.AccessKeyID, value.SecretAccessKey)
+			}
+		})
+	}
+}
+
+func TestSignatureVersionRequests(t *testing.T) {
+	tests := []struct {
+		version    string
+		wantScheme string
+	}{
+		{SignatureV4, "AWS4-HMAC-SHA256 "},
+		{SignatureV2, "AWS "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			var (
+				mu             sync.Mutex
+				authorizations []string
+			)
+			fake := storagetest.NewFakeS3()
+			fake.Before = func(w http.ResponseWriter, r *http.Request) bool {
+				mu.Lock()
+				defer mu.Unlock()
+				authorizations = append(authorizations, r.Header.Get("Authorization"))
+				return false
+			}
+			service := newTestService(t, fake, Config{SignatureVersion: tt.version})
+			if _, err := service.UploadBuffer(context.Background(), "notes.txt", []byte("hello"), "text/plain", nil); err != nil {
+				t.Fatalf("UploadBuffer() error = %v", err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(authorizations) == 0 {
+				t.Fatal("the backend saw no requests")
+			}
+			for _, authorization := range authorizations {
+				if !strings.HasPrefix(authorization, tt.wantScheme) {
+					t.Errorf("Authorization = %q, want it signed with %q", authorization, tt.wantScheme)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestDeleteByPrefix(t *testing.T) {
+	keys := []string{
+		"docs/a.txt",
+		"docs/b/c.txt",
+		"docs/b/d/e.txt",
+		"docsx/f.txt",
+		"other/g.txt",
+	}
+
+	tests := []struct {
+		name        string
+		prefix      string
+		force       bool
+		wantDeleted int
+		wantErr     error
+		wantLeft    []string
+	}{
+		{name: "subtree", prefix: "docs/", wantDeleted: 3, wantLeft: []string{"docsx/f.txt", "other/g.txt"}},
+		{name: "nested subtree", prefix: "docs/b/", wantDeleted: 2, wantLeft: []string{"docs/a.txt", "docsx/f.txt", "other/g.txt"}},
+		{name: "nothing under prefix", prefix: "missing/", wantDeleted: 0, wantLeft: keys},
+		{name: "empty prefix", prefix: "", wantErr: ErrEmptyPrefix, wantLeft: keys},
+		{name: "empty prefix forced", prefix: "", force: true, wantDeleted: 5, wantLeft: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, store := range testBackends(t) {
+				t.Run(name, func(t *testing.T) {
+					ctx := context.Background()
+					for _, key := range keys {
+						if _, err := store.UploadBuffer(ctx, key, []byte(key), "text/plain", nil); err != nil {
+							t.Fatalf("UploadBuffer(%q) error = %v", key, err)
+						}
+					}
+
+					deleted, err := store.DeleteByPrefix(ctx, tt.prefix, tt.force)
+					if !errors.Is(err, tt.wantErr) {
+						t.Fatalf("DeleteByPrefix() error = %v, want %v", err, tt.wantErr)
+					}
+					if deleted != tt.wantDeleted {
+						t.Errorf("DeleteByPrefix() = %d, want %d", deleted, tt.wantDeleted)
+					}
+
+					objects, err := store.ListObjects(ctx, "")
+					if err != nil {
+						t.Fatalf("ListObjects() error = %v", err)
+					}
+					var left []string
+					for _, object := range objects {
+						left = append(left, object.Key)
+					}
+					slices.Sort(left)
+					if !slices.Equal(left, tt.wantLeft) {
+						t.Errorf("left %v, want %v", left, tt.wantLeft)
+					}
+				})
+			}
+		})
+	}
+}
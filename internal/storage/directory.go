@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"mime"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// defaultDirectoryConcurrency is how many files UploadDirectory uploads at
+// once when no concurrency is configured.
+const defaultDirectoryConcurrency = 4
+
+// DirectoryUploadResult summarizes an UploadDirectory run. Uploaded holds the
+// keys written; Skipped the paths, relative to the directory, of symlinks and
+// other entries that aren't regular files. All are sorted.
+type DirectoryUploadResult struct {
+	Uploaded []string
+	Skipped  []string
+	Failed   []ObjectError
+}
+
+// UploadDirectory uploads every regular file under localDir to keyPrefix
+// followed by its path relative to localDir, with forward slashes, so
+// "photos/2024/a.jpg" under localDir lands at keyPrefix+"photos/2024/a.jpg".
+// Symlinks are skipped rather than followed. A file that fails doesn't stop
+// the others; the error is only non-nil if localDir can't be read at all or
+// ctx is canceled.
+func (s *MinIOService) UploadDirectory(ctx context.Context, localDir, keyPrefix string) (DirectoryUploadResult, error) {
+	return uploadDirectory(ctx, localDir, keyPrefix, s.uploadConcurrency, s.UploadLargeFile)
+}
+
+func uploadDirectory(ctx context.Context, localDir, keyPrefix string, concurrency int,
+	upload func(ctx context.Context, objectName, filePath, contentType string, metadata map[string]string) (minio.UploadInfo, error)) (DirectoryUploadResult, error) {
+	if concurrency < 1 {
+		concurrency = defaultDirectoryConcurrency
+	}
+
+	type job struct{ path, key string }
+	jobs := make(chan job)
+
+	var (
+		mu     sync.Mutex
+		result DirectoryUploadResult
+		wg     sync.WaitGroup
+	)
+	fail := func(name string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		result.Failed = append(result.Failed, ObjectError{ObjectName: name, Err: err})
+	}
+
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				contentType := mime.TypeByExtension(filepath.Ext(j.path))
+				if contentType == "" {
+					contentType = "application/octet-stream"
+				}
+				_, err := upload(ctx, j.key, j.path, contentType, OriginalFilenameMetadata(filepath.Base(j.path)))
+				if err != nil {
+					fail(j.key, err)
+					continue
+				}
+				mu.Lock()
+				result.Uploaded = append(result.Uploaded, j.key)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	walkErr := filepath.WalkDir(localDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == localDir {
+				return err
+			}
+			// An unreadable subdirectory; carry on with the rest.
+			fail(path, err)
+			return nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(localDir, path)
+		if err != nil {
+			fail(path, err)
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			mu.Lock()
+			result.Skipped = append(result.Skipped, filepath.ToSlash(rel))
+			mu.Unlock()
+			return nil
+		}
+
+		key := keyPrefix + filepath.ToSlash(rel)
+		if err := ValidateObjectName(key); err != nil {
+			fail(key, err)
+			return nil
+		}
+
+		select {
+		case jobs <- job{path: path, key: key}:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	close(jobs)
+	wg.Wait()
+
+	sort.Strings(result.Uploaded)
+	sort.Strings(result.Skipped)
+	sort.Slice(result.Failed, func(i, j int) bool { return result.Failed[i].ObjectName < result.Failed[j].ObjectName })
+
+	if walkErr != nil {
+		return result, fmt.Errorf("failed to walk directory: %w", walkErr)
+	}
+	return result, nil
+}
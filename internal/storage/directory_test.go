@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// writeTree creates files, keyed by slash-separated path relative to dir,
+// along with any directories they need.
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestUploadDirectory(t *testing.T) {
+	files := map[string]string{
+		"readme.txt":               "top level",
+		"photos/2024/a.jpg":        "jpeg bytes",
+		"photos/2024/deep/raw.bin": "raw bytes",
+		"docs/notes.json":          `{"ok":true}`,
+	}
+	dir := t.TempDir()
+	writeTree(t, dir, files)
+	if err := os.MkdirAll(filepath.Join(dir, "empty", "nested"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "readme.txt"), filepath.Join(dir, "photos", "link.txt")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	for name, store := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			result, err := store.UploadDirectory(ctx, dir, "seed/")
+			if err != nil {
+				t.Fatalf("UploadDirectory() error = %v", err)
+			}
+
+			wantKeys := []string{
+				"seed/docs/notes.json",
+				"seed/photos/2024/a.jpg",
+				"seed/photos/2024/deep/raw.bin",
+				"seed/readme.txt",
+			}
+			if !slices.Equal(result.Uploaded, wantKeys) {
+				t.Errorf("Uploaded = %q, want %q", result.Uploaded, wantKeys)
+			}
+			if want := []string{"photos/link.txt"}; !slices.Equal(result.Skipped, want) {
+				t.Errorf("Skipped = %q, want %q", result.Skipped, want)
+			}
+			if len(result.Failed) != 0 {
+				t.Errorf("Failed = %v, want none", result.Failed)
+			}
+
+			for rel, content := range files {
+				data, err := store.DownloadBuffer(ctx, "seed/"+rel)
+				if err != nil {
+					t.Fatalf("DownloadBuffer(%q) error = %v", rel, err)
+				}
+				if string(data) != content {
+					t.Errorf("%s = %q, want %q", rel, data, content)
+				}
+			}
+
+			info, err := store.GetObjectInfo(ctx, "seed/photos/2024/a.jpg")
+			if err != nil {
+				t.Fatalf("GetObjectInfo() error = %v", err)
+			}
+			if info.ContentType != "image/jpeg" {
+				t.Errorf("ContentType = %q, want image/jpeg", info.ContentType)
+			}
+			if got := OriginalFilename(info); got != "a.jpg" {
+				t.Errorf("OriginalFilename() = %q, want a.jpg", got)
+			}
+		})
+	}
+}
+
+func TestUploadDirectoryFailures(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"a/1.txt": "1", "a/2.txt": "2", "b/3.txt": "3", "b/c/4.txt": "4", "b/c/5.txt": "5",
+	})
+
+	const concurrency = 2
+	var (
+		mu                  sync.Mutex
+		inFlight, maxFlight int
+	)
+	release := make(chan struct{})
+	var releaseOnce sync.Once
+	upload := func(ctx context.Context, objectName, filePath, contentType string, metadata map[string]string) (minio.UploadInfo, error) {
+		mu.Lock()
+		inFlight++
+		maxFlight = max(maxFlight, inFlight)
+		if inFlight == concurrency {
+			releaseOnce.Do(func() { close(release) })
+		}
+		mu.Unlock()
+		// Hold the first uploads until the limit is reached.
+		<-release
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		if objectName == "b/c/4.txt" {
+			return minio.UploadInfo{}, errors.New("upload refused")
+		}
+		return minio.UploadInfo{Key: objectName}, nil
+	}
+
+	result, err := uploadDirectory(context.Background(), dir, "", concurrency, upload)
+	if err != nil {
+		t.Fatalf("uploadDirectory() error = %v", err)
+	}
+	if want := []string{"a/1.txt", "a/2.txt", "b/3.txt", "b/c/5.txt"}; !slices.Equal(result.Uploaded, want) {
+		t.Errorf("Uploaded = %q, want %q", result.Uploaded, want)
+	}
+	if len(result.Failed) != 1 || result.Failed[0].ObjectName != "b/c/4.txt" {
+		t.Errorf("Failed = %v, want only b/c/4.txt", result.Failed)
+	}
+	if maxFlight != concurrency {
+		t.Errorf("at most %d uploads ran at once, want %d", maxFlight, concurrency)
+	}
+}
+
+func TestUploadDirectoryMissing(t *testing.T) {
+	store := NewMemoryStorage("test-bucket")
+	_, err := store.UploadDirectory(context.Background(), filepath.Join(t.TempDir(), "missing"), "")
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("UploadDirectory() error = %v, want os.ErrNotExist", err)
+	}
+}
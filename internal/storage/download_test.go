@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDownloadToWriter(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"small", []byte("hello, writer")},
+		{"large", bytes.Repeat([]byte("streamed download "), 64<<10)},
+		{"empty", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, store := range testBackends(t) {
+				t.Run(name, func(t *testing.T) {
+					ctx := context.Background()
+					if _, err := store.UploadBuffer(ctx, "download.bin", tt.data, "application/octet-stream", nil); err != nil {
+						t.Fatalf("UploadBuffer() error = %v", err)
+					}
+
+					var buf bytes.Buffer
+					written, err := store.DownloadToWriter(ctx, "download.bin", &buf)
+					if err != nil {
+						t.Fatalf("DownloadToWriter() error = %v", err)
+					}
+					if written != int64(len(tt.data)) {
+						t.Errorf("DownloadToWriter() = %d, want %d", written, len(tt.data))
+					}
+					if !bytes.Equal(buf.Bytes(), tt.data) {
+						t.Errorf("wrote %d bytes, want the %d uploaded", buf.Len(), len(tt.data))
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestDownloadToWriterMissing(t *testing.T) {
+	for name, store := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			written, err := store.DownloadToWriter(context.Background(), "missing.bin", &buf)
+			if !errors.Is(err, ErrObjectNotFound) {
+				t.Errorf("DownloadToWriter() error = %v, want ErrObjectNotFound", err)
+			}
+			if written != 0 || buf.Len() != 0 {
+				t.Errorf("DownloadToWriter() wrote %d bytes (reported %d), want none", buf.Len(), written)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"maps"
+	"net/http"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+)
+
+// readEncryption returns what must accompany reads of objects this service
+// wrote. SSE-C objects can only be read by presenting the same key again;
+// SSE-S3 objects are decrypted transparently and need nothing.
+func (s *MinIOService) readEncryption() encrypt.ServerSide {
+	if s.encryption != nil && s.encryption.Type() == encrypt.SSEC {
+		return s.encryption
+	}
+	return nil
+}
+
+func (s *MinIOService) getOptions() minio.GetObjectOptions {
+	return minio.GetObjectOptions{ServerSideEncryption: s.readEncryption()}
+}
+
+// putOptions returns the options for writing an object with metadata. A
+// storage class from StorageClassMetadata is sent as its own header rather
+// than user metadata, where minio-go rejects it.
+func (s *MinIOService) putOptions(contentType string, metadata map[string]string) minio.PutObjectOptions {
+	opts := minio.PutObjectOptions{
+		ContentType:          contentType,
+		UserMetadata:         metadata,
+		StorageClass:         s.storageClass,
+		ServerSideEncryption: s.encryption,
+	}
+	for key, value := range metadata {
+		if http.CanonicalHeaderKey(key) == storageClassKey {
+			opts.StorageClass = value
+			opts.UserMetadata = maps.Clone(metadata)
+			delete(opts.UserMetadata, key)
+			break
+		}
+	}
+	return opts
+}
+
+// copyOptions returns the destination and source options for a server-side
+// copy within the bucket that keeps the object encrypted.
+func (s *MinIOService) copyOptions(srcObject, dstObject string) (minio.CopyDestOptions, minio.CopySrcOptions) {
+	return minio.CopyDestOptions{Bucket: s.BucketName, Object: dstObject, Encryption: s.encryption},
+		minio.CopySrcOptions{Bucket: s.BucketName, Object: srcObject, Encryption: s.readEncryption()}
+}
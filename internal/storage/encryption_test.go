@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/pem"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"MinIO-Learn/internal/storage/storagetest"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+)
+
+// newTLSTestService is newTestService over TLS, which minio-go requires
+// before it sends SSE-C keys.
+func newTLSTestService(t *testing.T, fake *storagetest.FakeS3, config Config) *MinIOService {
+	t.Helper()
+	server := httptest.NewTLSServer(fake)
+	t.Cleanup(server.Close)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	config.Endpoint = strings.TrimPrefix(server.URL, "https://")
+	config.UseSSL = true
+	config.CACertFile = caFile
+	config.AccessKeyID = "test-access-key"
+	config.SecretAccessKey = "test-secret-key"
+	config.BucketName = "test-bucket"
+	config.Logger = slog.New(slog.DiscardHandler)
+
+	service, err := NewMinIOService(config)
+	if err != nil {
+		t.Fatalf("NewMinIOService() error = %v", err)
+	}
+	return service
+}
+
+func TestServerSideEncryption(t *testing.T) {
+	key := bytes.Repeat([]byte{0x11}, 32)
+	otherKey := bytes.Repeat([]byte{0x22}, 32)
+	mustSSEC := func(key []byte) encrypt.ServerSide {
+		sse, err := encrypt.NewSSEC(key)
+		if err != nil {
+			t.Fatalf("NewSSEC() error = %v", err)
+		}
+		return sse
+	}
+
+	tests := []struct {
+		name       string
+		writer     encrypt.ServerSide
+		reader     encrypt.ServerSide
+		wantHeader string
+		wantRead   bool
+	}{
+		{"SSE-C with the key", mustSSEC(key), mustSSEC(key), "X-Amz-Server-Side-Encryption-Customer-Algorithm", true},
+		{"SSE-C without a key", mustSSEC(key), nil, "X-Amz-Server-Side-Encryption-Customer-Algorithm", false},
+		{"SSE-C with another key", mustSSEC(key), mustSSEC(otherKey), "X-Amz-Server-Side-Encryption-Customer-Algorithm", false},
+		{"SSE-S3 without a key", encrypt.NewSSE(), nil, "X-Amz-Server-Side-Encryption", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := storagetest.NewFakeS3()
+			writer := newTLSTestService(t, fake, Config{Encryption: tt.writer})
+			reader := newTLSTestService(t, fake, Config{Encryption: tt.reader})
+			ctx := context.Background()
+
+			if _, err := writer.UploadBuffer(ctx, "secret.txt", []byte("classified"), "text/plain", nil); err != nil {
+				t.Fatalf("UploadBuffer() error = %v", err)
+			}
+			if got := fake.Object("test-bucket", "secret.txt").Header.Get(tt.wantHeader); got == "" {
+				t.Errorf("stored object lacks %s", tt.wantHeader)
+			}
+
+			data, err := writer.DownloadBuffer(ctx, "secret.txt")
+			if err != nil || string(data) != "classified" {
+				t.Fatalf("writer DownloadBuffer() = %q, %v, want the object", data, err)
+			}
+
+			data, err = reader.DownloadBuffer(ctx, "secret.txt")
+			if tt.wantRead {
+				if err != nil || string(data) != "classified" {
+					t.Errorf("reader DownloadBuffer() = %q, %v, want the object", data, err)
+				}
+			} else if err == nil {
+				t.Errorf("reader DownloadBuffer() = %q, want an error without the key", data)
+			}
+		})
+	}
+}
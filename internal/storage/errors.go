@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"errors"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// Errors from the backend are wrapped so that errors.Is matches one of these
+// when their S3 error code is known, while errors.As still finds the original
+// minio.ErrorResponse.
+var (
+	ErrObjectNotFound = errors.New("object not found")
+	ErrBucketNotFound = errors.New("bucket not found")
+	ErrAccessDenied   = errors.New("access denied")
+)
+
+// backendError is a backend error matched to one of the sentinels above. Its
+// message is the backend's, unchanged.
+type backendError struct {
+	kind error
+	err  error
+}
+
+func (e *backendError) Error() string { return e.err.Error() }
+
+func (e *backendError) Unwrap() []error { return []error{e.kind, e.err} }
+
+// classifyError wraps err with the sentinel its S3 error code maps to, and
+// returns other errors, including already classified ones, as-is.
+func classifyError(err error) error {
+	var errResp minio.ErrorResponse
+	if !errors.As(err, &errResp) {
+		return err
+	}
+
+	var kind error
+	switch errResp.Code {
+	case "NoSuchKey", "NoSuchVersion":
+		kind = ErrObjectNotFound
+	case "NoSuchBucket":
+		kind = ErrBucketNotFound
+	case "AccessDenied", "InvalidAccessKeyId", "SignatureDoesNotMatch":
+		kind = ErrAccessDenied
+	default:
+		return err
+	}
+	if errors.Is(err, kind) {
+		return err
+	}
+
+	return &backendError{kind: kind, err: err}
+}
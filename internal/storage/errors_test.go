@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"MinIO-Learn/internal/storage/storagetest"
+
+	"github.com/minio/minio-go/v7"
+)
+
+func TestClassifyError(t *testing.T) {
+	sentinels := []error{ErrObjectNotFound, ErrBucketNotFound, ErrAccessDenied}
+
+	tests := []struct {
+		code string
+		want error
+	}{
+		{"NoSuchKey", ErrObjectNotFound},
+		{"NoSuchVersion", ErrObjectNotFound},
+		{"NoSuchBucket", ErrBucketNotFound},
+		{"AccessDenied", ErrAccessDenied},
+		{"InvalidAccessKeyId", ErrAccessDenied},
+		{"SignatureDoesNotMatch", ErrAccessDenied},
+		{"InternalError", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			backend := minio.ErrorResponse{Code: tt.code, Message: "backend says " + tt.code}
+			err := fmt.Errorf("failed to stat object: %w", classifyError(backend))
+
+			for _, sentinel := range sentinels {
+				if got := errors.Is(err, sentinel); got != (sentinel == tt.want) {
+					t.Errorf("errors.Is(err, %v) = %v, want %v", sentinel, got, !got)
+				}
+			}
+			var errResp minio.ErrorResponse
+			if !errors.As(err, &errResp) || errResp.Code != tt.code {
+				t.Errorf("errors.As() found %+v, want the original %s response", errResp, tt.code)
+			}
+			if want := "failed to stat object: backend says " + tt.code; err.Error() != want {
+				t.Errorf("Error() = %q, want %q", err, want)
+			}
+		})
+	}
+}
+
+func TestClassifyErrorUnchanged(t *testing.T) {
+	plain := errors.New("connection refused")
+	if got := classifyError(plain); got != plain {
+		t.Errorf("classifyError(plain) = %v, want it returned as-is", got)
+	}
+
+	classified := classifyError(minio.ErrorResponse{Code: "NoSuchKey"})
+	if got := classifyError(classified); got != classified {
+		t.Errorf("classifyError(classified) = %#v, want it returned as-is", got)
+	}
+}
+
+func TestBackendErrors(t *testing.T) {
+	ctx := context.Background()
+
+	for name, store := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			if _, err := store.GetObjectInfo(ctx, "missing.txt"); !errors.Is(err, ErrObjectNotFound) {
+				t.Errorf("GetObjectInfo() error = %v, want ErrObjectNotFound", err)
+			}
+			if _, err := store.DownloadBuffer(ctx, "missing.txt"); !errors.Is(err, ErrObjectNotFound) {
+				t.Errorf("DownloadBuffer() error = %v, want ErrObjectNotFound", err)
+			}
+			if _, err := store.GetObjectTags(ctx, "missing.txt"); !errors.Is(err, ErrObjectNotFound) || errors.Is(err, ErrAccessDenied) {
+				t.Errorf("GetObjectTags() error = %v, want only ErrObjectNotFound", err)
+			}
+		})
+	}
+
+	t.Run("missing bucket", func(t *testing.T) {
+		service := newTestService(t, storagetest.NewFakeS3(), Config{}).WithBucket("missing-bucket")
+		if _, err := service.GetObjectTags(ctx, "notes.txt"); !errors.Is(err, ErrBucketNotFound) {
+			t.Errorf("GetObjectTags() error = %v, want ErrBucketNotFound", err)
+		}
+	})
+
+	t.Run("access denied", func(t *testing.T) {
+		fake := storagetest.NewFakeS3()
+		service := newTestService(t, fake, Config{})
+		fake.Before = func(w http.ResponseWriter, r *http.Request) bool {
+			if r.URL.Path != "/test-bucket/secret.txt" {
+				return false
+			}
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `<Error><Code>AccessDenied</Code><Message>Access Denied.</Message></Error>`)
+			return true
+		}
+
+		if _, err := service.DownloadBuffer(ctx, "secret.txt"); !errors.Is(err, ErrAccessDenied) || errors.Is(err, ErrObjectNotFound) {
+			t.Errorf("DownloadBuffer() error = %v, want only ErrAccessDenied", err)
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/minio/minio-go/v7/pkg/notification"
+)
+
+// ObjectEvent is a bucket notification about one object.
+type ObjectEvent struct {
+	Type      string // e.g. "s3:ObjectCreated:Put" or "s3:ObjectRemoved:Delete"
+	Bucket    string
+	Key       string
+	Size      int64
+	ETag      string
+	VersionID string
+	Time      time.Time
+}
+
+var objectEventTypes = []string{
+	string(notification.ObjectCreatedAll),
+	string(notification.ObjectRemovedAll),
+}
+
+// ListenObjectEvents calls fn for every object created or removed in the
+// bucket whose key matches prefix and suffix (either may be empty), until ctx
+// is done. fn runs on the listening goroutine, so it should not block for
+// long. If the subscription fails it is re-established with backoff; events
+// in between are missed. Listening is a MinIO extension that AWS S3 and GCS
+// don't support.
+func (s *MinIOService) ListenObjectEvents(ctx context.Context, prefix, suffix string, fn func(ObjectEvent)) error {
+	delay := listenRetry.BaseDelay
+	for {
+		var err error
+		for info := range s.Client.ListenBucketNotification(ctx, s.BucketName, prefix, suffix, objectEventTypes) {
+			if info.Err != nil {
+				err = info.Err
+				continue
+			}
+			delay = listenRetry.BaseDelay
+			for _, record := range info.Records {
+				fn(newObjectEvent(record))
+			}
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		s.logger.Warn("Bucket notification subscription ended, retrying", "bucket", s.BucketName, "delay", delay, "error", err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		delay = min(delay*2, listenRetry.MaxDelay)
+	}
+}
+
+func newObjectEvent(record notification.Event) ObjectEvent {
+	// Keys arrive URL-encoded, as in S3 event payloads.
+	key, err := url.QueryUnescape(record.S3.Object.Key)
+	if err != nil {
+		key = record.S3.Object.Key
+	}
+	eventTime, _ := time.Parse(time.RFC3339Nano, record.EventTime)
+
+	return ObjectEvent{
+		Type:      record.EventName,
+		Bucket:    record.S3.Bucket.Name,
+		Key:       key,
+		Size:      record.S3.Object.Size,
+		ETag:      record.S3.Object.ETag,
+		VersionID: record.S3.Object.VersionID,
+		Time:      eventTime,
+	}
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"MinIO-Learn/internal/storage/storagetest"
+)
+
+// concurrency tracks how many goroutines are inside a section and the most
+// that ever were at once.
+type concurrency struct {
+	now, peak atomic.Int32
+}
+
+func (c *concurrency) enter() {
+	n := c.now.Add(1)
+	for {
+		peak := c.peak.Load()
+		if n <= peak || c.peak.CompareAndSwap(peak, n) {
+			return
+		}
+	}
+}
+
+func (c *concurrency) leave() { c.now.Add(-1) }
+
+func TestKeyLocker(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     []string
+		parallel bool
+	}{
+		{name: "same key serializes", keys: []string{"a.txt", "a.txt", "a.txt"}, parallel: false},
+		{name: "different keys run together", keys: []string{"a.txt", "b.txt", "c.txt"}, parallel: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var locks keyLocker
+			var held concurrency
+			var wg sync.WaitGroup
+			start := make(chan struct{})
+
+			for _, key := range tt.keys {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					<-start
+					unlock := locks.lock(key)
+					defer unlock()
+
+					held.enter()
+					time.Sleep(20 * time.Millisecond)
+					held.leave()
+				}()
+			}
+			close(start)
+			wg.Wait()
+
+			if got := held.peak.Load() > 1; got != tt.parallel {
+				t.Errorf("locks held together = %d, want parallel = %v", held.peak.Load(), tt.parallel)
+			}
+			if len(locks.locks) != 0 {
+				t.Errorf("locks left after release = %d, want 0", len(locks.locks))
+			}
+		})
+	}
+}
+
+func TestConcurrentUploadsSameKey(t *testing.T) {
+	fake := storagetest.NewFakeS3()
+	var inFlight concurrency
+	fake.Before = func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method != http.MethodPut || !strings.HasSuffix(r.URL.Path, "/shared.txt") {
+			return false
+		}
+		inFlight.enter()
+		time.Sleep(20 * time.Millisecond)
+		inFlight.leave()
+		return false
+	}
+	service := newTestService(t, fake, Config{})
+
+	payloads := [][]byte{
+		bytes.Repeat([]byte("a"), 4096),
+		bytes.Repeat([]byte("b"), 4096),
+		bytes.Repeat([]byte("c"), 4096),
+	}
+	var wg sync.WaitGroup
+	for _, payload := range payloads {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := service.UploadBuffer(context.Background(), "shared.txt", payload, "text/plain", nil); err != nil {
+				t.Errorf("UploadBuffer() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := inFlight.peak.Load(); got != 1 {
+		t.Errorf("uploads in flight together = %d, want 1", got)
+	}
+
+	data, err := service.DownloadBuffer(context.Background(), "shared.txt")
+	if err != nil {
+		t.Fatalf("DownloadBuffer() error = %v", err)
+	}
+	consistent := false
+	for _, payload := range payloads {
+		if bytes.Equal(data, payload) {
+			consistent = true
+		}
+	}
+	if !consistent {
+		t.Errorf("stored object (%d bytes) is not one of the uploaded payloads", len(data))
+	}
+}
@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	"MinIO-Learn/internal/storage/storagetest"
+	"github.com/minio/minio-go/v7"
+)
+
+func TestForEachObjectStops(t *testing.T) {
+	const objects, stopAfter = 2500, 3
+	errStop := errors.New("stop")
+
+	var listRequests atomic.Int32
+	fake := storagetest.NewFakeS3()
+	fake.Before = func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2" {
+			listRequests.Add(1)
+		}
+		return false
+	}
+	memory := NewMemoryStorage("test-bucket")
+	backends := map[string]Storage{
+		"minio":  newTestService(t, fake, Config{}),
+		"memory": memory,
+	}
+
+	ctx := context.Background()
+	for i := range objects {
+		key := fmt.Sprintf("logs/%05d.txt", i)
+		fake.PutObject("test-bucket", key, []byte(key), nil)
+		if _, err := memory.UploadBuffer(ctx, key, []byte(key), "text/plain", nil); err != nil {
+			t.Fatalf("UploadBuffer() error = %v", err)
+		}
+	}
+
+	for name, store := range backends {
+		t.Run(name, func(t *testing.T) {
+			var seen []string
+			err := store.ForEachObject(ctx, "logs/", func(object minio.ObjectInfo) error {
+				seen = append(seen, object.Key)
+				if len(seen) == stopAfter {
+					return errStop
+				}
+				return nil
+			})
+			if !errors.Is(err, errStop) {
+				t.Fatalf("ForEachObject() error = %v, want the error fn returned", err)
+			}
+			if len(seen) != stopAfter {
+				t.Errorf("fn called %d times, want %d", len(seen), stopAfter)
+			}
+			for i, key := range seen {
+				if want := fmt.Sprintf("logs/%05d.txt", i); key != want {
+					t.Errorf("object %d = %q, want %q", i, key, want)
+				}
+			}
+		})
+	}
+
+	// Stopping early must not page through the rest of the listing.
+	if got := listRequests.Load(); got != 1 {
+		t.Errorf("list requests = %d, want 1", got)
+	}
+}
+
+func TestListObjectsPages(t *testing.T) {
+	const objects = 2500
+
+	for name, store := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			for i := range objects {
+				key := fmt.Sprintf("logs/%05d.txt", i)
+				if _, err := store.UploadBuffer(ctx, key, nil, "text/plain", nil); err != nil {
+					t.Fatalf("UploadBuffer() error = %v", err)
+				}
+			}
+
+			listed, err := store.ListObjects(ctx, "logs/")
+			if err != nil {
+				t.Fatalf("ListObjects() error = %v", err)
+			}
+			if len(listed) != objects {
+				t.Errorf("ListObjects() returned %d objects, want %d", len(listed), objects)
+			}
+		})
+	}
+}
+
+func TestListFolder(t *testing.T) {
+	keys := []string{
+		"docs/readme.txt",
+		"docs/2024/jan/report.pdf",
+		"docs/2024/feb/report.pdf",
+		"docs/2024/summary.txt",
+		"docs/archive/old.txt",
+		"photos/cat.jpg",
+		"top.txt",
+	}
+	tests := []struct {
+		prefix       string
+		wantObjects  []string
+		wantPrefixes []string
+	}{
+		{"", []string{"top.txt"}, []string{"docs/", "photos/"}},
+		{"docs/", []string{"docs/readme.txt"}, []string{"docs/2024/", "docs/archive/"}},
+		{"docs/2024/", []string{"docs/2024/summary.txt"}, []string{"docs/2024/feb/", "docs/2024/jan/"}},
+		{"docs/2024/jan/", []string{"docs/2024/jan/report.pdf"}, nil},
+		{"missing/", nil, nil},
+	}
+
+	for name, store := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			for _, key := range keys {
+				if _, err := store.UploadBuffer(ctx, key, []byte(key), "text/plain", nil); err != nil {
+					t.Fatalf("UploadBuffer(%q) error = %v", key, err)
+				}
+			}
+
+			for _, tt := range tests {
+				listing, err := store.ListFolder(ctx, tt.prefix)
+				if err != nil {
+					t.Fatalf("ListFolder(%q) error = %v", tt.prefix, err)
+				}
+				var objects []string
+				for _, object := range listing.Objects {
+					objects = append(objects, object.Key)
+				}
+				if !slices.Equal(objects, tt.wantObjects) {
+					t.Errorf("ListFolder(%q) objects = %q, want %q", tt.prefix, objects, tt.wantObjects)
+				}
+				if !slices.Equal(listing.Prefixes, tt.wantPrefixes) {
+					t.Errorf("ListFolder(%q) prefixes = %q, want %q", tt.prefix, listing.Prefixes, tt.wantPrefixes)
+				}
+			}
+		})
+	}
+}
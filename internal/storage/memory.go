@@ -0,0 +1,1444 @@
+package storage
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// MemoryStorage is a Storage kept in a map, for running the server without
+// a MinIO backend, e.g. in tests. Objects, versions, tags and lifecycle
+// rules are stored but nothing acts on them in the background: lifecycle
+// rules never expire anything and storage classes are only recorded.
+// Presigned URLs use a memory:// scheme that no client can fetch.
+type MemoryStorage struct {
+	BucketName string
+
+	mu          sync.Mutex
+	objects     map[string][]*memoryObject // versions of each key, oldest first
+	buckets     map[string]bool
+	versioning  bool
+	objectLock  bool
+	allowPublic bool
+	softDelete  bool
+	multiTenant bool
+	maxBuffer   int64
+	nextVersion int
+	lifecycle   []LifecycleRule
+	sessions    map[string]*memorySession
+
+	others map[string]*MemoryStorage // other buckets, by name
+}
+
+// memorySession is an upload session with its parts, by part number.
+type memorySession struct {
+	session  UploadSession
+	metadata map[string]string
+	parts    map[int][]byte
+	hash     partHash
+}
+
+type memoryObject struct {
+	info minio.ObjectInfo
+	data []byte
+	tags map[string]string
+
+	retention   minio.RetentionMode
+	retainUntil time.Time
+	legalHold   bool
+}
+
+// locked reports whether the version can't be deleted at now.
+func (o *memoryObject) locked(now time.Time) bool {
+	return o.legalHold || (o.retention != "" && now.Before(o.retainUntil))
+}
+
+// NewMemoryStorage returns an empty MemoryStorage whose bucket already
+// exists.
+func NewMemoryStorage(bucketName string) *MemoryStorage {
+	return &MemoryStorage{
+		BucketName: bucketName,
+		objects:    make(map[string][]*memoryObject),
+		buckets:    map[string]bool{bucketName: true},
+	}
+}
+
+func (m *MemoryStorage) noSuchKey(objectName string) error {
+	return classifyError(minio.ErrorResponse{
+		StatusCode: http.StatusNotFound,
+		Code:       "NoSuchKey",
+		Message:    "The specified key does not exist.",
+		BucketName: m.BucketName,
+		Key:        objectName,
+	})
+}
+
+// latest returns the current version of objectName. m.mu must be held.
+func (m *MemoryStorage) latest(objectName string) (*memoryObject, error) {
+	return m.version(objectName, "")
+}
+
+// version returns the given version of objectName, or the current one if
+// versionID is empty. m.mu must be held.
+func (m *MemoryStorage) version(objectName, versionID string) (*memoryObject, error) {
+	versions := m.objects[objectName]
+	if versionID == "" {
+		if len(versions) == 0 || versions[len(versions)-1].info.IsDeleteMarker {
+			return nil, m.noSuchKey(objectName)
+		}
+		return versions[len(versions)-1], nil
+	}
+
+	for _, obj := range versions {
+		if obj.info.VersionID == versionID && !obj.info.IsDeleteMarker {
+			return obj, nil
+		}
+	}
+	return nil, classifyError(minio.ErrorResponse{
+		StatusCode: http.StatusNotFound,
+		Code:       "NoSuchVersion",
+		Message:    "The specified version does not exist.",
+		BucketName: m.BucketName,
+		Key:        objectName,
+	})
+}
+
+// newVersionID returns the ID for the next version written, or "" when
+// versioning is off. m.mu must be held.
+func (m *MemoryStorage) newVersionID() string {
+	if !m.versioning {
+		return ""
+	}
+	m.nextVersion++
+	return strconv.Itoa(m.nextVersion)
+}
+
+// store adds a new version of objectName. data is kept as-is, so callers
+// must not modify it afterwards. m.mu must be held.
+func (m *MemoryStorage) store(objectName string, data []byte, contentType string, metadata map[string]string, objectTags map[string]string) minio.UploadInfo {
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	sum := md5.Sum(data)
+	info := minio.ObjectInfo{
+		Key:          objectName,
+		Size:         int64(len(data)),
+		ETag:         hex.EncodeToString(sum[:]),
+		LastModified: time.Now().UTC(),
+		ContentType:  contentType,
+		StorageClass: "STANDARD",
+		UserMetadata: make(minio.StringMap, len(metadata)),
+		VersionID:    m.newVersionID(),
+	}
+	for key, value := range metadata {
+		key = http.CanonicalHeaderKey(key)
+		if key == storageClassKey {
+			info.StorageClass = value
+			continue
+		}
+		info.UserMetadata[key] = value
+	}
+	if info.UserMetadata[checksumKey] == "" {
+		checksum := sha256.Sum256(data)
+		info.UserMetadata[checksumKey] = hex.EncodeToString(checksum[:])
+	}
+
+	obj := &memoryObject{info: info, data: data, tags: copyTags(objectTags)}
+	if m.versioning {
+		m.objects[objectName] = append(m.objects[objectName], obj)
+	} else {
+		m.objects[objectName] = []*memoryObject{obj}
+	}
+
+	return minio.UploadInfo{
+		Bucket:       m.BucketName,
+		Key:          objectName,
+		ETag:         info.ETag,
+		Size:         info.Size,
+		LastModified: info.LastModified,
+		VersionID:    info.VersionID,
+	}
+}
+
+// remove deletes the current version of objectName, leaving a delete marker
+// on a versioned bucket. Removing a missing key is not an error. m.mu must be
+// held.
+func (m *MemoryStorage) remove(objectName string) {
+	if !m.versioning {
+		delete(m.objects, objectName)
+		return
+	}
+	m.objects[objectName] = append(m.objects[objectName], &memoryObject{info: minio.ObjectInfo{
+		Key:            objectName,
+		LastModified:   time.Now().UTC(),
+		IsDeleteMarker: true,
+		VersionID:      m.newVersionID(),
+	}})
+}
+
+// list returns the current version of every object under prefix sorted by
+// key, leaving out system keys like the MinIOService listings.
+func (m *MemoryStorage) list(prefix string) []minio.ObjectInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var objects []minio.ObjectInfo
+	for key := range m.objects {
+		if !strings.HasPrefix(key, prefix) || IsSystemKey(key) {
+			continue
+		}
+		if obj, err := m.latest(key); err == nil {
+			objects = append(objects, obj.info)
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+
+	return objects
+}
+
+func copyTags(objectTags map[string]string) map[string]string {
+	copied := make(map[string]string, len(objectTags))
+	for key, value := range objectTags {
+		copied[key] = value
+	}
+	return copied
+}
+
+func (m *MemoryStorage) EnsureBucket(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.buckets[m.BucketName] = true
+	return nil
+}
+
+// EnsureBuckets records the buckets in specs as existing. Their versioning,
+// lifecycle and policy settings are accepted but not applied.
+func (m *MemoryStorage) EnsureBuckets(ctx context.Context, specs []BucketSpec) ([]BucketProvisionResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	results := make([]BucketProvisionResult, 0, len(specs))
+	for _, spec := range specs {
+		results = append(results, BucketProvisionResult{Name: spec.Name, Created: !m.buckets[spec.Name]})
+		m.buckets[spec.Name] = true
+	}
+
+	return results, nil
+}
+
+// ListBuckets returns the known buckets sorted by name. Creation dates are
+// not tracked.
+func (m *MemoryStorage) ListBuckets(ctx context.Context) ([]minio.BucketInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	buckets := make([]minio.BucketInfo, 0, len(m.buckets))
+	for name := range m.buckets {
+		buckets = append(buckets, minio.BucketInfo{Name: name})
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Name < buckets[j].Name })
+	return buckets, nil
+}
+
+func (m *MemoryStorage) CreateBucket(ctx context.Context, name, region string) error {
+	if err := ValidateBucketName(name); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.buckets[name] {
+		return fmt.Errorf("%w: '%s'", ErrBucketExists, name)
+	}
+	m.buckets[name] = true
+	return nil
+}
+
+func (m *MemoryStorage) HealthCheck(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.buckets[m.BucketName] {
+		return fmt.Errorf("%w: '%s'", ErrBucketNotFound, m.BucketName)
+	}
+	return nil
+}
+
+func (m *MemoryStorage) EnableVersioning(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.versioning = true
+	return nil
+}
+
+func (m *MemoryStorage) UploadFile(ctx context.Context, objectName, filePath, contentType string, metadata map[string]string) (minio.UploadInfo, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return minio.UploadInfo{}, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	newProgressCounter(ctx, int64(len(data))).finish()
+	return m.store(objectName, data, contentType, metadata, nil), nil
+}
+
+func (m *MemoryStorage) UploadBuffer(ctx context.Context, objectName string, data []byte, contentType string, metadata map[string]string) (minio.UploadInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	newProgressCounter(ctx, int64(len(data))).finish()
+	return m.store(objectName, append([]byte(nil), data...), contentType, metadata, nil), nil
+}
+
+func (m *MemoryStorage) UploadLargeFile(ctx context.Context, objectName, filePath, contentType string, metadata map[string]string) (minio.UploadInfo, error) {
+	return m.UploadFile(ctx, objectName, filePath, contentType, metadata)
+}
+
+func (m *MemoryStorage) UploadDirectory(ctx context.Context, localDir, keyPrefix string) (DirectoryUploadResult, error) {
+	return uploadDirectory(ctx, localDir, keyPrefix, defaultDirectoryConcurrency, m.UploadFile)
+}
+
+// UploadStream reads reader to the end. A non-negative size must match the
+// number of bytes read.
+func (m *MemoryStorage) UploadStream(ctx context.Context, objectName string, reader io.Reader, size int64, contentType string, metadata map[string]string) (minio.UploadInfo, error) {
+	progress := newProgressCounter(ctx, size)
+	if progress != nil {
+		reader = &progressReader{Reader: reader, progress: progress}
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return minio.UploadInfo{}, fmt.Errorf("failed to upload stream: %w", err)
+	}
+	if size >= 0 && int64(len(data)) != size {
+		return minio.UploadInfo{}, fmt.Errorf("stored size %d does not match %d streamed bytes", size, len(data))
+	}
+	progress.finish()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.store(objectName, data, contentType, metadata, nil), nil
+}
+
+func (m *MemoryStorage) InitUploadSession(ctx context.Context, objectName, contentType, owner string, metadata map[string]string) (UploadSession, error) {
+	id, err := newSessionID()
+	if err != nil {
+		return UploadSession{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.sessions == nil {
+		m.sessions = make(map[string]*memorySession)
+	}
+	session := UploadSession{
+		ID:          id,
+		ObjectName:  objectName,
+		ContentType: contentType,
+		Checksum:    metadata[checksumKey],
+		UploadID:    id,
+		Owner:       owner,
+		Created:     time.Now().UTC(),
+	}
+	m.sessions[id] = &memorySession{session: session, metadata: metadata, parts: make(map[int][]byte)}
+	return session, nil
+}
+
+// uploadSession returns the session id. m.mu must be held.
+func (m *MemoryStorage) uploadSession(id string) (*memorySession, error) {
+	session, ok := m.sessions[id]
+	if !ok {
+		return nil, ErrUnknownSession
+	}
+	return session, nil
+}
+
+func (m *MemoryStorage) UploadPart(ctx context.Context, sessionID string, partNumber int, data io.Reader, size int64) (UploadedPart, error) {
+	if err := validPartNumber(partNumber); err != nil {
+		return UploadedPart{}, err
+	}
+
+	part, err := io.ReadAll(data)
+	if err != nil {
+		return UploadedPart{}, fmt.Errorf("failed to upload part: %w", err)
+	}
+	if size >= 0 && int64(len(part)) != size {
+		return UploadedPart{}, fmt.Errorf("part size %d does not match %d streamed bytes", size, len(part))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, err := m.uploadSession(sessionID)
+	if err != nil {
+		return UploadedPart{}, err
+	}
+	session.parts[partNumber] = part
+
+	uploaded := memoryPart(partNumber, part)
+	if content, ok := session.hash.extend(partNumber); ok {
+		content.Write(part)
+		if err := session.hash.add(content, uploaded); err != nil {
+			return UploadedPart{}, err
+		}
+	}
+	return uploaded, nil
+}
+
+func memoryPart(partNumber int, data []byte) UploadedPart {
+	sum := md5.Sum(data)
+	return UploadedPart{PartNumber: partNumber, Size: int64(len(data)), ETag: hex.EncodeToString(sum[:])}
+}
+
+func (m *MemoryStorage) GetUploadSession(ctx context.Context, sessionID string) (UploadSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, err := m.uploadSession(sessionID)
+	if err != nil {
+		return UploadSession{}, err
+	}
+
+	result := session.session
+	for _, partNumber := range session.partNumbers() {
+		result.Parts = append(result.Parts, memoryPart(partNumber, session.parts[partNumber]))
+	}
+	return result, nil
+}
+
+func (s *memorySession) partNumbers() []int {
+	partNumbers := make([]int, 0, len(s.parts))
+	for partNumber := range s.parts {
+		partNumbers = append(partNumbers, partNumber)
+	}
+	sort.Ints(partNumbers)
+	return partNumbers
+}
+
+func (m *MemoryStorage) CompleteUpload(ctx context.Context, sessionID string) (minio.UploadInfo, string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, err := m.uploadSession(sessionID)
+	if err != nil {
+		return minio.UploadInfo{}, "", err
+	}
+	if len(session.parts) == 0 {
+		return minio.UploadInfo{}, "", ErrNoParts
+	}
+
+	var data []byte
+	var parts []UploadedPart
+	for _, partNumber := range session.partNumbers() {
+		data = append(data, session.parts[partNumber]...)
+		parts = append(parts, memoryPart(partNumber, session.parts[partNumber]))
+	}
+	checksum := session.hash.checksum(parts)
+	if err := verifyParts(session.session.Checksum, checksum); err != nil {
+		return minio.UploadInfo{}, "", err
+	}
+	delete(m.sessions, sessionID)
+
+	return m.store(session.session.ObjectName, data, session.session.ContentType, session.metadata, nil), checksum, nil
+}
+
+func (m *MemoryStorage) AbortUpload(ctx context.Context, sessionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := m.uploadSession(sessionID); err != nil {
+		return err
+	}
+	delete(m.sessions, sessionID)
+	return nil
+}
+
+func (m *MemoryStorage) ExpireUploadSessions(ctx context.Context, olderThan time.Duration) (int, error) {
+	cutoff := time.Now().Add(-olderThan)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	expired := 0
+	for id, session := range m.sessions {
+		if session.session.Created.Before(cutoff) {
+			delete(m.sessions, id)
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+func (m *MemoryStorage) DownloadFile(ctx context.Context, objectName, filePath string) error {
+	data, err := m.DownloadBuffer(ctx, objectName)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filePath, data, 0o644); err != nil {
+		return fmt.Errorf("failed to download file: %w", err)
+	}
+	return nil
+}
+
+func (m *MemoryStorage) DownloadBuffer(ctx context.Context, objectName string) ([]byte, error) {
+	return m.DownloadBufferVersion(ctx, objectName, "")
+}
+
+func (m *MemoryStorage) DownloadBufferVersion(ctx context.Context, objectName, versionID string) ([]byte, error) {
+	var opts ReadOptions
+	opts.SetVersionID(versionID)
+	return m.DownloadBufferWithOptions(ctx, objectName, opts)
+}
+
+// SetMaxDownloadBuffer caps the size of objects DownloadBuffer returns, like
+// Config.MaxDownloadBuffer.
+func (m *MemoryStorage) SetMaxDownloadBuffer(limit int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxBuffer = limit
+}
+
+// DownloadBufferWithOptions honours the version and ETag preconditions in
+// opts the way MinIO does.
+func (m *MemoryStorage) DownloadBufferWithOptions(ctx context.Context, objectName string, opts ReadOptions) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	data, err := m.read(objectName, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkBufferSize(int64(len(data)), m.maxBuffer); err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// read returns the data of the version of objectName opts selects, without
+// copying it. m.mu must be held.
+func (m *MemoryStorage) read(objectName string, opts ReadOptions) ([]byte, error) {
+
+	obj, err := m.version(objectName, opts.opts.VersionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object: %w", err)
+	}
+
+	header := opts.opts.Header()
+	if match := header.Get("If-Match"); match != "" && strings.Trim(match, `"`) != obj.info.ETag {
+		return nil, fmt.Errorf("failed to get object: %w", ErrPreconditionFailed)
+	}
+	if noneMatch := header.Get("If-None-Match"); noneMatch != "" && strings.Trim(noneMatch, `"`) == obj.info.ETag {
+		return nil, fmt.Errorf("failed to get object: %w", ErrNotModified)
+	}
+
+	return obj.data, nil
+}
+
+func (m *MemoryStorage) DownloadToWriter(ctx context.Context, objectName string, w io.Writer) (int64, error) {
+	return m.DownloadToWriterWithOptions(ctx, objectName, ReadOptions{}, w)
+}
+
+// DownloadToWriterWithOptions streams, so unlike DownloadBufferWithOptions
+// it isn't subject to SetMaxDownloadBuffer.
+func (m *MemoryStorage) DownloadToWriterWithOptions(ctx context.Context, objectName string, opts ReadOptions, w io.Writer) (int64, error) {
+	m.mu.Lock()
+	data, err := m.read(objectName, opts)
+	m.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	progress := newProgressCounter(ctx, int64(len(data)))
+	if progress != nil {
+		w = &progressWriter{Writer: w, progress: progress}
+	}
+
+	n, err := w.Write(data)
+	if err != nil {
+		return int64(n), fmt.Errorf("failed to stream object data after %d bytes: %w", n, err)
+	}
+	progress.finish()
+	return int64(n), nil
+}
+
+// GetObjectRange accepts the same ranges as MinIOService.GetObjectRange: a
+// negative end with a zero start reads the last -end bytes, and a zero end
+// with a positive start reads to the end of the object.
+func (m *MemoryStorage) GetObjectRange(ctx context.Context, objectName string, start, end int64) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	obj, err := m.latest(objectName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object: %w", err)
+	}
+
+	data, err := m.slice(objectName, obj.data, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkBufferSize(int64(len(data)), m.maxBuffer); err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), data...), nil
+}
+
+func (m *MemoryStorage) DownloadRangeToWriter(ctx context.Context, objectName string, opts ReadOptions, start, end int64, w io.Writer) (int64, error) {
+	m.mu.Lock()
+	data, err := m.read(objectName, opts)
+	if err == nil {
+		data, err = m.slice(objectName, data, start, end)
+	}
+	m.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(data)
+	if err != nil {
+		return int64(n), fmt.Errorf("failed to stream object range after %d bytes: %w", n, err)
+	}
+	return int64(n), nil
+}
+
+// slice resolves a range the way MinIOService.GetObjectRange does and returns
+// that part of data, the content of objectName.
+func (m *MemoryStorage) slice(objectName string, data []byte, start, end int64) ([]byte, error) {
+	var opts minio.GetObjectOptions
+	if err := opts.SetRange(start, end); err != nil {
+		return nil, fmt.Errorf("invalid range: %w", err)
+	}
+
+	size := int64(len(data))
+	switch {
+	case start == 0 && end < 0:
+		start, end = max(size+end, 0), size-1
+	case start > 0 && end == 0:
+		end = size - 1
+	}
+	if start >= size {
+		return nil, fmt.Errorf("failed to read object range: %w", minio.ErrorResponse{
+			StatusCode: http.StatusRequestedRangeNotSatisfiable,
+			Code:       "InvalidRange",
+			Message:    "The requested range is not satisfiable",
+			BucketName: m.BucketName,
+			Key:        objectName,
+		})
+	}
+	end = min(end, size-1)
+
+	return data[start : end+1], nil
+}
+
+func (m *MemoryStorage) CheckObjectExists(ctx context.Context, objectName string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := m.latest(objectName)
+	return err == nil, nil
+}
+
+func (m *MemoryStorage) GetObjectInfo(ctx context.Context, objectName string) (minio.ObjectInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	obj, err := m.latest(objectName)
+	if err != nil {
+		return minio.ObjectInfo{}, fmt.Errorf("failed to stat object: %w", err)
+	}
+	return obj.info, nil
+}
+
+func (m *MemoryStorage) VerifyObject(ctx context.Context, objectName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	obj, err := m.latest(objectName)
+	if err != nil {
+		return fmt.Errorf("failed to get object: %w", err)
+	}
+	expected := ObjectChecksum(obj.info)
+	if expected == "" {
+		return ErrNoChecksum
+	}
+
+	checksum := sha256.Sum256(obj.data)
+	if actual := hex.EncodeToString(checksum[:]); actual != expected {
+		return fmt.Errorf("%w: stored %s, computed %s", ErrChecksumMismatch, expected, actual)
+	}
+	return nil
+}
+
+// FindObjectCaseInsensitive looks among the keys sharing objectName's parent
+// prefix, like MinIOService.FindObjectCaseInsensitive.
+func (m *MemoryStorage) FindObjectCaseInsensitive(ctx context.Context, objectName string) (string, error) {
+	prefix := ""
+	if i := strings.LastIndex(objectName, "/"); i >= 0 {
+		prefix = objectName[:i+1]
+	}
+
+	for _, object := range m.list(prefix) {
+		if strings.EqualFold(object.Key, objectName) {
+			return object.Key, nil
+		}
+	}
+	return "", nil
+}
+
+func (m *MemoryStorage) ListObjects(ctx context.Context, prefix string) ([]minio.ObjectInfo, error) {
+	var objects []minio.ObjectInfo
+	err := m.ForEachObject(ctx, prefix, func(object minio.ObjectInfo) error {
+		objects = append(objects, object)
+		return nil
+	})
+	return objects, err
+}
+
+func (m *MemoryStorage) ListObjectsPaginated(ctx context.Context, prefix, startAfter string, maxKeys int) (ObjectPage, error) {
+	return m.SearchObjectsPaginated(ctx, prefix, startAfter, maxKeys, ObjectFilter{})
+}
+
+func (m *MemoryStorage) ListFolder(ctx context.Context, prefix string) (FolderListing, error) {
+	var listing FolderListing
+	for _, object := range m.list(prefix) {
+		rest := strings.TrimPrefix(object.Key, prefix)
+		if rest == "" {
+			continue
+		}
+		if i := strings.Index(rest, "/"); i >= 0 {
+			folder := prefix + rest[:i+1]
+			// Keys are sorted, so a folder's keys are adjacent.
+			if n := len(listing.Prefixes); n == 0 || listing.Prefixes[n-1] != folder {
+				listing.Prefixes = append(listing.Prefixes, folder)
+			}
+			continue
+		}
+		listing.Objects = append(listing.Objects, object)
+	}
+	return listing, nil
+}
+
+func (m *MemoryStorage) SearchObjects(ctx context.Context, prefix string, filter ObjectFilter) ([]minio.ObjectInfo, error) {
+	var objects []minio.ObjectInfo
+	for _, object := range m.list(prefix) {
+		if filter.matches(object) {
+			objects = append(objects, object)
+		}
+	}
+	return objects, nil
+}
+
+func (m *MemoryStorage) SearchObjectsPaginated(ctx context.Context, prefix, startAfter string, maxKeys int, filter ObjectFilter) (ObjectPage, error) {
+	var page ObjectPage
+	for _, object := range m.list(prefix) {
+		if object.Key <= startAfter || !filter.matches(object) {
+			continue
+		}
+		if len(page.Objects) == maxKeys {
+			page.NextToken = page.Objects[maxKeys-1].Key
+			break
+		}
+		page.Objects = append(page.Objects, object)
+	}
+	return page, nil
+}
+
+// ForEachObject calls fn on a snapshot of the listing, so fn may modify the
+// storage.
+func (m *MemoryStorage) ForEachObject(ctx context.Context, prefix string, fn func(minio.ObjectInfo) error) error {
+	for _, object := range m.list(prefix) {
+		if err := fn(object); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStorage) RecentUploads(ctx context.Context, prefix string, n int) ([]minio.ObjectInfo, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("n must be positive, got %d", n)
+	}
+
+	objects := m.list(prefix)
+	sort.SliceStable(objects, func(i, j int) bool {
+		return objects[i].LastModified.After(objects[j].LastModified)
+	})
+	if len(objects) > n {
+		objects = objects[:n]
+	}
+	return objects, nil
+}
+
+func (m *MemoryStorage) ListModifiedSince(ctx context.Context, prefix string, since time.Time) ([]minio.ObjectInfo, error) {
+	var changed []minio.ObjectInfo
+	for _, object := range m.list(prefix) {
+		if !object.LastModified.Before(since) {
+			changed = append(changed, object)
+		}
+	}
+	sort.SliceStable(changed, func(i, j int) bool {
+		return changed[i].LastModified.Before(changed[j].LastModified)
+	})
+	return changed, nil
+}
+
+func (m *MemoryStorage) ListObjectVersions(ctx context.Context, prefix string) ([]minio.ObjectInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]string, 0, len(m.objects))
+	for key := range m.objects {
+		if strings.HasPrefix(key, prefix) && !IsSystemKey(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var versions []minio.ObjectInfo
+	for _, key := range keys {
+		history := m.objects[key]
+		for i := len(history) - 1; i >= 0; i-- {
+			info := history[i].info
+			info.IsLatest = i == len(history)-1
+			versions = append(versions, info)
+		}
+	}
+	return versions, nil
+}
+
+func (m *MemoryStorage) DeleteObject(ctx context.Context, objectName string) error {
+	if m.trashing(objectName) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.trash(objectName)
+		return nil
+	}
+	return m.DeleteObjectVersion(ctx, objectName, "")
+}
+
+// DeleteObjectVersion permanently removes one version. With an empty
+// versionID it removes the object, or adds a delete marker if versioning is
+// enabled.
+func (m *MemoryStorage) DeleteObjectVersion(ctx context.Context, objectName, versionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if versionID == "" {
+		m.remove(objectName)
+		return nil
+	}
+
+	history := m.objects[objectName]
+	for i, obj := range history {
+		if obj.info.VersionID == versionID {
+			if obj.locked(time.Now()) {
+				return fmt.Errorf("failed to delete object: %w", m.wormProtected(objectName))
+			}
+			history = append(history[:i:i], history[i+1:]...)
+			break
+		}
+	}
+	if len(history) == 0 {
+		delete(m.objects, objectName)
+	} else {
+		m.objects[objectName] = history
+	}
+	return nil
+}
+
+func (m *MemoryStorage) DeleteObjects(ctx context.Context, objectNames []string) []ObjectError {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, name := range objectNames {
+		if m.softDelete && !isTrashKey(name, m.multiTenant) {
+			m.trash(name)
+			continue
+		}
+		m.remove(name)
+	}
+	return nil
+}
+
+func (m *MemoryStorage) DeleteByPrefix(ctx context.Context, prefix string, force bool) (int, error) {
+	if prefix == "" && !force {
+		return 0, ErrEmptyPrefix
+	}
+
+	objects := m.list(prefix)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, object := range objects {
+		m.remove(object.Key)
+	}
+	return len(objects), nil
+}
+
+// copy copies the current version of srcObject, with its tags, to
+// dstObject. m.mu must be held.
+func (m *MemoryStorage) copy(srcObject, dstObject string) (minio.UploadInfo, error) {
+	src, err := m.latest(srcObject)
+	if err != nil {
+		return minio.UploadInfo{}, fmt.Errorf("failed to copy object: %w", err)
+	}
+	return m.store(dstObject, src.data, src.info.ContentType, src.info.UserMetadata, src.tags), nil
+}
+
+func (m *MemoryStorage) CopyObject(ctx context.Context, srcObject, dstObject string) (minio.UploadInfo, error) {
+	if srcObject == dstObject {
+		return minio.UploadInfo{}, fmt.Errorf("source and destination are the same object")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.copy(srcObject, dstObject)
+}
+
+// WithBucket returns the storage of bucket bucketName, which holds objects
+// copied there with CopyObjectToBucket. The bucket is not created.
+func (m *MemoryStorage) WithBucket(bucketName string) *MemoryStorage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.bucket(bucketName)
+}
+
+// bucket returns the storage of bucketName. m.mu must be held.
+func (m *MemoryStorage) bucket(bucketName string) *MemoryStorage {
+	if bucketName == m.BucketName {
+		return m
+	}
+	other, ok := m.others[bucketName]
+	if !ok {
+		other = NewMemoryStorage(bucketName)
+		if m.others == nil {
+			m.others = make(map[string]*MemoryStorage)
+		}
+		m.others[bucketName] = other
+	}
+	return other
+}
+
+func (m *MemoryStorage) CopyObjectToBucket(ctx context.Context, srcObject, dstBucket, dstObject string) (minio.UploadInfo, error) {
+	if dstBucket == m.BucketName {
+		return m.CopyObject(ctx, srcObject, dstObject)
+	}
+
+	m.mu.Lock()
+	if !m.buckets[dstBucket] {
+		m.mu.Unlock()
+		return minio.UploadInfo{}, fmt.Errorf("%w: '%s'", ErrBucketNotFound, dstBucket)
+	}
+	src, err := m.latest(srcObject)
+	dst := m.bucket(dstBucket)
+	m.mu.Unlock()
+	if err != nil {
+		return minio.UploadInfo{}, fmt.Errorf("failed to copy object: %w", err)
+	}
+
+	dst.mu.Lock()
+	defer dst.mu.Unlock()
+
+	return dst.store(dstObject, src.data, src.info.ContentType, src.info.UserMetadata, src.tags), nil
+}
+
+func (m *MemoryStorage) MoveObject(ctx context.Context, srcObject, dstObject string) (minio.UploadInfo, error) {
+	if srcObject == dstObject {
+		return minio.UploadInfo{}, fmt.Errorf("source and destination are the same object")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	uploadInfo, err := m.copy(srcObject, dstObject)
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+	m.remove(srcObject)
+
+	return uploadInfo, nil
+}
+
+// EnableSoftDelete makes deletes move objects to the trash, like
+// Config.SoftDelete.
+func (m *MemoryStorage) EnableSoftDelete() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.softDelete = true
+}
+
+// EnableMultiTenant keeps each tenant's trash under its prefix, like
+// Config.MultiTenant.
+func (m *MemoryStorage) EnableMultiTenant() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.multiTenant = true
+}
+
+// trashing reports whether deleting objectName moves it to the trash.
+func (m *MemoryStorage) trashing(objectName string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.softDelete && !isTrashKey(objectName, m.multiTenant)
+}
+
+// trash moves objectName to the trash. A missing object is ignored. m.mu
+// must be held.
+func (m *MemoryStorage) trash(objectName string) {
+	if _, err := m.copy(objectName, trashKey(objectName, m.multiTenant)); err != nil {
+		return
+	}
+	m.remove(objectName)
+}
+
+func (m *MemoryStorage) RestoreObject(ctx context.Context, objectName string) (minio.UploadInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	trashed := trashKey(objectName, m.multiTenant)
+	if _, err := m.latest(trashed); err != nil {
+		return minio.UploadInfo{}, fmt.Errorf("%w: '%s' is not in the trash", ErrObjectNotFound, objectName)
+	}
+	if _, err := m.latest(objectName); err == nil {
+		return minio.UploadInfo{}, ErrRestoreConflict
+	}
+
+	uploadInfo, err := m.copy(trashed, objectName)
+	if err != nil {
+		return minio.UploadInfo{}, fmt.Errorf("failed to restore object: %w", err)
+	}
+	m.remove(trashed)
+
+	return uploadInfo, nil
+}
+
+func (m *MemoryStorage) PurgeTrash(ctx context.Context, olderThan time.Duration) (int, error) {
+	cutoff := time.Now().Add(-olderThan)
+	objects := m.list("")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var purged int
+	for _, object := range objects {
+		if isTrashKey(object.Key, m.multiTenant) && object.LastModified.Before(cutoff) {
+			m.remove(object.Key)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// TransitionObject records storageClass on the object; nothing is moved.
+func (m *MemoryStorage) TransitionObject(ctx context.Context, objectName, storageClass string) (string, error) {
+	if storageClass == "" {
+		return "", fmt.Errorf("storage class is required")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	obj, err := m.latest(objectName)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat object: %w", err)
+	}
+	obj.info.StorageClass = storageClass
+
+	return storageClass, nil
+}
+
+func (m *MemoryStorage) UpdateObjectMetadata(ctx context.Context, objectName, contentType string, metadata map[string]string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	obj, err := m.latest(objectName)
+	if err != nil {
+		return fmt.Errorf("failed to stat object: %w", err)
+	}
+	obj.info.ContentType = contentType
+	for k, v := range metadata {
+		obj.info.UserMetadata[k] = v
+	}
+
+	return nil
+}
+
+func (m *MemoryStorage) ArchiveByDate(ctx context.Context, srcPrefix, archivePrefix string, olderThan time.Duration, deleteSource bool) (int, error) {
+	if archivePrefix == "" {
+		return 0, fmt.Errorf("archive prefix is required")
+	}
+	if !strings.HasSuffix(archivePrefix, "/") {
+		archivePrefix += "/"
+	}
+	if strings.HasPrefix(archivePrefix, srcPrefix) || strings.HasPrefix(srcPrefix, archivePrefix) {
+		return 0, fmt.Errorf("archive prefix '%s' must not overlap source prefix '%s'", archivePrefix, srcPrefix)
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	objects := m.list(srcPrefix)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	archived := 0
+	for _, object := range objects {
+		if !object.LastModified.Before(cutoff) {
+			continue
+		}
+		dst := archivePrefix + object.LastModified.UTC().Format("2006/01/02/") + strings.TrimPrefix(object.Key, srcPrefix)
+		if _, err := m.copy(object.Key, dst); err != nil {
+			return archived, fmt.Errorf("failed to archive '%s': %w", object.Key, err)
+		}
+		if deleteSource {
+			m.remove(object.Key)
+		}
+		archived++
+	}
+
+	return archived, nil
+}
+
+// presignedURL returns a memory:// URL shaped like a presigned S3 URL.
+func (m *MemoryStorage) presignedURL(objectName string, expiry time.Duration, params url.Values) string {
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("X-Amz-Expires", strconv.Itoa(int(expiry.Seconds())))
+
+	u := url.URL{Scheme: "memory", Host: m.BucketName, Path: "/" + objectName, RawQuery: params.Encode()}
+	return u.String()
+}
+
+func (m *MemoryStorage) GetObjectURL(ctx context.Context, objectName string, expiry time.Duration) (string, error) {
+	return m.presignedURL(objectName, expiry, nil), nil
+}
+
+// AllowPublic lets SetPublicReadPolicy succeed, like
+// Config.AllowPublic.
+func (m *MemoryStorage) AllowPublic() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.allowPublic = true
+}
+
+// SetPublicReadPolicy only checks that public access is allowed; there is no
+// anonymous access to enforce it against.
+func (m *MemoryStorage) SetPublicReadPolicy(ctx context.Context, prefix string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.allowPublic {
+		return ErrPublicAccessDisabled
+	}
+	return nil
+}
+
+func (m *MemoryStorage) GetPublicURL(objectName string) string {
+	u := url.URL{Scheme: "memory", Host: m.BucketName, Path: "/" + objectName}
+	return u.String()
+}
+
+func (m *MemoryStorage) GetDownloadURL(ctx context.Context, objectName, fileName string, expiry time.Duration) (string, error) {
+	params := url.Values{}
+	params.Set("response-content-disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	return m.presignedURL(objectName, expiry, params), nil
+}
+
+func (m *MemoryStorage) GetUploadURL(ctx context.Context, objectName string, expiry time.Duration) (string, error) {
+	return m.presignedURL(objectName, expiry, nil), nil
+}
+
+func (m *MemoryStorage) GetPresignedPostPolicy(ctx context.Context, keyPrefix string, minSize, maxSize int64, expiry time.Duration) (string, map[string]string, error) {
+	policy := minio.NewPostPolicy()
+	if err := policy.SetContentLengthRange(minSize, maxSize); err != nil {
+		return "", nil, fmt.Errorf("invalid content length range: %w", err)
+	}
+	if err := policy.SetExpires(time.Now().UTC().Add(expiry)); err != nil {
+		return "", nil, fmt.Errorf("invalid expiry: %w", err)
+	}
+
+	u := url.URL{Scheme: "memory", Host: m.BucketName, Path: "/"}
+	return u.String(), map[string]string{"bucket": m.BucketName, "key": keyPrefix}, nil
+}
+
+func (m *MemoryStorage) GeneratePresignedGetForIP(ctx context.Context, objectName, clientIP string, expiry time.Duration) (string, bool, error) {
+	if net.ParseIP(clientIP) == nil {
+		return "", false, fmt.Errorf("invalid client IP %q", clientIP)
+	}
+	return m.presignedURL(objectName, expiry, nil), false, nil
+}
+
+// IndexContentHash stores the index entry under contentIndexPrefix, like
+// MinIOService, so it is left out of listings the same way.
+func (m *MemoryStorage) IndexContentHash(ctx context.Context, namespace, hash, objectName string) error {
+	if !validSHA256(hash) {
+		return fmt.Errorf("invalid sha256 %q", hash)
+	}
+	if !strings.HasPrefix(objectName, namespace) {
+		return fmt.Errorf("object %q is outside namespace %q", objectName, namespace)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.store(contentIndexKey(namespace, hash), []byte(objectName), "text/plain", nil, nil)
+	return nil
+}
+
+func (m *MemoryStorage) LookupContentHash(ctx context.Context, namespace, hash string, size int64) (minio.ObjectInfo, bool, error) {
+	if !validSHA256(hash) {
+		return minio.ObjectInfo{}, false, fmt.Errorf("invalid sha256 %q", hash)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, err := m.latest(contentIndexKey(namespace, hash))
+	if err != nil || !strings.HasPrefix(string(entry.data), namespace) {
+		return minio.ObjectInfo{}, false, nil
+	}
+	obj, err := m.latest(string(entry.data))
+	if err != nil {
+		return minio.ObjectInfo{}, false, nil
+	}
+
+	if size >= 0 && obj.info.Size != size {
+		return obj.info, false, nil
+	}
+	return obj.info, true, nil
+}
+
+func (m *MemoryStorage) SetObjectTags(ctx context.Context, objectName string, objectTags map[string]string) error {
+	if err := ValidateTags(objectTags); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	obj, err := m.latest(objectName)
+	if err != nil {
+		return fmt.Errorf("failed to set object tags: %w", err)
+	}
+	obj.tags = copyTags(objectTags)
+	return nil
+}
+
+func (m *MemoryStorage) GetObjectTags(ctx context.Context, objectName string) (map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	obj, err := m.latest(objectName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object tags: %w", err)
+	}
+	return copyTags(obj.tags), nil
+}
+
+func (m *MemoryStorage) RemoveObjectTags(ctx context.Context, objectName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	obj, err := m.latest(objectName)
+	if err != nil {
+		return fmt.Errorf("failed to remove object tags: %w", err)
+	}
+	obj.tags = map[string]string{}
+	return nil
+}
+
+// SetLifecycleRule records the rule; objects are never expired by it.
+func (m *MemoryStorage) SetLifecycleRule(ctx context.Context, prefix string, expireDays int) error {
+	if prefix == "" {
+		return fmt.Errorf("prefix is required")
+	}
+	if expireDays <= 0 {
+		return fmt.Errorf("expireDays must be positive, got %d", expireDays)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := expirationRule(prefix, expireDays).ID
+	rule := LifecycleRule{ID: id, Prefix: prefix, ExpireDays: expireDays, Enabled: true}
+	for i := range m.lifecycle {
+		if m.lifecycle[i].ID == id {
+			m.lifecycle[i] = rule
+			return nil
+		}
+	}
+	m.lifecycle = append(m.lifecycle, rule)
+	return nil
+}
+
+func (m *MemoryStorage) GetLifecycle(ctx context.Context) ([]LifecycleRule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]LifecycleRule{}, m.lifecycle...), nil
+}
+
+func (m *MemoryStorage) SweepExpired(ctx context.Context, prefix string, now time.Time, dryRun bool) (SweepResult, error) {
+	objects := m.list(prefix)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var result SweepResult
+	for _, object := range objects {
+		result.Scanned++
+
+		obj, err := m.latest(object.Key)
+		if err != nil {
+			continue
+		}
+		value, ok := obj.tags[DeleteAfterTag]
+		if !ok {
+			continue
+		}
+		deleteAfter, err := time.Parse(time.RFC3339, value)
+		if err != nil || deleteAfter.After(now) {
+			continue
+		}
+		result.Expired = append(result.Expired, object.Key)
+	}
+
+	if dryRun || len(result.Expired) == 0 {
+		return result, nil
+	}
+
+	for _, key := range result.Expired {
+		m.remove(key)
+	}
+	result.Failed = map[string]error{}
+	result.Deleted = len(result.Expired)
+	return result, nil
+}
+
+// EnableObjectLock makes the bucket behave as if created with object
+// locking: versioning is enabled and retention and legal holds are accepted
+// and enforced when deleting versions.
+func (m *MemoryStorage) EnableObjectLock() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.versioning = true
+	m.objectLock = true
+}
+
+func (m *MemoryStorage) wormProtected(objectName string) error {
+	return classifyError(minio.ErrorResponse{
+		StatusCode: http.StatusForbidden,
+		Code:       "AccessDenied",
+		Message:    "Object is WORM protected and cannot be overwritten",
+		BucketName: m.BucketName,
+		Key:        objectName,
+	})
+}
+
+// lockedVersion returns the version to apply a lock setting to. m.mu must be
+// held.
+func (m *MemoryStorage) lockedVersion(objectName, versionID string) (*memoryObject, error) {
+	if !m.objectLock {
+		return nil, minio.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Code:       "InvalidRequest",
+			Message:    "Bucket is missing ObjectLockConfiguration",
+			BucketName: m.BucketName,
+			Key:        objectName,
+		}
+	}
+	return m.version(objectName, versionID)
+}
+
+func (m *MemoryStorage) GetObjectLockConfig(ctx context.Context) (ObjectLockConfig, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return ObjectLockConfig{Enabled: m.objectLock}, nil
+}
+
+// SetObjectRetention enforces COMPLIANCE retention the way MinIO does: it can
+// be extended but not shortened or downgraded.
+func (m *MemoryStorage) SetObjectRetention(ctx context.Context, objectName, versionID string, mode minio.RetentionMode, retainUntil time.Time) error {
+	if !mode.IsValid() {
+		return fmt.Errorf("invalid retention mode '%s', must be %s or %s", mode, minio.Governance, minio.Compliance)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	obj, err := m.lockedVersion(objectName, versionID)
+	if err != nil {
+		return fmt.Errorf("failed to set object retention: %w", err)
+	}
+	if obj.retention == minio.Compliance && obj.locked(time.Now()) &&
+		(mode != minio.Compliance || retainUntil.Before(obj.retainUntil)) {
+		return fmt.Errorf("failed to set object retention: %w", m.wormProtected(objectName))
+	}
+
+	obj.retention = mode
+	obj.retainUntil = retainUntil
+	return nil
+}
+
+func (m *MemoryStorage) GetObjectRetention(ctx context.Context, objectName, versionID string) (minio.RetentionMode, time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	obj, err := m.version(objectName, versionID)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to get object retention: %w", err)
+	}
+	return obj.retention, obj.retainUntil, nil
+}
+
+func (m *MemoryStorage) SetLegalHold(ctx context.Context, objectName, versionID string, hold bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	obj, err := m.lockedVersion(objectName, versionID)
+	if err != nil {
+		return fmt.Errorf("failed to set legal hold: %w", err)
+	}
+	obj.legalHold = hold
+	return nil
+}
+
+func (m *MemoryStorage) GetLegalHold(ctx context.Context, objectName, versionID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	obj, err := m.version(objectName, versionID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get legal hold: %w", err)
+	}
+	return obj.legalHold, nil
+}
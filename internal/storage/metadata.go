@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"slices"
+
+	"github.com/minio/minio-go/v7"
+)
+
+var ErrInvalidStorageClass = errors.New("invalid storage class")
+
+// StorageClasses are the storage classes MinIO accepts on upload.
+var StorageClasses = []string{"STANDARD", "REDUCED_REDUNDANCY"}
+
+// storageClassKey is sent as a header rather than user metadata, setting the
+// class the object is stored in.
+const storageClassKey = "X-Amz-Storage-Class"
+
+// withStorageClass fills in info.StorageClass from a stat response. minio-go
+// only sets it on listings; stats carry it in the storage class header,
+// which S3 omits for STANDARD objects.
+func withStorageClass(info minio.ObjectInfo) minio.ObjectInfo {
+	info.StorageClass = info.Metadata.Get(storageClassKey)
+	if info.StorageClass == "" {
+		info.StorageClass = "STANDARD"
+	}
+	return info
+}
+
+// originalFilenameKey is the user metadata key holding the client's filename.
+// Keys are timestamped or otherwise rewritten, so this is the only place the
+// name the user uploaded survives. The value is path-escaped because S3
+// metadata must be ASCII.
+const originalFilenameKey = "Original-Filename"
+
+// OriginalFilenameMetadata returns user metadata recording fileName as the
+// object's original filename.
+func OriginalFilenameMetadata(fileName string) map[string]string {
+	return map[string]string{originalFilenameKey: url.PathEscape(fileName)}
+}
+
+// OriginalFilename returns the filename an object was uploaded with, falling
+// back to the last element of its key for objects stored without one.
+func OriginalFilename(info minio.ObjectInfo) string {
+	if value := info.UserMetadata[originalFilenameKey]; value != "" {
+		if name, err := url.PathUnescape(value); err == nil {
+			return name
+		}
+		return value
+	}
+	return path.Base(info.Key)
+}
+
+// ValidateStorageClass returns ErrInvalidStorageClass unless class is one of
+// StorageClasses.
+func ValidateStorageClass(class string) error {
+	if !slices.Contains(StorageClasses, class) {
+		return fmt.Errorf("%w '%s', must be one of %v", ErrInvalidStorageClass, class, StorageClasses)
+	}
+	return nil
+}
+
+// StorageClassMetadata returns metadata that stores an upload in class
+// instead of the service's default.
+func StorageClassMetadata(class string) map[string]string {
+	return map[string]string{storageClassKey: class}
+}
@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"MinIO-Learn/internal/storage/storagetest"
+	"github.com/minio/minio-go/v7"
+)
+
+func TestOriginalFilename(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      string
+		fileName string
+		want     string
+	}{
+		{"plain name", "uploads/1700000000-report.pdf", "report.pdf", "report.pdf"},
+		{"spaces", "uploads/1700000000-q3_report.pdf", "Q3 report.pdf", "Q3 report.pdf"},
+		{"non-ASCII", "uploads/1700000000-resume.pdf", "résumé.pdf", "résumé.pdf"},
+		{"no stored name", "uploads/1700000000-report.pdf", "", "1700000000-report.pdf"},
+	}
+
+	for name, store := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					ctx := context.Background()
+					var metadata map[string]string
+					if tt.fileName != "" {
+						metadata = OriginalFilenameMetadata(tt.fileName)
+					}
+					if _, err := store.UploadBuffer(ctx, tt.key, []byte("%PDF-1.4"), "application/pdf", metadata); err != nil {
+						t.Fatalf("UploadBuffer() error = %v", err)
+					}
+					info, err := store.GetObjectInfo(ctx, tt.key)
+					if err != nil {
+						t.Fatalf("GetObjectInfo() error = %v", err)
+					}
+					if got := OriginalFilename(info); got != tt.want {
+						t.Errorf("OriginalFilename() = %q, want %q", got, tt.want)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestOriginalFilenameUnescaped(t *testing.T) {
+	// Metadata written by hand rather than through OriginalFilenameMetadata
+	// is returned as stored.
+	info := minio.ObjectInfo{Key: "a/b.txt", UserMetadata: minio.StringMap{originalFilenameKey: "100%.txt"}}
+	if got := OriginalFilename(info); got != "100%.txt" {
+		t.Errorf("OriginalFilename() = %q, want %q", got, "100%.txt")
+	}
+}
+
+func TestUserMetadataRoundTrip(t *testing.T) {
+	metadata := map[string]string{"owner": "alice", "Project-Code": "x-42"}
+	want := map[string]string{"Owner": "alice", "Project-Code": "x-42"}
+
+	uploads := []struct {
+		name   string
+		upload func(ctx context.Context, store Storage, t *testing.T) error
+	}{
+		{"UploadBuffer", func(ctx context.Context, store Storage, t *testing.T) error {
+			_, err := store.UploadBuffer(ctx, "meta.txt", []byte("data"), "text/plain", metadata)
+			return err
+		}},
+		{"UploadStream", func(ctx context.Context, store Storage, t *testing.T) error {
+			_, err := store.UploadStream(ctx, "meta.txt", strings.NewReader("data"), 4, "text/plain", metadata)
+			return err
+		}},
+		{"UploadFile", func(ctx context.Context, store Storage, t *testing.T) error {
+			path := filepath.Join(t.TempDir(), "meta.txt")
+			if err := os.WriteFile(path, []byte("data"), 0o644); err != nil {
+				return err
+			}
+			_, err := store.UploadFile(ctx, "meta.txt", path, "text/plain", metadata)
+			return err
+		}},
+	}
+
+	for _, tt := range uploads {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, store := range testBackends(t) {
+				t.Run(name, func(t *testing.T) {
+					ctx := context.Background()
+					if err := tt.upload(ctx, store, t); err != nil {
+						t.Fatalf("upload error = %v", err)
+					}
+					info, err := store.GetObjectInfo(ctx, "meta.txt")
+					if err != nil {
+						t.Fatalf("GetObjectInfo() error = %v", err)
+					}
+					for key, value := range want {
+						if got := info.UserMetadata[key]; got != value {
+							t.Errorf("UserMetadata[%q] = %q, want %q (all: %v)", key, got, value, info.UserMetadata)
+						}
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestStorageClass(t *testing.T) {
+	tests := []struct {
+		name         string
+		defaultClass string
+		metadata     map[string]string
+		want         string
+	}{
+		{"backend default", "", nil, ""},
+		{"service default", "REDUCED_REDUNDANCY", nil, "REDUCED_REDUNDANCY"},
+		{"per upload", "", StorageClassMetadata("REDUCED_REDUNDANCY"), "REDUCED_REDUNDANCY"},
+		{"per upload overrides default", "REDUCED_REDUNDANCY", StorageClassMetadata("STANDARD"), "STANDARD"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			fake := storagetest.NewFakeS3()
+			service := newTestService(t, fake, Config{StorageClass: tt.defaultClass})
+			if err := service.EnsureBucket(ctx); err != nil {
+				t.Fatalf("EnsureBucket() error = %v", err)
+			}
+
+			if _, err := service.UploadBuffer(ctx, "archive.tar", []byte("archive"), "application/x-tar", tt.metadata); err != nil {
+				t.Fatalf("UploadBuffer() error = %v", err)
+			}
+			stored := fake.Object("test-bucket", "archive.tar")
+			if got := stored.Header.Get("X-Amz-Storage-Class"); got != tt.want {
+				t.Errorf("stored X-Amz-Storage-Class = %q, want %q", got, tt.want)
+			}
+			if got := stored.Header.Get("X-Amz-Meta-Storage-Class"); got != "" {
+				t.Errorf("storage class also stored as user metadata %q", got)
+			}
+			info, err := service.GetObjectInfo(ctx, "archive.tar")
+			if err != nil {
+				t.Fatalf("GetObjectInfo() error = %v", err)
+			}
+			// S3 reports objects stored without a class as STANDARD.
+			wantInfo := tt.want
+			if wantInfo == "" {
+				wantInfo = "STANDARD"
+			}
+			if info.StorageClass != wantInfo {
+				t.Errorf("StorageClass = %q, want %q", info.StorageClass, wantInfo)
+			}
+		})
+	}
+}
+
+func TestStorageClassMemory(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStorage("test-bucket")
+	if _, err := store.UploadBuffer(ctx, "archive.tar", []byte("archive"), "application/x-tar", StorageClassMetadata("REDUCED_REDUNDANCY")); err != nil {
+		t.Fatalf("UploadBuffer() error = %v", err)
+	}
+	info, err := store.GetObjectInfo(ctx, "archive.tar")
+	if err != nil {
+		t.Fatalf("GetObjectInfo() error = %v", err)
+	}
+	if info.StorageClass != "REDUCED_REDUNDANCY" {
+		t.Errorf("StorageClass = %q, want REDUCED_REDUNDANCY", info.StorageClass)
+	}
+	if _, ok := info.UserMetadata[storageClassKey]; ok {
+		t.Errorf("storage class also stored as user metadata: %v", info.UserMetadata)
+	}
+}
+
+func TestValidateStorageClass(t *testing.T) {
+	for _, class := range StorageClasses {
+		if err := ValidateStorageClass(class); err != nil {
+			t.Errorf("ValidateStorageClass(%q) error = %v", class, err)
+		}
+	}
+	for _, class := range []string{"", "standard", "GLACIER"} {
+		if err := ValidateStorageClass(class); !errors.Is(err, ErrInvalidStorageClass) {
+			t.Errorf("ValidateStorageClass(%q) error = %v, want ErrInvalidStorageClass", class, err)
+		}
+	}
+}
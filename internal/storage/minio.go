@@ -3,13 +3,26 @@ package storage
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	io "io"
+	"log/slog"
+	"mime"
+	"net/url"
 	"os"
+	"strings"
 	"time"
 
+	"MinIO-Learn/internal/metrics"
 	"github.com/minio/minio-go/v7"
-	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+)
+
+var (
+	ErrUnexpectedObject = errors.New("object does not match expectation")
+	ErrShortRead        = errors.New("object data shorter than its reported size")
+	ErrObjectTooLarge   = errors.New("object too large to buffer")
+	ErrEmptyPrefix      = errors.New("refusing to delete every object without force")
 )
 
 type Config struct {
@@ -19,30 +32,174 @@ type Config struct {
 	UseSSL          bool
 	BucketName      string
 	Location        string
+
+	// CredsMode selects how the client authenticates: CredsStatic (the
+	// default) signs with AccessKeyID and SecretAccessKey, CredsIAM fetches
+	// role credentials from IAMEndpoint (discovered when empty), and CredsSTS
+	// exchanges the static keys for temporary ones by assuming RoleARN at
+	// STSEndpoint.
+	CredsMode   string
+	IAMEndpoint string
+	STSEndpoint string
+	RoleARN     string
+
+	// SignatureVersion is SignatureV4 (the default when empty) or
+	// SignatureV2 for older S3-compatible backends. V2 needs static
+	// credentials.
+	SignatureVersion string
+
+	// ObjectLock creates the bucket, if missing, with object locking (WORM)
+	// enabled, which also enables versioning. It can't be turned on for an
+	// existing bucket.
+	ObjectLock bool
+
+	// AllowPublic permits SetPublicReadPolicy and BucketSpec.PublicReadPrefix
+	// to make objects readable by anyone. See SetPublicReadPolicy for the
+	// tradeoff.
+	AllowPublic bool
+
+	// SoftDelete makes DeleteObject and DeleteObjects move objects under
+	// TrashPrefix instead of removing them. See RestoreObject and PurgeTrash.
+	SoftDelete bool
+
+	// MultiTenant means keys start with a tenant's prefix, such as "acme/",
+	// so each tenant's trash is kept under that prefix.
+	MultiTenant bool
+
+	// DialTimeout, ResponseHeaderTimeout and IdleConnTimeout tune the
+	// client's HTTP transport so a hung MinIO can't block calls forever;
+	// zero keeps minio-go's defaults. CACertFile names a PEM file of CA
+	// certificates to trust besides the system ones, for MinIO serving TLS
+	// with a self-signed certificate.
+	DialTimeout           time.Duration
+	ResponseHeaderTimeout time.Duration
+	IdleConnTimeout       time.Duration
+	CACertFile            string
+
+	// RegionEndpoints maps regions to the endpoints ForRegion sends their
+	// requests to, for federated setups spanning several regions.
+	RegionEndpoints map[string]string
+
+	// ConnectTimeout keeps retrying the initial bucket check with backoff
+	// for this long, for when MinIO is still starting. Zero tries once.
+	ConnectTimeout time.Duration
+
+	// StatCacheTTL enables caching StatObject results for this long. Zero
+	// disables the cache.
+	StatCacheTTL time.Duration
+
+	// PartSize and UploadThreads tune UploadLargeFile's multipart uploads.
+	PartSize      uint64
+	UploadThreads uint
+
+	// UploadConcurrency is how many files UploadDirectory uploads at once.
+	// Zero uses a default of 4.
+	UploadConcurrency int
+
+	// MaxDownloadBuffer caps the size of objects DownloadBuffer and its
+	// variants read into memory, after decompression. Zero disables the cap.
+	MaxDownloadBuffer int64
+
+	// Compress gzips uploads whose content type is compressible (see
+	// IsCompressibleType) and stores them with Content-Encoding: gzip.
+	// Downloads through this service decompress them transparently; listed
+	// sizes and byte ranges refer to the compressed data.
+	Compress bool
+
+	// StorageClass is the class uploads are stored in unless their metadata
+	// carries StorageClassMetadata. Empty uses the backend's default.
+	StorageClass string
+
+	// Encryption, if set, is applied to every object written: encrypt.NewSSE()
+	// for SSE-S3 or encrypt.NewSSEC(key) for SSE-C. With SSE-C the key is also
+	// sent on every read, and presigned GET URLs won't work because they can't
+	// carry it.
+	Encryption encrypt.ServerSide
+
+	// Logger receives a record per storage operation: debug on success,
+	// error on failure. Nil uses slog.Default().
+	Logger *slog.Logger
 }
 
 type MinIOService struct {
 	Client     *minio.Client
 	BucketName string
 	Location   string
+
+	locks keyLocker
+	stats *statCache
+
+	objectLock  bool
+	allowPublic bool
+	softDelete  bool
+	multiTenant bool
+
+	partSize          uint64
+	uploadThreads     uint
+	uploadConcurrency int
+	maxDownloadBuffer int64
+	compress          bool
+	storageClass      string
+	encryption        encrypt.ServerSide
+
+	clients *clientCache
+	regions map[string]string
+
+	logger *slog.Logger
 }
 
 func NewMinIOService(config Config) (*MinIOService, error) {
-	client, err := minio.New(config.Endpoint, &minio.Options{
-		Creds:  credentials.NewStaticV4(config.AccessKeyID, config.SecretAccessKey, ""),
-		Secure: config.UseSSL,
+	creds, err := newCredentials(config)
+	if err != nil {
+		return nil, err
+	}
+
+	transport, err := newTransport(config)
+	if err != nil {
+		return nil, err
+	}
+
+	clients := newClientCache(func(endpoint string) (*minio.Client, error) {
+		return minio.New(endpoint, &minio.Options{
+			Creds:     creds,
+			Secure:    config.UseSSL,
+			Transport: transport,
+		})
 	})
+	client, err := clients.get(config.Endpoint)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize MinIO client: %w", err)
+		return nil, err
+	}
+
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.Default()
 	}
 
 	service := &MinIOService{
-		Client:     client,
-		BucketName: config.BucketName,
-		Location:   config.Location,
+		Client:      client,
+		BucketName:  config.BucketName,
+		Location:    config.Location,
+		stats:       newStatCache(config.StatCacheTTL),
+		clients:     clients,
+		regions:     config.RegionEndpoints,
+		objectLock:  config.ObjectLock,
+		allowPublic: config.AllowPublic,
+		softDelete:  config.SoftDelete,
+		multiTenant: config.MultiTenant,
+
+		partSize:          config.PartSize,
+		uploadThreads:     config.UploadThreads,
+		uploadConcurrency: config.UploadConcurrency,
+		maxDownloadBuffer: config.MaxDownloadBuffer,
+		compress:          config.Compress,
+		storageClass:      config.StorageClass,
+		encryption:        config.Encryption,
+
+		logger: logger,
 	}
 
-	err = service.EnsureBucket()
+	err = service.connect(config.ConnectTimeout)
 	if err != nil {
 		return nil, fmt.Errorf("failed to ensure bucket exists: %w", err)
 	}
@@ -50,25 +207,120 @@ func NewMinIOService(config Config) (*MinIOService, error) {
 	return service, nil
 }
 
-func (s *MinIOService) EnsureBucket() error {
-	ctx := context.Background()
+// connect runs EnsureBucket, retrying failures until timeout has passed.
+func (s *MinIOService) connect(timeout time.Duration) error {
+	if timeout <= 0 {
+		return s.EnsureBucket(context.Background())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	attempt := 0
+	return retry(ctx, connectRetry, func() error {
+		attempt++
+		err := s.EnsureBucket(ctx)
+		if err != nil {
+			s.logger.Warn("MinIO connection attempt failed",
+				"attempt", attempt, "bucket", s.BucketName, "timeout", timeout, "error", err)
+		} else if attempt > 1 {
+			s.logger.Info("MinIO connection established", "attempt", attempt, "bucket", s.BucketName)
+		}
+		return err
+	})
+}
+
+// WithBucket returns a view of s bound to bucketName. The view shares s's
+// client and settings but has its own per-key locks and stat cache, so
+// operations through different views of the same bucket are not serialized
+// against each other. Call EnsureBucket on the view to create the bucket.
+func (s *MinIOService) WithBucket(bucketName string) *MinIOService {
+	if bucketName == s.BucketName {
+		return s
+	}
+
+	view := s.view()
+	view.BucketName = bucketName
+	return view
+}
+
+// view returns a copy of s with its own per-key locks and stat cache.
+func (s *MinIOService) view() *MinIOService {
+	var ttl time.Duration
+	if s.stats != nil {
+		ttl = s.stats.ttl
+	}
+
+	return &MinIOService{
+		Client:      s.Client,
+		BucketName:  s.BucketName,
+		Location:    s.Location,
+		stats:       newStatCache(ttl),
+		clients:     s.clients,
+		regions:     s.regions,
+		objectLock:  s.objectLock,
+		allowPublic: s.allowPublic,
+		softDelete:  s.softDelete,
+		multiTenant: s.multiTenant,
+
+		partSize:          s.partSize,
+		uploadThreads:     s.uploadThreads,
+		uploadConcurrency: s.uploadConcurrency,
+		maxDownloadBuffer: s.maxDownloadBuffer,
+		compress:          s.compress,
+		storageClass:      s.storageClass,
+		encryption:        s.encryption,
+
+		logger: s.logger,
+	}
+}
+
+func (s *MinIOService) EnsureBucket(ctx context.Context) error {
 	exists, err := s.Client.BucketExists(ctx, s.BucketName)
 	if err != nil {
 		return fmt.Errorf("failed to check if bucket exists: %w", err)
 	}
 
 	if !exists {
-		err = s.Client.MakeBucket(ctx, s.BucketName, minio.MakeBucketOptions{Region: s.Location})
-		if err != nil {
+		err = s.Client.MakeBucket(ctx, s.BucketName, minio.MakeBucketOptions{Region: s.Location, ObjectLocking: s.objectLock})
+		// Another instance starting at the same time may have won the race.
+		if err != nil && !bucketAlreadyCreated(err) {
 			return fmt.Errorf("failed to create bucket: %w", err)
 		}
+		return nil
+	}
+
+	if s.objectLock {
+		lock, err := s.GetObjectLockConfig(ctx)
+		if err != nil {
+			return err
+		}
+		if !lock.Enabled {
+			s.logger.Warn("Bucket exists without object locking, which can't be enabled afterwards", "bucket", s.BucketName)
+		}
 	}
 
 	return nil
 }
 
-func (s *MinIOService) UploadFile(objectName, filePath, contentType string) (minio.UploadInfo, error) {
-	ctx := context.Background()
+// HealthCheck verifies MinIO is reachable and the bucket exists with a single
+// BucketExists request, so it stays cheap regardless of bucket size.
+func (s *MinIOService) HealthCheck(ctx context.Context) error {
+	exists, err := s.Client.BucketExists(ctx, s.BucketName)
+	if err != nil {
+		return fmt.Errorf("failed to reach MinIO: %w", classifyError(err))
+	}
+	if !exists {
+		return fmt.Errorf("bucket '%s' does not exist", s.BucketName)
+	}
+	return nil
+}
+
+func (s *MinIOService) UploadFile(ctx context.Context, objectName, filePath, contentType string, metadata map[string]string) (info minio.UploadInfo, err error) {
+	defer s.observe(ctx, metrics.OpUpload, slog.String("object", objectName), time.Now(), &info.Size, &err)
+	defer s.locks.lock(objectName)()
+	defer s.stats.invalidate(objectName)
+
 	file, err := os.Open(filePath)
 	if err != nil {
 		return minio.UploadInfo{}, fmt.Errorf("failed to open file: %w", err)
@@ -80,74 +332,491 @@ func (s *MinIOService) UploadFile(objectName, filePath, contentType string) (min
 		return minio.UploadInfo{}, fmt.Errorf("failed to get file stats: %w", err)
 	}
 
-	uploadInfo, err := s.Client.PutObject(ctx, s.BucketName, objectName, file, fileInfo.Size(),
-		minio.PutObjectOptions{ContentType: contentType})
+	metadata, err = withChecksum(metadata, file)
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+
+	opts := s.putOptions(contentType, metadata)
+	reader, progress := s.uploadProgress(ctx, file, fileInfo.Size(), contentType, &opts)
+	body, size, done := s.compressUpload(reader, fileInfo.Size(), contentType, &opts)
+	defer done()
+
+	uploadInfo, err := s.Client.PutObject(ctx, s.BucketName, objectName, body, size, opts)
 	if err != nil {
 		return minio.UploadInfo{}, fmt.Errorf("failed to upload file: %w", err)
 	}
+	progress.finish()
 
 	return uploadInfo, nil
 }
 
-func (s *MinIOService) UploadBuffer(objectName string, data []byte, contentType string) (minio.UploadInfo, error) {
-	ctx := context.Background()
+func (s *MinIOService) UploadBuffer(ctx context.Context, objectName string, data []byte, contentType string, metadata map[string]string) (info minio.UploadInfo, err error) {
+	defer s.observe(ctx, metrics.OpUpload, slog.String("object", objectName), time.Now(), &info.Size, &err)
+	defer s.locks.lock(objectName)()
+	defer s.stats.invalidate(objectName)
+
 	reader := bytes.NewReader(data)
-	uploadInfo, err := s.Client.PutObject(ctx, s.BucketName, objectName, reader, int64(len(data)),
-		minio.PutObjectOptions{ContentType: contentType})
+	metadata, err = withChecksum(metadata, reader)
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+
+	opts := s.putOptions(contentType, metadata)
+	progress := newProgressCounter(ctx, int64(len(data)))
+	if s.shouldCompress(contentType) {
+		data, err = gzipBytes(data)
+		if err != nil {
+			return minio.UploadInfo{}, err
+		}
+		reader = bytes.NewReader(data)
+		opts.ContentEncoding = "gzip"
+	} else if progress != nil {
+		opts.Progress = progress
+	}
+
+	uploadInfo, err := s.Client.PutObject(ctx, s.BucketName, objectName, reader, int64(len(data)), opts)
 	if err != nil {
 		return minio.UploadInfo{}, fmt.Errorf("failed to upload data: %w", err)
 	}
+	progress.finish()
+
+	return uploadInfo, nil
+}
+
+// UploadLargeFile uploads a local file as a multipart upload using the
+// configured part size and number of parallel part uploads. Parts are read
+// straight from the file, so memory use is bounded by PartSize*UploadThreads.
+func (s *MinIOService) UploadLargeFile(ctx context.Context, objectName, filePath, contentType string, metadata map[string]string) (info minio.UploadInfo, err error) {
+	defer s.observe(ctx, metrics.OpUpload, slog.String("object", objectName), time.Now(), &info.Size, &err)
+	defer s.locks.lock(objectName)()
+	defer s.stats.invalidate(objectName)
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return minio.UploadInfo{}, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return minio.UploadInfo{}, fmt.Errorf("failed to get file stats: %w", err)
+	}
+
+	metadata, err = withChecksum(metadata, file)
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+
+	opts := s.putOptions(contentType, metadata)
+	opts.PartSize = s.partSize
+	opts.NumThreads = s.uploadThreads
+	reader, progress := s.uploadProgress(ctx, file, fileInfo.Size(), contentType, &opts)
+	body, size, done := s.compressUpload(reader, fileInfo.Size(), contentType, &opts)
+	defer done()
+
+	uploadInfo, err := s.Client.PutObject(ctx, s.BucketName, objectName, body, size, opts)
+	if err != nil {
+		return minio.UploadInfo{}, fmt.Errorf("failed to upload large file: %w", err)
+	}
+	progress.finish()
 
 	return uploadInfo, nil
 }
 
-func (s *MinIOService) DownloadFile(objectName, filePath string) error {
-	ctx := context.Background()
-	err := s.Client.FGetObject(ctx, s.BucketName, objectName, filePath, minio.GetObjectOptions{})
+// streamPartSize is the part size used when a stream's length is unknown.
+// PutObject buffers one part in memory at a time, so this bounds memory use
+// while keeping the part count well under the 10,000 part limit.
+const streamPartSize = 16 << 20
+
+// UploadStream uploads from reader without staging it on disk. A negative
+// size means the length is unknown (e.g. chunked transfer encoding), in which
+// case the stream is uploaded as a multipart upload of streamPartSize parts.
+func (s *MinIOService) UploadStream(ctx context.Context, objectName string, reader io.Reader, size int64, contentType string, metadata map[string]string) (info minio.UploadInfo, err error) {
+	defer s.observe(ctx, metrics.OpUpload, slog.String("object", objectName), time.Now(), &info.Size, &err)
+	defer s.locks.lock(objectName)()
+	defer s.stats.invalidate(objectName)
+
+	// Metadata is sent before the body, so only content that can be read
+	// twice gets a checksum unless the caller supplies one.
+	if seeker, ok := reader.(io.ReadSeeker); ok {
+		metadata, err = withChecksum(metadata, seeker)
+		if err != nil {
+			return minio.UploadInfo{}, err
+		}
+	}
+
+	opts := s.putOptions(contentType, metadata)
+	if size < 0 {
+		size = -1
+		opts.PartSize = streamPartSize
+	}
+
+	counter := &countingReader{Reader: reader}
+	source, progress := s.uploadProgress(ctx, counter, size, contentType, &opts)
+	body, size, done := s.compressUpload(source, size, contentType, &opts)
+	defer done()
+
+	uploadInfo, err := s.Client.PutObject(ctx, s.BucketName, objectName, body, size, opts)
+	if err != nil {
+		return minio.UploadInfo{}, fmt.Errorf("failed to upload stream: %w", err)
+	}
+	progress.finish()
+
+	// Compressed uploads are stored smaller than what was streamed.
+	if opts.ContentEncoding == "" && uploadInfo.Size != counter.n {
+		return uploadInfo, fmt.Errorf("stored size %d does not match %d streamed bytes", uploadInfo.Size, counter.n)
+	}
+
+	return uploadInfo, nil
+}
+
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.Reader.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (s *MinIOService) DownloadFile(ctx context.Context, objectName, filePath string) (err error) {
+	defer s.observe(ctx, metrics.OpDownload, slog.String("object", objectName), time.Now(), nil, &err)
+	defer s.locks.lock(objectName)()
+
+	obj, err := s.Client.GetObject(ctx, s.BucketName, objectName, s.getOptions())
+	if err != nil {
+		return fmt.Errorf("failed to download file: %w", err)
+	}
+	defer obj.Close()
+
+	info, err := obj.Stat()
 	if err != nil {
 		return fmt.Errorf("failed to download file: %w", err)
 	}
+	content, err := decodeContent(info, obj)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a failed download doesn't leave a
+	// truncated file at filePath.
+	partPath := filePath + ".part"
+	file, err := os.Create(partPath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(partPath)
 
+	_, err = io.Copy(file, content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download file: %w", err)
+	}
+
+	if err := os.Rename(partPath, filePath); err != nil {
+		return fmt.Errorf("failed to download file: %w", err)
+	}
 	return nil
 }
 
-func (s *MinIOService) DownloadBuffer(objectName string) ([]byte, error) {
-	ctx := context.Background()
-	obj, err := s.Client.GetObject(ctx, s.BucketName, objectName, minio.GetObjectOptions{})
+// DownloadBuffer reads a whole object into memory. If the read fails or ends
+// before the object's reported size, the bytes read so far are returned along
+// with the error; a truncated stream yields ErrShortRead. Objects larger than
+// Config.MaxDownloadBuffer fail with ErrObjectTooLarge before any data is
+// read.
+func (s *MinIOService) DownloadBuffer(ctx context.Context, objectName string) ([]byte, error) {
+	return s.DownloadBufferVersion(ctx, objectName, "")
+}
+
+// DownloadBufferVersion is DownloadBuffer for a specific version of the
+// object. An empty versionID reads the latest version.
+func (s *MinIOService) DownloadBufferVersion(ctx context.Context, objectName, versionID string) ([]byte, error) {
+	var opts ReadOptions
+	opts.SetVersionID(versionID)
+	return s.DownloadBufferWithOptions(ctx, objectName, opts)
+}
+
+// DownloadBufferWithOptions is DownloadBuffer honouring opts. Failed ETag
+// preconditions are reported as ErrNotModified or ErrPreconditionFailed.
+func (s *MinIOService) DownloadBufferWithOptions(ctx context.Context, objectName string, opts ReadOptions) (data []byte, err error) {
+	defer s.observe(ctx, metrics.OpDownload, slog.String("object", objectName), time.Now(), nil, &err)
+	defer s.locks.lock(objectName)()
+
+	obj, err := s.Client.GetObject(ctx, s.BucketName, objectName, s.getObjectOptions(opts))
 	if err != nil {
-		return nil, fmt.Errorf("failed to get object: %w", err)
+		return nil, fmt.Errorf("failed to get object: %w", conditionError(err))
 	}
 	defer obj.Close()
 
-	data, err := io.ReadAll(obj)
+	info, err := obj.Stat()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read object data: %w", err)
+		return nil, fmt.Errorf("failed to stat object: %w", conditionError(err))
+	}
+	if err := checkBufferSize(info.Size, s.maxDownloadBuffer); err != nil {
+		return nil, err
+	}
+
+	data, err = readAllLimited(obj, s.maxDownloadBuffer)
+	if errors.Is(err, ErrObjectTooLarge) {
+		return nil, err
+	}
+	// A connection cut mid-body ends the read with io.ErrUnexpectedEOF; it is
+	// as much a truncated download as a body that ends early.
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return data, fmt.Errorf("%w: read %d of %d bytes: %w", ErrShortRead, len(data), info.Size, err)
+	}
+	if err != nil {
+		return data, fmt.Errorf("failed to read object data after %d of %d bytes: %w", len(data), info.Size, err)
+	}
+	if int64(len(data)) != info.Size {
+		return data, fmt.Errorf("%w: read %d of %d bytes", ErrShortRead, len(data), info.Size)
+	}
+
+	if IsCompressed(info) {
+		content, err := decodeContent(info, bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		data, err = readAllLimited(content, s.maxDownloadBuffer)
+		if errors.Is(err, ErrObjectTooLarge) {
+			return nil, err
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress object: %w", err)
+		}
+	}
+
+	return data, nil
+}
+
+// checkBufferSize fails with ErrObjectTooLarge if size exceeds limit. A limit
+// of zero or less allows any size.
+func checkBufferSize(size, limit int64) error {
+	if limit > 0 && size > limit {
+		return fmt.Errorf("%w: %d bytes exceeds the limit of %d", ErrObjectTooLarge, size, limit)
+	}
+	return nil
+}
+
+// readAllLimited is io.ReadAll stopping one byte past limit, so a stream
+// longer than its reported size, or a compressed object that expands past
+// limit, fails with ErrObjectTooLarge instead of exhausting memory.
+func readAllLimited(r io.Reader, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		return io.ReadAll(r)
 	}
 
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return data, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrObjectTooLarge, limit)
+	}
 	return data, nil
 }
 
-func (s *MinIOService) ListObjects(prefix string) ([]minio.ObjectInfo, error) {
-	ctx := context.Background()
+// DownloadToWriter streams the object into w without holding it in memory
+// and returns the number of bytes written. Errors that occur before anything
+// is written, such as a missing object, leave w untouched. Progress is
+// reported to the WithProgress callback in ctx, if any.
+func (s *MinIOService) DownloadToWriter(ctx context.Context, objectName string, w io.Writer) (int64, error) {
+	return s.DownloadToWriterWithOptions(ctx, objectName, ReadOptions{}, w)
+}
+
+// DownloadToWriterWithOptions is DownloadToWriter honouring opts. The object
+// isn't locked while streaming, as a slow writer would otherwise hold up
+// uploads to it; pin the read with SetMatchETag where that matters.
+func (s *MinIOService) DownloadToWriterWithOptions(ctx context.Context, objectName string, opts ReadOptions, w io.Writer) (written int64, err error) {
+	defer s.observe(ctx, metrics.OpDownload, slog.String("object", objectName), time.Now(), &written, &err)
+
+	obj, err := s.Client.GetObject(ctx, s.BucketName, objectName, s.getObjectOptions(opts))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get object: %w", conditionError(err))
+	}
+	defer obj.Close()
+
+	info, err := obj.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat object: %w", conditionError(err))
+	}
+	content, err := decodeContent(info, obj)
+	if err != nil {
+		return 0, err
+	}
+
+	total := info.Size
+	if IsCompressed(info) {
+		total = -1
+	}
+	if progress := newProgressCounter(ctx, total); progress != nil {
+		w = &progressWriter{Writer: w, progress: progress}
+		defer func() {
+			if err == nil {
+				progress.finish()
+			}
+		}()
+	}
+
+	written, err = io.Copy(w, content)
+	if errors.Is(err, io.ErrUnexpectedEOF) && !IsCompressed(info) {
+		return written, fmt.Errorf("%w: read %d of %d bytes: %w", ErrShortRead, written, info.Size, err)
+	}
+	if err != nil {
+		return written, fmt.Errorf("failed to stream object data after %d bytes: %w", written, err)
+	}
+	if !IsCompressed(info) && written != info.Size {
+		return written, fmt.Errorf("%w: read %d of %d bytes", ErrShortRead, written, info.Size)
+	}
+
+	return written, nil
+}
+
+// ListObjects collects every object under prefix. Prefer ForEachObject for
+// large listings.
+func (s *MinIOService) ListObjects(ctx context.Context, prefix string) (objects []minio.ObjectInfo, err error) {
+	defer s.observe(ctx, metrics.OpList, slog.String("prefix", prefix), time.Now(), nil, &err)
+
+	err = s.ForEachObject(ctx, prefix, func(object minio.ObjectInfo) error {
+		objects = append(objects, object)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+// FolderListing is one level of the key hierarchy under a prefix: the
+// objects directly under it and the prefixes of its subfolders, each ending
+// in "/".
+type FolderListing struct {
+	Objects  []minio.ObjectInfo
+	Prefixes []string
+}
+
+// ListFolder lists prefix like a directory, splitting keys at "/". prefix
+// should itself end in "/" (or be empty for the top level).
+func (s *MinIOService) ListFolder(ctx context.Context, prefix string) (listing FolderListing, err error) {
+	defer s.observe(ctx, metrics.OpList, slog.String("prefix", prefix), time.Now(), nil, &err)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	objectCh := s.Client.ListObjects(ctx, s.BucketName, minio.ListObjectsOptions{
+		Prefix:    prefix,
+		Recursive: false,
+	})
+
+	for object := range objectCh {
+		if object.Err != nil {
+			return FolderListing{}, fmt.Errorf("error listing objects: %w", object.Err)
+		}
+		if IsSystemKey(object.Key) {
+			continue
+		}
+		switch {
+		case object.Key == prefix:
+			// The folder's own marker object.
+		case strings.HasSuffix(object.Key, "/"):
+			listing.Prefixes = append(listing.Prefixes, object.Key)
+		default:
+			listing.Objects = append(listing.Objects, object)
+		}
+	}
+
+	return listing, nil
+}
+
+// ObjectPage is one page of a paginated listing. NextToken is the key to
+// pass as startAfter for the next page, or "" on the last page.
+type ObjectPage struct {
+	Objects   []minio.ObjectInfo
+	NextToken string
+}
+
+// ListObjectsPaginated lists at most maxKeys objects under prefix whose keys
+// sort after startAfter.
+func (s *MinIOService) ListObjectsPaginated(ctx context.Context, prefix, startAfter string, maxKeys int) (page ObjectPage, err error) {
+	defer s.observe(ctx, metrics.OpList, slog.String("prefix", prefix), time.Now(), nil, &err)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	objectCh := s.Client.ListObjects(ctx, s.BucketName, minio.ListObjectsOptions{
+		Prefix:     prefix,
+		StartAfter: startAfter,
+		MaxKeys:    maxKeys,
+		Recursive:  true,
+	})
+
+	for object := range objectCh {
+		if object.Err != nil {
+			return ObjectPage{}, fmt.Errorf("error listing objects: %w", object.Err)
+		}
+		if IsSystemKey(object.Key) {
+			continue
+		}
+		if len(page.Objects) == maxKeys {
+			page.NextToken = page.Objects[maxKeys-1].Key
+			break
+		}
+		page.Objects = append(page.Objects, object)
+	}
+
+	return page, nil
+}
+
+// ForEachObject streams the objects under prefix to fn without collecting
+// them. Iteration stops at the first error from fn, which is returned.
+func (s *MinIOService) ForEachObject(ctx context.Context, prefix string, fn func(minio.ObjectInfo) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	objectCh := s.Client.ListObjects(ctx, s.BucketName, minio.ListObjectsOptions{
 		Prefix:    prefix,
 		Recursive: true,
 	})
 
-	var objects []minio.ObjectInfo
 	for object := range objectCh {
 		if object.Err != nil {
-			return nil, fmt.Errorf("error listing objects: %w", object.Err)
+			return fmt.Errorf("error listing objects: %w", object.Err)
+		}
+		if IsSystemKey(object.Key) {
+			continue
+		}
+		if err := fn(object); err != nil {
+			return err
 		}
-		objects = append(objects, object)
 	}
 
-	return objects, nil
+	return nil
+}
+
+// DeleteObject removes objectName, or with SoftDelete moves it to the trash.
+// Objects already in the trash are always removed for good.
+func (s *MinIOService) DeleteObject(ctx context.Context, objectName string) error {
+	if s.softDelete && !isTrashKey(objectName, s.multiTenant) {
+		return s.trashObject(ctx, objectName)
+	}
+	return s.DeleteObjectVersion(ctx, objectName, "")
 }
 
-func (s *MinIOService) DeleteObject(objectName string) error {
-	ctx := context.Background()
-	err := s.Client.RemoveObject(ctx, s.BucketName, objectName, minio.RemoveObjectOptions{})
+// DeleteObjectVersion permanently removes one version of an object. With an
+// empty versionID it behaves like DeleteObject, which on a versioned bucket
+// only adds a delete marker.
+func (s *MinIOService) DeleteObjectVersion(ctx context.Context, objectName, versionID string) (err error) {
+	defer s.observe(ctx, metrics.OpDelete, slog.String("object", objectName), time.Now(), nil, &err)
+	defer s.locks.lock(objectName)()
+	defer s.stats.invalidate(objectName)
+
+	err = s.Client.RemoveObject(ctx, s.BucketName, objectName, minio.RemoveObjectOptions{VersionID: versionID})
 	if err != nil {
 		return fmt.Errorf("failed to delete object: %w", err)
 	}
@@ -155,9 +824,160 @@ func (s *MinIOService) DeleteObject(objectName string) error {
 	return nil
 }
 
-func (s *MinIOService) GetObjectURL(objectName string, expiry time.Duration) (string, error) {
-	ctx := context.Background()
-	presignedURL, err := s.Client.PresignedGetObject(ctx, s.BucketName, objectName, expiry, nil)
+// ObjectError is the failure of one object in a bulk operation.
+type ObjectError struct {
+	ObjectName string
+	Err        error
+}
+
+// DeleteObjects removes objectNames in bulk with a single streamed
+// RemoveObjects call. It returns one ObjectError per object that could not
+// be deleted, in the order given; deleting a missing key is not an error.
+// With SoftDelete each object is moved to the trash in turn instead.
+func (s *MinIOService) DeleteObjects(ctx context.Context, objectNames []string) []ObjectError {
+	if s.softDelete {
+		var errs []ObjectError
+		for _, name := range objectNames {
+			if err := s.DeleteObject(ctx, name); err != nil {
+				errs = append(errs, ObjectError{ObjectName: name, Err: err})
+			}
+		}
+		return errs
+	}
+
+	start := time.Now()
+	failed := s.removeObjects(ctx, objectNames)
+
+	var errs []ObjectError
+	for _, name := range objectNames {
+		err, ok := failed[name]
+		s.observe(ctx, metrics.OpDelete, slog.String("object", name), start, nil, &err)
+		if ok {
+			errs = append(errs, ObjectError{ObjectName: name, Err: err})
+			delete(failed, name)
+		}
+	}
+
+	return errs
+}
+
+// DeleteByPrefix removes every object under prefix, streaming the listing
+// into RemoveObjects, and returns how many were deleted. An empty prefix
+// would empty the bucket, so it fails with ErrEmptyPrefix unless force is
+// set. If some objects could not be deleted, the error reports how many.
+func (s *MinIOService) DeleteByPrefix(ctx context.Context, prefix string, force bool) (deleted int, err error) {
+	defer s.observe(ctx, metrics.OpDelete, slog.String("prefix", prefix), time.Now(), nil, &err)
+
+	if prefix == "" && !force {
+		return 0, ErrEmptyPrefix
+	}
+
+	listCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var listed int
+	objectsCh := make(chan minio.ObjectInfo)
+	listErrCh := make(chan error, 1)
+	go func() {
+		defer close(objectsCh)
+		listErrCh <- s.ForEachObject(listCtx, prefix, func(object minio.ObjectInfo) error {
+			select {
+			case objectsCh <- minio.ObjectInfo{Key: object.Key}:
+				listed++
+				s.stats.invalidate(object.Key)
+				return nil
+			case <-listCtx.Done():
+				return listCtx.Err()
+			}
+		})
+	}()
+
+	var failed int
+	var firstErr error
+	for removeErr := range s.Client.RemoveObjects(ctx, s.BucketName, objectsCh, minio.RemoveObjectsOptions{}) {
+		if firstErr == nil {
+			firstErr = fmt.Errorf("failed to delete '%s': %w", removeErr.ObjectName, removeErr.Err)
+		}
+		failed++
+	}
+	// Unblock the lister in case RemoveObjects stopped reading early.
+	cancel()
+	listErr := <-listErrCh
+
+	deleted = listed - failed
+	if err := ctx.Err(); err != nil {
+		return deleted, err
+	}
+	if listErr != nil && !errors.Is(listErr, context.Canceled) {
+		return deleted, listErr
+	}
+	if firstErr != nil {
+		return deleted, fmt.Errorf("%d objects under '%s' were not deleted, first error: %w", failed, prefix, firstErr)
+	}
+	return deleted, nil
+}
+
+func (s *MinIOService) GetObjectURL(ctx context.Context, objectName string, expiry time.Duration) (string, error) {
+	return s.presignGet(ctx, objectName, expiry, nil)
+}
+
+// GetDownloadURL is like GetObjectURL, but the URL makes the backend answer
+// with an attachment Content-Disposition naming fileName.
+func (s *MinIOService) GetDownloadURL(ctx context.Context, objectName, fileName string, expiry time.Duration) (string, error) {
+	params := url.Values{}
+	params.Set("response-content-disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	return s.presignGet(ctx, objectName, expiry, params)
+}
+
+// GetUploadURL returns a presigned PUT URL for objectName. The object key is
+// part of the signature, so the URL cannot be used to write any other key.
+func (s *MinIOService) GetUploadURL(ctx context.Context, objectName string, expiry time.Duration) (string, error) {
+	var presignedURL *url.URL
+	err := retry(ctx, presignRetry, func() error {
+		var err error
+		presignedURL, err = s.Client.PresignedPutObject(ctx, s.BucketName, objectName, expiry)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to generate presigned upload URL: %w", err)
+	}
+
+	return presignedURL.String(), nil
+}
+
+// GetPresignedPostPolicy returns the URL and form fields for a browser form
+// upload. The policy only accepts keys starting with keyPrefix and bodies
+// between minSize and maxSize bytes, and expires after expiry.
+func (s *MinIOService) GetPresignedPostPolicy(ctx context.Context, keyPrefix string, minSize, maxSize int64, expiry time.Duration) (string, map[string]string, error) {
+	policy := minio.NewPostPolicy()
+	if err := policy.SetBucket(s.BucketName); err != nil {
+		return "", nil, fmt.Errorf("invalid bucket: %w", err)
+	}
+	if err := policy.SetKeyStartsWith(keyPrefix); err != nil {
+		return "", nil, fmt.Errorf("invalid key prefix: %w", err)
+	}
+	if err := policy.SetContentLengthRange(minSize, maxSize); err != nil {
+		return "", nil, fmt.Errorf("invalid content length range: %w", err)
+	}
+	if err := policy.SetExpires(time.Now().UTC().Add(expiry)); err != nil {
+		return "", nil, fmt.Errorf("invalid expiry: %w", err)
+	}
+
+	presignedURL, formData, err := s.Client.PresignedPostPolicy(ctx, policy)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate presigned post policy: %w", err)
+	}
+
+	return presignedURL.String(), formData, nil
+}
+
+func (s *MinIOService) presignGet(ctx context.Context, objectName string, expiry time.Duration, params url.Values) (string, error) {
+	var presignedURL *url.URL
+	err := retry(ctx, presignRetry, func() error {
+		var err error
+		presignedURL, err = s.Client.PresignedGetObject(ctx, s.BucketName, objectName, expiry, params)
+		return err
+	})
 	if err != nil {
 		return "", fmt.Errorf("failed to generate presigned URL: %w", err)
 	}
@@ -165,15 +985,151 @@ func (s *MinIOService) GetObjectURL(objectName string, expiry time.Duration) (st
 	return presignedURL.String(), nil
 }
 
-func (s *MinIOService) CheckObjectExists(objectName string) (bool, error) {
-	ctx := context.Background()
-	_, err := s.Client.StatObject(ctx, s.BucketName, objectName, minio.StatObjectOptions{})
+func (s *MinIOService) CheckObjectExists(ctx context.Context, objectName string) (bool, error) {
+	_, err := s.statObject(ctx, objectName)
 	if err != nil {
 		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
 			return false, nil
 		}
-		return false, fmt.Errorf("failed to check if object exists: %w", err)
+		return false, fmt.Errorf("failed to check if object exists: %w", classifyError(err))
 	}
 
 	return true, nil
 }
+
+// ObjectExpectation describes what a caller expects a stored object to look
+// like before it is served. Zero values disable the corresponding check.
+type ObjectExpectation struct {
+	ContentType string
+	MaxSize     int64
+}
+
+// Check returns an error wrapping ErrUnexpectedObject if info doesn't match
+// expect.
+func (expect ObjectExpectation) Check(info minio.ObjectInfo) error {
+	if expect.MaxSize > 0 && info.Size > expect.MaxSize {
+		return fmt.Errorf("%w: size %d exceeds limit of %d bytes", ErrUnexpectedObject, info.Size, expect.MaxSize)
+	}
+	if expect.ContentType != "" && !sameMediaType(info.ContentType, expect.ContentType) {
+		return fmt.Errorf("%w: content type %q does not match %q", ErrUnexpectedObject, info.ContentType, expect.ContentType)
+	}
+	return nil
+}
+
+func sameMediaType(actual, expected string) bool {
+	actualType, _, err := mime.ParseMediaType(actual)
+	if err != nil {
+		return false
+	}
+	expectedType, _, err := mime.ParseMediaType(expected)
+	if err != nil {
+		return false
+	}
+	return actualType == expectedType
+}
+
+// caseInsensitiveScanLimit bounds how many keys FindObjectCaseInsensitive
+// inspects, since S3 has no case-insensitive lookup and it must scan.
+const caseInsensitiveScanLimit = 10000
+
+// FindObjectCaseInsensitive looks for a key equal to objectName ignoring case
+// among the objects sharing its parent prefix. It returns "" if none is found
+// within caseInsensitiveScanLimit keys.
+func (s *MinIOService) FindObjectCaseInsensitive(ctx context.Context, objectName string) (string, error) {
+	prefix := ""
+	if i := strings.LastIndex(objectName, "/"); i >= 0 {
+		prefix = objectName[:i+1]
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	objectCh := s.Client.ListObjects(ctx, s.BucketName, minio.ListObjectsOptions{
+		Prefix:    prefix,
+		Recursive: false,
+	})
+
+	scanned := 0
+	for object := range objectCh {
+		if object.Err != nil {
+			return "", fmt.Errorf("error listing objects: %w", object.Err)
+		}
+		if IsSystemKey(object.Key) {
+			continue
+		}
+		if strings.EqualFold(object.Key, objectName) {
+			return object.Key, nil
+		}
+		scanned++
+		if scanned >= caseInsensitiveScanLimit {
+			break
+		}
+	}
+
+	return "", nil
+}
+
+// GetObjectInfo returns an object's size, content type, ETag, modification
+// time and user metadata.
+func (s *MinIOService) GetObjectInfo(ctx context.Context, objectName string) (minio.ObjectInfo, error) {
+	info, err := s.statObject(ctx, objectName)
+	if err != nil {
+		return minio.ObjectInfo{}, fmt.Errorf("failed to stat object: %w", classifyError(err))
+	}
+
+	return info, nil
+}
+
+// GetObjectRange reads the inclusive byte range [start, end] of an object.
+// Like DownloadBuffer it fails with ErrObjectTooLarge past
+// Config.MaxDownloadBuffer; DownloadRangeToWriter has no such limit.
+func (s *MinIOService) GetObjectRange(ctx context.Context, objectName string, start, end int64) (data []byte, err error) {
+	defer s.observe(ctx, metrics.OpDownload, slog.String("object", objectName), time.Now(), nil, &err)
+
+	opts := s.getOptions()
+	if err := opts.SetRange(start, end); err != nil {
+		return nil, fmt.Errorf("invalid range: %w", err)
+	}
+
+	obj, err := s.Client.GetObject(ctx, s.BucketName, objectName, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object: %w", err)
+	}
+	defer obj.Close()
+
+	data, err = readAllLimited(obj, s.maxDownloadBuffer)
+	if errors.Is(err, ErrObjectTooLarge) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object range: %w", err)
+	}
+
+	return data, nil
+}
+
+// DownloadRangeToWriter streams the inclusive byte range [start, end] of an
+// object into w without holding it in memory, accepting the same ranges as
+// GetObjectRange. The range indexes the stored bytes, which for a compressed
+// object are its compressed data.
+func (s *MinIOService) DownloadRangeToWriter(ctx context.Context, objectName string, opts ReadOptions, start, end int64, w io.Writer) (written int64, err error) {
+	defer s.observe(ctx, metrics.OpDownload, slog.String("object", objectName), time.Now(), &written, &err)
+
+	getOpts := s.getObjectOptions(opts)
+	if err := getOpts.SetRange(start, end); err != nil {
+		return 0, fmt.Errorf("invalid range: %w", err)
+	}
+
+	obj, err := s.Client.GetObject(ctx, s.BucketName, objectName, getOpts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get object: %w", conditionError(err))
+	}
+	defer obj.Close()
+
+	written, err = io.Copy(w, obj)
+	if err != nil {
+		return written, fmt.Errorf("failed to stream object range after %d bytes: %w", written, conditionError(err))
+	}
+
+	return written, nil
+}
@@ -0,0 +1,159 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"MinIO-Learn/internal/storage/storagetest"
+	"github.com/minio/minio-go/v7"
+)
+
+func TestObjectExpectationCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		expect  ObjectExpectation
+		info    minio.ObjectInfo
+		wantErr bool
+	}{
+		{"no expectation", ObjectExpectation{}, minio.ObjectInfo{Size: 1 << 40, ContentType: "video/mp4"}, false},
+		{"within size", ObjectExpectation{MaxSize: 10}, minio.ObjectInfo{Size: 10}, false},
+		{"oversized", ObjectExpectation{MaxSize: 10}, minio.ObjectInfo{Size: 11}, true},
+		{"matching type", ObjectExpectation{ContentType: "application/json"}, minio.ObjectInfo{ContentType: "application/json"}, false},
+		{"matching type with parameters", ObjectExpectation{ContentType: "text/plain"}, minio.ObjectInfo{ContentType: "text/plain; charset=utf-8"}, false},
+		{"wrong type", ObjectExpectation{ContentType: "application/json"}, minio.ObjectInfo{ContentType: "text/html"}, true},
+		{"unparseable type", ObjectExpectation{ContentType: "application/json"}, minio.ObjectInfo{ContentType: ""}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.expect.Check(tt.info)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrUnexpectedObject) {
+				t.Errorf("Check() error = %v, want ErrUnexpectedObject", err)
+			}
+		})
+	}
+}
+
+func TestFindObjectCaseInsensitive(t *testing.T) {
+	tests := []struct {
+		name   string
+		object string
+		want   string
+	}{
+		{name: "exact case", object: "docs/Report.PDF", want: "docs/Report.PDF"},
+		{name: "wrong case", object: "docs/report.pdf", want: "docs/Report.PDF"},
+		{name: "top level", object: "README.md", want: "readme.md"},
+		{name: "no match", object: "docs/summary.pdf", want: ""},
+		{name: "different folder", object: "other/report.pdf", want: ""},
+	}
+
+	for name, store := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			for _, key := range []string{"docs/Report.PDF", "docs/notes.txt", "readme.md"} {
+				if _, err := store.UploadBuffer(ctx, key, []byte(key), "application/octet-stream", nil); err != nil {
+					t.Fatalf("UploadBuffer(%q) error = %v", key, err)
+				}
+			}
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					got, err := store.FindObjectCaseInsensitive(ctx, tt.object)
+					if err != nil {
+						t.Fatalf("FindObjectCaseInsensitive() error = %v", err)
+					}
+					if got != tt.want {
+						t.Errorf("FindObjectCaseInsensitive(%q) = %q, want %q", tt.object, got, tt.want)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestDownloadShortRead(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 10)
+
+	tests := []struct {
+		name    string
+		respond func(w *bufio.ReadWriter)
+		wantErr error
+	}{
+		{
+			name: "connection cut",
+			respond: func(w *bufio.ReadWriter) {
+				fmt.Fprintf(w, "HTTP/1.1 200 OK\r\nContent-Length: %d\r\n%s\r\n%s", len(content), objectHeaders(), content[:40])
+			},
+			wantErr: ErrShortRead,
+		},
+		{
+			name: "body ends early",
+			respond: func(w *bufio.ReadWriter) {
+				fmt.Fprintf(w, "HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n%s\r\n28\r\n%s\r\n0\r\n\r\n", objectHeaders(), content[:40])
+			},
+			wantErr: ErrShortRead,
+		},
+		{
+			name: "complete",
+			respond: func(w *bufio.ReadWriter) {
+				fmt.Fprintf(w, "HTTP/1.1 200 OK\r\nContent-Length: %d\r\n%s\r\n%s", len(content), objectHeaders(), content)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := storagetest.NewFakeS3()
+			fake.PutObject("test-bucket", "data.bin", content, nil)
+			fake.Before = func(w http.ResponseWriter, r *http.Request) bool {
+				if r.Method != http.MethodGet || r.URL.Path != "/test-bucket/data.bin" {
+					return false
+				}
+				conn, buf, err := w.(http.Hijacker).Hijack()
+				if err != nil {
+					t.Errorf("Hijack() error = %v", err)
+					return true
+				}
+				defer conn.Close()
+				tt.respond(buf)
+				buf.Flush()
+				return true
+			}
+			service := newTestService(t, fake, Config{})
+			ctx := context.Background()
+
+			data, err := service.DownloadBuffer(ctx, "data.bin")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("DownloadBuffer() error = %v, want %v", err, tt.wantErr)
+			}
+			if !bytes.HasPrefix(content, data) || (tt.wantErr == nil && len(data) != len(content)) {
+				t.Errorf("DownloadBuffer() returned %d bytes that are not the start of the object", len(data))
+			}
+
+			var buf bytes.Buffer
+			written, err := service.DownloadToWriter(ctx, "data.bin", &buf)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("DownloadToWriter() error = %v, want %v", err, tt.wantErr)
+			}
+			if written != int64(buf.Len()) || !bytes.HasPrefix(content, buf.Bytes()) {
+				t.Errorf("DownloadToWriter() wrote %d bytes, reported %d", buf.Len(), written)
+			}
+		})
+	}
+}
+
+// objectHeaders returns the response headers, each ending in CRLF, that
+// minio-go requires on an object read.
+func objectHeaders() string {
+	return "Content-Type: application/octet-stream\r\nETag: \"0123\"\r\nLast-Modified: " +
+		time.Now().UTC().Format(http.TimeFormat) + "\r\n"
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidObjectName = errors.New("invalid object name")
+	ErrInvalidBucketName = errors.New("invalid bucket name")
+)
+
+// maxObjectNameLength is the S3 limit on key length, in bytes.
+const maxObjectNameLength = 1024
+
+// SystemPrefix holds the records the service keeps for itself, such as the
+// content index. Listings skip it and ValidateObjectName refuses it, so
+// clients can neither see nor overwrite those records. Tenant IDs can't
+// start with a dot, so no tenant prefix overlaps it.
+const SystemPrefix = ".minio-learn/"
+
+// IsSystemKey reports whether objectName is under SystemPrefix.
+func IsSystemKey(objectName string) bool {
+	return strings.HasPrefix(objectName, SystemPrefix)
+}
+
+// ValidateObjectName rejects keys that are empty, too long, not UTF-8, start
+// with a slash, contain "." or ".." path segments, or contain control
+// characters or backslashes. Such keys are legal in S3 but let callers escape
+// the prefix they were meant to be confined to once keys are treated as paths.
+// Keys under SystemPrefix are refused too.
+func ValidateObjectName(objectName string) error {
+	if objectName == "" {
+		return fmt.Errorf("%w: name is empty", ErrInvalidObjectName)
+	}
+	if len(objectName) > maxObjectNameLength {
+		return fmt.Errorf("%w: name is longer than %d bytes", ErrInvalidObjectName, maxObjectNameLength)
+	}
+	if !utf8.ValidString(objectName) {
+		return fmt.Errorf("%w: name is not valid UTF-8", ErrInvalidObjectName)
+	}
+	if strings.HasPrefix(objectName, "/") {
+		return fmt.Errorf("%w: name must not start with '/'", ErrInvalidObjectName)
+	}
+	if IsSystemKey(objectName) {
+		return fmt.Errorf("%w: name uses the reserved prefix %q", ErrInvalidObjectName, SystemPrefix)
+	}
+	for _, c := range objectName {
+		if c < 0x20 || c == 0x7f {
+			return fmt.Errorf("%w: name contains control characters", ErrInvalidObjectName)
+		}
+		if c == '\\' {
+			return fmt.Errorf("%w: name must not contain '\\'", ErrInvalidObjectName)
+		}
+	}
+	for _, segment := range strings.Split(objectName, "/") {
+		if segment == "." || segment == ".." {
+			return fmt.Errorf("%w: name must not contain '.' or '..' segments", ErrInvalidObjectName)
+		}
+	}
+	return nil
+}
+
+// ValidateBucketName applies the S3 bucket naming rules: 3 to 63 lowercase
+// letters, digits, dots and hyphens, starting and ending with a letter or
+// digit, without adjacent dots or dot-hyphen pairs, not formatted like an IP
+// address and without the prefixes and suffixes S3 reserves.
+func ValidateBucketName(bucketName string) error {
+	if len(bucketName) < 3 || len(bucketName) > 63 {
+		return fmt.Errorf("%w: name must be 3 to 63 characters long", ErrInvalidBucketName)
+	}
+	for _, c := range bucketName {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '.' || c == '-') {
+			return fmt.Errorf("%w: name may only contain lowercase letters, digits, '.' and '-'", ErrInvalidBucketName)
+		}
+	}
+	first, last := bucketName[0], bucketName[len(bucketName)-1]
+	if first == '.' || first == '-' || last == '.' || last == '-' {
+		return fmt.Errorf("%w: name must start and end with a letter or digit", ErrInvalidBucketName)
+	}
+	if strings.Contains(bucketName, "..") || strings.Contains(bucketName, ".-") || strings.Contains(bucketName, "-.") {
+		return fmt.Errorf("%w: name must not contain '..', '.-' or '-.'", ErrInvalidBucketName)
+	}
+	if net.ParseIP(bucketName) != nil {
+		return fmt.Errorf("%w: name must not be formatted as an IP address", ErrInvalidBucketName)
+	}
+	if strings.HasPrefix(bucketName, "xn--") || strings.HasSuffix(bucketName, "-s3alias") || strings.HasSuffix(bucketName, "--ol-s3") {
+		return fmt.Errorf("%w: name uses a reserved prefix or suffix", ErrInvalidBucketName)
+	}
+	return nil
+}
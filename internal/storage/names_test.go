@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateObjectName(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{"simple", "uploads/report.pdf", false},
+		{"nested", "uploads/2024/05/report.pdf", false},
+		{"dots in name", "uploads/report..v2.pdf", false},
+		{"dot file", "uploads/.hidden", false},
+		{"unicode", "uploads/résumé.pdf", false},
+		{"empty", "", true},
+		{"too long", strings.Repeat("a", maxObjectNameLength+1), true},
+		{"parent segment", "uploads/../secret.txt", true},
+		{"leading parent", "../secret.txt", true},
+		{"trailing parent", "uploads/..", true},
+		{"current segment", "uploads/./report.pdf", true},
+		{"leading slash", "/etc/passwd", true},
+		{"backslash traversal", "uploads\\..\\secret.txt", true},
+		{"newline", "uploads/report\n.pdf", true},
+		{"NUL", "uploads/report\x00.pdf", true},
+		{"DEL", "uploads/report\x7f.pdf", true},
+		{"invalid UTF-8", "uploads/\xff.pdf", true},
+		{"system prefix", SystemPrefix + "index", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateObjectName(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateObjectName(%q) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidObjectName) {
+				t.Errorf("ValidateObjectName(%q) error = %v, want ErrInvalidObjectName", tt.key, err)
+			}
+		})
+	}
+}
+
+func TestValidateBucketName(t *testing.T) {
+	tests := []struct {
+		name    string
+		bucket  string
+		wantErr bool
+	}{
+		{"simple", "uploads", false},
+		{"digits dots and hyphens", "my-app.logs-2024", false},
+		{"too short", "ab", true},
+		{"too long", strings.Repeat("a", 64), true},
+		{"uppercase", "MyBucket", true},
+		{"underscore", "my_bucket", true},
+		{"leading hyphen", "-bucket", true},
+		{"consecutive dots", "my..bucket", true},
+		{"hyphen next to dot", "my-.bucket", true},
+		{"IP address", "10.0.0.1", true},
+		{"reserved suffix", "bucket--ol-s3", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateBucketName(tt.bucket)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateBucketName(%q) error = %v, wantErr %v", tt.bucket, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidBucketName) {
+				t.Errorf("ValidateBucketName(%q) error = %v, want ErrInvalidBucketName", tt.bucket, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+func TestObjectLockPreventsDelete(t *testing.T) {
+	data := []byte("audit log")
+
+	tests := []struct {
+		name       string
+		lock       func(ctx context.Context, store Storage, versionID string) error
+		wantLocked bool
+	}{
+		{"unlocked", nil, false},
+		{"governance retention", func(ctx context.Context, store Storage, versionID string) error {
+			return store.SetObjectRetention(ctx, "audit.log", versionID, minio.Governance, time.Now().Add(time.Hour))
+		}, true},
+		{"compliance retention", func(ctx context.Context, store Storage, versionID string) error {
+			return store.SetObjectRetention(ctx, "audit.log", versionID, minio.Compliance, time.Now().Add(time.Hour))
+		}, true},
+		{"expired retention", func(ctx context.Context, store Storage, versionID string) error {
+			return store.SetObjectRetention(ctx, "audit.log", versionID, minio.Governance, time.Now().Add(-time.Hour))
+		}, false},
+		{"legal hold", func(ctx context.Context, store Storage, versionID string) error {
+			return store.SetLegalHold(ctx, "audit.log", versionID, true)
+		}, true},
+		{"legal hold lifted", func(ctx context.Context, store Storage, versionID string) error {
+			if err := store.SetLegalHold(ctx, "audit.log", versionID, true); err != nil {
+				return err
+			}
+			return store.SetLegalHold(ctx, "audit.log", versionID, false)
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := NewMemoryStorage("test-bucket")
+			store.EnableObjectLock()
+			info, err := store.UploadBuffer(ctx, "audit.log", data, "text/plain", nil)
+			if err != nil {
+				t.Fatalf("UploadBuffer() error = %v", err)
+			}
+			if tt.lock != nil {
+				if err := tt.lock(ctx, store, info.VersionID); err != nil {
+					t.Fatalf("locking error = %v", err)
+				}
+			}
+
+			err = store.DeleteObjectVersion(ctx, "audit.log", info.VersionID)
+			if !tt.wantLocked {
+				if err != nil {
+					t.Fatalf("DeleteObjectVersion() error = %v", err)
+				}
+				if exists, _ := store.CheckObjectExists(ctx, "audit.log"); exists {
+					t.Error("audit.log exists after deleting its only version")
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrAccessDenied) {
+				t.Fatalf("DeleteObjectVersion() of a locked version error = %v, want ErrAccessDenied", err)
+			}
+			got, err := store.DownloadBufferVersion(ctx, "audit.log", info.VersionID)
+			if err != nil || !bytes.Equal(got, data) {
+				t.Errorf("locked version = %q, %v, want it intact", got, err)
+			}
+		})
+	}
+}
+
+func TestComplianceRetentionCannotBeShortened(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStorage("test-bucket")
+	store.EnableObjectLock()
+	info, err := store.UploadBuffer(ctx, "audit.log", []byte("audit log"), "text/plain", nil)
+	if err != nil {
+		t.Fatalf("UploadBuffer() error = %v", err)
+	}
+	until := time.Now().Add(time.Hour)
+	if err := store.SetObjectRetention(ctx, "audit.log", info.VersionID, minio.Compliance, until); err != nil {
+		t.Fatalf("SetObjectRetention() error = %v", err)
+	}
+
+	if err := store.SetObjectRetention(ctx, "audit.log", info.VersionID, minio.Compliance, until.Add(-time.Minute)); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("shortening compliance retention error = %v, want ErrAccessDenied", err)
+	}
+	if err := store.SetObjectRetention(ctx, "audit.log", info.VersionID, minio.Governance, until); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("downgrading to governance error = %v, want ErrAccessDenied", err)
+	}
+	if err := store.SetObjectRetention(ctx, "audit.log", info.VersionID, minio.Compliance, until.Add(time.Hour)); err != nil {
+		t.Errorf("extending compliance retention error = %v", err)
+	}
+}
+
+func TestObjectLockNotEnabled(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStorage("test-bucket")
+	if _, err := store.UploadBuffer(ctx, "audit.log", []byte("audit log"), "text/plain", nil); err != nil {
+		t.Fatalf("UploadBuffer() error = %v", err)
+	}
+
+	if err := store.SetLegalHold(ctx, "audit.log", "", true); err == nil {
+		t.Error("SetLegalHold() succeeded on a bucket without object locking")
+	}
+	if err := store.SetObjectRetention(ctx, "audit.log", "", minio.Governance, time.Now().Add(time.Hour)); err == nil {
+		t.Error("SetObjectRetention() succeeded on a bucket without object locking")
+	}
+}
@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"MinIO-Learn/internal/metrics"
+)
+
+// observe records the metrics and log line for an operation that started at
+// start. target names what it acted on (an object or a prefix); size, if not
+// nil, is the number of bytes it transferred. size and err are pointers so
+// observe can be deferred before the operation's results are known. It also
+// classifies *err, so observed operations return the package's sentinel
+// errors for missing objects and denied access.
+func (s *MinIOService) observe(ctx context.Context, operation string, target slog.Attr, start time.Time, size *int64, err *error) {
+	duration := time.Since(start)
+	*err = classifyError(*err)
+	metrics.ObserveOperation(operation, start, err)
+
+	attrs := []slog.Attr{
+		slog.String("operation", operation),
+		slog.String("bucket", s.BucketName),
+		target,
+		slog.Duration("duration", duration),
+	}
+
+	if *err != nil {
+		attrs = append(attrs, slog.Any("error", *err))
+		s.logger.LogAttrs(ctx, slog.LevelError, "Storage operation failed", attrs...)
+		return
+	}
+
+	if size != nil {
+		attrs = append(attrs, slog.Int64("size", *size))
+		if operation == metrics.OpUpload {
+			metrics.ObserveUploadedBytes(*size)
+		}
+	}
+	s.logger.LogAttrs(ctx, slog.LevelDebug, "Storage operation", attrs...)
+}
@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrPublicAccessDisabled is returned when asked to make objects public
+// without the service being configured with AllowPublic.
+var ErrPublicAccessDisabled = errors.New("public access is disabled")
+
+// policyDocument is an S3 bucket policy. Statements are kept raw so ones
+// written by others survive a round trip unchanged.
+type policyDocument struct {
+	Version   string            `json:"Version"`
+	Statement []json.RawMessage `json:"Statement"`
+}
+
+type policyStatement struct {
+	Sid       string              `json:"Sid"`
+	Effect    string              `json:"Effect"`
+	Principal map[string][]string `json:"Principal"`
+	Action    []string            `json:"Action"`
+	Resource  []string            `json:"Resource"`
+}
+
+// publicReadSid identifies the statement publishing prefix, so publishing it
+// again replaces the statement. Sids may only hold alphanumerics, hence the
+// hash.
+func publicReadSid(prefix string) string {
+	sum := sha256.Sum256([]byte(prefix))
+	return "PublicRead" + hex.EncodeToString(sum[:8])
+}
+
+// publicReadPolicy returns the bucket policy current, which may be empty,
+// with a statement allowing anonymous GetObject on every key under prefix.
+// Listing the bucket stays forbidden.
+func publicReadPolicy(current, bucket, prefix string) (string, error) {
+	doc := policyDocument{Version: "2012-10-17"}
+	if current != "" {
+		if err := json.Unmarshal([]byte(current), &doc); err != nil {
+			return "", fmt.Errorf("failed to parse bucket policy: %w", err)
+		}
+	}
+
+	sid := publicReadSid(prefix)
+	statement, err := json.Marshal(policyStatement{
+		Sid:       sid,
+		Effect:    "Allow",
+		Principal: map[string][]string{"AWS": {"*"}},
+		Action:    []string{"s3:GetObject"},
+		Resource:  []string{fmt.Sprintf("arn:aws:s3:::%s/%s*", bucket, prefix)},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode bucket policy: %w", err)
+	}
+
+	replaced := false
+	for i, raw := range doc.Statement {
+		var existing struct{ Sid string }
+		if json.Unmarshal(raw, &existing) == nil && existing.Sid == sid {
+			doc.Statement[i] = statement
+			replaced = true
+		}
+	}
+	if !replaced {
+		doc.Statement = append(doc.Statement, statement)
+	}
+
+	policy, err := json.Marshal(doc)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode bucket policy: %w", err)
+	}
+	return string(policy), nil
+}
+
+// SetPublicReadPolicy lets anyone download objects under prefix, without
+// credentials, at the URLs GetPublicURL returns. Unlike a presigned URL, that
+// access never expires and can't be revoked per link: a leaked URL or a
+// guessable key exposes the object until the policy is removed. It therefore
+// fails with ErrPublicAccessDisabled unless the service was configured with
+// AllowPublic. An empty prefix publishes the whole bucket. Other statements
+// in the bucket policy are kept.
+func (s *MinIOService) SetPublicReadPolicy(ctx context.Context, prefix string) error {
+	return s.setPublicRead(ctx, s.BucketName, prefix)
+}
+
+func (s *MinIOService) setPublicRead(ctx context.Context, bucket, prefix string) error {
+	if !s.allowPublic {
+		return ErrPublicAccessDisabled
+	}
+
+	current, err := s.Client.GetBucketPolicy(ctx, bucket)
+	if err != nil {
+		return fmt.Errorf("failed to get bucket policy: %w", err)
+	}
+
+	policy, err := publicReadPolicy(current, bucket, prefix)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Client.SetBucketPolicy(ctx, bucket, policy); err != nil {
+		return fmt.Errorf("failed to set bucket policy: %w", err)
+	}
+	return nil
+}
+
+// GetPublicURL returns the permanent, unsigned URL of objectName. It only
+// serves objects under a prefix published with SetPublicReadPolicy.
+func (s *MinIOService) GetPublicURL(objectName string) string {
+	endpoint := strings.TrimSuffix(s.Client.EndpointURL().String(), "/")
+	return endpoint + (&url.URL{Path: "/" + s.BucketName + "/" + objectName}).EscapedPath()
+}
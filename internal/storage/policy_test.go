@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"MinIO-Learn/internal/storage/storagetest"
+)
+
+func TestPublicReadPolicy(t *testing.T) {
+	imgSid := publicReadSid("img/")
+	imgStatement := `{"Sid":"` + imgSid + `","Effect":"Allow","Principal":{"AWS":["*"]},` +
+		`"Action":["s3:GetObject"],"Resource":["arn:aws:s3:::assets/img/*"]}`
+	other := `{"Sid":"Other","Effect":"Deny","Principal":{"AWS":["*"]},"Action":["s3:DeleteObject"],"Resource":["arn:aws:s3:::assets/*"]}`
+
+	tests := []struct {
+		name    string
+		current string
+		prefix  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "no policy",
+			prefix: "img/",
+			want:   `{"Version":"2012-10-17","Statement":[` + imgStatement + `]}`,
+		},
+		{
+			name:    "keeps other statements",
+			current: `{"Version":"2012-10-17","Statement":[` + other + `]}`,
+			prefix:  "img/",
+			want:    `{"Version":"2012-10-17","Statement":[` + other + `,` + imgStatement + `]}`,
+		},
+		{
+			name:    "replaces its own statement",
+			current: `{"Version":"2012-10-17","Statement":[{"Sid":"` + imgSid + `","Effect":"Allow"},` + other + `]}`,
+			prefix:  "img/",
+			want:    `{"Version":"2012-10-17","Statement":[` + imgStatement + `,` + other + `]}`,
+		},
+		{
+			name:   "whole bucket",
+			prefix: "",
+			want: `{"Version":"2012-10-17","Statement":[{"Sid":"` + publicReadSid("") + `","Effect":"Allow","Principal":{"AWS":["*"]},` +
+				`"Action":["s3:GetObject"],"Resource":["arn:aws:s3:::assets/*"]}]}`,
+		},
+		{name: "invalid policy", current: "not json", prefix: "img/", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := publicReadPolicy(tt.current, "assets", tt.prefix)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("publicReadPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("publicReadPolicy() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPublicReadSid(t *testing.T) {
+	sid := publicReadSid("img/")
+	if sid != publicReadSid("img/") {
+		t.Error("publicReadSid() differs between calls for the same prefix")
+	}
+	if sid == publicReadSid("docs/") {
+		t.Error("publicReadSid() is the same for different prefixes")
+	}
+	for _, r := range sid {
+		if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9') {
+			t.Fatalf("publicReadSid() = %q, want only alphanumerics", sid)
+		}
+	}
+}
+
+func TestSetPublicReadPolicy(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("disabled", func(t *testing.T) {
+		fake := storagetest.NewFakeS3()
+		service := newTestService(t, fake, Config{})
+		if err := service.EnsureBucket(ctx); err != nil {
+			t.Fatalf("EnsureBucket() error = %v", err)
+		}
+		if err := service.SetPublicReadPolicy(ctx, "img/"); !errors.Is(err, ErrPublicAccessDisabled) {
+			t.Errorf("SetPublicReadPolicy() error = %v, want ErrPublicAccessDisabled", err)
+		}
+		if _, ok := fake.BucketConfig("test-bucket", "policy"); ok {
+			t.Error("a bucket policy was set with public access disabled")
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		fake := storagetest.NewFakeS3()
+		service := newTestService(t, fake, Config{AllowPublic: true})
+		if err := service.EnsureBucket(ctx); err != nil {
+			t.Fatalf("EnsureBucket() error = %v", err)
+		}
+		for _, prefix := range []string{"img/", "docs/", "img/"} {
+			if err := service.SetPublicReadPolicy(ctx, prefix); err != nil {
+				t.Fatalf("SetPublicReadPolicy(%q) error = %v", prefix, err)
+			}
+		}
+
+		stored, ok := fake.BucketConfig("test-bucket", "policy")
+		if !ok {
+			t.Fatal("no bucket policy set")
+		}
+		var doc struct {
+			Statement []policyStatement
+		}
+		if err := json.Unmarshal(stored, &doc); err != nil {
+			t.Fatalf("decoding policy %s: %v", stored, err)
+		}
+		var resources []string
+		for _, statement := range doc.Statement {
+			if statement.Effect != "Allow" || len(statement.Action) != 1 || statement.Action[0] != "s3:GetObject" {
+				t.Errorf("statement %+v, want an Allow of s3:GetObject only", statement)
+			}
+			resources = append(resources, statement.Resource...)
+		}
+		if got := strings.Join(resources, " "); got != "arn:aws:s3:::test-bucket/img/* arn:aws:s3:::test-bucket/docs/*" {
+			t.Errorf("resources = %s, want img/ and docs/ once each", got)
+		}
+	})
+}
+
+func TestGetPublicURL(t *testing.T) {
+	service := newTestService(t, storagetest.NewFakeS3(), Config{AllowPublic: true})
+	url := service.GetPublicURL("img/summer photo #1.jpg")
+	want := service.Client.EndpointURL().String() + "/test-bucket/img/summer%20photo%20%231.jpg"
+	if url != want {
+		t.Errorf("GetPublicURL() = %q, want %q", url, want)
+	}
+}
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"MinIO-Learn/internal/storage/storagetest"
+)
+
+func TestGetPresignedPostPolicy(t *testing.T) {
+	tests := []struct {
+		name             string
+		prefix           string
+		minSize, maxSize int64
+		expiry           time.Duration
+		wantErr          bool
+	}{
+		{name: "uploads", prefix: "uploads/", minSize: 1, maxSize: 10 << 20, expiry: 15 * time.Minute},
+		{name: "tenant prefix", prefix: "tenants/alpha/uploads/", minSize: 1, maxSize: 1 << 30, expiry: time.Hour},
+		{name: "inverted size range", prefix: "uploads/", minSize: 10, maxSize: 1, expiry: time.Hour, wantErr: true},
+		{name: "negative size", prefix: "uploads/", minSize: -1, maxSize: 1, expiry: time.Hour, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newTestService(t, storagetest.NewFakeS3(), Config{})
+			start := time.Now()
+			url, fields, err := service.GetPresignedPostPolicy(context.Background(), tt.prefix, tt.minSize, tt.maxSize, tt.expiry)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetPresignedPostPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if url == "" {
+				t.Error("URL is empty")
+			}
+			for _, field := range []string{"policy", "x-amz-signature", "x-amz-credential", "x-amz-date", "x-amz-algorithm", "bucket"} {
+				if fields[field] == "" {
+					t.Errorf("form field %q missing from %v", field, fields)
+				}
+			}
+
+			raw, err := base64.StdEncoding.DecodeString(fields["policy"])
+			if err != nil {
+				t.Fatalf("decoding policy: %v", err)
+			}
+			var policy struct {
+				Expiration time.Time `json:"expiration"`
+				Conditions [][]any   `json:"conditions"`
+			}
+			if err := json.Unmarshal(raw, &policy); err != nil {
+				t.Fatalf("parsing policy %s: %v", raw, err)
+			}
+
+			if want := start.Add(tt.expiry); policy.Expiration.Before(want.Add(-time.Second)) || policy.Expiration.After(want.Add(time.Minute)) {
+				t.Errorf("expiration = %v, want about %v", policy.Expiration, want)
+			}
+			wantConditions := [][]any{
+				{"eq", "$bucket", "test-bucket"},
+				{"starts-with", "$key", tt.prefix},
+				{"content-length-range", float64(tt.minSize), float64(tt.maxSize)},
+			}
+			for _, want := range wantConditions {
+				if !slices.ContainsFunc(policy.Conditions, func(c []any) bool { return slices.Equal(c, want) }) {
+					t.Errorf("conditions %v lack %v", policy.Conditions, want)
+				}
+			}
+		})
+	}
+}
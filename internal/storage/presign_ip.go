@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+)
+
+// GeneratePresignedGetForIP returns a presigned GET URL intended for use only
+// from clientIP. The boolean result reports whether the backend actually
+// enforces that restriction.
+//
+// S3 and MinIO accept aws:SourceIp conditions only in bucket policies and
+// POST policies; a SigV4 query-string signature has nowhere to carry one. So
+// for GET this currently always falls back to an unrestricted presigned URL
+// and reports false, leaving the caller to warn or refuse.
+func (s *MinIOService) GeneratePresignedGetForIP(ctx context.Context, objectName, clientIP string, expiry time.Duration) (string, bool, error) {
+	if net.ParseIP(clientIP) == nil {
+		return "", false, fmt.Errorf("invalid client IP %q", clientIP)
+	}
+
+	presignedURL, err := s.Client.PresignedGetObject(ctx, s.BucketName, objectName, expiry, nil)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to generate presigned URL: %w", err)
+	}
+
+	return presignedURL.String(), false, nil
+}
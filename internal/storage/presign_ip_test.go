@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGeneratePresignedGetForIP(t *testing.T) {
+	tests := []struct {
+		name     string
+		clientIP string
+		wantErr  bool
+	}{
+		{name: "IPv4", clientIP: "203.0.113.7"},
+		{name: "IPv6", clientIP: "2001:db8::1"},
+		{name: "not an IP", clientIP: "example.com", wantErr: true},
+		{name: "empty", clientIP: "", wantErr: true},
+	}
+
+	for name, store := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					url, restricted, err := store.GeneratePresignedGetForIP(context.Background(), "report.pdf", tt.clientIP, time.Minute)
+					if (err != nil) != tt.wantErr {
+						t.Fatalf("GeneratePresignedGetForIP() error = %v, wantErr %v", err, tt.wantErr)
+					}
+					if tt.wantErr {
+						return
+					}
+					if !strings.Contains(url, "report.pdf") {
+						t.Errorf("URL %q does not name the object", url)
+					}
+					// Neither backend can bind a presigned GET to an address, so
+					// callers must be told the URL is unrestricted.
+					if restricted {
+						t.Error("restricted = true, want the unrestricted fallback")
+					}
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"context"
+	io "io"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// ProgressFunc receives the number of bytes transferred so far and the total
+// expected, which is -1 when unknown.
+type ProgressFunc func(transferred, total int64)
+
+// progressInterval is the minimum time between two progress reports.
+const progressInterval = 250 * time.Millisecond
+
+type progressKey struct{}
+
+// WithProgress returns a context under which uploads and DownloadToWriter
+// report their progress to fn at most every progressInterval, and once more
+// when they complete. Uploads count the bytes of the original content, even
+// when it is stored compressed.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// progressCounter accumulates transferred bytes and throttles reports. It is
+// safe for concurrent use, as parallel multipart uploads report from several
+// goroutines. A nil *progressCounter ignores everything.
+type progressCounter struct {
+	fn    ProgressFunc
+	total int64
+
+	mu       sync.Mutex
+	n        int64
+	reported time.Time
+}
+
+func newProgressCounter(ctx context.Context, total int64) *progressCounter {
+	fn, _ := ctx.Value(progressKey{}).(ProgressFunc)
+	if fn == nil {
+		return nil
+	}
+	if total < 0 {
+		total = -1
+	}
+	return &progressCounter{fn: fn, total: total}
+}
+
+func (p *progressCounter) add(n int) {
+	if p == nil || n == 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.n += int64(n)
+	if p.total >= 0 && p.n > p.total {
+		// PutObject counts retried chunks again.
+		p.n = p.total
+	}
+	if now := time.Now(); now.Sub(p.reported) >= progressInterval {
+		p.reported = now
+		p.fn(p.n, p.total)
+	}
+}
+
+// finish reports the final count after a successful transfer, which is the
+// total when it is known.
+func (p *progressCounter) finish() {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.total >= 0 {
+		p.n = p.total
+	}
+	p.fn(p.n, p.total)
+}
+
+// Read lets the counter serve as PutObjectOptions.Progress, which PutObject
+// reads from with each chunk it has sent.
+func (p *progressCounter) Read(b []byte) (int, error) {
+	p.add(len(b))
+	return len(b), nil
+}
+
+type progressReader struct {
+	io.Reader
+	progress *progressCounter
+}
+
+func (r *progressReader) Read(b []byte) (int, error) {
+	n, err := r.Reader.Read(b)
+	r.progress.add(n)
+	return n, err
+}
+
+type progressWriter struct {
+	io.Writer
+	progress *progressCounter
+}
+
+func (w *progressWriter) Write(b []byte) (int, error) {
+	n, err := w.Writer.Write(b)
+	w.progress.add(n)
+	return n, err
+}
+
+// uploadProgress hooks the progress reporter in ctx, if any, into an upload of
+// size bytes from reader, returning the reader to upload from. Call finish on
+// the returned counter once the upload succeeds.
+func (s *MinIOService) uploadProgress(ctx context.Context, reader io.Reader, size int64, contentType string, opts *minio.PutObjectOptions) (io.Reader, *progressCounter) {
+	progress := newProgressCounter(ctx, size)
+	if progress == nil {
+		return reader, nil
+	}
+
+	// Wrapping the reader would hide io.ReaderAt, which PutObject uses to
+	// upload parts of a file in parallel, so let PutObject report what it
+	// sent instead. Compressed uploads are wrapped anyway and PutObject would
+	// count compressed bytes, so those count the source.
+	if _, ok := reader.(io.ReaderAt); ok && !s.shouldCompress(contentType) {
+		opts.Progress = progress
+		return reader, progress
+	}
+	return &progressReader{Reader: reader, progress: progress}, progress
+}
@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// progressLog records the reports made to a ProgressFunc.
+type progressLog struct {
+	mu      sync.Mutex
+	reports [][2]int64
+}
+
+func (l *progressLog) report(transferred, total int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.reports = append(l.reports, [2]int64{transferred, total})
+}
+
+// check fails t unless the reports never go down and the last one is the
+// whole transfer of want bytes.
+func (l *progressLog) check(t *testing.T, want int64) {
+	t.Helper()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.reports) == 0 {
+		t.Fatal("no progress reported")
+	}
+	for i := 1; i < len(l.reports); i++ {
+		if l.reports[i][0] < l.reports[i-1][0] {
+			t.Errorf("progress went from %d to %d", l.reports[i-1][0], l.reports[i][0])
+		}
+	}
+	if last := l.reports[len(l.reports)-1]; last != [2]int64{want, want} {
+		t.Errorf("last report = %d of %d, want %d of %d", last[0], last[1], want, want)
+	}
+}
+
+func TestProgressCounter(t *testing.T) {
+	tests := []struct {
+		name  string
+		total int64
+		adds  []int
+		want  []int64
+	}{
+		{"in steps", 10, []int{3, 3, 4}, []int64{3, 6, 10, 10}},
+		// PutObject counts a retried chunk again; the count stops at the total.
+		{"retried chunk", 10, []int{4, 4, 4, 4}, []int64{4, 8, 10, 10, 10}},
+		{"unknown total", -1, []int{5, 5}, []int64{5, 10, 10}},
+		{"empty reads", 10, []int{0, 6, 0, 4}, []int64{6, 10, 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int64
+			ctx := WithProgress(context.Background(), func(transferred, total int64) {
+				got = append(got, transferred)
+			})
+			progress := newProgressCounter(ctx, tt.total)
+			for _, n := range tt.adds {
+				// Clear the throttle so every add reports.
+				progress.reported = time.Time{}
+				progress.add(n)
+			}
+			progress.finish()
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("reports = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProgressNeverDecreases(t *testing.T) {
+	data := bytes.Repeat([]byte("progress "), 2<<20)
+	size := int64(len(data))
+	source := filepath.Join(t.TempDir(), "large.bin")
+	if err := os.WriteFile(source, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	operations := []struct {
+		name string
+		run  func(ctx context.Context, store Storage) error
+	}{
+		{"UploadBuffer", func(ctx context.Context, store Storage) error {
+			_, err := store.UploadBuffer(ctx, "progress.bin", data, "application/octet-stream", nil)
+			return err
+		}},
+		{"UploadStream", func(ctx context.Context, store Storage) error {
+			_, err := store.UploadStream(ctx, "progress.bin", io.MultiReader(bytes.NewReader(data)), size, "application/octet-stream", nil)
+			return err
+		}},
+		{"UploadLargeFile", func(ctx context.Context, store Storage) error {
+			_, err := store.UploadLargeFile(ctx, "progress.bin", source, "application/octet-stream", nil)
+			return err
+		}},
+		{"DownloadToWriter", func(ctx context.Context, store Storage) error {
+			if _, err := store.UploadBuffer(context.Background(), "progress.bin", data, "application/octet-stream", nil); err != nil {
+				return err
+			}
+			_, err := store.DownloadToWriter(ctx, "progress.bin", io.Discard)
+			return err
+		}},
+	}
+
+	for _, op := range operations {
+		t.Run(op.name, func(t *testing.T) {
+			for name, store := range testBackends(t) {
+				t.Run(name, func(t *testing.T) {
+					var log progressLog
+					if err := op.run(WithProgress(context.Background(), log.report), store); err != nil {
+						t.Fatalf("%s error = %v", op.name, err)
+					}
+					log.check(t, size)
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGetObjectRange(t *testing.T) {
+	const content = "0123456789abcdef"
+
+	tests := []struct {
+		name       string
+		start, end int64
+		want       string
+		wantErr    bool
+	}{
+		{name: "bounded", start: 2, end: 5, want: "2345"},
+		{name: "single byte", start: 7, end: 7, want: "7"},
+		{name: "from the start", start: 0, end: 3, want: "0123"},
+		{name: "open-ended", start: 10, end: 0, want: "abcdef"},
+		{name: "suffix", start: 0, end: -4, want: "cdef"},
+		{name: "suffix longer than object", start: 0, end: -100, want: content},
+		{name: "end past object", start: 12, end: 100, want: "cdef"},
+		{name: "start past object", start: 16, end: 20, wantErr: true},
+		{name: "inverted", start: 5, end: 2, wantErr: true},
+		{name: "negative start", start: -1, end: 4, wantErr: true},
+	}
+
+	for name, store := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if _, err := store.UploadBuffer(ctx, "range.txt", []byte(content), "text/plain", nil); err != nil {
+				t.Fatalf("UploadBuffer() error = %v", err)
+			}
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					got, err := store.GetObjectRange(ctx, "range.txt", tt.start, tt.end)
+					if (err != nil) != tt.wantErr {
+						t.Fatalf("GetObjectRange(%d, %d) error = %v, wantErr %v", tt.start, tt.end, err, tt.wantErr)
+					}
+					if string(got) != tt.want {
+						t.Errorf("GetObjectRange(%d, %d) = %q, want %q", tt.start, tt.end, got, tt.want)
+					}
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// objectHeap is a min-heap on LastModified, so the oldest of the retained
+// objects is always at the root and can be evicted cheaply.
+type objectHeap []minio.ObjectInfo
+
+func (h objectHeap) Len() int           { return len(h) }
+func (h objectHeap) Less(i, j int) bool { return h[i].LastModified.Before(h[j].LastModified) }
+func (h objectHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *objectHeap) Push(x any)        { *h = append(*h, x.(minio.ObjectInfo)) }
+func (h *objectHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// RecentUploads returns the n most recently modified objects under prefix,
+// newest first. Only n objects are held in memory while the listing streams.
+func (s *MinIOService) RecentUploads(ctx context.Context, prefix string, n int) ([]minio.ObjectInfo, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("n must be positive, got %d", n)
+	}
+
+	objectCh := s.Client.ListObjects(ctx, s.BucketName, minio.ListObjectsOptions{
+		Prefix:    prefix,
+		Recursive: true,
+	})
+
+	h := make(objectHeap, 0, n)
+	for object := range objectCh {
+		if object.Err != nil {
+			return nil, fmt.Errorf("error listing objects: %w", object.Err)
+		}
+		if IsSystemKey(object.Key) {
+			continue
+		}
+
+		if h.Len() < n {
+			heap.Push(&h, object)
+		} else if object.LastModified.After(h[0].LastModified) {
+			h[0] = object
+			heap.Fix(&h, 0)
+		}
+	}
+
+	recent := []minio.ObjectInfo(h)
+	sort.Slice(recent, func(i, j int) bool {
+		return recent[i].LastModified.After(recent[j].LastModified)
+	})
+
+	return recent, nil
+}
+
+// ListModifiedSince returns the objects under prefix last modified at or
+// after since, oldest first. S3 cannot filter by modification time on the
+// server, so this still scans every key under prefix; only the matches are
+// kept in memory.
+func (s *MinIOService) ListModifiedSince(ctx context.Context, prefix string, since time.Time) ([]minio.ObjectInfo, error) {
+	var changed []minio.ObjectInfo
+	err := s.ForEachObject(ctx, prefix, func(object minio.ObjectInfo) error {
+		if !object.LastModified.Before(since) {
+			changed = append(changed, object)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(changed, func(i, j int) bool {
+		return changed[i].LastModified.Before(changed[j].LastModified)
+	})
+
+	return changed, nil
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"MinIO-Learn/internal/storage/storagetest"
+)
+
+func TestRecentUploads(t *testing.T) {
+	fake := storagetest.NewFakeS3()
+	service := newTestService(t, fake, Config{})
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	ages := map[string]time.Duration{
+		"docs/a.txt":       5 * time.Hour,
+		"docs/b.txt":       1 * time.Hour,
+		"docs/c.txt":       3 * time.Hour,
+		"images/d.png":     2 * time.Hour,
+		"images/e.png":     4 * time.Hour,
+		SystemPrefix + "x": 0,
+	}
+	for key, age := range ages {
+		fake.PutObject("test-bucket", key, []byte(key), nil).LastModified = base.Add(-age)
+	}
+
+	tests := []struct {
+		name    string
+		prefix  string
+		n       int
+		want    []string
+		wantErr bool
+	}{
+		{name: "newest first", n: 3, want: []string{"docs/b.txt", "images/d.png", "docs/c.txt"}},
+		{name: "fewer objects than n", n: 10, want: []string{"docs/b.txt", "images/d.png", "docs/c.txt", "images/e.png", "docs/a.txt"}},
+		{name: "prefix", prefix: "images/", n: 1, want: []string{"images/d.png"}},
+		{name: "non-positive n", n: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects, err := service.RecentUploads(context.Background(), tt.prefix, tt.n)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RecentUploads() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, object := range objects {
+				got = append(got, object.Key)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("RecentUploads() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/minio/minio-go/v7"
+)
+
+var ErrUnknownRegion = errors.New("no endpoint configured for region")
+
+// clientCache holds one client per endpoint, created on first use with the
+// service's credentials and transport. Every view of a service shares it.
+type clientCache struct {
+	mu        sync.Mutex
+	clients   map[string]*minio.Client
+	newClient func(endpoint string) (*minio.Client, error)
+}
+
+func newClientCache(newClient func(endpoint string) (*minio.Client, error)) *clientCache {
+	return &clientCache{
+		clients:   make(map[string]*minio.Client),
+		newClient: newClient,
+	}
+}
+
+func (c *clientCache) get(endpoint string) (*minio.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if client, ok := c.clients[endpoint]; ok {
+		return client, nil
+	}
+	client, err := c.newClient(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize MinIO client for '%s': %w", endpoint, err)
+	}
+	c.clients[endpoint] = client
+	return client, nil
+}
+
+// ForRegion returns a view of s that sends its requests to the endpoint
+// Config.RegionEndpoints names for region, with s's credentials and
+// settings. Clients are cached per endpoint, so every view of one endpoint
+// shares a client. An empty region, or s's own Location when it has no
+// entry, returns s. Like WithBucket, the view has its own per-key locks and
+// stat cache.
+func (s *MinIOService) ForRegion(region string) (*MinIOService, error) {
+	endpoint, ok := s.regions[region]
+	if !ok {
+		if region == "" || region == s.Location {
+			return s, nil
+		}
+		return nil, fmt.Errorf("%w: '%s'", ErrUnknownRegion, region)
+	}
+
+	client, err := s.clients.get(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	view := s.view()
+	view.Client = client
+	view.Location = region
+	return view, nil
+}
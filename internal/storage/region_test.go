@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"MinIO-Learn/internal/storage/storagetest"
+)
+
+// regionEndpoint serves fake on a new server and returns its endpoint.
+func regionEndpoint(t *testing.T, fake *storagetest.FakeS3) string {
+	t.Helper()
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+func TestForRegionClients(t *testing.T) {
+	eu, us := storagetest.NewFakeS3(), storagetest.NewFakeS3()
+	var euAuthorization string
+	eu.Before = func(w http.ResponseWriter, r *http.Request) bool {
+		euAuthorization = r.Header.Get("Authorization")
+		return false
+	}
+	primary := storagetest.NewFakeS3()
+	service := newTestService(t, primary, Config{
+		Location: "home",
+		RegionEndpoints: map[string]string{
+			"eu-west": regionEndpoint(t, eu),
+			"us-east": regionEndpoint(t, us),
+		},
+	})
+
+	euView, err := service.ForRegion("eu-west")
+	if err != nil {
+		t.Fatalf("ForRegion(eu-west) error = %v", err)
+	}
+	usView, err := service.ForRegion("us-east")
+	if err != nil {
+		t.Fatalf("ForRegion(us-east) error = %v", err)
+	}
+	again, err := service.ForRegion("eu-west")
+	if err != nil {
+		t.Fatalf("ForRegion(eu-west) again error = %v", err)
+	}
+
+	if euView.Client == usView.Client {
+		t.Error("eu-west and us-east share a client, want one per endpoint")
+	}
+	if euView.Client == service.Client || usView.Client == service.Client {
+		t.Error("a region view uses the primary client")
+	}
+	if again.Client != euView.Client {
+		t.Error("a second eu-west view created a new client, want the cached one")
+	}
+	if len(service.clients.clients) != 3 {
+		t.Errorf("cached %d clients, want the primary and one per region", len(service.clients.clients))
+	}
+
+	ctx := context.Background()
+	if err := euView.EnsureBucket(ctx); err != nil {
+		t.Fatalf("EnsureBucket() in eu-west error = %v", err)
+	}
+	if _, err := euView.UploadBuffer(ctx, "notes.txt", []byte("hello"), "text/plain", nil); err != nil {
+		t.Fatalf("UploadBuffer() in eu-west error = %v", err)
+	}
+	if eu.Object("test-bucket", "notes.txt") == nil {
+		t.Error("object missing from the eu-west endpoint")
+	}
+	if us.Object("test-bucket", "notes.txt") != nil || primary.Object("test-bucket", "notes.txt") != nil {
+		t.Error("object written to an endpoint other than eu-west")
+	}
+	if !strings.Contains(euAuthorization, "Credential=test-access-key/") {
+		t.Errorf("eu-west Authorization = %q, want it signed with the service's credentials", euAuthorization)
+	}
+}
+
+func TestForRegionFallback(t *testing.T) {
+	service := newTestService(t, storagetest.NewFakeS3(), Config{Location: "home"})
+
+	for _, region := range []string{"", "home"} {
+		view, err := service.ForRegion(region)
+		if err != nil || view != service {
+			t.Errorf("ForRegion(%q) = %p, %v, want the service itself", region, view, err)
+		}
+	}
+	if _, err := service.ForRegion("mars"); !errors.Is(err, ErrUnknownRegion) {
+		t.Errorf("ForRegion(mars) error = %v, want ErrUnknownRegion", err)
+	}
+}
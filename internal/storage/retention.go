@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// DeleteAfterTag is the object tag holding an RFC 3339 time after which
+// SweepExpired removes the object.
+const DeleteAfterTag = "delete-after"
+
+// SweepResult summarizes one SweepExpired pass.
+type SweepResult struct {
+	Scanned int
+	Expired []string
+	Deleted int
+	Failed  map[string]error
+}
+
+// SweepExpired removes objects under prefix whose delete-after tag is at or
+// before now. Objects without the tag, or with an unparseable one, are left
+// alone. With dryRun set, expired objects are reported but not deleted.
+func (s *MinIOService) SweepExpired(ctx context.Context, prefix string, now time.Time, dryRun bool) (SweepResult, error) {
+	var result SweepResult
+
+	objectCh := s.Client.ListObjects(ctx, s.BucketName, minio.ListObjectsOptions{
+		Prefix:    prefix,
+		Recursive: true,
+	})
+
+	for object := range objectCh {
+		if object.Err != nil {
+			return result, fmt.Errorf("error listing objects: %w", object.Err)
+		}
+		if IsSystemKey(object.Key) {
+			continue
+		}
+		result.Scanned++
+
+		objectTags, err := s.Client.GetObjectTagging(ctx, s.BucketName, object.Key, minio.GetObjectTaggingOptions{})
+		if err != nil {
+			return result, fmt.Errorf("failed to get tags for '%s': %w", object.Key, err)
+		}
+
+		value, ok := objectTags.ToMap()[DeleteAfterTag]
+		if !ok {
+			continue
+		}
+		deleteAfter, err := time.Parse(time.RFC3339, value)
+		if err != nil || deleteAfter.After(now) {
+			continue
+		}
+		result.Expired = append(result.Expired, object.Key)
+	}
+
+	if dryRun || len(result.Expired) == 0 {
+		return result, nil
+	}
+
+	result.Failed = s.removeObjects(ctx, result.Expired)
+	result.Deleted = len(result.Expired) - len(result.Failed)
+	return result, nil
+}
+
+// removeObjects deletes keys with the bulk RemoveObjects API and returns the
+// keys that could not be deleted with their errors.
+func (s *MinIOService) removeObjects(ctx context.Context, keys []string) map[string]error {
+	objectsCh := make(chan minio.ObjectInfo)
+	go func() {
+		defer close(objectsCh)
+		for _, key := range keys {
+			select {
+			case objectsCh <- minio.ObjectInfo{Key: key}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	defer func() {
+		for _, key := range keys {
+			s.stats.invalidate(key)
+		}
+	}()
+
+	failed := make(map[string]error)
+	for removeErr := range s.Client.RemoveObjects(ctx, s.BucketName, objectsCh, minio.RemoveObjectsOptions{}) {
+		failed[removeErr.ObjectName] = removeErr.Err
+	}
+
+	return failed
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSweepExpired(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	tagged := map[string]string{
+		"logs/past.txt":    now.Add(-time.Hour).Format(time.RFC3339),
+		"logs/now.txt":     now.Format(time.RFC3339),
+		"logs/future.txt":  now.Add(time.Hour).Format(time.RFC3339),
+		"logs/garbage.txt": "next tuesday",
+		"other/past.txt":   now.Add(-time.Hour).Format(time.RFC3339),
+	}
+	untagged := []string{"logs/untagged.txt"}
+
+	tests := []struct {
+		name        string
+		prefix      string
+		dryRun      bool
+		wantExpired []string
+		wantDeleted int
+		wantScanned int
+	}{
+		{name: "deletes expired", prefix: "logs/", wantExpired: []string{"logs/now.txt", "logs/past.txt"}, wantDeleted: 2, wantScanned: 5},
+		{name: "dry run", prefix: "logs/", dryRun: true, wantExpired: []string{"logs/now.txt", "logs/past.txt"}, wantScanned: 5},
+		{name: "whole bucket", prefix: "", wantExpired: []string{"logs/now.txt", "logs/past.txt", "other/past.txt"}, wantDeleted: 3, wantScanned: 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, store := range testBackends(t) {
+				t.Run(name, func(t *testing.T) {
+					ctx := context.Background()
+					for key, deleteAfter := range tagged {
+						if _, err := store.UploadBuffer(ctx, key, []byte(key), "text/plain", nil); err != nil {
+							t.Fatalf("UploadBuffer() error = %v", err)
+						}
+						if err := store.SetObjectTags(ctx, key, map[string]string{DeleteAfterTag: deleteAfter}); err != nil {
+							t.Fatalf("SetObjectTags() error = %v", err)
+						}
+					}
+					for _, key := range untagged {
+						if _, err := store.UploadBuffer(ctx, key, []byte(key), "text/plain", nil); err != nil {
+							t.Fatalf("UploadBuffer() error = %v", err)
+						}
+					}
+
+					result, err := store.SweepExpired(ctx, tt.prefix, now, tt.dryRun)
+					if err != nil {
+						t.Fatalf("SweepExpired() error = %v", err)
+					}
+					expired := slices.Sorted(slices.Values(result.Expired))
+					if !slices.Equal(expired, tt.wantExpired) {
+						t.Errorf("Expired = %v, want %v", expired, tt.wantExpired)
+					}
+					if result.Deleted != tt.wantDeleted || len(result.Failed) != 0 {
+						t.Errorf("Deleted = %d, Failed = %v, want %d deleted", result.Deleted, result.Failed, tt.wantDeleted)
+					}
+					if result.Scanned != tt.wantScanned {
+						t.Errorf("Scanned = %d, want %d", result.Scanned, tt.wantScanned)
+					}
+
+					for key := range tagged {
+						exists, err := store.CheckObjectExists(ctx, key)
+						if err != nil {
+							t.Fatalf("CheckObjectExists() error = %v", err)
+						}
+						wantGone := !tt.dryRun && slices.Contains(tt.wantExpired, key)
+						if exists == wantGone {
+							t.Errorf("%s exists = %v, want %v", key, exists, !wantGone)
+						}
+					}
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// retryPolicy describes a bounded exponential backoff.
+type retryPolicy struct {
+	Attempts  int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// presignRetry keeps presigning retries short: presigning is local, so a
+// failure is almost always a credential refresh that resolves within
+// milliseconds, and listings call it once per object.
+var presignRetry = retryPolicy{Attempts: 3, BaseDelay: 20 * time.Millisecond, MaxDelay: 100 * time.Millisecond}
+
+// connectRetry is bounded by Config.ConnectTimeout rather than by attempts,
+// so MinIO gets as long as it needs to boot within that window.
+var connectRetry = retryPolicy{Attempts: math.MaxInt, BaseDelay: 500 * time.Millisecond, MaxDelay: 5 * time.Second}
+
+// retry calls fn until it succeeds, the attempts are exhausted, or ctx is
+// done. It returns the last error from fn.
+func retry(ctx context.Context, policy retryPolicy, fn func() error) error {
+	delay := policy.BaseDelay
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		if attempt >= policy.Attempts {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		delay *= 2
+		if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+			delay = policy.MaxDelay
+		}
+	}
+}
+
+// listenRetry paces resubscribing to bucket notifications; it never gives up.
+var listenRetry = retryPolicy{Attempts: math.MaxInt, BaseDelay: time.Second, MaxDelay: 30 * time.Second}
@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"MinIO-Learn/internal/storage/storagetest"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+func TestRetry(t *testing.T) {
+	errTransient := errors.New("transient")
+	policy := retryPolicy{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+
+	tests := []struct {
+		name      string
+		failures  int
+		cancelled bool
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "first attempt succeeds", failures: 0, wantCalls: 1},
+		{name: "transient failure is retried", failures: 2, wantCalls: 3},
+		{name: "gives up after the attempts", failures: 5, wantCalls: 3, wantErr: true},
+		{name: "cancelled context stops retrying", failures: 5, cancelled: true, wantCalls: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelled {
+				cancel()
+			}
+
+			calls := 0
+			err := retry(ctx, policy, func() error {
+				calls++
+				if calls <= tt.failures {
+					return errTransient
+				}
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("retry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, errTransient) {
+				t.Errorf("retry() error = %v, want the last error from fn", err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("retry() called fn %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+// flakyProvider fails to retrieve credentials a set number of times, as a
+// provider does while its credentials are being refreshed.
+type flakyProvider struct {
+	mu        sync.Mutex
+	failures  int
+	retrieved bool
+}
+
+func (p *flakyProvider) Retrieve() (credentials.Value, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.failures > 0 {
+		p.failures--
+		return credentials.Value{}, errors.New("credentials are being refreshed")
+	}
+	p.retrieved = true
+	return credentials.Value{AccessKeyID: "test-access-key", SecretAccessKey: "test-secret-key", SignerType: credentials.SignatureV4}, nil
+}
+
+func (p *flakyProvider) RetrieveWithCredContext(*credentials.CredContext) (credentials.Value, error) {
+	return p.Retrieve()
+}
+
+func (p *flakyProvider) IsExpired() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return !p.retrieved
+}
+
+func TestPresignRetriesCredentialRefresh(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		wantErr  bool
+	}{
+		{name: "no failure", failures: 0},
+		{name: "transient failure", failures: presignRetry.Attempts - 1},
+		{name: "persistent failure", failures: presignRetry.Attempts, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newTestService(t, storagetest.NewFakeS3(), Config{})
+			client, err := minio.New(service.Client.EndpointURL().Host, &minio.Options{
+				Creds: credentials.New(&flakyProvider{failures: tt.failures}),
+			})
+			if err != nil {
+				t.Fatalf("minio.New() error = %v", err)
+			}
+			service.Client = client
+
+			presignedURL, err := service.GetObjectURL(context.Background(), "report.pdf", time.Minute)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetObjectURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && presignedURL == "" {
+				t.Error("GetObjectURL() returned an empty URL")
+			}
+		})
+	}
+}
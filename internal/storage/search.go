@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"MinIO-Learn/internal/metrics"
+	"github.com/minio/minio-go/v7"
+)
+
+// ObjectFilter selects objects by size, modification time and content type.
+// Zero-valued fields don't filter. ContentType matches exactly, or by major
+// type when it ends in "/*" (e.g. "image/*").
+type ObjectFilter struct {
+	MinSize        int64
+	MaxSize        int64
+	ModifiedAfter  time.Time
+	ModifiedBefore time.Time
+	ContentType    string
+}
+
+// IsZero reports whether f matches every object.
+func (f ObjectFilter) IsZero() bool {
+	return f == ObjectFilter{}
+}
+
+func (f ObjectFilter) matches(info minio.ObjectInfo) bool {
+	if f.MinSize > 0 && info.Size < f.MinSize {
+		return false
+	}
+	if f.MaxSize > 0 && info.Size > f.MaxSize {
+		return false
+	}
+	if !f.ModifiedAfter.IsZero() && !info.LastModified.After(f.ModifiedAfter) {
+		return false
+	}
+	if !f.ModifiedBefore.IsZero() && !info.LastModified.Before(f.ModifiedBefore) {
+		return false
+	}
+	if f.ContentType != "" {
+		contentType := listedContentType(info)
+		if major, ok := strings.CutSuffix(f.ContentType, "/*"); ok {
+			return strings.HasPrefix(strings.ToLower(contentType), strings.ToLower(major)+"/")
+		}
+		return strings.EqualFold(contentType, f.ContentType)
+	}
+	return true
+}
+
+// listedContentType returns the content type of an object from a listing.
+// Plain listings don't carry it; MinIO includes it in the user metadata of
+// listings made with WithMetadata.
+func listedContentType(info minio.ObjectInfo) string {
+	if info.ContentType != "" {
+		return info.ContentType
+	}
+	for key, value := range info.UserMetadata {
+		if strings.EqualFold(key, "Content-Type") {
+			contentType, _, _ := strings.Cut(value, ";")
+			return strings.TrimSpace(contentType)
+		}
+	}
+	return ""
+}
+
+// SearchObjects returns the objects under prefix matching filter. Objects are
+// filtered as the listing streams, so only matches are held in memory.
+func (s *MinIOService) SearchObjects(ctx context.Context, prefix string, filter ObjectFilter) ([]minio.ObjectInfo, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	objectCh := s.Client.ListObjects(ctx, s.BucketName, minio.ListObjectsOptions{
+		Prefix:       prefix,
+		Recursive:    true,
+		WithMetadata: filter.ContentType != "",
+	})
+
+	var objects []minio.ObjectInfo
+	for object := range objectCh {
+		if object.Err != nil {
+			return nil, fmt.Errorf("error listing objects: %w", object.Err)
+		}
+		if IsSystemKey(object.Key) {
+			continue
+		}
+		if filter.matches(object) {
+			objects = append(objects, object)
+		}
+	}
+
+	return objects, nil
+}
+
+// SearchObjectsPaginated is SearchObjects returning at most maxKeys matches
+// whose keys sort after startAfter.
+func (s *MinIOService) SearchObjectsPaginated(ctx context.Context, prefix, startAfter string, maxKeys int, filter ObjectFilter) (page ObjectPage, err error) {
+	defer s.observe(ctx, metrics.OpList, slog.String("prefix", prefix), time.Now(), nil, &err)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	objectCh := s.Client.ListObjects(ctx, s.BucketName, minio.ListObjectsOptions{
+		Prefix:       prefix,
+		StartAfter:   startAfter,
+		Recursive:    true,
+		WithMetadata: filter.ContentType != "",
+	})
+
+	for object := range objectCh {
+		if object.Err != nil {
+			return ObjectPage{}, fmt.Errorf("error listing objects: %w", object.Err)
+		}
+		if IsSystemKey(object.Key) {
+			continue
+		}
+		if !filter.matches(object) {
+			continue
+		}
+		if len(page.Objects) == maxKeys {
+			page.NextToken = page.Objects[maxKeys-1].Key
+			break
+		}
+		page.Objects = append(page.Objects, object)
+	}
+
+	return page, nil
+}
@@ -0,0 +1,479 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"MinIO-Learn/internal/metrics"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// uploadSessionPrefix holds one small JSON object per resumable upload
+// session recording the multipart upload it continues, so a client that
+// reconnects, possibly to another server instance, can pick it up again.
+const uploadSessionPrefix = SystemPrefix + "sessions/uploads/"
+
+// MaxUploadParts is the most parts an upload session can have.
+const MaxUploadParts = 10000
+
+var (
+	ErrUnknownSession = errors.New("unknown upload session")
+	ErrNoParts        = errors.New("upload session has no parts")
+)
+
+// UploadSession is a resumable upload in progress. Owner is an opaque value
+// identifying who started it, for the caller to check; it is stored but not
+// interpreted. Checksum is the SHA-256 the content was declared to have when
+// the session started, if any. Parts are only filled in by GetUploadSession.
+type UploadSession struct {
+	ID          string         `json:"id"`
+	ObjectName  string         `json:"objectName"`
+	ContentType string         `json:"contentType"`
+	Checksum    string         `json:"sha256,omitempty"`
+	UploadID    string         `json:"uploadId"`
+	Owner       string         `json:"owner,omitempty"`
+	Created     time.Time      `json:"created"`
+	Parts       []UploadedPart `json:"parts,omitempty"`
+}
+
+// sessionRecord is what is stored for a session under uploadSessionPrefix.
+type sessionRecord struct {
+	UploadSession
+	Hash partHash `json:"hash"`
+}
+
+// partHash is the running SHA-256 of a session's parts 1 to Parts, so an
+// upload whose parts arrive in order needn't be read back to learn its
+// checksum. Content is the marshaled state of that hash and ETags of one
+// over the hashed parts' numbers, sizes and ETags, which tells at completion
+// whether the parts are still the ones that were hashed.
+type partHash struct {
+	Parts   int    `json:"parts"`
+	Content []byte `json:"content,omitempty"`
+	ETags   []byte `json:"etags,omitempty"`
+}
+
+// resumeHash returns a SHA-256 hash continuing from state, or a new one if
+// state is empty.
+func resumeHash(state []byte) (hash.Hash, error) {
+	h := sha256.New()
+	if len(state) == 0 {
+		return h, nil
+	}
+	if err := h.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+		return nil, fmt.Errorf("failed to resume part hash: %w", err)
+	}
+	return h, nil
+}
+
+func marshalHash(h hash.Hash) ([]byte, error) {
+	return h.(encoding.BinaryMarshaler).MarshalBinary()
+}
+
+func partLine(part UploadedPart) string {
+	return fmt.Sprintf("%d %d %s\n", part.PartNumber, part.Size, strings.Trim(part.ETag, `"`))
+}
+
+// extend returns a hash of the content so far for part partNumber to be
+// written to, or false if it isn't the part after those already hashed.
+func (h partHash) extend(partNumber int) (hash.Hash, bool) {
+	if partNumber != h.Parts+1 {
+		return nil, false
+	}
+	content, err := resumeHash(h.Content)
+	if err != nil {
+		return nil, false
+	}
+	return content, true
+}
+
+// add records part, whose bytes content has been extended with, as the
+// next hashed part.
+func (h *partHash) add(content hash.Hash, part UploadedPart) error {
+	etags, err := resumeHash(h.ETags)
+	if err != nil {
+		return err
+	}
+	io.WriteString(etags, partLine(part))
+
+	contentState, err := marshalHash(content)
+	if err != nil {
+		return fmt.Errorf("failed to save part hash: %w", err)
+	}
+	etagState, err := marshalHash(etags)
+	if err != nil {
+		return fmt.Errorf("failed to save part hash: %w", err)
+	}
+
+	h.Parts = part.PartNumber
+	h.Content = contentState
+	h.ETags = etagState
+	return nil
+}
+
+// checksum returns the lowercase hex SHA-256 of the content if parts, in
+// part number order, are exactly the parts that were hashed, and "" if not.
+func (h partHash) checksum(parts []UploadedPart) string {
+	if h.Parts == 0 || len(parts) != h.Parts {
+		return ""
+	}
+
+	etags := sha256.New()
+	for _, part := range parts {
+		io.WriteString(etags, partLine(part))
+	}
+	recorded, err := resumeHash(h.ETags)
+	if err != nil || !bytes.Equal(etags.Sum(nil), recorded.Sum(nil)) {
+		return ""
+	}
+
+	content, err := resumeHash(h.Content)
+	if err != nil {
+		return ""
+	}
+	return hex.EncodeToString(content.Sum(nil))
+}
+
+// verifyParts checks the checksum the session was started with against
+// the one its parts hash to, if both are known.
+func verifyParts(declared, computed string) error {
+	if declared != "" && computed != "" && declared != computed {
+		return fmt.Errorf("%w: declared %s, parts hash to %s", ErrChecksumMismatch, declared, computed)
+	}
+	return nil
+}
+
+// UploadedPart is one part stored in an upload session.
+type UploadedPart struct {
+	PartNumber int    `json:"partNumber"`
+	Size       int64  `json:"size"`
+	ETag       string `json:"etag"`
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate session ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func validSessionID(id string) bool {
+	if len(id) != 32 || strings.ToLower(id) != id {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
+
+func validPartNumber(partNumber int) error {
+	if partNumber < 1 || partNumber > MaxUploadParts {
+		return fmt.Errorf("part number must be between 1 and %d, got %d", MaxUploadParts, partNumber)
+	}
+	return nil
+}
+
+// InitUploadSession starts a multipart upload of objectName that parts can
+// be added to with UploadPart, across as many requests and reconnections as
+// needed, until CompleteUpload assembles them. Parts other than the last
+// must be at least 5 MiB. Uploads through a session are not compressed.
+// The object gets contentType and metadata now, since they can't be changed
+// once it is assembled; a checksum from ChecksumMetadata in metadata is
+// recorded as the session's declared Checksum.
+func (s *MinIOService) InitUploadSession(ctx context.Context, objectName, contentType, owner string, metadata map[string]string) (UploadSession, error) {
+	id, err := newSessionID()
+	if err != nil {
+		return UploadSession{}, err
+	}
+
+	core := minio.Core{Client: s.Client}
+	uploadID, err := core.NewMultipartUpload(ctx, s.BucketName, objectName, s.putOptions(contentType, metadata))
+	if err != nil {
+		return UploadSession{}, fmt.Errorf("failed to start multipart upload: %w", classifyError(err))
+	}
+
+	session := UploadSession{
+		ID:          id,
+		ObjectName:  objectName,
+		ContentType: contentType,
+		Checksum:    metadata[checksumKey],
+		UploadID:    uploadID,
+		Owner:       owner,
+		Created:     time.Now().UTC(),
+	}
+	if err := s.saveSession(ctx, sessionRecord{UploadSession: session}); err != nil {
+		if abortErr := core.AbortMultipartUpload(ctx, s.BucketName, objectName, uploadID); abortErr != nil {
+			s.logger.WarnContext(ctx, "Failed to abort multipart upload", "object", objectName, "error", abortErr)
+		}
+		return UploadSession{}, err
+	}
+
+	return session, nil
+}
+
+func (s *MinIOService) saveSession(ctx context.Context, record sessionRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode upload session: %w", err)
+	}
+
+	body := strings.NewReader(string(data))
+	_, err = s.Client.PutObject(ctx, s.BucketName, uploadSessionPrefix+record.ID, body, body.Size(),
+		minio.PutObjectOptions{ContentType: "application/json"})
+	if err != nil {
+		return fmt.Errorf("failed to save upload session: %w", classifyError(err))
+	}
+
+	return nil
+}
+
+// loadSession reads the session record for id, failing with
+// ErrUnknownSession if there is none.
+func (s *MinIOService) loadSession(ctx context.Context, id string) (sessionRecord, error) {
+	if !validSessionID(id) {
+		return sessionRecord{}, ErrUnknownSession
+	}
+
+	obj, err := s.Client.GetObject(ctx, s.BucketName, uploadSessionPrefix+id, minio.GetObjectOptions{})
+	if err != nil {
+		return sessionRecord{}, fmt.Errorf("failed to read upload session: %w", classifyError(err))
+	}
+	defer obj.Close()
+
+	data, err := io.ReadAll(io.LimitReader(obj, 64<<10))
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return sessionRecord{}, ErrUnknownSession
+	}
+	if err != nil {
+		return sessionRecord{}, fmt.Errorf("failed to read upload session: %w", classifyError(err))
+	}
+
+	var record sessionRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return sessionRecord{}, fmt.Errorf("failed to decode upload session: %w", err)
+	}
+	return record, nil
+}
+
+// UploadPart stores size bytes from data as part partNumber of the session,
+// replacing any part already uploaded with that number. The part after the
+// ones uploaded in order so far is added to the session's running checksum.
+func (s *MinIOService) UploadPart(ctx context.Context, sessionID string, partNumber int, data io.Reader, size int64) (part UploadedPart, err error) {
+	if err := validPartNumber(partNumber); err != nil {
+		return UploadedPart{}, err
+	}
+	record, err := s.loadSession(ctx, sessionID)
+	if err != nil {
+		return UploadedPart{}, err
+	}
+
+	defer s.observe(ctx, metrics.OpUpload, slog.String("object", record.ObjectName), time.Now(), &part.Size, &err)
+
+	content, hashing := record.Hash.extend(partNumber)
+	if hashing {
+		data = io.TeeReader(data, content)
+	}
+
+	core := minio.Core{Client: s.Client}
+	objectPart, err := core.PutObjectPart(ctx, s.BucketName, record.ObjectName, record.UploadID, partNumber,
+		data, size, minio.PutObjectPartOptions{SSE: s.encryption})
+	if err != nil {
+		return UploadedPart{}, fmt.Errorf("failed to upload part: %w", sessionError(err))
+	}
+
+	part = UploadedPart{PartNumber: objectPart.PartNumber, Size: objectPart.Size, ETag: objectPart.ETag}
+	if hashing {
+		s.advanceHash(ctx, sessionID, record.Hash, content, part)
+	}
+	return part, nil
+}
+
+// advanceHash adds part, hashed into content from the state in from, to the
+// session's running checksum, unless another upload changed it meanwhile.
+// Failing only leaves the session without a checksum of its own, so errors
+// are logged rather than returned.
+func (s *MinIOService) advanceHash(ctx context.Context, sessionID string, from partHash, content hash.Hash, part UploadedPart) {
+	defer s.locks.lock(uploadSessionPrefix + sessionID)()
+
+	record, err := s.loadSession(ctx, sessionID)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Failed to update upload session checksum", "session", sessionID, "error", err)
+		return
+	}
+	if record.Hash.Parts != from.Parts || !bytes.Equal(record.Hash.Content, from.Content) {
+		return
+	}
+	if err := record.Hash.add(content, part); err != nil {
+		s.logger.WarnContext(ctx, "Failed to update upload session checksum", "session", sessionID, "error", err)
+		return
+	}
+	if err := s.saveSession(ctx, record); err != nil {
+		s.logger.WarnContext(ctx, "Failed to update upload session checksum", "session", sessionID, "error", err)
+	}
+}
+
+// GetUploadSession returns the session with the parts uploaded so far, in
+// part number order, so a reconnecting client can skip them.
+func (s *MinIOService) GetUploadSession(ctx context.Context, sessionID string) (UploadSession, error) {
+	record, err := s.loadSession(ctx, sessionID)
+	if err != nil {
+		return UploadSession{}, err
+	}
+
+	session := record.UploadSession
+	session.Parts, err = s.listParts(ctx, session)
+	if err != nil {
+		return UploadSession{}, err
+	}
+	return session, nil
+}
+
+func (s *MinIOService) listParts(ctx context.Context, session UploadSession) ([]UploadedPart, error) {
+	var parts []UploadedPart
+
+	core := minio.Core{Client: s.Client}
+	marker := 0
+	for {
+		result, err := core.ListObjectParts(ctx, s.BucketName, session.ObjectName, session.UploadID, marker, 1000)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list uploaded parts: %w", sessionError(err))
+		}
+		for _, part := range result.ObjectParts {
+			parts = append(parts, UploadedPart{PartNumber: part.PartNumber, Size: part.Size, ETag: part.ETag})
+		}
+		if !result.IsTruncated {
+			break
+		}
+		marker = result.NextPartNumberMarker
+	}
+
+	return parts, nil
+}
+
+// CompleteUpload assembles the session's parts, in part number order, into
+// its object and ends the session. It also returns the content's SHA-256 if
+// the parts were all uploaded in order, and "" if it couldn't be worked out
+// without reading the object back. If that checksum differs from the one the
+// session was started with, it fails with ErrChecksumMismatch and leaves the
+// session open.
+func (s *MinIOService) CompleteUpload(ctx context.Context, sessionID string) (info minio.UploadInfo, checksum string, err error) {
+	record, err := s.loadSession(ctx, sessionID)
+	if err != nil {
+		return minio.UploadInfo{}, "", err
+	}
+	session := record.UploadSession
+	session.Parts, err = s.listParts(ctx, session)
+	if err != nil {
+		return minio.UploadInfo{}, "", err
+	}
+	if len(session.Parts) == 0 {
+		return minio.UploadInfo{}, "", ErrNoParts
+	}
+
+	checksum = record.Hash.checksum(session.Parts)
+	if err := verifyParts(session.Checksum, checksum); err != nil {
+		return minio.UploadInfo{}, "", err
+	}
+
+	defer s.observe(ctx, metrics.OpUpload, slog.String("object", session.ObjectName), time.Now(), &info.Size, &err)
+	defer s.locks.lock(session.ObjectName)()
+	defer s.stats.invalidate(session.ObjectName)
+
+	parts := make([]minio.CompletePart, 0, len(session.Parts))
+	for _, part := range session.Parts {
+		parts = append(parts, minio.CompletePart{PartNumber: part.PartNumber, ETag: part.ETag})
+	}
+
+	core := minio.Core{Client: s.Client}
+	info, err = core.CompleteMultipartUpload(ctx, s.BucketName, session.ObjectName, session.UploadID, parts,
+		s.putOptions(session.ContentType, nil))
+	if err != nil {
+		return minio.UploadInfo{}, "", fmt.Errorf("failed to complete upload: %w", sessionError(err))
+	}
+
+	s.removeSession(ctx, session.ID)
+	return info, checksum, nil
+}
+
+// AbortUpload discards the session and the parts uploaded to it.
+func (s *MinIOService) AbortUpload(ctx context.Context, sessionID string) error {
+	session, err := s.loadSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	core := minio.Core{Client: s.Client}
+	err = core.AbortMultipartUpload(ctx, s.BucketName, session.ObjectName, session.UploadID)
+	if err != nil && !errors.Is(sessionError(err), ErrUnknownSession) {
+		return fmt.Errorf("failed to abort upload: %w", classifyError(err))
+	}
+
+	s.removeSession(ctx, session.ID)
+	return nil
+}
+
+// ExpireUploadSessions aborts the sessions started more than olderThan ago,
+// discarding their parts, and returns how many it aborted.
+func (s *MinIOService) ExpireUploadSessions(ctx context.Context, olderThan time.Duration) (int, error) {
+	cutoff := time.Now().Add(-olderThan)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	expired := 0
+	for object := range s.Client.ListObjects(ctx, s.BucketName, minio.ListObjectsOptions{Prefix: uploadSessionPrefix}) {
+		if object.Err != nil {
+			return expired, fmt.Errorf("error listing upload sessions: %w", object.Err)
+		}
+
+		id := strings.TrimPrefix(object.Key, uploadSessionPrefix)
+		record, err := s.loadSession(ctx, id)
+		if errors.Is(err, ErrUnknownSession) {
+			continue
+		}
+		if err != nil {
+			return expired, err
+		}
+		if !record.Created.Before(cutoff) {
+			continue
+		}
+
+		if err := s.AbortUpload(ctx, id); err != nil && !errors.Is(err, ErrUnknownSession) {
+			return expired, err
+		}
+		expired++
+	}
+
+	return expired, nil
+}
+
+// removeSession deletes a finished session's record. A failure only leaves
+// a stale record behind, so it is logged rather than returned.
+func (s *MinIOService) removeSession(ctx context.Context, id string) {
+	err := s.Client.RemoveObject(ctx, s.BucketName, uploadSessionPrefix+id, minio.RemoveObjectOptions{})
+	if err != nil {
+		s.logger.WarnContext(ctx, "Failed to remove upload session", "session", id, "error", err)
+	}
+}
+
+// sessionError reports a multipart upload that no longer exists, because it
+// was completed, aborted or expired, as ErrUnknownSession.
+func sessionError(err error) error {
+	if minio.ToErrorResponse(err).Code == "NoSuchUpload" {
+		return fmt.Errorf("%w: %w", ErrUnknownSession, err)
+	}
+	return classifyError(err)
+}
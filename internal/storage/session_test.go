@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestUploadSessionChecksum(t *testing.T) {
+	parts := [][]byte{
+		bytes.Repeat([]byte("first "), 1000),
+		bytes.Repeat([]byte("second "), 1000),
+		[]byte("last"),
+	}
+	content := bytes.Join(parts, nil)
+	sum := sha256.Sum256(content)
+	checksum := hex.EncodeToString(sum[:])
+	otherSum := sha256.Sum256([]byte("something else"))
+
+	tests := []struct {
+		name         string
+		declared     string
+		order        []int
+		wantChecksum string
+		wantErr      error
+	}{
+		{"in order", "", []int{1, 2, 3}, checksum, nil},
+		{"in order with declared checksum", checksum, []int{1, 2, 3}, checksum, nil},
+		{"part resent", "", []int{1, 2, 2, 3}, checksum, nil},
+		{"out of order", "", []int{2, 1, 3}, "", nil},
+		{"declared checksum mismatch", hex.EncodeToString(otherSum[:]), []int{1, 2, 3}, "", ErrChecksumMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, store := range testBackends(t) {
+				t.Run(name, func(t *testing.T) {
+					ctx := context.Background()
+					var metadata map[string]string
+					if tt.declared != "" {
+						metadata = ChecksumMetadata(tt.declared)
+					}
+					session, err := store.InitUploadSession(ctx, "big.bin", "application/octet-stream", "owner", metadata)
+					if err != nil {
+						t.Fatalf("InitUploadSession() error = %v", err)
+					}
+					for _, number := range tt.order {
+						data := parts[number-1]
+						if _, err := store.UploadPart(ctx, session.ID, number, bytes.NewReader(data), int64(len(data))); err != nil {
+							t.Fatalf("UploadPart(%d) error = %v", number, err)
+						}
+					}
+
+					_, got, err := store.CompleteUpload(ctx, session.ID)
+					if !errors.Is(err, tt.wantErr) {
+						t.Fatalf("CompleteUpload() error = %v, want %v", err, tt.wantErr)
+					}
+					if got != tt.wantChecksum {
+						t.Errorf("CompleteUpload() checksum = %q, want %q", got, tt.wantChecksum)
+					}
+					if tt.wantErr != nil {
+						// A mismatch leaves the session open for the parts to be fixed.
+						if _, err := store.GetUploadSession(ctx, session.ID); err != nil {
+							t.Errorf("GetUploadSession() after mismatch error = %v", err)
+						}
+						return
+					}
+
+					stored, err := store.DownloadBuffer(ctx, "big.bin")
+					if err != nil {
+						t.Fatalf("DownloadBuffer() error = %v", err)
+					}
+					if !bytes.Equal(stored, content) {
+						t.Errorf("stored %d bytes, want the %d uploaded", len(stored), len(content))
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestExpireUploadSessions(t *testing.T) {
+	for name, store := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			session, err := store.InitUploadSession(ctx, "abandoned.bin", "application/octet-stream", "owner", nil)
+			if err != nil {
+				t.Fatalf("InitUploadSession() error = %v", err)
+			}
+			if _, err := store.UploadPart(ctx, session.ID, 1, bytes.NewReader([]byte("part")), 4); err != nil {
+				t.Fatalf("UploadPart() error = %v", err)
+			}
+
+			expired, err := store.ExpireUploadSessions(ctx, time.Hour)
+			if err != nil || expired != 0 {
+				t.Fatalf("ExpireUploadSessions(1h) = %d, %v, want 0", expired, err)
+			}
+			if _, err := store.GetUploadSession(ctx, session.ID); err != nil {
+				t.Fatalf("GetUploadSession() of a fresh session error = %v", err)
+			}
+
+			time.Sleep(10 * time.Millisecond)
+			expired, err = store.ExpireUploadSessions(ctx, 5*time.Millisecond)
+			if err != nil || expired != 1 {
+				t.Fatalf("ExpireUploadSessions(5ms) = %d, %v, want 1", expired, err)
+			}
+			if _, err := store.GetUploadSession(ctx, session.ID); !errors.Is(err, ErrUnknownSession) {
+				t.Errorf("GetUploadSession() of an expired session error = %v, want ErrUnknownSession", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// statCacheMaxEntries bounds memory use; when full, expired entries are
+// pruned and, failing that, the cache starts over.
+const statCacheMaxEntries = 10000
+
+// statCache remembers StatObject results for a short TTL so that a handler
+// checking existence, content type and ETag of one object makes a single
+// round trip. Misses are cached too, so repeated lookups of absent keys don't
+// each reach MinIO. Writes through the service invalidate their entry, but
+// objects written by others (e.g. through presigned URLs) may be reported
+// missing until the entry expires. A nil *statCache is a valid, disabled
+// cache.
+type statCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]statEntry
+}
+
+type statEntry struct {
+	info    minio.ObjectInfo
+	err     error // the NoSuchKey error for a cached miss
+	expires time.Time
+}
+
+func newStatCache(ttl time.Duration) *statCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &statCache{ttl: ttl, entries: make(map[string]statEntry)}
+}
+
+func (c *statCache) get(objectName string) (statEntry, bool) {
+	if c == nil {
+		return statEntry{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[objectName]
+	if !ok {
+		return statEntry{}, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, objectName)
+		return statEntry{}, false
+	}
+	return entry, true
+}
+
+func (c *statCache) put(objectName string, info minio.ObjectInfo) {
+	c.store(objectName, statEntry{info: info})
+}
+
+// putMissing caches err, a NoSuchKey error, as the result for objectName.
+func (c *statCache) putMissing(objectName string, err error) {
+	c.store(objectName, statEntry{err: err})
+}
+
+func (c *statCache) store(objectName string, entry statEntry) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= statCacheMaxEntries {
+		for key, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, key)
+			}
+		}
+		if len(c.entries) >= statCacheMaxEntries {
+			c.entries = make(map[string]statEntry)
+		}
+	}
+	entry.expires = now.Add(c.ttl)
+	c.entries[objectName] = entry
+}
+
+func (c *statCache) invalidate(objectName string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, objectName)
+}
+
+// statObject is StatObject reading through the stat cache.
+func (s *MinIOService) statObject(ctx context.Context, objectName string) (minio.ObjectInfo, error) {
+	if entry, ok := s.stats.get(objectName); ok {
+		return entry.info, entry.err
+	}
+
+	info, err := s.Client.StatObject(ctx, s.BucketName, objectName, s.getOptions())
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			s.stats.putMissing(objectName, err)
+		}
+		return minio.ObjectInfo{}, err
+	}
+
+	info = withStorageClass(info)
+	s.stats.put(objectName, info)
+	return info, nil
+}
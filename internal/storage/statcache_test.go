@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"MinIO-Learn/internal/storage/storagetest"
+)
+
+func TestStatCache(t *testing.T) {
+	const key = "docs/report.txt"
+
+	tests := []struct {
+		name       string
+		ttl        time.Duration
+		exists     bool
+		between    func(t *testing.T, service *MinIOService)
+		wantExists bool
+		wantHeads  int
+	}{
+		{name: "cached", ttl: time.Minute, exists: true, wantExists: true, wantHeads: 1},
+		{name: "cached miss", ttl: time.Minute, wantHeads: 1},
+		{name: "disabled", exists: true, wantExists: true, wantHeads: 2},
+		{
+			name: "expired", ttl: 10 * time.Millisecond, exists: true, wantExists: true, wantHeads: 2,
+			between: func(t *testing.T, service *MinIOService) { time.Sleep(20 * time.Millisecond) },
+		},
+		{
+			name: "invalidated by upload", ttl: time.Minute, exists: true, wantExists: true, wantHeads: 2,
+			between: func(t *testing.T, service *MinIOService) {
+				if _, err := service.UploadBuffer(context.Background(), key, []byte("v2"), "text/plain", nil); err != nil {
+					t.Fatalf("UploadBuffer() error = %v", err)
+				}
+			},
+		},
+		{
+			name: "miss invalidated by upload", ttl: time.Minute, wantExists: true, wantHeads: 2,
+			between: func(t *testing.T, service *MinIOService) {
+				if _, err := service.UploadBuffer(context.Background(), key, []byte("v1"), "text/plain", nil); err != nil {
+					t.Fatalf("UploadBuffer() error = %v", err)
+				}
+			},
+		},
+		{
+			name: "invalidated by delete", ttl: time.Minute, exists: true, wantHeads: 2,
+			between: func(t *testing.T, service *MinIOService) {
+				if err := service.DeleteObject(context.Background(), key); err != nil {
+					t.Fatalf("DeleteObject() error = %v", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := storagetest.NewFakeS3()
+			service := newTestService(t, fake, Config{StatCacheTTL: tt.ttl})
+			if tt.exists {
+				fake.PutObject("test-bucket", key, []byte("v1"), http.Header{"Content-Type": {"text/plain"}})
+			}
+
+			ctx := context.Background()
+			if _, err := service.CheckObjectExists(ctx, key); err != nil {
+				t.Fatalf("CheckObjectExists() error = %v", err)
+			}
+			if tt.between != nil {
+				tt.between(t, service)
+			}
+			exists, err := service.CheckObjectExists(ctx, key)
+			if err != nil {
+				t.Fatalf("CheckObjectExists() error = %v", err)
+			}
+			if exists != tt.wantExists {
+				t.Errorf("exists = %v, want %v", exists, tt.wantExists)
+			}
+
+			if got := fake.Count(http.MethodHead, "test-bucket", key); got != tt.wantHeads {
+				t.Errorf("backend stats = %d, want %d", got, tt.wantHeads)
+			}
+		})
+	}
+}
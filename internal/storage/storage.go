@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// Storage is the object store the HTTP server works against, bound to a
+// single bucket. MinIOService implements it on a MinIO or S3 backend and
+// MemoryStorage in process memory.
+type Storage interface {
+	EnsureBucket(ctx context.Context) error
+	EnsureBuckets(ctx context.Context, specs []BucketSpec) ([]BucketProvisionResult, error)
+	HealthCheck(ctx context.Context) error
+	EnableVersioning(ctx context.Context) error
+	ListBuckets(ctx context.Context) ([]minio.BucketInfo, error)
+	CreateBucket(ctx context.Context, name, region string) error
+
+	UploadFile(ctx context.Context, objectName, filePath, contentType string, metadata map[string]string) (minio.UploadInfo, error)
+	UploadBuffer(ctx context.Context, objectName string, data []byte, contentType string, metadata map[string]string) (minio.UploadInfo, error)
+	UploadLargeFile(ctx context.Context, objectName, filePath, contentType string, metadata map[string]string) (minio.UploadInfo, error)
+	UploadStream(ctx context.Context, objectName string, reader io.Reader, size int64, contentType string, metadata map[string]string) (minio.UploadInfo, error)
+	UploadDirectory(ctx context.Context, localDir, keyPrefix string) (DirectoryUploadResult, error)
+
+	InitUploadSession(ctx context.Context, objectName, contentType, owner string, metadata map[string]string) (UploadSession, error)
+	UploadPart(ctx context.Context, sessionID string, partNumber int, data io.Reader, size int64) (UploadedPart, error)
+	GetUploadSession(ctx context.Context, sessionID string) (UploadSession, error)
+	CompleteUpload(ctx context.Context, sessionID string) (minio.UploadInfo, string, error)
+	AbortUpload(ctx context.Context, sessionID string) error
+	ExpireUploadSessions(ctx context.Context, olderThan time.Duration) (int, error)
+
+	DownloadFile(ctx context.Context, objectName, filePath string) error
+	DownloadBuffer(ctx context.Context, objectName string) ([]byte, error)
+	DownloadBufferVersion(ctx context.Context, objectName, versionID string) ([]byte, error)
+	DownloadBufferWithOptions(ctx context.Context, objectName string, opts ReadOptions) ([]byte, error)
+	DownloadToWriter(ctx context.Context, objectName string, w io.Writer) (int64, error)
+	DownloadToWriterWithOptions(ctx context.Context, objectName string, opts ReadOptions, w io.Writer) (int64, error)
+	GetObjectRange(ctx context.Context, objectName string, start, end int64) ([]byte, error)
+	DownloadRangeToWriter(ctx context.Context, objectName string, opts ReadOptions, start, end int64, w io.Writer) (int64, error)
+
+	CheckObjectExists(ctx context.Context, objectName string) (bool, error)
+	GetObjectInfo(ctx context.Context, objectName string) (minio.ObjectInfo, error)
+	FindObjectCaseInsensitive(ctx context.Context, objectName string) (string, error)
+	VerifyObject(ctx context.Context, objectName string) error
+
+	ListObjects(ctx context.Context, prefix string) ([]minio.ObjectInfo, error)
+	ListObjectsPaginated(ctx context.Context, prefix, startAfter string, maxKeys int) (ObjectPage, error)
+	ListFolder(ctx context.Context, prefix string) (FolderListing, error)
+	SearchObjects(ctx context.Context, prefix string, filter ObjectFilter) ([]minio.ObjectInfo, error)
+	SearchObjectsPaginated(ctx context.Context, prefix, startAfter string, maxKeys int, filter ObjectFilter) (ObjectPage, error)
+	ForEachObject(ctx context.Context, prefix string, fn func(minio.ObjectInfo) error) error
+	RecentUploads(ctx context.Context, prefix string, n int) ([]minio.ObjectInfo, error)
+	ListModifiedSince(ctx context.Context, prefix string, since time.Time) ([]minio.ObjectInfo, error)
+	ListObjectVersions(ctx context.Context, prefix string) ([]minio.ObjectInfo, error)
+
+	DeleteObject(ctx context.Context, objectName string) error
+	DeleteObjectVersion(ctx context.Context, objectName, versionID string) error
+	DeleteObjects(ctx context.Context, objectNames []string) []ObjectError
+	DeleteByPrefix(ctx context.Context, prefix string, force bool) (int, error)
+	RestoreObject(ctx context.Context, objectName string) (minio.UploadInfo, error)
+	PurgeTrash(ctx context.Context, olderThan time.Duration) (int, error)
+
+	CopyObject(ctx context.Context, srcObject, dstObject string) (minio.UploadInfo, error)
+	MoveObject(ctx context.Context, srcObject, dstObject string) (minio.UploadInfo, error)
+	CopyObjectToBucket(ctx context.Context, srcObject, dstBucket, dstObject string) (minio.UploadInfo, error)
+	TransitionObject(ctx context.Context, objectName, storageClass string) (string, error)
+	UpdateObjectMetadata(ctx context.Context, objectName, contentType string, metadata map[string]string) error
+	ArchiveByDate(ctx context.Context, srcPrefix, archivePrefix string, olderThan time.Duration, deleteSource bool) (int, error)
+
+	GetObjectURL(ctx context.Context, objectName string, expiry time.Duration) (string, error)
+	GetDownloadURL(ctx context.Context, objectName, fileName string, expiry time.Duration) (string, error)
+	GetUploadURL(ctx context.Context, objectName string, expiry time.Duration) (string, error)
+	GetPresignedPostPolicy(ctx context.Context, keyPrefix string, minSize, maxSize int64, expiry time.Duration) (string, map[string]string, error)
+	GeneratePresignedGetForIP(ctx context.Context, objectName, clientIP string, expiry time.Duration) (string, bool, error)
+	SetPublicReadPolicy(ctx context.Context, prefix string) error
+	GetPublicURL(objectName string) string
+
+	IndexContentHash(ctx context.Context, namespace, hash, objectName string) error
+	LookupContentHash(ctx context.Context, namespace, hash string, size int64) (minio.ObjectInfo, bool, error)
+
+	SetObjectTags(ctx context.Context, objectName string, objectTags map[string]string) error
+	GetObjectTags(ctx context.Context, objectName string) (map[string]string, error)
+	RemoveObjectTags(ctx context.Context, objectName string) error
+
+	SetLifecycleRule(ctx context.Context, prefix string, expireDays int) error
+	GetLifecycle(ctx context.Context) ([]LifecycleRule, error)
+	SweepExpired(ctx context.Context, prefix string, now time.Time, dryRun bool) (SweepResult, error)
+
+	GetObjectLockConfig(ctx context.Context) (ObjectLockConfig, error)
+	SetObjectRetention(ctx context.Context, objectName, versionID string, mode minio.RetentionMode, retainUntil time.Time) error
+	GetObjectRetention(ctx context.Context, objectName, versionID string) (minio.RetentionMode, time.Time, error)
+	SetLegalHold(ctx context.Context, objectName, versionID string, hold bool) error
+	GetLegalHold(ctx context.Context, objectName, versionID string) (bool, error)
+}
+
+var (
+	_ Storage = (*MinIOService)(nil)
+	_ Storage = (*MemoryStorage)(nil)
+)
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"MinIO-Learn/internal/storage/storagetest"
+)
+
+// newTestService starts fake on a test server and returns a MinIOService
+// using it with config, whose endpoint, credentials and bucket (default
+// "test-bucket") are filled in. The bucket is created as the service starts.
+func newTestService(t *testing.T, fake *storagetest.FakeS3, config Config) *MinIOService {
+	t.Helper()
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	config.Endpoint = strings.TrimPrefix(server.URL, "http://")
+	config.AccessKeyID = "test-access-key"
+	config.SecretAccessKey = "test-secret-key"
+	if config.BucketName == "" {
+		config.BucketName = "test-bucket"
+	}
+	if config.Logger == nil {
+		config.Logger = slog.New(slog.DiscardHandler)
+	}
+
+	service, err := NewMinIOService(config)
+	if err != nil {
+		t.Fatalf("NewMinIOService() error = %v", err)
+	}
+	return service
+}
+
+// testBackends returns a MinIOService on a fake S3 endpoint and a
+// MemoryStorage, both for the bucket "test-bucket", so behaviour the Storage
+// interface promises can be checked against each implementation.
+func testBackends(t *testing.T) map[string]Storage {
+	t.Helper()
+	return map[string]Storage{
+		"minio":  newTestService(t, storagetest.NewFakeS3(), Config{}),
+		"memory": NewMemoryStorage("test-bucket"),
+	}
+}
@@ -0,0 +1,776 @@
+// Package storagetest provides an in-memory S3 endpoint for testing code
+// that talks to a bucket through minio-go, without a MinIO server.
+package storagetest
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FakeS3 is an http.Handler serving an in-memory S3 API. It understands the part of the API the service uses: bucket
+// checks and creation, single-part and multipart uploads, reads with ranges
+// and If-Match, stats, deletes, copies, listings and tagging.
+type FakeS3 struct {
+	mu       sync.Mutex
+	buckets  map[string]map[string]*Object
+	configs  map[string]map[string][]byte
+	uploads  map[string]*fakeUpload
+	nextID   int
+	requests []string
+
+	// Before, if set, sees every request first and reports whether it
+	// answered it itself, so tests can inject failures or delays.
+	Before func(w http.ResponseWriter, r *http.Request) bool
+
+	// SecretKey, if set, is the secret presigned URLs must be signed with;
+	// requests carrying a bad or expired signature get 403. Requests signed
+	// in headers are never checked.
+	SecretKey string
+}
+
+// Object is a stored object. Tests may adjust its fields before the code
+// under test reads it.
+type Object struct {
+	Data         []byte
+	Header       http.Header
+	Tags         map[string]string
+	ETag         string
+	LastModified time.Time
+}
+
+// bucketConfigs are the bucket subresources stored as sent, with the error
+// code S3 answers a GET with while they are unset. Versioning reads back as
+// an empty configuration instead.
+var bucketConfigs = map[string]string{
+	"location":    "",
+	"versioning":  "",
+	"lifecycle":   "NoSuchLifecycleConfiguration",
+	"policy":      "NoSuchBucketPolicy",
+	"object-lock": "ObjectLockConfigurationNotFoundError",
+	"tagging":     "NoSuchTagSet",
+}
+
+type fakeUpload struct {
+	bucket, key string
+	header      http.Header
+	parts       map[int][]byte
+}
+
+// storedHeaders are the request headers an object keeps and sends back on
+// reads, besides user metadata.
+var storedHeaders = []string{
+	"Content-Type",
+	"Content-Encoding",
+	"Content-Disposition",
+	"X-Amz-Storage-Class",
+	"X-Amz-Server-Side-Encryption",
+	"X-Amz-Server-Side-Encryption-Customer-Algorithm",
+	"X-Amz-Server-Side-Encryption-Customer-Key-Md5",
+}
+
+// NewFakeS3 returns a FakeS3 with no buckets.
+func NewFakeS3() *FakeS3 {
+	return &FakeS3{
+		buckets: make(map[string]map[string]*Object),
+		configs: make(map[string]map[string][]byte),
+		uploads: make(map[string]*fakeUpload),
+	}
+}
+
+// Count returns how many requests with method were made for key, which is
+// "" for bucket-level requests.
+func (f *FakeS3) Count(method, bucket, key string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	want := method + " /" + bucket
+	if key != "" {
+		want += "/" + key
+	}
+	n := 0
+	for _, request := range f.requests {
+		if request == want {
+			n++
+		}
+	}
+	return n
+}
+
+// Object returns the stored object, or nil.
+func (f *FakeS3) Object(bucket, key string) *Object {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.buckets[bucket][key]
+}
+
+// Buckets returns the sorted names of the existing buckets.
+func (f *FakeS3) Buckets() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	names := make([]string, 0, len(f.buckets))
+	for name := range f.buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BucketConfig returns the body last stored for a bucket subresource such as
+// "versioning", "lifecycle" or "policy", and whether one was. The location
+// is the body of the create bucket request.
+func (f *FakeS3) BucketConfig(bucket, subresource string) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	body, ok := f.configs[bucket][subresource]
+	return body, ok
+}
+
+// Keys returns the sorted keys stored in bucket.
+func (f *FakeS3) Keys(bucket string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys := make([]string, 0, len(f.buckets[bucket]))
+	for key := range f.buckets[bucket] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// PutObject stores data under key directly, bypassing the API, and returns
+// the object so tests can adjust it before the service reads it.
+func (f *FakeS3) PutObject(bucket, key string, data []byte, header http.Header) *Object {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if header == nil {
+		header = http.Header{}
+	}
+	if f.buckets[bucket] == nil {
+		f.buckets[bucket] = make(map[string]*Object)
+		f.configs[bucket] = make(map[string][]byte)
+	}
+	return f.store(bucket, key, data, header)
+}
+
+func (f *FakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+
+	f.mu.Lock()
+	f.requests = append(f.requests, strings.TrimSuffix(r.Method+" /"+bucket+"/"+key, "/"))
+	before := f.Before
+	f.mu.Unlock()
+
+	if before != nil && before(w, r) {
+		return
+	}
+	if f.SecretKey != "" && r.URL.Query().Has("X-Amz-Signature") && !f.checkPresigned(w, r) {
+		return
+	}
+
+	body, err := readFakeBody(r)
+	if err != nil {
+		writeFakeError(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if key == "" {
+		f.serveBucket(w, r, bucket, body)
+		return
+	}
+	if f.buckets[bucket] == nil {
+		writeFakeError(w, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist")
+		return
+	}
+	f.serveObject(w, r, bucket, key, body)
+}
+
+func (f *FakeS3) serveBucket(w http.ResponseWriter, r *http.Request, bucket string, body []byte) {
+	query := r.URL.Query()
+	objects, exists := f.buckets[bucket]
+
+	switch {
+	case r.Method == http.MethodGet && query.Has("location") && f.configs[bucket]["location"] == nil:
+		writeFakeXML(w, struct {
+			XMLName xml.Name `xml:"LocationConstraint"`
+		}{})
+	case r.Method == http.MethodHead:
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case r.Method == http.MethodPut && len(query) == 0:
+		if exists {
+			writeFakeError(w, http.StatusConflict, "BucketAlreadyOwnedByYou", "Your previous request to create the named bucket succeeded")
+			return
+		}
+		f.buckets[bucket] = make(map[string]*Object)
+		f.configs[bucket] = make(map[string][]byte)
+		if len(body) > 0 {
+			f.configs[bucket]["location"] = body
+		}
+	case !exists:
+		writeFakeError(w, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist")
+	case len(query) == 1 && hasBucketConfig(query):
+		f.serveBucketConfig(w, r, bucket, body)
+	case r.Method == http.MethodGet && query.Get("list-type") == "2":
+		f.listObjects(w, r, bucket, objects)
+	case r.Method == http.MethodPost && query.Has("delete"):
+		var req struct {
+			Objects []struct {
+				Key string `xml:"Key"`
+			} `xml:"Object"`
+		}
+		if err := xml.Unmarshal(body, &req); err != nil {
+			writeFakeError(w, http.StatusBadRequest, "MalformedXML", err.Error())
+			return
+		}
+		for _, object := range req.Objects {
+			delete(objects, object.Key)
+		}
+		writeFakeXML(w, struct {
+			XMLName xml.Name `xml:"DeleteResult"`
+		}{})
+	default:
+		writeFakeError(w, http.StatusNotImplemented, "NotImplemented", "FakeS3 does not support "+r.Method+" "+r.URL.String())
+	}
+}
+
+func hasBucketConfig(query url.Values) bool {
+	for subresource := range bucketConfigs {
+		if query.Has(subresource) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *FakeS3) serveBucketConfig(w http.ResponseWriter, r *http.Request, bucket string, body []byte) {
+	var subresource string
+	for name := range r.URL.Query() {
+		subresource = name
+	}
+	configs := f.configs[bucket]
+
+	switch r.Method {
+	case http.MethodGet:
+		stored, ok := configs[subresource]
+		switch {
+		case ok && subresource == "location":
+			var config struct {
+				LocationConstraint string
+			}
+			xml.Unmarshal(stored, &config)
+			writeFakeXML(w, struct {
+				XMLName  xml.Name `xml:"LocationConstraint"`
+				Location string   `xml:",chardata"`
+			}{Location: config.LocationConstraint})
+		case ok:
+			w.Write(stored)
+		case subresource == "versioning":
+			writeFakeXML(w, struct {
+				XMLName xml.Name `xml:"VersioningConfiguration"`
+			}{})
+		default:
+			writeFakeError(w, http.StatusNotFound, bucketConfigs[subresource], "The "+subresource+" configuration does not exist")
+		}
+	case http.MethodPut:
+		configs[subresource] = body
+	case http.MethodDelete:
+		delete(configs, subresource)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeFakeError(w, http.StatusNotImplemented, "NotImplemented", "fakeS3 does not support "+r.Method+" "+r.URL.String())
+	}
+}
+
+func (f *FakeS3) listObjects(w http.ResponseWriter, r *http.Request, bucket string, objects map[string]*Object) {
+	query := r.URL.Query()
+	prefix, delimiter := query.Get("prefix"), query.Get("delimiter")
+	startAfter := query.Get("start-after")
+	if token := query.Get("continuation-token"); token != "" {
+		startAfter = token
+	}
+	maxKeys := 1000
+	if value, err := strconv.Atoi(query.Get("max-keys")); err == nil && value > 0 {
+		maxKeys = value
+	}
+	encode := func(s string) string {
+		if query.Get("encoding-type") == "url" {
+			return url.QueryEscape(s)
+		}
+		return s
+	}
+
+	keys := make([]string, 0, len(objects))
+	for key := range objects {
+		if strings.HasPrefix(key, prefix) && key > startAfter {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	type content struct {
+		Key          string
+		LastModified string
+		ETag         string
+		Size         int64
+		StorageClass string
+	}
+	type commonPrefix struct {
+		Prefix string
+	}
+	result := struct {
+		XMLName               xml.Name `xml:"ListBucketResult"`
+		Name                  string
+		Prefix                string
+		Delimiter             string `xml:",omitempty"`
+		MaxKeys               int
+		KeyCount              int
+		IsTruncated           bool
+		NextContinuationToken string `xml:",omitempty"`
+		EncodingType          string `xml:",omitempty"`
+		Contents              []content
+		CommonPrefixes        []commonPrefix
+	}{
+		Name:         bucket,
+		Prefix:       encode(prefix),
+		Delimiter:    delimiter,
+		MaxKeys:      maxKeys,
+		EncodingType: query.Get("encoding-type"),
+	}
+
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		if result.KeyCount == maxKeys {
+			result.IsTruncated = true
+			break
+		}
+		if delimiter != "" {
+			if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+				folder := key[:len(prefix)+i+len(delimiter)]
+				if !seen[folder] {
+					seen[folder] = true
+					result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{encode(folder)})
+					result.KeyCount++
+				}
+				result.NextContinuationToken = key
+				continue
+			}
+		}
+		object := objects[key]
+		storageClass := object.Header.Get("X-Amz-Storage-Class")
+		if storageClass == "" {
+			storageClass = "STANDARD"
+		}
+		result.Contents = append(result.Contents, content{
+			Key:          encode(key),
+			LastModified: object.LastModified.Format("2006-01-02T15:04:05.000Z"),
+			ETag:         `"` + object.ETag + `"`,
+			Size:         int64(len(object.Data)),
+			StorageClass: storageClass,
+		})
+		result.KeyCount++
+		result.NextContinuationToken = key
+	}
+	if !result.IsTruncated {
+		result.NextContinuationToken = ""
+	}
+
+	writeFakeXML(w, result)
+}
+
+func (f *FakeS3) serveObject(w http.ResponseWriter, r *http.Request, bucket, key string, body []byte) {
+	query := r.URL.Query()
+	objects := f.buckets[bucket]
+	object := objects[key]
+
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		f.nextID++
+		id := fmt.Sprintf("upload-%d", f.nextID)
+		f.uploads[id] = &fakeUpload{bucket: bucket, key: key, header: objectHeader(r.Header), parts: make(map[int][]byte)}
+		writeFakeXML(w, struct {
+			XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+			Bucket   string
+			Key      string
+			UploadId string
+		}{Bucket: bucket, Key: key, UploadId: id})
+	case query.Has("uploadId"):
+		f.serveUpload(w, r, bucket, key, body)
+	case query.Has("tagging"):
+		f.serveTagging(w, r, object, body)
+	case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") != "":
+		f.copyObject(w, r, bucket, key)
+	case r.Method == http.MethodPut:
+		stored := f.store(bucket, key, body, objectHeader(r.Header))
+		if tagging := r.Header.Get("X-Amz-Tagging"); tagging != "" {
+			values, _ := url.ParseQuery(tagging)
+			stored.Tags = make(map[string]string)
+			for k := range values {
+				stored.Tags[k] = values.Get(k)
+			}
+		}
+		w.Header().Set("ETag", `"`+stored.ETag+`"`)
+	case object == nil:
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writeFakeError(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+	case r.Method == http.MethodDelete:
+		delete(objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		f.readObject(w, r, object)
+	default:
+		writeFakeError(w, http.StatusNotImplemented, "NotImplemented", "FakeS3 does not support "+r.Method+" "+r.URL.String())
+	}
+}
+
+// store saves an object. f.mu must be held.
+func (f *FakeS3) store(bucket, key string, data []byte, header http.Header) *Object {
+	sum := md5.Sum(data)
+	object := &Object{
+		Data:         data,
+		Header:       header,
+		ETag:         hex.EncodeToString(sum[:]),
+		LastModified: time.Now().UTC().Truncate(time.Second),
+	}
+	f.buckets[bucket][key] = object
+	return object
+}
+
+func (f *FakeS3) readObject(w http.ResponseWriter, r *http.Request, object *Object) {
+	status := http.StatusOK
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && strings.Trim(ifMatch, `"`) != object.ETag {
+		status = http.StatusPreconditionFailed
+	}
+	if md5 := object.Header.Get("X-Amz-Server-Side-Encryption-Customer-Key-Md5"); md5 != "" &&
+		r.Header.Get("X-Amz-Server-Side-Encryption-Customer-Key-Md5") != md5 {
+		status = http.StatusBadRequest
+	}
+	if status != http.StatusOK {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(status)
+			return
+		}
+		code := map[int]string{http.StatusPreconditionFailed: "PreconditionFailed", http.StatusBadRequest: "InvalidRequest"}[status]
+		writeFakeError(w, status, code, "The object can't be read with the given conditions")
+		return
+	}
+
+	header := w.Header()
+	for key, values := range object.Header {
+		header[key] = values
+	}
+	header.Set("ETag", `"`+object.ETag+`"`)
+	header.Set("Last-Modified", object.LastModified.Format(http.TimeFormat))
+	header.Set("Accept-Ranges", "bytes")
+	if len(object.Tags) > 0 {
+		header.Set("X-Amz-Tagging-Count", strconv.Itoa(len(object.Tags)))
+	}
+
+	data := object.Data
+	if spec, ok := strings.CutPrefix(r.Header.Get("Range"), "bytes="); ok {
+		start, end, ok := parseFakeRange(spec, int64(len(data)))
+		if !ok {
+			writeFakeError(w, http.StatusRequestedRangeNotSatisfiable, "InvalidRange", "The requested range is not satisfiable")
+			return
+		}
+		header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(data)))
+		data = data[start : end+1]
+		status = http.StatusPartialContent
+	}
+
+	header.Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(status)
+	if r.Method == http.MethodGet {
+		w.Write(data)
+	}
+}
+
+func parseFakeRange(spec string, size int64) (start, end int64, ok bool) {
+	first, last, found := strings.Cut(spec, "-")
+	if !found {
+		return 0, 0, false
+	}
+	var err error
+	switch {
+	case first == "":
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, false
+		}
+		return max(0, size-n), size - 1, size > 0
+	case last == "":
+		end = size - 1
+	default:
+		if end, err = strconv.ParseInt(last, 10, 64); err != nil {
+			return 0, 0, false
+		}
+	}
+	if start, err = strconv.ParseInt(first, 10, 64); err != nil || start >= size || end < start {
+		return 0, 0, false
+	}
+	return start, min(end, size-1), true
+}
+
+func (f *FakeS3) copyObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	source, err := url.PathUnescape(r.Header.Get("X-Amz-Copy-Source"))
+	if err != nil {
+		writeFakeError(w, http.StatusBadRequest, "InvalidArgument", err.Error())
+		return
+	}
+	source, _, _ = strings.Cut(source, "?")
+	srcBucket, srcKey, _ := strings.Cut(strings.TrimPrefix(source, "/"), "/")
+	src := f.buckets[srcBucket][srcKey]
+	if src == nil {
+		writeFakeError(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+		return
+	}
+	if ifMatch := r.Header.Get("X-Amz-Copy-Source-If-Match"); ifMatch != "" && strings.Trim(ifMatch, `"`) != src.ETag {
+		writeFakeError(w, http.StatusPreconditionFailed, "PreconditionFailed", "At least one of the preconditions you specified did not hold")
+		return
+	}
+
+	header := src.Header.Clone()
+	if r.Header.Get("X-Amz-Metadata-Directive") == "REPLACE" {
+		header = objectHeader(r.Header)
+	}
+	copied := f.store(bucket, key, bytes.Clone(src.Data), header)
+	copied.Tags = src.Tags
+
+	writeFakeXML(w, struct {
+		XMLName      xml.Name `xml:"CopyObjectResult"`
+		ETag         string
+		LastModified string
+	}{ETag: `"` + copied.ETag + `"`, LastModified: copied.LastModified.Format("2006-01-02T15:04:05.000Z")})
+}
+
+func (f *FakeS3) serveUpload(w http.ResponseWriter, r *http.Request, bucket, key string, body []byte) {
+	id := r.URL.Query().Get("uploadId")
+	upload := f.uploads[id]
+	if upload == nil || upload.bucket != bucket || upload.key != key {
+		writeFakeError(w, http.StatusNotFound, "NoSuchUpload", "The specified multipart upload does not exist.")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		partNumber, err := strconv.Atoi(r.URL.Query().Get("partNumber"))
+		if err != nil || partNumber < 1 {
+			writeFakeError(w, http.StatusBadRequest, "InvalidArgument", "invalid part number")
+			return
+		}
+		upload.parts[partNumber] = body
+		sum := md5.Sum(body)
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+	case http.MethodGet:
+		type part struct {
+			PartNumber   int
+			ETag         string
+			Size         int64
+			LastModified string
+		}
+		result := struct {
+			XMLName     xml.Name `xml:"ListPartsResult"`
+			Bucket      string
+			Key         string
+			UploadId    string
+			IsTruncated bool
+			Parts       []part `xml:"Part"`
+		}{Bucket: bucket, Key: key, UploadId: id}
+		for _, number := range sortedParts(upload.parts) {
+			sum := md5.Sum(upload.parts[number])
+			result.Parts = append(result.Parts, part{
+				PartNumber:   number,
+				ETag:         `"` + hex.EncodeToString(sum[:]) + `"`,
+				Size:         int64(len(upload.parts[number])),
+				LastModified: time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
+			})
+		}
+		writeFakeXML(w, result)
+	case http.MethodPost:
+		var req struct {
+			Parts []struct {
+				PartNumber int
+			} `xml:"Part"`
+		}
+		if err := xml.Unmarshal(body, &req); err != nil {
+			writeFakeError(w, http.StatusBadRequest, "MalformedXML", err.Error())
+			return
+		}
+		var data []byte
+		for _, part := range req.Parts {
+			content, ok := upload.parts[part.PartNumber]
+			if !ok {
+				writeFakeError(w, http.StatusBadRequest, "InvalidPart", "One or more of the specified parts could not be found.")
+				return
+			}
+			data = append(data, content...)
+		}
+		delete(f.uploads, id)
+		object := f.store(bucket, key, data, upload.header)
+		object.ETag += fmt.Sprintf("-%d", len(req.Parts))
+		writeFakeXML(w, struct {
+			XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+			Bucket  string
+			Key     string
+			ETag    string
+		}{Bucket: bucket, Key: key, ETag: `"` + object.ETag + `"`})
+	case http.MethodDelete:
+		delete(f.uploads, id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func sortedParts(parts map[int][]byte) []int {
+	numbers := make([]int, 0, len(parts))
+	for number := range parts {
+		numbers = append(numbers, number)
+	}
+	sort.Ints(numbers)
+	return numbers
+}
+
+type fakeTagging struct {
+	XMLName xml.Name `xml:"Tagging"`
+	Tags    []struct {
+		Key   string
+		Value string
+	} `xml:"TagSet>Tag"`
+}
+
+func (f *FakeS3) serveTagging(w http.ResponseWriter, r *http.Request, object *Object, body []byte) {
+	if object == nil {
+		writeFakeError(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		var tagging fakeTagging
+		for _, key := range sortedKeys(object.Tags) {
+			tagging.Tags = append(tagging.Tags, struct {
+				Key   string
+				Value string
+			}{key, object.Tags[key]})
+		}
+		writeFakeXML(w, tagging)
+	case http.MethodPut:
+		var tagging fakeTagging
+		if err := xml.Unmarshal(body, &tagging); err != nil {
+			writeFakeError(w, http.StatusBadRequest, "MalformedXML", err.Error())
+			return
+		}
+		object.Tags = make(map[string]string)
+		for _, tag := range tagging.Tags {
+			object.Tags[tag.Key] = tag.Value
+		}
+	case http.MethodDelete:
+		object.Tags = nil
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// objectHeader returns the headers of an upload request that are stored with
+// the object.
+func objectHeader(r http.Header) http.Header {
+	header := http.Header{}
+	for key, values := range r {
+		if strings.HasPrefix(key, "X-Amz-Meta-") {
+			header[key] = values
+		}
+	}
+	for _, key := range storedHeaders {
+		if value := r.Get(key); value != "" {
+			header.Set(key, value)
+		}
+	}
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "binary/octet-stream")
+	}
+	return header
+}
+
+// readFakeBody reads a request body, decoding the aws-chunked encoding
+// minio-go uses to sign streamed uploads over plain HTTP.
+func readFakeBody(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	var data []byte
+	reader := bufio.NewReader(r.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("reading chunk header: %w", err)
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parsing chunk size %q: %w", sizeHex, err)
+		}
+		if size == 0 {
+			// Trailers, if any, follow; they aren't needed.
+			return data, nil
+		}
+		chunk := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, chunk); err != nil {
+			return nil, fmt.Errorf("reading chunk: %w", err)
+		}
+		data = append(data, chunk[:size]...)
+	}
+}
+
+func writeFakeXML(w http.ResponseWriter, v any) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.Write(data)
+}
+
+func writeFakeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string
+		Message string
+	}{Code: code, Message: message})
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/tags"
+)
+
+var ErrInvalidTags = errors.New("invalid object tags")
+
+// S3 limits on object tags. Lengths are in characters.
+const (
+	maxObjectTags     = 10
+	maxTagKeyLength   = 128
+	maxTagValueLength = 256
+)
+
+// ValidateTags checks tags against the S3 object tagging limits.
+func ValidateTags(objectTags map[string]string) error {
+	if len(objectTags) > maxObjectTags {
+		return fmt.Errorf("%w: at most %d tags are allowed, got %d", ErrInvalidTags, maxObjectTags, len(objectTags))
+	}
+	for key, value := range objectTags {
+		if key == "" {
+			return fmt.Errorf("%w: tag keys must not be empty", ErrInvalidTags)
+		}
+		if utf8.RuneCountInString(key) > maxTagKeyLength {
+			return fmt.Errorf("%w: tag key '%s' is longer than %d characters", ErrInvalidTags, key, maxTagKeyLength)
+		}
+		if utf8.RuneCountInString(value) > maxTagValueLength {
+			return fmt.Errorf("%w: value of tag '%s' is longer than %d characters", ErrInvalidTags, key, maxTagValueLength)
+		}
+	}
+	return nil
+}
+
+// SetObjectTags replaces the object's tags with objectTags.
+func (s *MinIOService) SetObjectTags(ctx context.Context, objectName string, objectTags map[string]string) error {
+	if err := ValidateTags(objectTags); err != nil {
+		return err
+	}
+
+	t, err := tags.NewTags(objectTags, true)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTags, err)
+	}
+
+	if err := s.Client.PutObjectTagging(ctx, s.BucketName, objectName, t, minio.PutObjectTaggingOptions{}); err != nil {
+		return fmt.Errorf("failed to set object tags: %w", classifyError(err))
+	}
+	return nil
+}
+
+func (s *MinIOService) GetObjectTags(ctx context.Context, objectName string) (map[string]string, error) {
+	t, err := s.Client.GetObjectTagging(ctx, s.BucketName, objectName, minio.GetObjectTaggingOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object tags: %w", classifyError(err))
+	}
+	return t.ToMap(), nil
+}
+
+func (s *MinIOService) RemoveObjectTags(ctx context.Context, objectName string) error {
+	if err := s.Client.RemoveObjectTagging(ctx, s.BucketName, objectName, minio.RemoveObjectTaggingOptions{}); err != nil {
+		return fmt.Errorf("failed to remove object tags: %w", classifyError(err))
+	}
+	return nil
+}
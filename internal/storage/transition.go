@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// TransitionObject moves an object to storageClass immediately by copying it
+// onto itself with the new class, preserving its content type and user
+// metadata. It returns the storage class the backend reports afterwards,
+// which may differ from the requested one on backends without tiering.
+func (s *MinIOService) TransitionObject(ctx context.Context, objectName, storageClass string) (string, error) {
+	if storageClass == "" {
+		return "", fmt.Errorf("storage class is required")
+	}
+
+	defer s.locks.lock(objectName)()
+	defer s.stats.invalidate(objectName)
+
+	info, err := s.Client.StatObject(ctx, s.BucketName, objectName, s.getOptions())
+	if err != nil {
+		return "", fmt.Errorf("failed to stat object: %w", classifyError(err))
+	}
+
+	metadata := make(map[string]string, len(info.UserMetadata)+3)
+	for k, v := range info.UserMetadata {
+		metadata[k] = v
+	}
+	metadata["Content-Type"] = info.ContentType
+	if encoding := info.Metadata.Get("Content-Encoding"); encoding != "" {
+		metadata["Content-Encoding"] = encoding
+	}
+	metadata[storageClassKey] = storageClass
+
+	dstOpts, srcOpts := s.copyOptions(objectName, objectName)
+	dstOpts.UserMetadata = metadata
+	dstOpts.ReplaceMetadata = true
+
+	_, err = s.Client.CopyObject(ctx, dstOpts, srcOpts)
+	if err != nil {
+		return "", fmt.Errorf("failed to transition object: %w", classifyError(err))
+	}
+
+	info, err = s.Client.StatObject(ctx, s.BucketName, objectName, s.getOptions())
+	if err != nil {
+		return "", fmt.Errorf("failed to stat transitioned object: %w", classifyError(err))
+	}
+
+	return withStorageClass(info).StorageClass, nil
+}
+
+// UpdateObjectMetadata sets objectName's content type and adds metadata to
+// its user metadata, replacing values under the same keys, by copying the
+// object onto itself like TransitionObject.
+func (s *MinIOService) UpdateObjectMetadata(ctx context.Context, objectName, contentType string, metadata map[string]string) error {
+	defer s.locks.lock(objectName)()
+	defer s.stats.invalidate(objectName)
+
+	info, err := s.Client.StatObject(ctx, s.BucketName, objectName, s.getOptions())
+	if err != nil {
+		return fmt.Errorf("failed to stat object: %w", classifyError(err))
+	}
+
+	merged := make(map[string]string, len(info.UserMetadata)+len(metadata)+3)
+	for k, v := range info.UserMetadata {
+		merged[k] = v
+	}
+	for k, v := range metadata {
+		merged[k] = v
+	}
+	merged["Content-Type"] = contentType
+	if encoding := info.Metadata.Get("Content-Encoding"); encoding != "" {
+		merged["Content-Encoding"] = encoding
+	}
+	merged[storageClassKey] = withStorageClass(info).StorageClass
+
+	dstOpts, srcOpts := s.copyOptions(objectName, objectName)
+	dstOpts.UserMetadata = merged
+	dstOpts.ReplaceMetadata = true
+
+	if _, err := s.Client.CopyObject(ctx, dstOpts, srcOpts); err != nil {
+		return fmt.Errorf("failed to update object metadata: %w", classifyError(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestTransitionObject(t *testing.T) {
+	tests := []struct {
+		name         string
+		object       string
+		storageClass string
+		wantErr      error
+	}{
+		{name: "colder class", object: "report.txt", storageClass: "REDUCED_REDUNDANCY"},
+		{name: "back to standard", object: "report.txt", storageClass: "STANDARD"},
+		{name: "missing object", object: "missing.txt", storageClass: "REDUCED_REDUNDANCY", wantErr: ErrObjectNotFound},
+	}
+
+	for name, store := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			metadata := map[string]string{"owner": "alice"}
+			if _, err := store.UploadBuffer(ctx, "report.txt", []byte("quarterly"), "text/plain", metadata); err != nil {
+				t.Fatalf("UploadBuffer() error = %v", err)
+			}
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					got, err := store.TransitionObject(ctx, tt.object, tt.storageClass)
+					if tt.wantErr != nil {
+						if !errors.Is(err, tt.wantErr) {
+							t.Fatalf("TransitionObject() error = %v, want %v", err, tt.wantErr)
+						}
+						return
+					}
+					if err != nil {
+						t.Fatalf("TransitionObject() error = %v", err)
+					}
+					if got != tt.storageClass {
+						t.Errorf("TransitionObject() = %q, want %q", got, tt.storageClass)
+					}
+
+					info, err := store.GetObjectInfo(ctx, tt.object)
+					if err != nil {
+						t.Fatalf("GetObjectInfo() error = %v", err)
+					}
+					if info.StorageClass != tt.storageClass {
+						t.Errorf("StorageClass = %q, want %q", info.StorageClass, tt.storageClass)
+					}
+					if info.ContentType != "text/plain" {
+						t.Errorf("ContentType = %q, want text/plain", info.ContentType)
+					}
+					if info.UserMetadata["Owner"] != "alice" && info.UserMetadata["owner"] != "alice" {
+						t.Errorf("UserMetadata = %v, want owner kept", info.UserMetadata)
+					}
+				})
+			}
+
+			if _, err := store.TransitionObject(ctx, "report.txt", ""); err == nil {
+				t.Error("TransitionObject() with no storage class succeeded")
+			}
+		})
+	}
+}
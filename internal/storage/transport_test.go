@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"context"
+	"encoding/pem"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"MinIO-Learn/internal/storage/storagetest"
+)
+
+func TestResponseHeaderTimeout(t *testing.T) {
+	fake := storagetest.NewFakeS3()
+	service := newTestService(t, fake, Config{ResponseHeaderTimeout: 100 * time.Millisecond})
+	fake.PutObject("test-bucket", "slow.txt", []byte("eventually"), nil)
+	// The backend accepts the first request but doesn't answer it for far
+	// longer than the timeout; minio-go retries the request once it times out.
+	var attempts atomic.Int32
+	fake.Before = func(w http.ResponseWriter, r *http.Request) bool {
+		if r.URL.Path != "/test-bucket/slow.txt" || attempts.Add(1) > 1 {
+			return false
+		}
+		select {
+		case <-r.Context().Done():
+		case <-time.After(10 * time.Second):
+		}
+		return true
+	}
+
+	start := time.Now()
+	info, err := service.GetObjectInfo(context.Background(), "slow.txt")
+	elapsed := time.Since(start)
+
+	if err != nil {
+		t.Fatalf("GetObjectInfo() error = %v", err)
+	}
+	if info.Size != int64(len("eventually")) {
+		t.Errorf("Size = %d, want %d", info.Size, len("eventually"))
+	}
+	if got := attempts.Load(); got != 2 {
+		t.Errorf("backend saw %d attempts, want the hung one and a retry", got)
+	}
+	if elapsed > 3*time.Second {
+		t.Errorf("GetObjectInfo() took %v, want the hung attempt abandoned after the timeout", elapsed)
+	}
+}
+
+func TestResponseHeaderTimeoutError(t *testing.T) {
+	transport, err := newTransport(Config{ResponseHeaderTimeout: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("newTransport() error = %v", err)
+	}
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	start := time.Now()
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	if err == nil {
+		resp.Body.Close()
+	}
+	if err == nil || !strings.Contains(err.Error(), "timeout awaiting response headers") {
+		t.Errorf("Get() error = %v, want a response header timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Get() took %v, want it to fail soon after the timeout", elapsed)
+	}
+}
+
+func TestCACertFile(t *testing.T) {
+	fake := storagetest.NewFakeS3()
+	server := httptest.NewTLSServer(fake)
+	t.Cleanup(server.Close)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	badFile := filepath.Join(t.TempDir(), "bad.pem")
+	if err := os.WriteFile(badFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		caCertFile string
+		wantErr    string
+	}{
+		{"trusted", caFile, ""},
+		{"untrusted", "", "certificate"},
+		{"missing file", filepath.Join(t.TempDir(), "missing.pem"), "failed to read CA certificate"},
+		{"not PEM", badFile, "no PEM certificates found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewMinIOService(Config{
+				Endpoint:        strings.TrimPrefix(server.URL, "https://"),
+				AccessKeyID:     "test-access-key",
+				SecretAccessKey: "test-secret-key",
+				BucketName:      "test-bucket",
+				UseSSL:          true,
+				CACertFile:      tt.caCertFile,
+				Logger:          slog.New(slog.DiscardHandler),
+			})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("NewMinIOService() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewMinIOService() error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"MinIO-Learn/internal/metrics"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// TrashPrefix is where soft-deleted objects are kept, under their original
+// key: "uploads/a.txt" is trashed as "trash/uploads/a.txt". With MultiTenant
+// each tenant has its own trash, so "acme/uploads/a.txt" is trashed as
+// "acme/trash/uploads/a.txt".
+const TrashPrefix = "trash/"
+
+var ErrRestoreConflict = errors.New("an object already exists where the trashed object would be restored")
+
+// trashKey returns the key objectName is moved to when soft-deleted.
+func trashKey(objectName string, multiTenant bool) string {
+	if tenant, rest, ok := strings.Cut(objectName, "/"); multiTenant && ok {
+		return tenant + "/" + TrashPrefix + rest
+	}
+	return TrashPrefix + objectName
+}
+
+// isTrashKey reports whether objectName is in the trash. Deleting such an
+// object removes it for good even with soft delete enabled.
+func isTrashKey(objectName string, multiTenant bool) bool {
+	if multiTenant {
+		_, objectName, _ = strings.Cut(objectName, "/")
+	}
+	return strings.HasPrefix(objectName, TrashPrefix)
+}
+
+// trashObject moves objectName to the trash. A missing object is not an
+// error, as with a hard delete.
+func (s *MinIOService) trashObject(ctx context.Context, objectName string) (err error) {
+	defer s.observe(ctx, metrics.OpDelete, slog.String("object", objectName), time.Now(), nil, &err)
+
+	_, err = s.MoveObject(ctx, objectName, trashKey(objectName, s.multiTenant))
+	if errors.Is(err, ErrObjectNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to move object to trash: %w", err)
+	}
+
+	return nil
+}
+
+// RestoreObject moves a soft-deleted object back from the trash to
+// objectName. It fails with ErrObjectNotFound if objectName isn't in the
+// trash, and with ErrRestoreConflict rather than overwrite an object written
+// to objectName since the delete.
+func (s *MinIOService) RestoreObject(ctx context.Context, objectName string) (minio.UploadInfo, error) {
+	trashed := trashKey(objectName, s.multiTenant)
+	exists, err := s.CheckObjectExists(ctx, trashed)
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+	if !exists {
+		return minio.UploadInfo{}, fmt.Errorf("%w: '%s' is not in the trash", ErrObjectNotFound, objectName)
+	}
+
+	exists, err = s.CheckObjectExists(ctx, objectName)
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+	if exists {
+		return minio.UploadInfo{}, ErrRestoreConflict
+	}
+
+	uploadInfo, err := s.MoveObject(ctx, trashed, objectName)
+	if err != nil {
+		return uploadInfo, fmt.Errorf("failed to restore object: %w", err)
+	}
+
+	return uploadInfo, nil
+}
+
+// PurgeTrash permanently removes trashed objects that were deleted more than
+// olderThan ago and returns how many were removed. If some could not be
+// removed, the error reports how many.
+func (s *MinIOService) PurgeTrash(ctx context.Context, olderThan time.Duration) (purged int, err error) {
+	defer s.observe(ctx, metrics.OpDelete, slog.String("prefix", TrashPrefix), time.Now(), nil, &err)
+
+	prefixes, err := s.trashPrefixes(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	var expired []string
+	for _, prefix := range prefixes {
+		err = s.ForEachObject(ctx, prefix, func(object minio.ObjectInfo) error {
+			if object.LastModified.Before(cutoff) {
+				expired = append(expired, object.Key)
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+
+	failed := s.removeObjects(ctx, expired)
+	purged = len(expired) - len(failed)
+	for key, removeErr := range failed {
+		return purged, fmt.Errorf("%d trashed objects were not purged, including '%s': %w", len(failed), key, removeErr)
+	}
+
+	return purged, nil
+}
+
+// trashPrefixes returns the prefixes trashed objects are kept under: just
+// TrashPrefix, or with MultiTenant the trash of every tenant.
+func (s *MinIOService) trashPrefixes(ctx context.Context) ([]string, error) {
+	if !s.multiTenant {
+		return []string{TrashPrefix}, nil
+	}
+
+	listing, err := s.ListFolder(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	prefixes := make([]string, 0, len(listing.Prefixes))
+	for _, tenant := range listing.Prefixes {
+		prefixes = append(prefixes, tenant+TrashPrefix)
+	}
+	return prefixes, nil
+}
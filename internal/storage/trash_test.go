@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"MinIO-Learn/internal/storage/storagetest"
+)
+
+// softDeleteBackends returns the test backends with soft delete enabled,
+// each with a function that backdates an object's last modified time.
+func softDeleteBackends(t *testing.T) map[string]struct {
+	store    Storage
+	backdate func(key string, modified time.Time)
+} {
+	t.Helper()
+	fake := storagetest.NewFakeS3()
+	memory := NewMemoryStorage("test-bucket")
+	memory.EnableSoftDelete()
+	return map[string]struct {
+		store    Storage
+		backdate func(key string, modified time.Time)
+	}{
+		"minio": {newTestService(t, fake, Config{SoftDelete: true}), func(key string, modified time.Time) {
+			fake.Object("test-bucket", key).LastModified = modified
+		}},
+		"memory": {memory, func(key string, modified time.Time) {
+			memory.mu.Lock()
+			versions := memory.objects[key]
+			versions[len(versions)-1].info.LastModified = modified
+			memory.mu.Unlock()
+		}},
+	}
+}
+
+func TestSoftDeleteRestore(t *testing.T) {
+	data := []byte("accidentally deleted")
+
+	for name, backend := range softDeleteBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := backend.store
+			if _, err := store.UploadBuffer(ctx, "uploads/a.txt", data, "text/plain", nil); err != nil {
+				t.Fatalf("UploadBuffer() error = %v", err)
+			}
+
+			if err := store.DeleteObject(ctx, "uploads/a.txt"); err != nil {
+				t.Fatalf("DeleteObject() error = %v", err)
+			}
+			if exists, err := store.CheckObjectExists(ctx, "uploads/a.txt"); err != nil || exists {
+				t.Fatalf("uploads/a.txt exists = %v, %v after delete, want false", exists, err)
+			}
+			if trashed, err := store.DownloadBuffer(ctx, "trash/uploads/a.txt"); err != nil || !bytes.Equal(trashed, data) {
+				t.Fatalf("trash/uploads/a.txt = %q, %v, want the deleted content", trashed, err)
+			}
+
+			if _, err := store.RestoreObject(ctx, "uploads/a.txt"); err != nil {
+				t.Fatalf("RestoreObject() error = %v", err)
+			}
+			restored, err := store.DownloadBuffer(ctx, "uploads/a.txt")
+			if err != nil {
+				t.Fatalf("DownloadBuffer() after restore error = %v", err)
+			}
+			if !bytes.Equal(restored, data) {
+				t.Errorf("restored content = %q, want %q", restored, data)
+			}
+			if exists, err := store.CheckObjectExists(ctx, "trash/uploads/a.txt"); err != nil || exists {
+				t.Errorf("trash/uploads/a.txt exists = %v, %v after restore, want false", exists, err)
+			}
+
+			if _, err := store.RestoreObject(ctx, "uploads/a.txt"); !errors.Is(err, ErrObjectNotFound) {
+				t.Errorf("RestoreObject() of an object not in the trash error = %v, want ErrObjectNotFound", err)
+			}
+		})
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	for name, backend := range softDeleteBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := backend.store
+			for _, key := range []string{"uploads/old.txt", "uploads/recent.txt", "uploads/kept.txt"} {
+				if _, err := store.UploadBuffer(ctx, key, []byte(key), "text/plain", nil); err != nil {
+					t.Fatalf("UploadBuffer(%q) error = %v", key, err)
+				}
+			}
+			for _, key := range []string{"uploads/old.txt", "uploads/recent.txt"} {
+				if err := store.DeleteObject(ctx, key); err != nil {
+					t.Fatalf("DeleteObject(%q) error = %v", key, err)
+				}
+			}
+			backend.backdate("trash/uploads/old.txt", time.Now().Add(-48*time.Hour))
+
+			purged, err := store.PurgeTrash(ctx, 24*time.Hour)
+			if err != nil {
+				t.Fatalf("PurgeTrash() error = %v", err)
+			}
+			if purged != 1 {
+				t.Errorf("PurgeTrash() = %d, want 1", purged)
+			}
+
+			for key, want := range map[string]bool{
+				"trash/uploads/old.txt":    false,
+				"trash/uploads/recent.txt": true,
+				"uploads/kept.txt":         true,
+			} {
+				exists, err := store.CheckObjectExists(ctx, key)
+				if err != nil {
+					t.Fatalf("CheckObjectExists(%q) error = %v", key, err)
+				}
+				if exists != want {
+					t.Errorf("%s exists = %v, want %v", key, exists, want)
+				}
+			}
+			if _, err := store.RestoreObject(ctx, "uploads/old.txt"); !errors.Is(err, ErrObjectNotFound) {
+				t.Errorf("RestoreObject() of a purged object error = %v, want ErrObjectNotFound", err)
+			}
+		})
+	}
+}
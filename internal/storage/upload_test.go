@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+)
+
+func TestUploadStream(t *testing.T) {
+	data := bytes.Repeat([]byte("streamed upload "), 64<<10)
+
+	tests := []struct {
+		name   string
+		data   []byte
+		size   int64
+		reader func(data []byte) io.Reader
+	}{
+		{"known size", data, int64(len(data)), func(data []byte) io.Reader { return bytes.NewReader(data) }},
+		{"unknown size", data, -1, func(data []byte) io.Reader { return bytes.NewReader(data) }},
+		{"unseekable", data, int64(len(data)), func(data []byte) io.Reader { return io.MultiReader(bytes.NewReader(data)) }},
+		{"unseekable unknown size", data, -1, func(data []byte) io.Reader { return io.MultiReader(bytes.NewReader(data)) }},
+		{"empty", nil, 0, func(data []byte) io.Reader { return bytes.NewReader(data) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, store := range testBackends(t) {
+				t.Run(name, func(t *testing.T) {
+					ctx := context.Background()
+					info, err := store.UploadStream(ctx, "streamed.bin", tt.reader(tt.data), tt.size, "application/octet-stream", nil)
+					if err != nil {
+						t.Fatalf("UploadStream() error = %v", err)
+					}
+					if info.Size != int64(len(tt.data)) {
+						t.Errorf("UploadInfo.Size = %d, want %d", info.Size, len(tt.data))
+					}
+
+					got, err := store.DownloadBuffer(ctx, "streamed.bin")
+					if err != nil {
+						t.Fatalf("DownloadBuffer() error = %v", err)
+					}
+					if !bytes.Equal(got, tt.data) {
+						t.Errorf("stored %d bytes, want the %d streamed", len(got), len(tt.data))
+					}
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// EnableVersioning turns on versioning for the bucket. Once enabled it can
+// only be suspended, not disabled.
+func (s *MinIOService) EnableVersioning(ctx context.Context) error {
+	if err := s.Client.EnableVersioning(ctx, s.BucketName); err != nil {
+		return fmt.Errorf("failed to enable versioning: %w", classifyError(err))
+	}
+	return nil
+}
+
+// ListObjectVersions returns every version and delete marker under prefix,
+// grouped by key with the newest version of each key first.
+func (s *MinIOService) ListObjectVersions(ctx context.Context, prefix string) ([]minio.ObjectInfo, error) {
+	objectCh := s.Client.ListObjects(ctx, s.BucketName, minio.ListObjectsOptions{
+		Prefix:       prefix,
+		Recursive:    true,
+		WithVersions: true,
+	})
+
+	var versions []minio.ObjectInfo
+	for object := range objectCh {
+		if object.Err != nil {
+			return nil, fmt.Errorf("error listing object versions: %w", object.Err)
+		}
+		if IsSystemKey(object.Key) {
+			continue
+		}
+		versions = append(versions, object)
+	}
+
+	return versions, nil
+}